@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+)
+
+// command is a contact-api subcommand. run returns the process exit code.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdout, stderr io.Writer) int
+}
+
+// commands lists the subcommands in the order they are shown in the usage
+func commands() []command {
+	return []command{
+		{name: "serve", summary: "Run the HTTP server (default)", run: serve},
+		{name: "send-test", summary: "Send a sample contact email for a website", run: sendTest},
+		{name: "render", summary: "Print the email a JSON submission would produce", run: render},
+		{name: "validate-config", summary: "Check the configuration and print it with secrets redacted", run: validateConfig},
+		{name: "outbox", summary: "List, retry or purge failed submissions", run: outbox},
+		{name: "version", summary: "Print build information", run: printVersion},
+	}
+}
+
+// run dispatches to the subcommand named by the first argument, serving when
+// none is given
+func run(args []string, stdout, stderr io.Writer) int {
+	name := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	if name == "help" {
+		usage(stdout)
+		return 0
+	}
+	for _, cmd := range commands() {
+		if cmd.name == name {
+			return cmd.run(args, stdout, stderr)
+		}
+	}
+
+	fmt.Fprintf(stderr, "unknown command %q\n\n", name)
+	usage(stderr)
+	return 2
+}
+
+// usage prints the list of subcommands
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: contact-api <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands() {
+		fmt.Fprintf(w, "  %-16s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "contact-api <command> -h" for the flags of a command.`)
+}
+
+// newFlagSet creates the flag set of a subcommand
+func newFlagSet(name, summary string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: contact-api %s [flags]\n\n%s\n\nFlags:\n", name, summary)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// configFlag maps a command-line flag to the environment variable it overrides
+type configFlag struct {
+	name  string
+	env   string
+	usage string
+}
+
+// configFlagEnv lists the flags that override configuration environment variables
+var configFlagEnv = []configFlag{
+	{name: "config", env: "CONFIG_FILE", usage: "JSON config file"},
+	{name: "port", env: "PORT", usage: "HTTP port"},
+	{name: "smtp-host", env: "SMTP_HOST", usage: "SMTP server host"},
+	{name: "smtp-port", env: "SMTP_PORT", usage: "SMTP server port"},
+	{name: "default-from", env: "DEFAULT_FROM", usage: "Default sender address"},
+	{name: "default-to", env: "DEFAULT_TO", usage: "Default recipient address"},
+	{name: "data-file", env: "DATA_FILE", usage: "Storage file"},
+	{name: "secrets-dir", env: "SECRETS_DIR", usage: "Directory resolving ${NAME} references"},
+	{name: "tls-cert", env: "TLS_CERT_FILE", usage: "TLS certificate file"},
+	{name: "tls-key", env: "TLS_KEY_FILE", usage: "TLS private key file"},
+	{name: "unix-socket", env: "UNIX_SOCKET", usage: "Listen on this Unix socket instead of TCP"},
+	{name: "trusted-proxies", env: "TRUSTED_PROXIES", usage: "Comma-separated proxy IPs and CIDR ranges"},
+}
+
+// configFlags registers the flags that override configuration environment variables
+func configFlags(fs *flag.FlagSet) {
+	for _, f := range configFlagEnv {
+		fs.String(f.name, "", fmt.Sprintf("%s (overrides %s)", f.usage, f.env))
+	}
+}
+
+// parseFlags parses the arguments of a subcommand and exports the config flags
+// that were set, so they also take precedence when the configuration is
+// reloaded. It returns false with the exit code when the command should stop.
+func parseFlags(fs *flag.FlagSet, args []string) (int, bool) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0, false
+		}
+		return 2, false
+	}
+
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		for _, cf := range configFlagEnv {
+			if cf.name == f.Name && err == nil {
+				err = os.Setenv(cf.env, f.Value.String())
+			}
+		}
+	})
+	if err != nil {
+		fmt.Fprintln(fs.Output(), err)
+		return 1, false
+	}
+	return 0, true
+}
+
+// loadConfig loads the configuration, printing the error when it is invalid
+func loadConfig(stderr io.Writer) (config.Config, bool) {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return cfg, false
+	}
+	return cfg, true
+}
+
+// printVersion prints build information
+func printVersion(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("version", "Print build information.", stderr)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	fmt.Fprintf(stdout, "contact-api %s (commit %s, built %s)\n", version, commit, buildDate)
+	return 0
+}
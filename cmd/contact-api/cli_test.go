@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsantos/contact-api/internal/clientip"
+	"github.com/nahuelsantos/contact-api/internal/email"
+	"github.com/nahuelsantos/contact-api/internal/storage"
+)
+
+// setupCLI isolates the configuration environment of a command test
+func setupCLI(t *testing.T) {
+	t.Helper()
+
+	for _, f := range configFlagEnv {
+		t.Setenv(f.env, "")
+	}
+	for _, key := range []string{"ADMIN_TOKEN", "ADMIN_TOKEN_FILE", "SMTP_USERNAME", "SMTP_PASSWORD", "SMTP_PASSWORD_FILE"} {
+		t.Setenv(key, "")
+	}
+}
+
+// runCLI runs the command line and returns its exit code and output
+func runCLI(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_Commands(t *testing.T) {
+	setupCLI(t)
+
+	tests := []struct {
+		name         string
+		args         []string
+		expectedCode int
+		expectedOut  string
+	}{
+		{name: "help", args: []string{"help"}, expectedCode: 0, expectedOut: "send-test"},
+		{name: "version", args: []string{"version"}, expectedCode: 0, expectedOut: "contact-api dev"},
+		{name: "unknown command", args: []string{"deploy"}, expectedCode: 2},
+		{name: "unknown flag", args: []string{"validate-config", "-nope"}, expectedCode: 2},
+		{name: "validate-config", args: []string{"validate-config", "-smtp-host", "smtp.example.com"}, expectedCode: 0, expectedOut: `"smtp_host": "smtp.example.com"`},
+		{name: "validate-config invalid", args: []string{"validate-config", "-port", "99999"}, expectedCode: 1},
+		{name: "outbox without command", args: []string{"outbox"}, expectedCode: 2},
+		{name: "outbox without data file", args: []string{"outbox", "list"}, expectedCode: 1},
+		{name: "render without website", args: []string{"render"}, expectedCode: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runCLI(tt.args...)
+			if code != tt.expectedCode {
+				t.Errorf("Expected exit code %d, got %d: %s", tt.expectedCode, code, stderr)
+			}
+			if !strings.Contains(stdout, tt.expectedOut) {
+				t.Errorf("Expected output to contain %q, got %q", tt.expectedOut, stdout)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	setupCLI(t)
+
+	config := filepath.Join(t.TempDir(), "config.json")
+	data := `{"websites": {"main": {"recipients": ["sales@example.com"], "from": "forms@example.com", "subject_template": "[{{.Website}}] {{.Subject}}"}}}`
+	if err := os.WriteFile(config, []byte(data), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	submission := filepath.Join(t.TempDir(), "submission.json")
+	form := `{"name": "John Doe", "email": "john@example.com", "subject": "Hello", "message": "Hi there"}`
+	if err := os.WriteFile(submission, []byte(form), 0o600); err != nil {
+		t.Fatalf("Failed to write submission: %v", err)
+	}
+
+	code, stdout, stderr := runCLI("render", "-config", config, "-website", "main", submission)
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	for _, want := range []string{"From: forms@example.com", "Reply-To: john@example.com", "To: sales@example.com", "Subject: [main] Hello", "Hi there"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Expected rendered email to contain %q, got:\n%s", want, stdout)
+		}
+	}
+
+	if code, _, _ := runCLI("render", "-config", config, "-website", "other", submission); code != 1 {
+		t.Errorf("Expected unknown website to fail, got exit code %d", code)
+	}
+}
+
+func TestSendTest(t *testing.T) {
+	setupCLI(t)
+
+	var rcpts []string
+	originalDialer := email.DefaultSMTPDialer
+	email.DefaultSMTPDialer = func(addr string) (email.SMTPClient, error) {
+		return &email.MockSMTPClient{
+			RcptFunc: func(to string) error {
+				rcpts = append(rcpts, to)
+				return nil
+			},
+		}, nil
+	}
+	defer func() { email.DefaultSMTPDialer = originalDialer }()
+
+	code, stdout, stderr := runCLI("send-test", "-website", "main", "-default-to", "owner@example.com")
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if len(rcpts) != 1 || rcpts[0] != "owner@example.com" {
+		t.Errorf("Expected test email to the default recipient, got %v", rcpts)
+	}
+	if !strings.Contains(stdout, "owner@example.com") || !strings.Contains(stdout, "the smtp transport") {
+		t.Errorf("Expected confirmation naming the transport, got %q", stdout)
+	}
+}
+
+func TestSendTest_Transports(t *testing.T) {
+	setupCLI(t)
+
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config.json")
+	data := `{
+		"delivery": {"transports": [{"name": "archive", "type": "file", "path": "` + filepath.Join(dir, "mail") + `"}]},
+		"websites": {"main": {"recipients": ["sales@example.com"], "transports": ["archive"]}}
+	}`
+	if err := os.WriteFile(config, []byte(data), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	code, stdout, stderr := runCLI("send-test", "-config", config, "-website", "main")
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, "the archive transport (file)") {
+		t.Errorf("Expected delivery through the website transport, got %q", stdout)
+	}
+	if files, _ := os.ReadDir(filepath.Join(dir, "mail")); len(files) != 1 {
+		t.Errorf("Expected the test email in the file transport, got %d files", len(files))
+	}
+}
+
+func TestOutbox(t *testing.T) {
+	setupCLI(t)
+
+	dataFile := filepath.Join(t.TempDir(), "contact-api.json")
+	store, err := storage.Open(dataFile)
+	if err != nil {
+		t.Fatalf("Failed to open storage: %v", err)
+	}
+	failed, _ := store.SaveSubmission(storage.Submission{Website: "main", Email: "john@example.com", Status: storage.StatusFailed, Error: "connection refused"})
+	store.SaveSubmission(storage.Submission{Website: "main", Email: "jane@example.com", Status: storage.StatusSent})
+	store.SaveSubmission(storage.Submission{Website: "blog", Email: "joe@example.com", Status: storage.StatusFailed})
+
+	code, stdout, _ := runCLI("outbox", "list", "-data-file", dataFile)
+	if code != 0 || strings.Count(stdout, "\n") != 3 || !strings.Contains(stdout, "connection refused") {
+		t.Errorf("Expected header and two failed submissions, got %d:\n%s", code, stdout)
+	}
+
+	// Retry a single submission through a failing and then a working server
+	originalDialer := email.DefaultSMTPDialer
+	defer func() { email.DefaultSMTPDialer = originalDialer }()
+
+	email.DefaultSMTPDialer = func(addr string) (email.SMTPClient, error) {
+		return nil, errors.New("connection refused")
+	}
+	if code, _, _ := runCLI("outbox", "retry", failed.ID); code != 1 {
+		t.Errorf("Expected failed retry to exit 1, got %d", code)
+	}
+
+	email.DefaultSMTPDialer = func(addr string) (email.SMTPClient, error) {
+		return &email.MockSMTPClient{}, nil
+	}
+	if code, stdout, stderr := runCLI("outbox", "retry", failed.ID); code != 0 || !strings.Contains(stdout, failed.ID+": sent") {
+		t.Errorf("Expected retry to succeed, got %d: %s%s", code, stdout, stderr)
+	}
+
+	reopened, _ := storage.Open(dataFile)
+	if sub, _ := reopened.GetSubmission(failed.ID); sub.Status != storage.StatusSent || sub.Attempts != 2 {
+		t.Errorf("Expected retried submission to be sent after 2 attempts, got %+v", sub)
+	}
+
+	// Purge the remaining failed submission
+	if code, stdout, _ := runCLI("outbox", "purge", "-dry-run"); code != 0 || !strings.Contains(stdout, "Would purge 1") {
+		t.Errorf("Expected dry run to report 1 submission, got %d: %s", code, stdout)
+	}
+	if code, stdout, _ := runCLI("outbox", "purge"); code != 0 || !strings.Contains(stdout, "Purged 1") {
+		t.Errorf("Expected 1 submission purged, got %d: %s", code, stdout)
+	}
+	reopened, _ = storage.Open(dataFile)
+	if got := reopened.CountSubmissions(storage.SubmissionFilter{}); got != 2 {
+		t.Errorf("Expected 2 submissions left, got %d", got)
+	}
+
+	// Retrying every failed submission skips permanent failures
+	rejected, _ := reopened.SaveSubmission(storage.Submission{Website: "main", Email: "bad@example.com", Status: storage.StatusFailed, Permanent: true})
+	code, stdout, stderr := runCLI("outbox", "retry")
+	if code != 0 || strings.Contains(stdout, rejected.ID) || !strings.Contains(stderr, "skipped 1 permanently failed") {
+		t.Errorf("Expected the permanent failure to be skipped, got %d: %s%s", code, stdout, stderr)
+	}
+
+	// Submissions a store transport kept are forwarded as well
+	reopened, _ = storage.Open(dataFile)
+	kept, _ := reopened.SaveSubmission(storage.Submission{Website: "main", Email: "kept@example.com", Status: storage.StatusStored, Transport: "archive"})
+	if code, stdout, stderr := runCLI("outbox", "retry"); code != 0 || !strings.Contains(stdout, kept.ID+": sent") {
+		t.Errorf("Expected the stored submission to be sent, got %d: %s%s", code, stdout, stderr)
+	}
+}
+
+func TestClientIPMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	resolver, err := clientip.New([]string{"10.0.0.0/8"}, clientip.DefaultHeader)
+	if err != nil {
+		t.Fatalf("clientip.New() returned error: %v", err)
+	}
+	var clientIPs atomic.Pointer[clientip.Resolver]
+	clientIPs.Store(resolver)
+
+	r := gin.New()
+	if err := r.SetTrustedProxies(nil); err != nil {
+		t.Fatalf("SetTrustedProxies() returned error: %v", err)
+	}
+	r.Use(clientIPMiddleware(&clientIPs))
+	r.GET("/", func(c *gin.Context) { c.String(http.StatusOK, c.ClientIP()) })
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		expected   string
+	}{
+		{name: "behind trusted proxy", remoteAddr: "10.0.0.2:4000", expected: "198.51.100.1"},
+		{name: "untrusted peer", remoteAddr: "203.0.113.7:4000", expected: "203.0.113.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Forwarded-For", "198.51.100.1")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Body.String() != tt.expected {
+				t.Errorf("Expected client IP %q, got %q", tt.expected, w.Body.String())
+			}
+		})
+	}
+}
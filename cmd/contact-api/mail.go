@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/email"
+	"github.com/nahuelsantos/contact-api/internal/handlers"
+	"github.com/nahuelsantos/contact-api/internal/storage"
+)
+
+// sendTest sends a sample submission for a website through its transports,
+// as the server would, without storing it
+func sendTest(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("send-test", "Send a sample contact email for a website through its configured transports.", stderr)
+	website := fs.String("website", "", "Website identifier (required)")
+	form := handlers.ContactFormData{}
+	fs.StringVar(&form.Name, "name", "John Doe", "Sample visitor name")
+	fs.StringVar(&form.Email, "email", "john@example.com", "Sample visitor email")
+	fs.StringVar(&form.Subject, "subject", "Test message", "Sample subject")
+	fs.StringVar(&form.Message, "message", "This is a test message sent by contact-api send-test.", "Sample message")
+	fs.StringVar(&form.Locale, "locale", "", "Locale of the email, such as es (default: the website default)")
+	configFlags(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *website == "" {
+		fmt.Fprintln(stderr, "-website is required")
+		fs.Usage()
+		return 2
+	}
+
+	cfg, ok := loadConfig(stderr)
+	if !ok {
+		return 1
+	}
+	api, ok := openAPI(cfg, stderr)
+	if !ok {
+		return 1
+	}
+	req, ok := composeEmail(api, *website, form, stderr)
+	if !ok {
+		return 1
+	}
+	site, _ := api.Website(*website)
+
+	result, err := api.Delivery.Deliver(context.Background(), *website, site.Transports, req)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to send test email: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "Sent test email for %s to %s through the %s transport (%s)\n", *website, req.To, result.Transport, result.Type)
+	return 0
+}
+
+// render prints the email a submission read from a JSON file, or stdin, would
+// produce for a website
+func render(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("render", "Print the email a JSON submission would produce. Reads the submission from the file argument or stdin.", stderr)
+	website := fs.String("website", "", "Website identifier (required)")
+	configFlags(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *website == "" || fs.NArg() > 1 {
+		fmt.Fprintln(stderr, "-website and at most one submission file are required")
+		fs.Usage()
+		return 2
+	}
+
+	var in io.Reader = os.Stdin
+	if path := fs.Arg(0); path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var form handlers.ContactFormData
+	if err := json.NewDecoder(in).Decode(&form); err != nil {
+		fmt.Fprintf(stderr, "invalid submission: %v\n", err)
+		return 1
+	}
+
+	cfg, ok := loadConfig(stderr)
+	if !ok {
+		return 1
+	}
+	api, ok := openAPI(cfg, stderr)
+	if !ok {
+		return 1
+	}
+	req, ok := composeEmail(api, *website, form, stderr)
+	if !ok {
+		return 1
+	}
+	if req.From == "" {
+		req.From = cfg.DefaultFrom
+	}
+
+	_, _ = stdout.Write(email.Message(req, time.Now()))
+	fmt.Fprintln(stdout)
+	return 0
+}
+
+// openAPI sets up the handlers with the configured and stored website
+// definitions. The storage file is only read, so that a running server keeps
+// sole ownership of it.
+func openAPI(cfg config.Config, stderr io.Writer) (*handlers.API, bool) {
+	store, err := storage.OpenReadOnly(cfg.DataFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to open storage: %v\n", err)
+		return nil, false
+	}
+	return handlers.New(cfg, store), true
+}
+
+// composeEmail builds the email for a submission to a website
+func composeEmail(api *handlers.API, website string, form handlers.ContactFormData, stderr io.Writer) (email.Request, bool) {
+	req, err := api.Compose(website, form)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", website, err)
+		return email.Request{}, false
+	}
+	return req, true
+}
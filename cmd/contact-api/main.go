@@ -1,24 +1,17 @@
-// Package contact provides the main entry point for the Contact API server
-package contact
+// Command contact-api runs the Contact API server and its maintenance commands
+package main
 
 import (
-	"context"
-	"log/slog"
-	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
-	"time"
 
-	"github.com/gin-gonic/gin"
 	_ "github.com/nahuelsantos/contact-api/docs"
-	"github.com/nahuelsantos/contact-api/internal/config"
-	"github.com/nahuelsantos/contact-api/internal/handlers"
-	"github.com/nahuelsantos/contact-api/internal/observability"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
-	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// Build information, set with -ldflags "-X main.version=..."
+var (
+	version   = "dev"
+	commit    = "none"
+	buildDate = "unknown"
 )
 
 // @title Contact API
@@ -36,155 +29,9 @@ import (
 // @host localhost:3002
 // @BasePath /api/v1
 
-// Run starts the Contact API server with observability, CORS, and graceful shutdown
-func Run() {
-	// Initialize structured logging
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
-	slog.SetDefault(logger)
-
-	// Load configuration
-	cfg, err := config.Load()
-	if err != nil {
-		slog.Error("Failed to load configuration", "error", err)
-		os.Exit(1)
-	}
-
-	// Initialize OpenTelemetry
-	cleanup, err := observability.InitTracing("contact-api")
-	if err != nil {
-		slog.Error("Failed to initialize tracing", "error", err)
-		os.Exit(1)
-	}
-	defer cleanup()
-
-	// Set Gin mode
-	if os.Getenv("GIN_MODE") == "" {
-		gin.SetMode(gin.ReleaseMode)
-	}
-
-	// Create Gin router
-	r := gin.New()
-
-	// Add middleware
-	r.Use(gin.Recovery())
-	r.Use(otelgin.Middleware("contact-api"))
-	r.Use(corsMiddleware())
-	r.Use(loggingMiddleware())
-
-	// Create API handlers
-	api := handlers.New(cfg)
-
-	// API routes
-	v1 := r.Group("/api/v1")
-	{
-		v1.POST("/contact/:website", api.ContactHandler)
-		v1.GET("/contact/:website/health", api.WebsiteHealthCheck)
-	}
-
-	// Global routes
-	r.GET("/health", api.HealthCheck)
-	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-
-	// Get port from environment variable or use default
-	port := cfg.Port
-	if port == "" {
-		port = "3002"
-	}
-
-	// Create server with timeouts
-	server := &http.Server{
-		Addr:         ":" + port,
-		Handler:      r,
-		ReadTimeout:  30 * time.Second,
-		WriteTimeout: 30 * time.Second,
-		IdleTimeout:  120 * time.Second,
-	}
-
-	// Start server in goroutine
-	go func() {
-		slog.Info("Starting Contact API server",
-			"port", port,
-			"smtp_host", cfg.SMTPHost,
-			"smtp_port", cfg.SMTPPort,
-		)
-
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			slog.Error("Server error", "error", err)
-			os.Exit(1)
-		}
-	}()
-
-	// Wait for interrupt signal for graceful shutdown
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-
-	slog.Info("Shutting down server...")
-
-	// Graceful shutdown with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	if err := server.Shutdown(ctx); err != nil {
-		slog.Error("Server forced to shutdown", "error", err)
-		os.Exit(1)
-	}
-
-	slog.Info("Server exited gracefully")
-}
-
-// corsMiddleware provides CORS support for web forms
-func corsMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
-
-		c.Next()
-	}
-}
-
-// loggingMiddleware provides structured logging for requests
-func loggingMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		start := time.Now()
-		path := c.Request.URL.Path
-		raw := c.Request.URL.RawQuery
-
-		// Process request
-		c.Next()
-
-		// Log request details
-		latency := time.Since(start)
-		clientIP := c.ClientIP()
-		method := c.Request.Method
-		statusCode := c.Writer.Status()
-
-		if raw != "" {
-			path = path + "?" + raw
-		}
-
-		slog.Info("HTTP request",
-			"status", statusCode,
-			"method", method,
-			"path", path,
-			"ip", clientIP,
-			"latency", latency.String(),
-		)
-	}
-}
-
-//nolint:unused // This is used when running the package directly
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
 func main() {
-	// Only run when directly invoked
-	Run()
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
 }
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/handlers"
+	"github.com/nahuelsantos/contact-api/internal/storage"
+)
+
+// outbox manages submissions that failed to deliver. It works on DATA_FILE
+// directly, so it should not run while a server writes to the same file.
+func outbox(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		outboxUsage(stderr)
+		return 2
+	}
+
+	switch args[0] {
+	case "list":
+		return outboxList(args[1:], stdout, stderr)
+	case "retry":
+		return outboxRetry(args[1:], stdout, stderr)
+	case "purge":
+		return outboxPurge(args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		outboxUsage(stdout)
+		return 0
+	}
+
+	fmt.Fprintf(stderr, "unknown outbox command %q\n\n", args[0])
+	outboxUsage(stderr)
+	return 2
+}
+
+// outboxUsage prints the outbox subcommands
+func outboxUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: contact-api outbox <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	fmt.Fprintln(w, "  list   List submissions, failed ones by default")
+	fmt.Fprintln(w, "  retry  Deliver failed and stored submissions again, the given IDs or all but permanent failures")
+	fmt.Fprintln(w, "  purge  Delete failed submissions")
+}
+
+// outboxList prints stored submissions
+func outboxList(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("outbox list", "List stored submissions, newest first.", stderr)
+	website := fs.String("website", "", "Only list submissions for this website")
+	status := fs.String("status", storage.StatusFailed, "Submission status: failed, stored, sent, quarantined or all")
+	limit := fs.Int("limit", 50, "Maximum number of submissions, 0 for all")
+	asJSON := fs.Bool("json", false, "Print JSON instead of a table")
+	configFlags(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	_, store, ok := openOutbox(stderr)
+	if !ok {
+		return 1
+	}
+	subs := store.ListSubmissions(storage.SubmissionFilter{
+		Website: *website,
+		Status:  statusFilter(*status),
+		Limit:   *limit,
+	})
+
+	if *asJSON {
+		if subs == nil {
+			subs = []storage.Submission{}
+		}
+		out, _ := json.MarshalIndent(subs, "", "  ")
+		fmt.Fprintln(stdout, string(out))
+		return 0
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tWEBSITE\tSTATUS\tATTEMPTS\tCREATED\tERROR")
+	for _, sub := range subs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\n",
+			sub.ID, sub.Website, sub.Status, sub.Attempts, sub.CreatedAt.Format(time.RFC3339), sub.Error)
+	}
+	_ = tw.Flush()
+	return 0
+}
+
+// outboxRetry delivers failed submissions again, along with those a store
+// transport kept because every transport before it failed
+func outboxRetry(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("outbox retry", "Deliver failed and stored submissions again. Retries the given IDs, or every submission that did not fail permanently.", stderr)
+	website := fs.String("website", "", "Only retry submissions for this website")
+	configFlags(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	cfg, store, ok := openOutbox(stderr)
+	if !ok {
+		return 1
+	}
+	api := handlers.New(cfg, store)
+
+	// Permanent failures are only retried by ID
+	var subs []storage.Submission
+	if fs.NArg() == 0 {
+		skipped := 0
+		for _, sub := range store.ListSubmissions(storage.SubmissionFilter{Website: *website, Status: storage.StatusFailed}) {
+			if sub.Permanent {
+				skipped++
+				continue
+			}
+			subs = append(subs, sub)
+		}
+		subs = append(subs, store.ListSubmissions(storage.SubmissionFilter{Website: *website, Status: storage.StatusStored})...)
+		if skipped > 0 {
+			fmt.Fprintf(stderr, "skipped %d permanently failed submissions, retry them by ID\n", skipped)
+		}
+	}
+	code := 0
+	for _, id := range fs.Args() {
+		sub, err := store.GetSubmission(id)
+		if err != nil || (*website != "" && sub.Website != *website) {
+			fmt.Fprintf(stderr, "%s: submission not found\n", id)
+			code = 1
+			continue
+		}
+		subs = append(subs, sub)
+	}
+
+	for _, sub := range subs {
+		sub, err := api.Resend(sub)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", sub.ID, err)
+			code = 1
+			continue
+		}
+		fmt.Fprintf(stdout, "%s: %s\n", sub.ID, sub.Status)
+	}
+	return code
+}
+
+// outboxPurge deletes stored submissions
+func outboxPurge(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("outbox purge", "Delete stored submissions, failed ones by default.", stderr)
+	website := fs.String("website", "", "Only purge submissions for this website")
+	status := fs.String("status", storage.StatusFailed, "Submission status: failed, stored, sent, quarantined or all")
+	olderThan := fs.Duration("older-than", 0, "Only purge submissions older than this, such as 720h")
+	dryRun := fs.Bool("dry-run", false, "Print how many submissions would be purged without deleting them")
+	configFlags(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	_, store, ok := openOutbox(stderr)
+	if !ok {
+		return 1
+	}
+
+	filter := storage.SubmissionFilter{Website: *website, Status: statusFilter(*status)}
+	if *olderThan > 0 {
+		filter.Before = time.Now().Add(-*olderThan)
+	}
+
+	if *dryRun {
+		fmt.Fprintf(stdout, "Would purge %d submissions\n", store.CountSubmissions(filter))
+		return 0
+	}
+
+	purged, err := store.PurgeSubmissions(filter)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to purge submissions: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "Purged %d submissions\n", purged)
+	return 0
+}
+
+// errNoDataFile explains why the outbox commands need persistent storage
+var errNoDataFile = errors.New("DATA_FILE is not set, so submissions only live in the memory of the running server")
+
+// openOutbox loads the configuration and opens the storage file holding submissions
+func openOutbox(stderr io.Writer) (config.Config, *storage.Store, bool) {
+	cfg, ok := loadConfig(stderr)
+	if !ok {
+		return cfg, nil, false
+	}
+	if cfg.DataFile == "" {
+		fmt.Fprintln(stderr, errNoDataFile)
+		return cfg, nil, false
+	}
+
+	store, err := storage.Open(cfg.DataFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to open storage: %v\n", err)
+		return cfg, nil, false
+	}
+	return cfg, store, true
+}
+
+// statusFilter maps the status flag to a submission filter, where "all" matches any status
+func statusFilter(status string) string {
+	if status == "all" {
+		return ""
+	}
+	return status
+}
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsantos/contact-api/internal/clientip"
+	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/handlers"
+	"github.com/nahuelsantos/contact-api/internal/middleware"
+	"github.com/nahuelsantos/contact-api/internal/observability"
+	"github.com/nahuelsantos/contact-api/internal/server"
+	"github.com/nahuelsantos/contact-api/internal/storage"
+	"github.com/nahuelsantos/contact-api/internal/widget"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// serve starts the Contact API server with observability, CORS, and graceful shutdown
+func serve(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("serve", "Run the HTTP server.", stderr)
+	configFlags(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	// Initialize structured logging, tagging request logs with their request ID
+	logger := slog.New(middleware.LogHandler{Handler: slog.NewJSONHandler(stdout, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	})})
+	slog.SetDefault(logger)
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("Failed to load configuration", "error", err)
+		return 1
+	}
+
+	// Initialize OpenTelemetry
+	cleanup, err := observability.InitTracing("contact-api")
+	if err != nil {
+		slog.Error("Failed to initialize tracing", "error", err)
+		return 1
+	}
+	defer cleanup()
+
+	// Set Gin mode
+	if os.Getenv("GIN_MODE") == "" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	// Open storage for submissions, API keys and the audit log
+	store, err := storage.Open(cfg.DataFile)
+	if err != nil {
+		slog.Error("Failed to open storage", "error", err)
+		return 1
+	}
+
+	// Create API handlers
+	api := handlers.New(cfg, store)
+
+	// Create Gin router. Proxy headers are resolved by clientIPMiddleware,
+	// so Gin itself trusts none and reports the resolved peer address.
+	r := gin.New()
+	if err := r.SetTrustedProxies(nil); err != nil {
+		slog.Error("Failed to configure trusted proxies", "error", err)
+		return 1
+	}
+
+	resolver, err := clientip.New(cfg.Server.TrustedProxies, cfg.Server.ClientIPHeader)
+	if err != nil {
+		slog.Error("Failed to configure trusted proxies", "error", err)
+		return 1
+	}
+	var clientIPs atomic.Pointer[clientip.Resolver]
+	clientIPs.Store(resolver)
+
+	// Add middleware
+	r.Use(clientIPMiddleware(&clientIPs))
+	r.Use(middleware.RequestID())
+	r.Use(gin.Recovery())
+	r.Use(otelgin.Middleware("contact-api"))
+	r.Use(middleware.SecurityHeaders(func() config.SecurityHeaders {
+		return api.Config().Server.SecurityHeaders
+	}))
+	r.Use(corsMiddleware())
+	r.Use(loggingMiddleware())
+
+	// API routes
+	v1 := r.Group("/api/v1")
+	{
+		v1.POST("/contact/:website", api.ContactHandler)
+		v1.GET("/contact/:website/health", api.WebsiteHealthCheck)
+		v1.GET("/contact/:website/challenge", api.IssueChallenge)
+		v1.GET("/contact/:website/form", api.ContactFormDefinition)
+		v1.GET("/pow.js", api.ChallengeScript)
+		v1.GET("/widget/"+widget.Version+"/widget.js", api.WidgetScript)
+		v1.GET("/widget/"+widget.Version+"/widget.css", api.WidgetStyles)
+		v1.GET("/widget/"+widget.Version+"/frame/:website", api.WidgetFrame)
+
+		api.RegisterAdminRoutes(v1)
+	}
+
+	// Global routes
+	r.GET("/health", api.HealthCheck)
+	r.GET("/livez", api.LivenessCheck)
+	r.GET("/readyz", api.ReadinessCheck)
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	api.RegisterDevMailRoutes(r)
+
+	// Load the TLS certificate, reloaded when its files change
+	var certs *server.CertReloader
+	if cfg.Server.TLS() {
+		certs, err = server.NewCertReloader(cfg.Server.TLSCertFile, cfg.Server.TLSKeyFile)
+		if err != nil {
+			slog.Error("Failed to load TLS certificate", "error", err)
+			return 1
+		}
+	}
+
+	// Create server with the configured timeouts and listener
+	srv := server.New(cfg.Server, r, certs)
+	ln, err := server.Listen(cfg)
+	if err != nil {
+		slog.Error("Failed to listen", "error", err)
+		return 1
+	}
+
+	// Start server in goroutine
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("Starting Contact API server",
+			"address", ln.Addr().String(),
+			"tls", certs != nil,
+			"smtp_host", cfg.SMTPHost,
+			"smtp_port", cfg.SMTPPort,
+			"version", version,
+		)
+		if cfg.DevMail.Enabled {
+			slog.Warn("Development mail catcher enabled, messages are captured at /_dev/mail instead of sent")
+		}
+
+		if err := server.Serve(srv, ln); err != nil && err != http.ErrServerClosed {
+			serverErr <- err
+		}
+	}()
+
+	// Reload configuration on SIGHUP or when the config file changes
+	observability.SetConfigHash(config.Hash(cfg))
+	reloadCtx, stopReload := context.WithCancel(context.Background())
+	defer stopReload()
+	go watchConfig(reloadCtx, api, &clientIPs, time.Duration(cfg.WatchInterval))
+	if certs != nil {
+		go watchCertificates(reloadCtx, certs, time.Duration(cfg.WatchInterval))
+	}
+
+	// Refresh list files and drop expired list entries
+	go api.Lists.Watch(reloadCtx, time.Duration(cfg.Lists.RefreshInterval), func() []config.ListFile {
+		return api.Config().Lists.Files
+	})
+
+	// Wait for interrupt signal for graceful shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case <-quit:
+	case err := <-serverErr:
+		slog.Error("Server error", "error", err)
+		return 1
+	}
+
+	slog.Info("Shutting down server...")
+
+	// Graceful shutdown with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Server.ShutdownGrace))
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Error("Server forced to shutdown", "error", err)
+		return 1
+	}
+
+	// Quit pooled SMTP connections
+	api.Delivery.Close()
+
+	slog.Info("Server exited gracefully")
+	return 0
+}
+
+// watchConfig serializes configuration reloads triggered by SIGHUP and by
+// changes to the config file or secret files
+func watchConfig(ctx context.Context, api *handlers.API, clientIPs *atomic.Pointer[clientip.Resolver], interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	// Watch the config file and the files secrets were read from
+	paths := func() []string {
+		return append([]string{os.Getenv("CONFIG_FILE")}, api.Config().SecretFiles...)
+	}
+
+	changed := make(chan struct{}, 1)
+	go config.Watch(ctx, interval, paths, func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			reloadConfig(api, clientIPs, "sighup")
+		case <-changed:
+			reloadConfig(api, clientIPs, "file")
+		}
+	}
+}
+
+// reloadConfig loads the configuration again and applies it if it is valid,
+// keeping the previous configuration otherwise
+func reloadConfig(api *handlers.API, clientIPs *atomic.Pointer[clientip.Resolver], trigger string) {
+	previous := api.Config()
+
+	cfg, err := config.Load()
+	var resolver *clientip.Resolver
+	if err == nil {
+		resolver, err = clientip.New(cfg.Server.TrustedProxies, cfg.Server.ClientIPHeader)
+	}
+	if err == nil {
+		err = api.Reload(cfg)
+	}
+	if err != nil {
+		observability.ConfigReloads.WithLabelValues("failure").Inc()
+		slog.Error("Configuration reload failed, keeping previous configuration",
+			"trigger", trigger,
+			"hash", config.Hash(previous),
+			"error", err,
+		)
+		return
+	}
+
+	clientIPs.Store(resolver)
+
+	if cfg.Port != previous.Port || cfg.DataFile != previous.DataFile || serverChanged(previous.Server, cfg.Server) {
+		slog.Warn("Port, data file and server listener changes only apply after a restart", "trigger", trigger)
+	}
+
+	hash := config.Hash(cfg)
+	observability.ConfigReloads.WithLabelValues("success").Inc()
+	observability.SetConfigHash(hash)
+	slog.Info("Configuration reloaded",
+		"trigger", trigger,
+		"hash", hash,
+		"previous_hash", config.Hash(previous),
+	)
+}
+
+// serverChanged reports whether server settings that need a restart differ
+func serverChanged(from, to config.Server) bool {
+	from.TrustedProxies, from.ClientIPHeader, from.SecurityHeaders = nil, "", config.SecurityHeaders{}
+	to.TrustedProxies, to.ClientIPHeader, to.SecurityHeaders = nil, "", config.SecurityHeaders{}
+	return !reflect.DeepEqual(from, to)
+}
+
+// watchCertificates reloads the TLS certificate on SIGHUP and when its files change
+func watchCertificates(ctx context.Context, certs *server.CertReloader, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	changed := make(chan struct{}, 1)
+	go config.Watch(ctx, interval, certs.Files, func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			reloadCertificates(certs, "sighup")
+		case <-changed:
+			reloadCertificates(certs, "file")
+		}
+	}
+}
+
+// reloadCertificates loads the TLS certificate again, keeping the previous
+// one if the new pair is invalid
+func reloadCertificates(certs *server.CertReloader, trigger string) {
+	if err := certs.Reload(); err != nil {
+		slog.Error("TLS certificate reload failed, keeping previous certificate", "trigger", trigger, "error", err)
+		return
+	}
+	slog.Info("TLS certificate reloaded", "trigger", trigger)
+}
+
+// clientIPMiddleware replaces the peer address of each request with the client
+// address resolved through trusted proxies, so c.ClientIP() reports the visitor
+func clientIPMiddleware(clientIPs *atomic.Pointer[clientip.Resolver]) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if ip := clientIPs.Load().ClientIP(c.Request); ip != "" {
+			_, port, err := net.SplitHostPort(c.Request.RemoteAddr)
+			if err != nil {
+				port = "0"
+			}
+			c.Request.RemoteAddr = net.JoinHostPort(ip, port)
+		}
+		c.Next()
+	}
+}
+
+// corsMiddleware provides CORS support for web forms
+func corsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Request-ID")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "X-Request-ID")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+
+		if c.Request.Method == "OPTIONS" {
+			c.AbortWithStatus(204)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// loggingMiddleware provides structured logging for requests
+func loggingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+		raw := c.Request.URL.RawQuery
+
+		// Process request
+		c.Next()
+
+		// Log request details
+		latency := time.Since(start)
+		clientIP := c.ClientIP()
+		method := c.Request.Method
+		statusCode := c.Writer.Status()
+
+		if raw != "" {
+			path = path + "?" + raw
+		}
+
+		slog.InfoContext(c.Request.Context(), "HTTP request",
+			"status", statusCode,
+			"method", method,
+			"path", path,
+			"ip", clientIP,
+			"latency", latency.String(),
+		)
+	}
+}
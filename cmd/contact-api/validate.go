@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+)
+
+// validateConfig loads the configuration from the environment and config file,
+// prints the effective configuration with secrets redacted and reports every
+// problem found
+func validateConfig(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("validate-config", "Check the configuration and print it with secrets redacted.", stderr)
+	configFlags(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	cfg, err := config.Load()
+
+	var validationErr config.ValidationError
+	if err != nil && !errors.As(err, &validationErr) {
+		// The config file could not be read, so there is nothing to print
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	out, _ := json.MarshalIndent(config.Redacted(cfg), "", "  ")
+	fmt.Fprintln(stdout, string(out))
+
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	fmt.Fprintf(stderr, "configuration is valid (hash %s)\n", config.Hash(cfg))
+	return 0
+}
@@ -0,0 +1,163 @@
+// Package auth implements admin API keys and their scoped permissions
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/nahuelsantos/contact-api/internal/storage"
+)
+
+// Permission grants access to a group of admin actions
+type Permission string
+
+// Admin permissions
+const (
+	PermReadSubmissions Permission = "read-submissions"
+	PermManageSites     Permission = "manage-sites"
+	PermResend          Permission = "resend"
+	PermManageKeys      Permission = "manage-keys"
+	PermReadAudit       Permission = "read-audit"
+	PermTrainSpam       Permission = "train-spam"
+	PermManageLists     Permission = "manage-lists"
+)
+
+// AllPermissions lists every known permission
+var AllPermissions = []Permission{
+	PermReadSubmissions,
+	PermManageSites,
+	PermResend,
+	PermManageKeys,
+	PermReadAudit,
+	PermTrainSpam,
+	PermManageLists,
+}
+
+// AllWebsites is the website scope that matches every website
+const AllWebsites = "*"
+
+// keyPrefix identifies contact-api keys in logs and secret scanners
+const keyPrefix = "cak"
+
+// ErrInvalidKey is returned when a key is malformed, unknown or revoked
+var ErrInvalidKey = errors.New("invalid API key")
+
+// Principal is an authenticated caller of the admin API
+type Principal struct {
+	KeyID       string
+	Name        string
+	Websites    []string
+	Permissions []Permission
+}
+
+// Root returns a principal with every permission on every website
+func Root() Principal {
+	return Principal{
+		KeyID:       "root",
+		Name:        "admin token",
+		Websites:    []string{AllWebsites},
+		Permissions: AllPermissions,
+	}
+}
+
+// Can reports whether the principal holds a permission
+func (p Principal) Can(perm Permission) bool {
+	return slices.Contains(p.Permissions, perm)
+}
+
+// CanAccessWebsite reports whether the principal is scoped to a website
+func (p Principal) CanAccessWebsite(website string) bool {
+	return slices.Contains(p.Websites, AllWebsites) || slices.Contains(p.Websites, website)
+}
+
+// Covers reports whether the principal holds every given permission and
+// website scope, so it cannot grant more than it has
+func (p Principal) Covers(websites []string, perms []Permission) bool {
+	for _, perm := range perms {
+		if !p.Can(perm) {
+			return false
+		}
+	}
+	for _, website := range websites {
+		if website == AllWebsites {
+			if !slices.Contains(p.Websites, AllWebsites) {
+				return false
+			}
+			continue
+		}
+		if !p.CanAccessWebsite(website) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParsePermissions validates permission names
+func ParsePermissions(names []string) ([]Permission, error) {
+	perms := make([]Permission, 0, len(names))
+	for _, name := range names {
+		perm := Permission(name)
+		if !slices.Contains(AllPermissions, perm) {
+			return nil, fmt.Errorf("unknown permission %q", name)
+		}
+		perms = append(perms, perm)
+	}
+	return perms, nil
+}
+
+// NewPrincipal builds a principal from a stored key
+func NewPrincipal(key storage.APIKey) Principal {
+	perms := make([]Permission, 0, len(key.Permissions))
+	for _, name := range key.Permissions {
+		perms = append(perms, Permission(name))
+	}
+	return Principal{
+		KeyID:       key.ID,
+		Name:        key.Name,
+		Websites:    key.Websites,
+		Permissions: perms,
+	}
+}
+
+// GenerateSecret creates the plaintext for a key with the given ID.
+// The plaintext is only ever shown once; store HashSecret of it instead.
+func GenerateSecret(id string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	return keyPrefix + "_" + id + "_" + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashSecret returns the value stored at rest for a plaintext key
+func HashSecret(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
+
+// ParseKeyID extracts the key ID from a plaintext key
+func ParseKeyID(plaintext string) (string, error) {
+	parts := strings.SplitN(plaintext, "_", 3)
+	if len(parts) != 3 || parts[0] != keyPrefix || parts[1] == "" || parts[2] == "" {
+		return "", ErrInvalidKey
+	}
+	return parts[1], nil
+}
+
+// Verify checks a plaintext key against a stored key
+func Verify(plaintext string, key storage.APIKey) error {
+	if key.RevokedAt != nil {
+		return ErrInvalidKey
+	}
+	if subtle.ConstantTimeCompare([]byte(HashSecret(plaintext)), []byte(key.Hash)) != 1 {
+		return ErrInvalidKey
+	}
+	return nil
+}
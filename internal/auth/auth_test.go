@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nahuelsantos/contact-api/internal/storage"
+)
+
+func TestGenerateAndVerify(t *testing.T) {
+	plaintext, err := GenerateSecret("abc123")
+	if err != nil {
+		t.Fatalf("GenerateSecret() returned error: %v", err)
+	}
+
+	id, err := ParseKeyID(plaintext)
+	if err != nil {
+		t.Fatalf("ParseKeyID() returned error: %v", err)
+	}
+	if id != "abc123" {
+		t.Errorf("ParseKeyID() = %q, want %q", id, "abc123")
+	}
+
+	key := storage.APIKey{ID: id, Hash: HashSecret(plaintext)}
+	if key.Hash == plaintext {
+		t.Fatal("hash must not equal the plaintext key")
+	}
+	if err := Verify(plaintext, key); err != nil {
+		t.Errorf("Verify() returned error for a valid key: %v", err)
+	}
+	if err := Verify(plaintext+"x", key); err == nil {
+		t.Error("Verify() accepted a wrong key")
+	}
+
+	revoked := time.Now()
+	key.RevokedAt = &revoked
+	if err := Verify(plaintext, key); err == nil {
+		t.Error("Verify() accepted a revoked key")
+	}
+}
+
+func TestParseKeyID_Invalid(t *testing.T) {
+	for _, plaintext := range []string{"", "secret", "cak__abc", "xyz_id_secret"} {
+		if _, err := ParseKeyID(plaintext); err == nil {
+			t.Errorf("ParseKeyID(%q) should fail", plaintext)
+		}
+	}
+}
+
+func TestPrincipal_Scopes(t *testing.T) {
+	p := Principal{
+		Websites:    []string{"main"},
+		Permissions: []Permission{PermReadSubmissions, PermManageKeys},
+	}
+
+	if !p.Can(PermReadSubmissions) || p.Can(PermResend) {
+		t.Error("Can() does not match granted permissions")
+	}
+	if !p.CanAccessWebsite("main") || p.CanAccessWebsite("blog") {
+		t.Error("CanAccessWebsite() does not match granted websites")
+	}
+
+	tests := []struct {
+		name     string
+		websites []string
+		perms    []Permission
+		expected bool
+	}{
+		{name: "subset", websites: []string{"main"}, perms: []Permission{PermReadSubmissions}, expected: true},
+		{name: "extra permission", websites: []string{"main"}, perms: []Permission{PermResend}, expected: false},
+		{name: "extra website", websites: []string{"blog"}, perms: nil, expected: false},
+		{name: "all websites", websites: []string{AllWebsites}, perms: nil, expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.Covers(tt.websites, tt.perms); got != tt.expected {
+				t.Errorf("Covers() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+
+	if !Root().Covers([]string{AllWebsites}, AllPermissions) {
+		t.Error("Root() should cover everything")
+	}
+}
+
+func TestParsePermissions(t *testing.T) {
+	if _, err := ParsePermissions([]string{"read-submissions", "resend"}); err != nil {
+		t.Errorf("ParsePermissions() returned error: %v", err)
+	}
+	if _, err := ParsePermissions([]string{"delete-everything"}); err == nil {
+		t.Error("ParsePermissions() accepted an unknown permission")
+	}
+}
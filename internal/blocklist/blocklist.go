@@ -0,0 +1,231 @@
+// Package blocklist decides whether submissions are blocked or always allowed
+// by their client IP address and sender email address
+package blocklist
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/netip"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/storage"
+)
+
+// Kinds of list values
+const (
+	KindIP     = "ip"
+	KindEmail  = "email"
+	KindDomain = "domain"
+)
+
+// domainPattern matches domain names, without a trailing dot
+var domainPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$`)
+
+// Parse classifies a list value and returns it in canonical form. Values are
+// IP addresses, CIDR ranges, email addresses, or domains optionally written
+// as @example.com or *.example.com; a domain also matches its subdomains.
+func Parse(value string) (kind, canonical string, err error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return "", "", errors.New("must not be empty")
+	}
+
+	if prefix, err := netip.ParsePrefix(value); err == nil {
+		return KindIP, prefix.Masked().String(), nil
+	}
+	if addr, err := netip.ParseAddr(value); err == nil {
+		return KindIP, addr.Unmap().WithZone("").String(), nil
+	}
+
+	if local, domain, ok := strings.Cut(value, "@"); ok && local != "" {
+		if strings.ContainsAny(local, " <>") || !domainPattern.MatchString(domain) {
+			return "", "", fmt.Errorf("invalid email address %q", value)
+		}
+		return KindEmail, value, nil
+	}
+
+	domain := strings.TrimPrefix(strings.TrimPrefix(value, "@"), "*.")
+	if !domainPattern.MatchString(domain) {
+		return "", "", fmt.Errorf("%q is not an IP address, CIDR range, email address or domain", value)
+	}
+	return KindDomain, domain, nil
+}
+
+// Match is the entry a submission matched and whether it matched by client IP
+// address or by sender. The zero Match means no entry did.
+type Match struct {
+	List  string            `json:"list,omitempty"`
+	Kind  string            `json:"kind,omitempty"`
+	Entry storage.ListEntry `json:"entry"`
+}
+
+// Blocked reports whether the submission matched the block list
+func (m Match) Blocked() bool { return m.List == config.ListBlock }
+
+// Allowed reports whether the submission matched the allow list
+func (m Match) Allowed() bool { return m.List == config.ListAllow }
+
+// Trusted reports whether the submission matched the allow list by its client
+// IP address. Senders are chosen by whoever submits, so an allowed sender
+// alone is not trusted.
+func (m Match) Trusted() bool { return m.Allowed() && m.Kind == KindIP }
+
+// Lists combines the entries managed through the admin API with the entries
+// imported from files and matches submissions against both
+type Lists struct {
+	store *storage.Store
+
+	// mu serializes rebuilds and guards files
+	mu    sync.Mutex
+	files map[string][]storage.ListEntry
+
+	index atomic.Pointer[index]
+}
+
+// New returns lists backed by the stored entries
+func New(store *storage.Store) *Lists {
+	l := &Lists{store: store, files: map[string][]storage.ListEntry{}}
+	l.Rebuild()
+	return l
+}
+
+// Check matches a submission against the lists, by client IP address first
+// and then by sender. Allow list entries win over block list entries of the
+// same kind, so a trusted address can be let through a blocked range or
+// domain, but an allowed sender never overrides a blocked IP address.
+func (l *Lists) Check(website, ip, email string) Match {
+	now := time.Now()
+	idx := l.index.Load()
+	for _, kind := range []string{KindIP, KindEmail} {
+		for _, list := range []string{config.ListAllow, config.ListBlock} {
+			if entry, ok := idx.match(list, kind, website, ip, email, now); ok {
+				return Match{List: list, Kind: kind, Entry: entry}
+			}
+		}
+	}
+	return Match{}
+}
+
+// Entries returns the stored entries followed by the imported ones
+func (l *Lists) Entries() []storage.ListEntry {
+	entries := l.store.ListEntries()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range slices.Sorted(maps.Keys(l.files)) {
+		entries = append(entries, l.files[key]...)
+	}
+	return entries
+}
+
+// Rebuild indexes the current entries. It runs after every change to the
+// stored entries.
+func (l *Lists) Rebuild() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	idx := newIndex()
+	for _, entry := range l.store.ListEntries() {
+		idx.add(entry)
+	}
+	for _, key := range slices.Sorted(maps.Keys(l.files)) {
+		for _, entry := range l.files[key] {
+			idx.add(entry)
+		}
+	}
+	l.index.Store(idx)
+}
+
+// LoadFiles reads the configured list files. A file that cannot be read
+// keeps the entries it had, and its error is returned.
+func (l *Lists) LoadFiles(files []config.ListFile) error {
+	var errs []error
+	loaded := make(map[string][]storage.ListEntry, len(files))
+
+	l.mu.Lock()
+	for _, file := range files {
+		key := file.List + ":" + file.Website + ":" + file.Path
+		entries, err := readFile(file)
+		if err != nil {
+			errs = append(errs, err)
+			entries = l.files[key]
+		}
+		loaded[key] = entries
+	}
+	l.files = loaded
+	l.mu.Unlock()
+
+	l.Rebuild()
+	return errors.Join(errs...)
+}
+
+// Watch reads the list files again and drops expired stored entries every
+// interval until ctx is cancelled
+func (l *Lists) Watch(ctx context.Context, interval time.Duration, files func() []config.ListFile) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := l.store.PurgeExpiredListEntries(time.Now()); err != nil {
+				slog.Error("Failed to purge expired list entries", "error", err)
+			}
+			if err := l.LoadFiles(files()); err != nil {
+				slog.Error("Failed to refresh list files", "error", err)
+			}
+		}
+	}
+}
+
+// readFile parses a list file into entries
+func readFile(file config.ListFile) ([]storage.ListEntry, error) {
+	f, err := os.Open(file.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read list file: %w", err)
+	}
+	defer f.Close()
+	return parseEntries(f, file)
+}
+
+// parseEntries reads one value per line, skipping blank lines and comments
+func parseEntries(r io.Reader, file config.ListFile) ([]storage.ListEntry, error) {
+	var entries []storage.ListEntry
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		value, _, _ := strings.Cut(scanner.Text(), "#")
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		if _, _, err := Parse(value); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", file.Path, line, err)
+		}
+		entries = append(entries, storage.ListEntry{
+			List:    file.List,
+			Value:   value,
+			Website: file.Website,
+			Source:  file.Path,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read list file %s: %w", file.Path, err)
+	}
+	return entries, nil
+}
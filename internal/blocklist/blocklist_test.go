@@ -0,0 +1,171 @@
+package blocklist
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/storage"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value     string
+		kind      string
+		canonical string
+		wantErr   bool
+	}{
+		{value: "203.0.113.7", kind: KindIP, canonical: "203.0.113.7"},
+		{value: "203.0.113.7/24", kind: KindIP, canonical: "203.0.113.0/24"},
+		{value: "2001:DB8::/32", kind: KindIP, canonical: "2001:db8::/32"},
+		{value: "::ffff:203.0.113.7", kind: KindIP, canonical: "203.0.113.7"},
+		{value: " Bot@Spam.Example ", kind: KindEmail, canonical: "bot@spam.example"},
+		{value: "spam.example", kind: KindDomain, canonical: "spam.example"},
+		{value: "@spam.example", kind: KindDomain, canonical: "spam.example"},
+		{value: "*.spam.example", kind: KindDomain, canonical: "spam.example"},
+		{value: "", wantErr: true},
+		{value: "not a domain", wantErr: true},
+		{value: "bot@", wantErr: true},
+		{value: "203.0.113.7/40", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			kind, canonical, err := Parse(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if kind != tt.kind || canonical != tt.canonical {
+				t.Errorf("Parse() = %s %q, want %s %q", kind, canonical, tt.kind, tt.canonical)
+			}
+		})
+	}
+}
+
+func TestLists_Check(t *testing.T) {
+	store, _ := storage.Open("")
+	expired := time.Now().Add(-time.Minute)
+	for _, entry := range []storage.ListEntry{
+		{List: config.ListBlock, Value: "203.0.113.0/24"},
+		{List: config.ListBlock, Value: "2001:db8::/32"},
+		{List: config.ListBlock, Value: "spam.example"},
+		{List: config.ListBlock, Value: "bot@example.com"},
+		{List: config.ListBlock, Value: "198.51.100.1", Website: "blog"},
+		{List: config.ListBlock, Value: "192.0.2.1", ExpiresAt: &expired},
+		{List: config.ListAllow, Value: "203.0.113.10"},
+		{List: config.ListAllow, Value: "partner@spam.example"},
+	} {
+		if _, err := store.SaveListEntry(entry); err != nil {
+			t.Fatalf("SaveListEntry() returned error: %v", err)
+		}
+	}
+	lists := New(store)
+
+	tests := []struct {
+		name    string
+		website string
+		ip      string
+		email   string
+		list    string
+		trusted bool
+	}{
+		{name: "unlisted", website: "main", ip: "192.0.2.10", email: "john@example.com"},
+		{name: "ip in range", website: "main", ip: "203.0.113.7", email: "john@example.com", list: config.ListBlock},
+		{name: "ipv6 in range", website: "main", ip: "2001:db8::1", email: "john@example.com", list: config.ListBlock},
+		{name: "ipv4-mapped ipv6", website: "main", ip: "::ffff:203.0.113.7", email: "john@example.com", list: config.ListBlock},
+		{name: "email", website: "main", ip: "192.0.2.10", email: "Bot@Example.com", list: config.ListBlock},
+		{name: "domain", website: "main", ip: "192.0.2.10", email: "john@spam.example", list: config.ListBlock},
+		{name: "subdomain", website: "main", ip: "192.0.2.10", email: "john@mail.spam.example", list: config.ListBlock},
+		{name: "similar domain", website: "main", ip: "192.0.2.10", email: "john@notspam.example"},
+		{name: "other website", website: "main", ip: "198.51.100.1", email: "john@example.com"},
+		{name: "website entry", website: "blog", ip: "198.51.100.1", email: "john@example.com", list: config.ListBlock},
+		{name: "expired", website: "main", ip: "192.0.2.1", email: "john@example.com"},
+		{name: "allowed ip in blocked range", website: "main", ip: "203.0.113.10", email: "john@example.com", list: config.ListAllow, trusted: true},
+		{name: "allowed email in blocked domain", website: "main", ip: "192.0.2.10", email: "partner@spam.example", list: config.ListAllow},
+		{name: "allowed email from blocked ip", website: "main", ip: "203.0.113.7", email: "partner@spam.example", list: config.ListBlock},
+		{name: "allowed ip with blocked email", website: "main", ip: "203.0.113.10", email: "bot@example.com", list: config.ListAllow, trusted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match := lists.Check(tt.website, tt.ip, tt.email)
+			if match.List != tt.list {
+				t.Errorf("Check() matched list %q (%+v), want %q", match.List, match.Entry, tt.list)
+			}
+			if match.Trusted() != tt.trusted {
+				t.Errorf("Trusted() = %v, want %v", match.Trusted(), tt.trusted)
+			}
+		})
+	}
+}
+
+func TestLists_Rebuild(t *testing.T) {
+	store, _ := storage.Open("")
+	lists := New(store)
+
+	entry, _ := store.SaveListEntry(storage.ListEntry{List: config.ListBlock, Value: "spam.example"})
+	if lists.Check("main", "", "bot@spam.example").Blocked() {
+		t.Error("Expected new entries to apply only after a rebuild")
+	}
+	lists.Rebuild()
+	if !lists.Check("main", "", "bot@spam.example").Blocked() {
+		t.Error("Expected the entry to apply after a rebuild")
+	}
+
+	store.DeleteListEntry(entry.ID)
+	lists.Rebuild()
+	if lists.Check("main", "", "bot@spam.example").Blocked() {
+		t.Error("Expected deleted entries not to apply")
+	}
+}
+
+func TestLists_LoadFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "blocked.txt")
+	if err := os.WriteFile(path, []byte("# Known abusers\n203.0.113.0/24\n\nspam.example # whole domain\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	store, _ := storage.Open("")
+	lists := New(store)
+	files := []config.ListFile{{Path: path, List: config.ListBlock, Website: "main"}}
+	if err := lists.LoadFiles(files); err != nil {
+		t.Fatalf("LoadFiles() returned error: %v", err)
+	}
+
+	match := lists.Check("main", "203.0.113.7", "john@example.com")
+	if !match.Blocked() || match.Entry.Source != path {
+		t.Errorf("Expected the file entry to block, got %+v", match)
+	}
+	if lists.Check("blog", "203.0.113.7", "john@example.com").Blocked() {
+		t.Error("Expected file entries to apply to their website only")
+	}
+	if n := len(lists.Entries()); n != 2 {
+		t.Errorf("Expected 2 entries, got %d", n)
+	}
+
+	// A broken file keeps the entries it had
+	if err := os.WriteFile(path, []byte("203.0.113.0/24\nnot a value\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := lists.LoadFiles(files); err == nil {
+		t.Error("Expected an error for an invalid line")
+	}
+	if !lists.Check("main", "", "john@spam.example").Blocked() {
+		t.Error("Expected the previous entries to be kept")
+	}
+
+	// Removing a file from the configuration drops its entries
+	if err := lists.LoadFiles(nil); err != nil {
+		t.Fatalf("LoadFiles() returned error: %v", err)
+	}
+	if lists.Check("main", "203.0.113.7", "").Blocked() {
+		t.Error("Expected entries of removed files to be dropped")
+	}
+
+	if err := lists.LoadFiles([]config.ListFile{{Path: filepath.Join(dir, "missing.txt"), List: config.ListAllow}}); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
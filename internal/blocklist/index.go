@@ -0,0 +1,102 @@
+package blocklist
+
+import (
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/nahuelsantos/contact-api/internal/storage"
+)
+
+// index holds the entries of each list, keyed for fast matching
+type index struct {
+	lists map[string]*set
+}
+
+// set holds the entries of one list by kind
+type set struct {
+	prefixes []prefixEntry
+	emails   map[string][]storage.ListEntry
+	domains  map[string][]storage.ListEntry
+}
+
+// prefixEntry is an IP range and the entry it came from
+type prefixEntry struct {
+	prefix netip.Prefix
+	entry  storage.ListEntry
+}
+
+func newIndex() *index {
+	return &index{lists: map[string]*set{}}
+}
+
+// add indexes an entry, skipping values that do not parse
+func (idx *index) add(entry storage.ListEntry) {
+	kind, value, err := Parse(entry.Value)
+	if err != nil {
+		return
+	}
+
+	s := idx.lists[entry.List]
+	if s == nil {
+		s = &set{emails: map[string][]storage.ListEntry{}, domains: map[string][]storage.ListEntry{}}
+		idx.lists[entry.List] = s
+	}
+
+	switch kind {
+	case KindIP:
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			addr := netip.MustParseAddr(value)
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		s.prefixes = append(s.prefixes, prefixEntry{prefix: prefix, entry: entry})
+	case KindEmail:
+		s.emails[value] = append(s.emails[value], entry)
+	case KindDomain:
+		s.domains[value] = append(s.domains[value], entry)
+	}
+}
+
+// match returns the first entry of a list that applies to a submission, by
+// its IP address for KindIP and by its sender for any other kind
+func (idx *index) match(list, kind, website, ip, email string, now time.Time) (storage.ListEntry, bool) {
+	s := idx.lists[list]
+	if s == nil {
+		return storage.ListEntry{}, false
+	}
+	applies := func(entry storage.ListEntry) bool {
+		return (entry.Website == "" || entry.Website == website) && !entry.Expired(now)
+	}
+
+	if kind == KindIP {
+		if addr, err := netip.ParseAddr(ip); err == nil {
+			addr = addr.Unmap()
+			for _, p := range s.prefixes {
+				if p.prefix.Contains(addr) && applies(p.entry) {
+					return p.entry, true
+				}
+			}
+		}
+		return storage.ListEntry{}, false
+	}
+
+	email = strings.ToLower(strings.TrimSpace(email))
+	for _, entry := range s.emails[email] {
+		if applies(entry) {
+			return entry, true
+		}
+	}
+
+	// A domain matches itself and its subdomains
+	_, domain, ok := strings.Cut(email, "@")
+	for ok && domain != "" {
+		for _, entry := range s.domains[domain] {
+			if applies(entry) {
+				return entry, true
+			}
+		}
+		_, domain, ok = strings.Cut(domain, ".")
+	}
+	return storage.ListEntry{}, false
+}
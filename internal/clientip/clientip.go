@@ -0,0 +1,195 @@
+// Package clientip resolves the address of the client behind trusted reverse proxies
+package clientip
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Supported headers carrying the client address
+const (
+	HeaderForwarded     = "Forwarded"
+	HeaderXForwardedFor = "X-Forwarded-For"
+	HeaderXRealIP       = "X-Real-IP"
+)
+
+// Headers lists the supported headers
+var Headers = []string{HeaderForwarded, HeaderXForwardedFor, HeaderXRealIP}
+
+// DefaultHeader is read when no header is configured, as most proxies set it
+const DefaultHeader = HeaderXForwardedFor
+
+// Resolver finds the client address of a request. A single header is read,
+// since a client could add any header its proxy does not set, and only when
+// the request comes from a trusted proxy.
+type Resolver struct {
+	trusted []netip.Prefix
+	header  string
+}
+
+// New creates a resolver trusting the given IPs and CIDR ranges and reading
+// the given header, or DefaultHeader when it is empty
+func New(trusted []string, header string) (*Resolver, error) {
+	prefixes, err := ParsePrefixes(trusted)
+	if err != nil {
+		return nil, err
+	}
+
+	if header == "" {
+		header = DefaultHeader
+	}
+	name, ok := canonicalHeader(header)
+	if !ok {
+		return nil, fmt.Errorf("unsupported client IP header %q", header)
+	}
+	return &Resolver{trusted: prefixes, header: name}, nil
+}
+
+// ParsePrefixes parses IP addresses and CIDR ranges, treating an address as a
+// single-host range
+func ParsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if strings.Contains(v, "/") {
+			prefix, err := netip.ParsePrefix(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", v, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", v, err)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// ValidHeader reports whether a header name is supported
+func ValidHeader(name string) bool {
+	_, ok := canonicalHeader(name)
+	return ok
+}
+
+// canonicalHeader returns the supported header matching a name case-insensitively
+func canonicalHeader(name string) (string, bool) {
+	for _, h := range Headers {
+		if strings.EqualFold(name, h) {
+			return h, true
+		}
+	}
+	return "", false
+}
+
+// ClientIP returns the client address of a request. Requests over a Unix
+// socket have no peer address; they are treated as coming from a trusted
+// proxy when trusted proxies are configured, and have no client address
+// otherwise.
+func (r *Resolver) ClientIP(req *http.Request) string {
+	remote, ok := peerAddr(req.RemoteAddr)
+	if ok && !r.isTrusted(remote) {
+		return remote.String()
+	}
+	if !ok && len(r.trusted) == 0 {
+		return ""
+	}
+
+	// Walk from the nearest hop and stop at the first untrusted address,
+	// since anything to its left can be forged by the client
+	chain := headerChain(r.header, req.Header.Values(r.header))
+	for i := len(chain) - 1; i >= 0; i-- {
+		if i == 0 || !r.isTrusted(chain[i]) {
+			return chain[i].String()
+		}
+	}
+
+	if ok {
+		return remote.String()
+	}
+	return ""
+}
+
+// isTrusted reports whether an address belongs to a trusted proxy
+func (r *Resolver) isTrusted(addr netip.Addr) bool {
+	for _, prefix := range r.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// peerAddr parses the IP of a RemoteAddr, which is empty or "@" for Unix sockets
+func peerAddr(remoteAddr string) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	return parseAddr(host)
+}
+
+// parseAddr parses an IP address, accepting IPv4-mapped IPv6 and brackets
+func parseAddr(value string) (netip.Addr, bool) {
+	value = strings.Trim(strings.TrimSpace(value), "[]")
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// headerChain returns the addresses in a header from the client to the
+// nearest proxy. An invalid entry makes the whole header unusable.
+func headerChain(header string, values []string) []netip.Addr {
+	var chain []netip.Addr
+	for _, value := range values {
+		var entries []string
+		switch header {
+		case HeaderForwarded:
+			entries = forwardedFor(value)
+		case HeaderXForwardedFor:
+			entries = strings.Split(value, ",")
+		case HeaderXRealIP:
+			entries = []string{value}
+		}
+
+		for _, entry := range entries {
+			addr, ok := parseHostAddr(entry)
+			if !ok {
+				return nil
+			}
+			chain = append(chain, addr)
+		}
+	}
+	return chain
+}
+
+// parseHostAddr parses an address that may carry a port, as in "[2001:db8::1]:4711"
+func parseHostAddr(value string) (netip.Addr, bool) {
+	value = strings.TrimSpace(value)
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		value = host
+	}
+	return parseAddr(value)
+}
+
+// forwardedFor extracts the for= parameters of an RFC 7239 Forwarded header
+func forwardedFor(value string) []string {
+	var addrs []string
+	for _, element := range strings.Split(value, ",") {
+		for _, pair := range strings.Split(element, ";") {
+			key, val, found := strings.Cut(strings.TrimSpace(pair), "=")
+			if found && strings.EqualFold(key, "for") {
+				addrs = append(addrs, strings.Trim(val, `"`))
+			}
+		}
+	}
+	return addrs
+}
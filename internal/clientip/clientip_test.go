@@ -0,0 +1,139 @@
+package clientip
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResolver_ClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		remoteAddr string
+		headers    map[string]string
+		expected   string
+	}{
+		{
+			name:       "direct client",
+			remoteAddr: "203.0.113.7:5000",
+			expected:   "203.0.113.7",
+		},
+		{
+			name:       "untrusted peer cannot spoof",
+			remoteAddr: "203.0.113.7:5000",
+			headers:    map[string]string{"X-Forwarded-For": "198.51.100.1"},
+			expected:   "203.0.113.7",
+		},
+		{
+			name:       "X-Forwarded-For through trusted proxies",
+			remoteAddr: "10.0.0.2:5000",
+			headers:    map[string]string{"X-Forwarded-For": "192.0.2.9, 198.51.100.1, 10.0.0.1"},
+			expected:   "198.51.100.1",
+		},
+		{
+			name:       "other headers are ignored",
+			remoteAddr: "10.0.0.2:5000",
+			headers: map[string]string{
+				"Forwarded":       "for=192.0.2.60",
+				"X-Real-IP":       "192.0.2.61",
+				"X-Forwarded-For": "198.51.100.1",
+			},
+			expected: "198.51.100.1",
+		},
+		{
+			name:       "X-Real-IP",
+			header:     "x-real-ip",
+			remoteAddr: "10.0.0.2:5000",
+			headers:    map[string]string{"X-Real-IP": "198.51.100.1"},
+			expected:   "198.51.100.1",
+		},
+		{
+			name:       "Forwarded",
+			header:     "Forwarded",
+			remoteAddr: "[2001:db8::1]:443",
+			headers:    map[string]string{"Forwarded": `for=192.0.2.60;proto=http, for="[2001:db8:cafe::17]:4711"`},
+			expected:   "2001:db8:cafe::17",
+		},
+		{
+			name:       "invalid header",
+			header:     "Forwarded",
+			remoteAddr: "10.0.0.2:5000",
+			headers:    map[string]string{"Forwarded": "for=unknown", "X-Forwarded-For": "198.51.100.1"},
+			expected:   "10.0.0.2",
+		},
+		{
+			name:       "all hops trusted",
+			remoteAddr: "10.0.0.2:5000",
+			headers:    map[string]string{"X-Forwarded-For": "10.0.0.5, 10.0.0.1"},
+			expected:   "10.0.0.5",
+		},
+		{
+			name:       "unix socket peer is trusted",
+			remoteAddr: "@",
+			headers:    map[string]string{"X-Forwarded-For": "198.51.100.1"},
+			expected:   "198.51.100.1",
+		},
+		{
+			name:       "trusted proxy without headers",
+			remoteAddr: "10.0.0.2:5000",
+			expected:   "10.0.0.2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver, err := New([]string{"10.0.0.0/8", "2001:db8::1"}, tt.header)
+			if err != nil {
+				t.Fatalf("New() returned error: %v", err)
+			}
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+
+			if got := resolver.ClientIP(req); got != tt.expected {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolver_NoTrustedProxies(t *testing.T) {
+	resolver, _ := New(nil, "")
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		expected   string
+	}{
+		{name: "tcp peer", remoteAddr: "127.0.0.1:5000", expected: "127.0.0.1"},
+		{name: "unix socket peer", remoteAddr: "@", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Forwarded-For", "198.51.100.1")
+			req.Header.Set("Forwarded", "for=1.2.3.4")
+
+			if got := resolver.ClientIP(req); got != tt.expected {
+				t.Errorf("ClientIP() = %q, want %q with headers ignored", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	if _, err := New([]string{"not-an-ip"}, ""); err == nil {
+		t.Error("New() should reject an invalid proxy")
+	}
+	if _, err := New(nil, "X-Client-IP"); err == nil {
+		t.Error("New() should reject an unsupported header")
+	}
+	if _, err := New([]string{"192.168.0.0/16", "::1"}, "x-forwarded-for"); err != nil {
+		t.Errorf("New() returned error: %v", err)
+	}
+}
@@ -1,34 +1,577 @@
 package config
 
 import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
 	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nahuelsantos/contact-api/internal/clientip"
 )
 
 // Config holds the contact API server configuration
 type Config struct {
-	SMTPHost     string   `json:"smtp_host"`
-	SMTPPort     string   `json:"smtp_port"`
-	DefaultFrom  string   `json:"default_from"`
-	DefaultTo    string   `json:"default_to"`
-	Port         string   `json:"port"`
-	MaxBodySize  int64    `json:"max_body_size"`
-	AllowedHosts []string `json:"allowed_hosts"`
+	SMTPHost       string             `json:"smtp_host"`
+	SMTPPort       string             `json:"smtp_port"`
+	SMTPUsername   string             `json:"smtp_username"`
+	SMTPPassword   string             `json:"smtp_password"`
+	DefaultFrom    string             `json:"default_from"`
+	DefaultTo      string             `json:"default_to"`
+	Port           string             `json:"port"`
+	MaxBodySize    int64              `json:"max_body_size"`
+	AllowedHosts   []string           `json:"allowed_hosts"`
+	HealthCacheTTL Duration           `json:"health_cache_ttl"`
+	HealthTimeout  Duration           `json:"health_timeout"`
+	AdminToken     string             `json:"admin_token"`
+	ChallengeKey   string             `json:"challenge_key"`
+	DataFile       string             `json:"data_file"`
+	MaxSubmissions int                `json:"max_submissions"`
+	Retention      Duration           `json:"submission_retention"`
+	WatchInterval  Duration           `json:"watch_interval"`
+	OutboxWarn     int                `json:"outbox_warn"`
+	OutboxMax      int                `json:"outbox_max"`
+	Server         Server             `json:"server"`
+	Lists          Lists              `json:"lists"`
+	DNS            DNS                `json:"dns"`
+	Delivery       Delivery           `json:"delivery"`
+	DevMail        DevMail            `json:"dev_mail"`
+	DKIM           DKIM               `json:"dkim"`
+	Websites       map[string]Website `json:"websites"`
+
+	// SecretFiles lists the files secrets were read from, watched for rotation
+	SecretFiles []string `json:"-"`
+}
+
+// Server holds the HTTP server settings. Only TrustedProxies,
+// ClientIPHeader and SecurityHeaders change on reload, the rest applies on
+// restart.
+type Server struct {
+	ReadTimeout       Duration        `json:"read_timeout"`
+	ReadHeaderTimeout Duration        `json:"read_header_timeout"`
+	WriteTimeout      Duration        `json:"write_timeout"`
+	IdleTimeout       Duration        `json:"idle_timeout"`
+	MaxHeaderBytes    int             `json:"max_header_bytes"`
+	ShutdownGrace     Duration        `json:"shutdown_grace"`
+	TLSCertFile       string          `json:"tls_cert_file"`
+	TLSKeyFile        string          `json:"tls_key_file"`
+	UnixSocket        string          `json:"unix_socket"`
+	TrustedProxies    []string        `json:"trusted_proxies"`
+	ClientIPHeader    string          `json:"client_ip_header"`
+	SecurityHeaders   SecurityHeaders `json:"security_headers"`
+}
+
+// SecurityHeaders holds the response headers added to every request. Routes
+// overrides Headers for paths starting with a prefix, the longest prefix
+// winning, and an empty value removes a header.
+type SecurityHeaders struct {
+	HSTSMaxAge            Duration                     `json:"hsts_max_age"`
+	HSTSIncludeSubdomains bool                         `json:"hsts_include_subdomains"`
+	Headers               map[string]string            `json:"headers"`
+	Routes                map[string]map[string]string `json:"routes"`
+}
+
+// TLS reports whether the server is configured to serve HTTPS
+func (s Server) TLS() bool {
+	return s.TLSCertFile != "" || s.TLSKeyFile != ""
+}
+
+// DefaultSecurityHeaders returns the headers sent when the configuration does
+// not override them. The API serves JSON only, so the policy denies everything
+// except for the Swagger UI, which needs its own scripts, styles and images,
+// the widget, whose form page is framed by other sites, and the development
+// mail catcher, which frames the HTML of captured messages.
+func DefaultSecurityHeaders() SecurityHeaders {
+	return SecurityHeaders{
+		HSTSMaxAge: Duration(365 * 24 * time.Hour),
+		Headers: map[string]string{
+			"Content-Security-Policy": "default-src 'none'; frame-ancestors 'none'",
+			"Referrer-Policy":         "no-referrer",
+			"X-Content-Type-Options":  "nosniff",
+			"X-Frame-Options":         "DENY",
+		},
+		Routes: map[string]map[string]string{
+			"/swagger/": {
+				"Content-Security-Policy": "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; img-src 'self' data:; frame-ancestors 'none'",
+			},
+			"/api/v1/widget/": {
+				"Content-Security-Policy": "default-src 'none'; script-src 'self'; style-src 'self'; connect-src 'self'; frame-ancestors *",
+				"X-Frame-Options":         "",
+			},
+			"/_dev/mail": {
+				"Content-Security-Policy": "default-src 'none'; style-src 'unsafe-inline'; frame-src 'self'; form-action 'self'; frame-ancestors 'self'",
+				"X-Frame-Options":         "SAMEORIGIN",
+			},
+		},
+	}
+}
+
+// Website holds the contact form configuration for a single site
+type Website struct {
+	Disabled        bool                 `json:"disabled"`
+	Recipients      []string             `json:"recipients"`
+	From            string               `json:"from"`
+	SubjectTemplate string               `json:"subject_template"`
+	BodyTemplate    string               `json:"body_template"`
+	DefaultLocale   string               `json:"default_locale"`
+	Locales         map[string]Templates `json:"locales"`
+	AutoReply       AutoReply            `json:"auto_reply"`
+	Webhooks        []string             `json:"webhooks"`
+	WebhookSecret   string               `json:"webhook_secret"`
+	AntiSpam        AntiSpam             `json:"anti_spam"`
+	EmailChecks     EmailChecks          `json:"email_checks"`
+	Routing         Routing              `json:"routing"`
+	Form            Form                 `json:"form"`
+	Transports      []string             `json:"transports"`
+}
+
+// Templates holds the localized variant of a pair of email templates. An
+// empty template falls back to the next locale of the fallback chain.
+type Templates struct {
+	SubjectTemplate string `json:"subject_template"`
+	BodyTemplate    string `json:"body_template"`
+}
+
+// AutoReply holds the confirmation email sent back to the visitor after a
+// successful submission
+type AutoReply struct {
+	Enabled         bool                 `json:"enabled"`
+	SubjectTemplate string               `json:"subject_template"`
+	BodyTemplate    string               `json:"body_template"`
+	Locales         map[string]Templates `json:"locales"`
+}
+
+// AntiSpam holds the spam protection settings for a website
+type AntiSpam struct {
+	Honeypot    bool        `json:"honeypot"`
+	MaxLinks    int         `json:"max_links"`
+	ProofOfWork ProofOfWork `json:"proof_of_work"`
+	Scoring     SpamScoring `json:"scoring"`
+}
+
+// MaxDifficulty is the highest proof of work difficulty, which takes a
+// browser minutes to solve
+const MaxDifficulty = 24
+
+// ProofOfWork requires submissions to carry the solution of a puzzle issued
+// by the challenge endpoint. Difficulty is the number of leading zero bits the
+// solution hash needs, each bit doubling the expected work, and TTL is how
+// long a puzzle can be solved and used. Zero values use the defaults.
+type ProofOfWork struct {
+	Enabled    bool     `json:"enabled"`
+	Difficulty int      `json:"difficulty"`
+	TTL        Duration `json:"ttl" swaggertype:"string" example:"10m"`
+}
+
+// SpamScoring configures the content checks that score submissions before
+// delivery. Each check contributes its weight times a strength between 0 and
+// 1; Weights override the built-in weights by check name and a zero weight
+// disables a check. Model picks the Bayesian model that scores submissions:
+// "website" (the default) uses the website's own model once it is trained
+// and the global one until then, and "global" always uses the global model.
+type SpamScoring struct {
+	Enabled           bool               `json:"enabled"`
+	Weights           map[string]float64 `json:"weights"`
+	Thresholds        SpamThresholds     `json:"thresholds"`
+	Keywords          []string           `json:"keywords"`
+	Patterns          []string           `json:"patterns"`
+	Phrases           []string           `json:"phrases"`
+	Scripts           []string           `json:"scripts"`
+	DisposableDomains []string           `json:"disposable_domains"`
+	Model             string             `json:"model"`
+}
+
+// SpamThresholds holds the score at which each action applies. The most
+// severe action reached wins and a zero threshold disables its action. When
+// none is set, submissions are tagged from 5 and quarantined from 10.
+type SpamThresholds struct {
+	Tag        float64 `json:"tag"`
+	Quarantine float64 `json:"quarantine"`
+	Reject     float64 `json:"reject"`
+}
+
+// EmailChecks configures checks on the sender address beyond its syntax, so
+// replies do not bounce. DNS requires the sender domain to accept email,
+// RejectDisposable rejects throwaway email services (the built-in ones plus
+// anti_spam.scoring.disposable_domains), and SuggestTypos rejects likely
+// misspellings of popular email providers with a suggestion.
+type EmailChecks struct {
+	DNS              bool `json:"dns"`
+	RejectDisposable bool `json:"reject_disposable"`
+	SuggestTypos     bool `json:"suggest_typos"`
+}
+
+// Form field types the widget can render
+const (
+	FieldText     = "text"
+	FieldTextarea = "textarea"
+	FieldEmail    = "email"
+	FieldTel      = "tel"
+	FieldURL      = "url"
+	FieldNumber   = "number"
+	FieldSelect   = "select"
+	FieldCheckbox = "checkbox"
+)
+
+// FormFieldTypes lists the form field types
+var FormFieldTypes = []string{FieldText, FieldTextarea, FieldEmail, FieldTel, FieldURL, FieldNumber, FieldSelect, FieldCheckbox}
+
+// MaxFormFields is the most custom fields a form can have, the most a
+// submission can carry
+const MaxFormFields = 20
+
+// Form describes the contact form the widget renders. Fields are custom
+// fields shown after the built-in name, email, subject and message, and sent
+// in the fields of a submission, which must satisfy them.
+type Form struct {
+	Fields []FormField `json:"fields"`
+}
+
+// FormField is a custom form field. Label belongs to the website default
+// locale and Labels holds the other languages by tag, falling back like
+// templates do, and then to Name. Type defaults to text; select
+// fields accept one of Options and checkbox fields are "true" when checked.
+type FormField struct {
+	Name     string            `json:"name" example:"company"`
+	Label    string            `json:"label" example:"Company"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Type     string            `json:"type,omitempty" example:"text"`
+	Required bool              `json:"required"`
+	Options  []string          `json:"options,omitempty"`
+}
+
+// DNS configures how sender domains are looked up. An empty Resolver uses
+// the system resolver.
+type DNS struct {
+	Resolver string   `json:"resolver"`
+	Timeout  Duration `json:"timeout"`
+}
+
+// Transport types. The HTTP APIs of transactional email providers are
+// transports too.
+const (
+	TransportSMTP     = "smtp"
+	TransportWebhook  = "webhook"
+	TransportStore    = "store"
+	TransportSendGrid = "sendgrid"
+	TransportMailgun  = "mailgun"
+	TransportPostmark = "postmark"
+	TransportSES      = "ses"
+	TransportResend   = "resend"
+	TransportSendmail = "sendmail"
+	TransportFile     = "file"
+	TransportMaildir  = "maildir"
+	TransportDev      = "dev"
+)
+
+// TransportTypes lists the transport types
+var TransportTypes = []string{
+	TransportSMTP, TransportWebhook, TransportStore,
+	TransportSendGrid, TransportMailgun, TransportPostmark, TransportSES, TransportResend,
+	TransportSendmail, TransportFile, TransportMaildir, TransportDev,
+}
+
+// DefaultTransport names the transport built from the SMTP settings when
+// Delivery defines none
+const DefaultTransport = "smtp"
+
+// Delivery holds the transports notifications can be delivered through.
+// Websites try their transports in order until one succeeds, all of them
+// when they list none. A transport is skipped for Cooldown after
+// FailureThreshold consecutive failures, then probed with one message.
+// Zero values use the defaults.
+type Delivery struct {
+	Transports       []Transport `json:"transports"`
+	FailureThreshold int         `json:"failure_threshold"`
+	Cooldown         Duration    `json:"cooldown"`
+	SMTPPool         SMTPPool    `json:"smtp_pool"`
+}
+
+// SMTPPool holds the connection pool of SMTP transports. With a Size above
+// zero each SMTP transport keeps up to Size authenticated connections and
+// reuses them, closing a connection after MaxMessages messages or when it
+// has been idle for IdleTimeout. Zero values of MaxMessages and IdleTimeout
+// use the defaults.
+type SMTPPool struct {
+	Size        int      `json:"size"`
+	MaxMessages int      `json:"max_messages"`
+	IdleTimeout Duration `json:"idle_timeout"`
+}
+
+// TransportNames returns the names of the transports websites can use
+func (d Delivery) TransportNames() []string {
+	if len(d.Transports) == 0 {
+		return []string{DefaultTransport}
+	}
+	names := make([]string, 0, len(d.Transports))
+	for _, t := range d.Transports {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+// Transport is a way of delivering notifications. SMTP transports without a
+// Host use the global SMTP server and credentials, webhook transports post
+// the message to URL signed with Secret, and store transports keep it in
+// storage for the admin API.
+//
+// Email provider transports authenticate with APIKey, except SES, which
+// signs requests with Username as the access key ID and Password as the
+// secret access key in Region. Mailgun sends from Domain. URL replaces the
+// provider's API base URL, such as Mailgun's EU endpoint.
+//
+// Sendmail transports pipe messages to the binary at Path, file transports
+// write them as .eml files to the directory at Path and maildir transports
+// deliver them to the Maildir at Path. Dev transports capture messages in the
+// development mail catcher.
+type Transport struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Host     string `json:"host,omitempty"`
+	Port     string `json:"port,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Secret   string `json:"secret,omitempty"`
+	APIKey   string `json:"api_key,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	Region   string `json:"region,omitempty"`
+	Path     string `json:"path,omitempty"`
+}
+
+// DevMail holds the development mail catcher. When Enabled, dev transports
+// keep the last Capacity messages in memory instead of sending them, and
+// /_dev/mail shows them without authentication, so it must stay disabled in
+// production. The default transport and auto-replies are captured too.
+type DevMail struct {
+	Enabled  bool `json:"enabled"`
+	Capacity int  `json:"capacity"`
+}
+
+// DKIM canonicalizations, as header/body pairs
+var DKIMCanonicalizations = []string{"relaxed/relaxed", "relaxed/simple", "simple/relaxed", "simple/simple"}
+
+// DKIM holds the keys signing messages sent over SMTP. A message is signed
+// with every key of its sender domain, or of the closest parent domain that
+// has keys, so a domain can be signed with both an RSA and an Ed25519 key.
+type DKIM struct {
+	Keys []DKIMKey `json:"keys"`
+}
+
+// DKIMKey signs messages for Domain with PrivateKey, a PEM encoded RSA or
+// Ed25519 key whose public key is published under Selector. Headers lists
+// the signed header fields and Canonicalization is one of
+// DKIMCanonicalizations; empty values use the defaults.
+type DKIMKey struct {
+	Domain           string   `json:"domain"`
+	Selector         string   `json:"selector"`
+	PrivateKey       string   `json:"private_key"`
+	Headers          []string `json:"headers,omitempty"`
+	Canonicalization string   `json:"canonicalization,omitempty"`
+}
+
+// Signer parses the private key
+func (k DKIMKey) Signer() (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(k.PrivateKey))
+	if block == nil {
+		return nil, errors.New("must be a PEM encoded private key")
+	}
+
+	var key any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("must be a PRIVATE KEY or RSA PRIVATE KEY, got %s", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		// Shorter keys are rejected by verifiers, see RFC 8301
+		if key.N.BitLen() < 1024 {
+			return nil, fmt.Errorf("RSA key must have at least 1024 bits, got %d", key.N.BitLen())
+		}
+		return key, nil
+	case ed25519.PrivateKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("must be an RSA or Ed25519 key, got %T", key)
+	}
 }
 
-// Load initializes configuration from environment variables
+// Block and allow lists
+const (
+	ListBlock = "block"
+	ListAllow = "allow"
+)
+
+// Lists holds the block and allow list entries imported from local files.
+// Files are read again every RefreshInterval and on reload.
+type Lists struct {
+	Files           []ListFile `json:"files"`
+	RefreshInterval Duration   `json:"refresh_interval"`
+}
+
+// ListFile is a local file adding entries to the block or allow list. Each
+// line holds an IP address, CIDR range, email address or domain, and
+// everything after a # is a comment.
+type ListFile struct {
+	Path    string `json:"path"`
+	List    string `json:"list"`
+	Website string `json:"website,omitempty"`
+}
+
+// Notification channels a route can deliver to
+const (
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+)
+
+// Routing sends submissions to different recipients and channels depending on
+// their content. Rules are tried in order and the first match wins; Default
+// applies when none matches.
+type Routing struct {
+	Rules   []RoutingRule `json:"rules"`
+	Default Route         `json:"default"`
+}
+
+// RoutingRule is a route taken when all of its conditions match
+type RoutingRule struct {
+	Name  string      `json:"name"`
+	Match []Condition `json:"match"`
+	Route
+}
+
+// Route describes where a submission is delivered. Unset fields keep the
+// website settings.
+type Route struct {
+	Recipients []string `json:"recipients,omitempty"`
+	SubjectTag string   `json:"subject_tag,omitempty"`
+	Priority   string   `json:"priority,omitempty"`
+	Channels   []string `json:"channels,omitempty"`
+	Webhooks   []string `json:"webhooks,omitempty"`
+}
+
+// Notifies reports whether the route delivers to a channel. A route without
+// channels delivers to all of them.
+func (r Route) Notifies(channel string) bool {
+	return len(r.Channels) == 0 || slices.Contains(r.Channels, channel)
+}
+
+// Condition matches one submission field, either name, email, subject,
+// message, locale or fields.<name> for custom form fields. Exactly one of
+// the operators is set; Equals and Contains ignore case, and OneOf matches a
+// select option against its allowed values.
+type Condition struct {
+	Field    string   `json:"field"`
+	Equals   string   `json:"equals,omitempty"`
+	Contains string   `json:"contains,omitempty"`
+	Regex    string   `json:"regex,omitempty"`
+	OneOf    []string `json:"one_of,omitempty"`
+}
+
+// Load initializes configuration from an optional JSON file and environment
+// variables, resolving secrets through DefaultSecretSources
 func Load() (Config, error) {
+	return LoadWithSecrets(DefaultSecretSources()...)
+}
+
+// LoadWithSecrets initializes configuration from an optional JSON file and
+// environment variables. Environment variables take precedence over the file,
+// and ${NAME} references in the file are resolved through sources in order.
+// The result is validated and every problem is reported in a single ValidationError.
+func LoadWithSecrets(sources ...SecretSource) (Config, error) {
 	// Default configuration
 	cfg := Config{
-		SMTPHost:     os.Getenv("SMTP_HOST"),
-		SMTPPort:     os.Getenv("SMTP_PORT"),
-		DefaultFrom:  os.Getenv("DEFAULT_FROM"),
-		DefaultTo:    os.Getenv("DEFAULT_TO"),
-		Port:         os.Getenv("PORT"),
-		MaxBodySize:  1024 * 1024, // 1MB
-		AllowedHosts: []string{},
+		MaxBodySize:    1024 * 1024, // 1MB
+		AllowedHosts:   []string{},
+		HealthCacheTTL: Duration(10 * time.Second),
+		HealthTimeout:  Duration(5 * time.Second),
+		OutboxWarn:     10,
+		OutboxMax:      100,
+		MaxSubmissions: 10000,
+		WatchInterval:  Duration(5 * time.Second),
+		Lists:          Lists{RefreshInterval: Duration(5 * time.Minute)},
+		DNS:            DNS{Timeout: Duration(3 * time.Second)},
+		Server: Server{
+			ReadTimeout:       Duration(30 * time.Second),
+			ReadHeaderTimeout: Duration(10 * time.Second),
+			WriteTimeout:      Duration(30 * time.Second),
+			IdleTimeout:       Duration(120 * time.Second),
+			MaxHeaderBytes:    1 << 20, // 1MB
+			ShutdownGrace:     Duration(10 * time.Second),
+			ClientIPHeader:    clientip.DefaultHeader,
+			SecurityHeaders:   DefaultSecurityHeaders(),
+		},
+	}
+
+	// Load the configuration file if one is given
+	r := &resolver{sources: sources}
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := loadFile(path, &cfg, r); err != nil {
+			return cfg, err
+		}
 	}
 
-	// If no environment variables, use defaults
+	var errs ValidationError
+	r.secretEnv(&errs, &cfg.AdminToken, "admin_token", "ADMIN_TOKEN")
+	r.secretEnv(&errs, &cfg.SMTPUsername, "smtp_username", "SMTP_USERNAME")
+	r.secretEnv(&errs, &cfg.SMTPPassword, "smtp_password", "SMTP_PASSWORD")
+	r.secretEnv(&errs, &cfg.ChallengeKey, "challenge_key", "CHALLENGE_KEY")
+	cfg.SecretFiles = r.files
+	stringEnv(&cfg.SMTPHost, "SMTP_HOST")
+	stringEnv(&cfg.SMTPPort, "SMTP_PORT")
+	stringEnv(&cfg.DefaultFrom, "DEFAULT_FROM")
+	stringEnv(&cfg.DefaultTo, "DEFAULT_TO")
+	stringEnv(&cfg.Port, "PORT")
+	stringEnv(&cfg.DataFile, "DATA_FILE")
+	cfg.MaxSubmissions = intEnv(&errs, "max_submissions", "MAX_SUBMISSIONS", cfg.MaxSubmissions)
+	cfg.Retention = durationEnv(&errs, "submission_retention", "SUBMISSION_RETENTION", cfg.Retention)
+	cfg.OutboxWarn = intEnv(&errs, "outbox_warn", "OUTBOX_WARN_DEPTH", cfg.OutboxWarn)
+	cfg.OutboxMax = intEnv(&errs, "outbox_max", "OUTBOX_MAX_DEPTH", cfg.OutboxMax)
+	cfg.WatchInterval = durationEnv(&errs, "watch_interval", "CONFIG_WATCH_INTERVAL", cfg.WatchInterval)
+	cfg.Lists.RefreshInterval = durationEnv(&errs, "lists.refresh_interval", "LIST_REFRESH_INTERVAL", cfg.Lists.RefreshInterval)
+	stringEnv(&cfg.DNS.Resolver, "DNS_RESOLVER")
+	cfg.DNS.Timeout = durationEnv(&errs, "dns.timeout", "DNS_TIMEOUT", cfg.DNS.Timeout)
+	cfg.Delivery.FailureThreshold = intEnv(&errs, "delivery.failure_threshold", "DELIVERY_FAILURE_THRESHOLD", cfg.Delivery.FailureThreshold)
+	cfg.Delivery.Cooldown = durationEnv(&errs, "delivery.cooldown", "DELIVERY_COOLDOWN", cfg.Delivery.Cooldown)
+	cfg.Delivery.SMTPPool.Size = intEnv(&errs, "delivery.smtp_pool.size", "SMTP_POOL_SIZE", cfg.Delivery.SMTPPool.Size)
+	cfg.Delivery.SMTPPool.MaxMessages = intEnv(&errs, "delivery.smtp_pool.max_messages", "SMTP_POOL_MAX_MESSAGES", cfg.Delivery.SMTPPool.MaxMessages)
+	cfg.Delivery.SMTPPool.IdleTimeout = durationEnv(&errs, "delivery.smtp_pool.idle_timeout", "SMTP_POOL_IDLE_TIMEOUT", cfg.Delivery.SMTPPool.IdleTimeout)
+	cfg.HealthCacheTTL = durationEnv(&errs, "health_cache_ttl", "HEALTH_CACHE_TTL", cfg.HealthCacheTTL)
+	cfg.HealthTimeout = durationEnv(&errs, "health_timeout", "HEALTH_CHECK_TIMEOUT", cfg.HealthTimeout)
+	cfg.Server.ReadTimeout = durationEnv(&errs, "server.read_timeout", "SERVER_READ_TIMEOUT", cfg.Server.ReadTimeout)
+	cfg.Server.ReadHeaderTimeout = durationEnv(&errs, "server.read_header_timeout", "SERVER_READ_HEADER_TIMEOUT", cfg.Server.ReadHeaderTimeout)
+	cfg.Server.WriteTimeout = durationEnv(&errs, "server.write_timeout", "SERVER_WRITE_TIMEOUT", cfg.Server.WriteTimeout)
+	cfg.Server.IdleTimeout = durationEnv(&errs, "server.idle_timeout", "SERVER_IDLE_TIMEOUT", cfg.Server.IdleTimeout)
+	cfg.Server.MaxHeaderBytes = intEnv(&errs, "server.max_header_bytes", "SERVER_MAX_HEADER_BYTES", cfg.Server.MaxHeaderBytes)
+	cfg.Server.ShutdownGrace = durationEnv(&errs, "server.shutdown_grace", "SHUTDOWN_GRACE", cfg.Server.ShutdownGrace)
+	stringEnv(&cfg.Server.TLSCertFile, "TLS_CERT_FILE")
+	stringEnv(&cfg.Server.TLSKeyFile, "TLS_KEY_FILE")
+	stringEnv(&cfg.Server.UnixSocket, "UNIX_SOCKET")
+	listEnv(&cfg.Server.TrustedProxies, "TRUSTED_PROXIES")
+	stringEnv(&cfg.Server.ClientIPHeader, "CLIENT_IP_HEADER")
+	cfg.Server.SecurityHeaders.HSTSMaxAge = durationEnv(&errs, "server.security_headers.hsts_max_age", "HSTS_MAX_AGE", cfg.Server.SecurityHeaders.HSTSMaxAge)
+
+	// If neither the file nor the environment set a value, use defaults
 	if cfg.SMTPHost == "" {
 		cfg.SMTPHost = "mail-server"
 	}
@@ -50,5 +593,154 @@ func Load() (Config, error) {
 		cfg.AllowedHosts = append(cfg.AllowedHosts, allowedHosts)
 	}
 
+	cfg.validate(&errs)
+	if len(errs) > 0 {
+		return cfg, errs
+	}
 	return cfg, nil
 }
+
+// redactedValue replaces secrets in output
+const redactedValue = "[REDACTED]"
+
+// Redacted returns a copy of the configuration with secrets replaced, safe to
+// print or log
+func Redacted(cfg Config) Config {
+	redact(&cfg.AdminToken)
+	redact(&cfg.SMTPPassword)
+	redact(&cfg.ChallengeKey)
+
+	keys := make([]DKIMKey, len(cfg.DKIM.Keys))
+	for i, k := range cfg.DKIM.Keys {
+		redact(&k.PrivateKey)
+		keys[i] = k
+	}
+	cfg.DKIM.Keys = keys
+
+	transports := make([]Transport, len(cfg.Delivery.Transports))
+	for i, t := range cfg.Delivery.Transports {
+		redact(&t.Password)
+		redact(&t.Secret)
+		redact(&t.APIKey)
+		transports[i] = t
+	}
+	cfg.Delivery.Transports = transports
+
+	websites := make(map[string]Website, len(cfg.Websites))
+	for name, site := range cfg.Websites {
+		websites[name] = RedactWebsite(site)
+	}
+	cfg.Websites = websites
+	return cfg
+}
+
+// RedactWebsite returns a copy of a website definition with secrets replaced
+func RedactWebsite(site Website) Website {
+	redact(&site.WebhookSecret)
+	return site
+}
+
+// IsRedacted reports whether a value is the placeholder used for secrets
+func IsRedacted(value string) bool {
+	return value == redactedValue
+}
+
+// redact replaces a secret that is set
+func redact(secret *string) {
+	if *secret != "" {
+		*secret = redactedValue
+	}
+}
+
+// Hash returns a short fingerprint of the configuration, used to tell which
+// configuration is live. Secrets are redacted before hashing.
+func Hash(cfg Config) string {
+	raw, _ := json.Marshal(Redacted(cfg))
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// loadFile decodes a JSON configuration file over cfg after resolving
+// ${NAME} references in its values. Keys that match no setting are rejected.
+func loadFile(path string, cfg *Config, r *resolver) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	raw, err = r.expandAll(raw)
+	if err != nil {
+		return fmt.Errorf("failed to resolve config file %s: %w", path, err)
+	}
+
+	var errs ValidationError
+	unknownFields(&errs, raw, reflect.TypeFor[Config](), "")
+	if len(errs) > 0 {
+		return fmt.Errorf("config file %s: %w", path, errs)
+	}
+
+	expanded, _ := json.Marshal(raw)
+	decoder := json.NewDecoder(bytes.NewReader(expanded))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(cfg); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// stringEnv overrides target with an environment variable when it is set
+func stringEnv(target *string, key string) {
+	if value := os.Getenv(key); value != "" {
+		*target = value
+	}
+}
+
+// listEnv overrides target with a comma-separated environment variable when it is set
+func listEnv(target *[]string, key string) {
+	value := os.Getenv(key)
+	if value == "" {
+		return
+	}
+
+	list := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	*target = list
+}
+
+// durationEnv parses a duration from an environment variable, falling back
+// to the default when it is unset and recording an error when it is invalid
+func durationEnv(errs *ValidationError, field, key string, fallback Duration) Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		errs.add(field, "%s must be a duration such as 10s, got %q", key, value)
+		return fallback
+	}
+	return Duration(d)
+}
+
+// intEnv parses an integer from an environment variable, falling back to the
+// default when it is unset and recording an error when it is invalid
+func intEnv(errs *ValidationError, field, key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		errs.add(field, "%s must be a number, got %q", key, value)
+		return fallback
+	}
+	return n
+}
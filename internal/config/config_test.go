@@ -1,8 +1,21 @@
 package config
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoad(t *testing.T) {
@@ -15,33 +28,44 @@ func TestLoad(t *testing.T) {
 			name:    "Default values",
 			envVars: map[string]string{},
 			expected: Config{
-				SMTPHost:     "mail-server",
-				SMTPPort:     "25",
-				DefaultFrom:  "noreply@example.com",
-				DefaultTo:    "contact@example.com",
-				Port:         "3002",
-				MaxBodySize:  1024 * 1024,
-				AllowedHosts: []string{},
+				SMTPHost:       "mail-server",
+				SMTPPort:       "25",
+				DefaultFrom:    "noreply@example.com",
+				DefaultTo:      "contact@example.com",
+				Port:           "3002",
+				MaxBodySize:    1024 * 1024,
+				AllowedHosts:   []string{},
+				HealthCacheTTL: Duration(10 * time.Second),
+				HealthTimeout:  Duration(5 * time.Second),
+				MaxSubmissions: 10000,
 			},
 		},
 		{
 			name: "Custom environment variables",
 			envVars: map[string]string{
-				"SMTP_HOST":     "custom-smtp",
-				"SMTP_PORT":     "587",
-				"DEFAULT_FROM":  "custom@example.com",
-				"DEFAULT_TO":    "custom-to@example.com",
-				"PORT":          "3002",
-				"ALLOWED_HOSTS": "example.com",
+				"SMTP_HOST":            "custom-smtp",
+				"SMTP_PORT":            "587",
+				"DEFAULT_FROM":         "custom@example.com",
+				"DEFAULT_TO":           "custom-to@example.com",
+				"PORT":                 "3002",
+				"ALLOWED_HOSTS":        "example.com",
+				"HEALTH_CACHE_TTL":     "30s",
+				"HEALTH_CHECK_TIMEOUT": "2s",
+				"MAX_SUBMISSIONS":      "500",
+				"SUBMISSION_RETENTION": "720h",
 			},
 			expected: Config{
-				SMTPHost:     "custom-smtp",
-				SMTPPort:     "587",
-				DefaultFrom:  "custom@example.com",
-				DefaultTo:    "custom-to@example.com",
-				Port:         "3002",
-				MaxBodySize:  1024 * 1024,
-				AllowedHosts: []string{"example.com"},
+				SMTPHost:       "custom-smtp",
+				SMTPPort:       "587",
+				DefaultFrom:    "custom@example.com",
+				DefaultTo:      "custom-to@example.com",
+				Port:           "3002",
+				MaxBodySize:    1024 * 1024,
+				AllowedHosts:   []string{"example.com"},
+				HealthCacheTTL: Duration(30 * time.Second),
+				HealthTimeout:  Duration(2 * time.Second),
+				MaxSubmissions: 500,
+				Retention:      Duration(720 * time.Hour),
 			},
 		},
 	}
@@ -80,6 +104,849 @@ func TestLoad(t *testing.T) {
 			if cfg.MaxBodySize != tt.expected.MaxBodySize {
 				t.Errorf("MaxBodySize = %d, want %d", cfg.MaxBodySize, tt.expected.MaxBodySize)
 			}
+			if cfg.HealthCacheTTL != tt.expected.HealthCacheTTL {
+				t.Errorf("HealthCacheTTL = %v, want %v", cfg.HealthCacheTTL, tt.expected.HealthCacheTTL)
+			}
+			if cfg.HealthTimeout != tt.expected.HealthTimeout {
+				t.Errorf("HealthTimeout = %v, want %v", cfg.HealthTimeout, tt.expected.HealthTimeout)
+			}
+			if cfg.MaxSubmissions != tt.expected.MaxSubmissions || cfg.Retention != tt.expected.Retention {
+				t.Errorf("MaxSubmissions, Retention = %d, %v, want %d, %v", cfg.MaxSubmissions, cfg.Retention, tt.expected.MaxSubmissions, tt.expected.Retention)
+			}
+		})
+	}
+}
+
+func TestLoad_ConfigFile(t *testing.T) {
+	os.Clearenv()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{
+		"smtp_host": "file-smtp",
+		"default_to": "file-to@example.com",
+		"websites": {
+			"main": {"recipients": ["sales@example.com", "support@example.com"]},
+			"old": {"disabled": true}
+		}
+	}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	os.Setenv("CONFIG_FILE", path)
+	os.Setenv("SMTP_HOST", "env-smtp")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+
+	if cfg.SMTPHost != "env-smtp" {
+		t.Errorf("SMTPHost = %q, want environment to override file", cfg.SMTPHost)
+	}
+	if cfg.DefaultTo != "file-to@example.com" {
+		t.Errorf("DefaultTo = %q, want %q", cfg.DefaultTo, "file-to@example.com")
+	}
+	if cfg.SMTPPort != "25" {
+		t.Errorf("SMTPPort = %q, want default %q", cfg.SMTPPort, "25")
+	}
+	if got := len(cfg.Websites["main"].Recipients); got != 2 {
+		t.Errorf("main website has %d recipients, want 2", got)
+	}
+	if !cfg.Websites["old"].Disabled {
+		t.Error("old website should be disabled")
+	}
+}
+
+func TestLoad_SecretReferences(t *testing.T) {
+	os.Clearenv()
+
+	dir := t.TempDir()
+	secrets := filepath.Join(dir, "secrets")
+	if err := os.Mkdir(secrets, 0o700); err != nil {
+		t.Fatalf("Failed to create secrets dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(secrets, "hook_secret"), []byte("from-dir\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write secret: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		data          string
+		expectedError bool
+	}{
+		{
+			name:          "Environment and directory",
+			data:          `{"smtp_host": "${SMTP_HOST_NAME}", "websites": {"main": {"webhook_secret": "${hook_secret}"}}}`,
+			expectedError: false,
+		},
+		{
+			name:          "Undefined reference",
+			data:          `{"smtp_password": "${MISSING_SECRET}"}`,
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Clearenv()
+			path := filepath.Join(dir, "config.json")
+			if err := os.WriteFile(path, []byte(tt.data), 0o600); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+			os.Setenv("CONFIG_FILE", path)
+			os.Setenv("SECRETS_DIR", secrets)
+			os.Setenv("SMTP_HOST_NAME", "smtp.example.com")
+
+			cfg, err := Load()
+			if tt.expectedError {
+				if err == nil {
+					t.Error("Load() should fail on an undefined reference")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() returned error: %v", err)
+			}
+
+			if cfg.SMTPHost != "smtp.example.com" {
+				t.Errorf("SMTPHost = %q, want %q", cfg.SMTPHost, "smtp.example.com")
+			}
+			if got := cfg.Websites["main"].WebhookSecret; got != "from-dir" {
+				t.Errorf("WebhookSecret = %q, want %q", got, "from-dir")
+			}
+			if len(cfg.SecretFiles) != 1 || cfg.SecretFiles[0] != filepath.Join(secrets, "hook_secret") {
+				t.Errorf("SecretFiles = %v, want the secret file", cfg.SecretFiles)
+			}
+		})
+	}
+}
+
+func TestLoad_SecretFileEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "smtp_password")
+	if err := os.WriteFile(path, []byte("file-secret\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write secret: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		env           map[string]string
+		expected      string
+		expectedError bool
+	}{
+		{name: "Value", env: map[string]string{"SMTP_PASSWORD": "env-secret"}, expected: "env-secret"},
+		{name: "File", env: map[string]string{"SMTP_PASSWORD_FILE": path}, expected: "file-secret"},
+		{name: "Both", env: map[string]string{"SMTP_PASSWORD": "env-secret", "SMTP_PASSWORD_FILE": path}, expectedError: true},
+		{name: "Missing File", env: map[string]string{"SMTP_PASSWORD_FILE": path + ".missing"}, expectedError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Clearenv()
+			for key, value := range tt.env {
+				os.Setenv(key, value)
+			}
+
+			cfg, err := Load()
+			if tt.expectedError {
+				if err == nil {
+					t.Error("Load() should fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() returned error: %v", err)
+			}
+			if cfg.SMTPPassword != tt.expected {
+				t.Errorf("SMTPPassword = %q, want %q", cfg.SMTPPassword, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoad_SecretFileFields(t *testing.T) {
+	dir := t.TempDir()
+	secret := func(name, value string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(value+"\n"), 0o600); err != nil {
+			t.Fatalf("Failed to write secret: %v", err)
+		}
+		return path
+	}
+	apiKey := secret("sendgrid", "SG.key")
+	hookSecret := secret("hook", "${NOT_EXPANDED}")
+
+	tests := []struct {
+		name          string
+		data          string
+		expectedError bool
+	}{
+		{
+			name: "Files",
+			data: `{"delivery": {"transports": [{"name": "sendgrid", "type": "sendgrid", "api_key_file": "` + apiKey + `"}]},
+				"websites": {"main": {"webhook_secret_file": "` + hookSecret + `"}}}`,
+		},
+		{
+			name:          "Value and file",
+			data:          `{"websites": {"main": {"webhook_secret": "inline", "webhook_secret_file": "` + hookSecret + `"}}}`,
+			expectedError: true,
+		},
+		{
+			name:          "Missing file",
+			data:          `{"websites": {"main": {"webhook_secret_file": "` + hookSecret + `.missing"}}}`,
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Clearenv()
+			path := filepath.Join(dir, "config.json")
+			if err := os.WriteFile(path, []byte(tt.data), 0o600); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+			os.Setenv("CONFIG_FILE", path)
+
+			cfg, err := Load()
+			if tt.expectedError {
+				if err == nil {
+					t.Error("Load() should fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() returned error: %v", err)
+			}
+
+			if got := cfg.Delivery.Transports[0].APIKey; got != "SG.key" {
+				t.Errorf("APIKey = %q, want %q", got, "SG.key")
+			}
+			if got := cfg.Websites["main"].WebhookSecret; got != "${NOT_EXPANDED}" {
+				t.Errorf("WebhookSecret = %q, want the file content as is", got)
+			}
+			if len(cfg.SecretFiles) != 2 {
+				t.Errorf("SecretFiles = %v, want both secret files", cfg.SecretFiles)
+			}
+		})
+	}
+}
+
+func TestLoad_InvalidConfigFile(t *testing.T) {
+	os.Clearenv()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	os.Setenv("CONFIG_FILE", path)
+
+	if _, err := Load(); err == nil {
+		t.Error("Load() should fail on an invalid config file")
+	}
+}
+
+func TestLoad_UnknownFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		expected []string
+	}{
+		{
+			name:     "misspelled website key",
+			file:     `{"websites": {"main": {"recipents": ["sales@example.com"]}}}`,
+			expected: []string{"websites.main.recipents"},
+		},
+		{
+			name:     "top level and nested keys",
+			file:     `{"smtp_hots": "mail", "delivery": {"transports": [{"name": "smtp", "type": "smtp", "hots": "mail"}]}}`,
+			expected: []string{"delivery.transports[0].hots", "smtp_hots"},
+		},
+		{
+			name:     "embedded route key",
+			file:     `{"websites": {"main": {"routing": {"rules": [{"match": [{"field": "subject", "equals": "x"}], "recipients": ["a@example.com"], "tag": "x"}]}}}}`,
+			expected: []string{"websites.main.routing.rules[0].tag"},
+		},
+		{
+			name: "known keys in any case",
+			file: `{"SMTP_Host": "mail", "websites": {"main": {"Recipients": ["sales@example.com"], "auto_reply": {"enabled": true}}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Clearenv()
+			path := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+			os.Setenv("CONFIG_FILE", path)
+
+			_, err := Load()
+			var errs ValidationError
+			errors.As(err, &errs)
+			if len(tt.expected) == 0 {
+				if len(errs) > 0 {
+					t.Errorf("Expected no unknown fields, got %v", err)
+				}
+				return
+			}
+			if len(errs) != len(tt.expected) {
+				t.Fatalf("Expected %d unknown fields, got %v", len(tt.expected), err)
+			}
+			for i, field := range tt.expected {
+				if errs[i].Field != field || errs[i].Message != "unknown field" {
+					t.Errorf("Expected unknown field %s, got %s: %s", field, errs[i].Field, errs[i].Message)
+				}
+			}
+		})
+	}
+}
+
+func TestDiffWebsites(t *testing.T) {
+	from := Website{
+		Recipients:      []string{"sales@example.com"},
+		SubjectTemplate: "{{.Subject}}",
+	}
+	to := Website{
+		Recipients:      []string{"sales@example.com", "support@example.com"},
+		SubjectTemplate: "{{.Subject}}",
+		Disabled:        true,
+	}
+
+	changes := DiffWebsites(from, to)
+	if len(changes) != 2 {
+		t.Fatalf("got %d changes, want 2: %+v", len(changes), changes)
+	}
+	if changes[0].Field != "disabled" || changes[1].Field != "recipients" {
+		t.Errorf("unexpected changed fields: %+v", changes)
+	}
+
+	if changes := DiffWebsites(from, from); len(changes) != 0 {
+		t.Errorf("expected no changes for identical websites, got %+v", changes)
+	}
+}
+
+func TestHash(t *testing.T) {
+	a := Config{SMTPHost: "mail-server", AdminToken: "secret"}
+	b := Config{SMTPHost: "mail-server", AdminToken: "other-secret"}
+	c := Config{SMTPHost: "other-server"}
+
+	if Hash(a) != Hash(b) {
+		t.Error("Hash() should not depend on secrets")
+	}
+	if Hash(a) == Hash(c) {
+		t.Error("Hash() should change when the configuration changes")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"port": "3002"}`), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changed := make(chan struct{}, 1)
+	go Watch(ctx, 10*time.Millisecond, func() []string { return []string{path} }, func() { changed <- struct{}{} })
+
+	// Let the watcher take its first snapshot before changing the file
+	time.Sleep(30 * time.Millisecond)
+	if err := os.WriteFile(path, []byte(`{"port": "3003"}`), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("Watch() did not report the change")
+	}
+}
+
+func TestLoad_ValidationErrors(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("DEFAULT_FROM", "not-an-address")
+	os.Setenv("SMTP_PORT", "smtp")
+	os.Setenv("PORT", "70000")
+	os.Setenv("HEALTH_CACHE_TTL", "soon")
+	os.Setenv("DNS_RESOLVER", "1.1.1.1")
+	os.Setenv("MAX_SUBMISSIONS", "-1")
+
+	_, err := Load()
+
+	var validationErr ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Load() error = %v, want a ValidationError", err)
+	}
+
+	fields := map[string]bool{}
+	for _, fe := range validationErr {
+		fields[fe.Field] = true
+	}
+	for _, field := range []string{"default_from", "smtp_port", "port", "health_cache_ttl", "dns.resolver", "max_submissions"} {
+		if !fields[field] {
+			t.Errorf("expected an error for %s, got %v", field, validationErr)
+		}
+	}
+}
+
+func TestLoad_Server(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("SERVER_READ_TIMEOUT", "5s")
+	os.Setenv("SHUTDOWN_GRACE", "30s")
+	os.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 192.168.1.1")
+	os.Setenv("CLIENT_IP_HEADER", "X-Real-IP")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+
+	if cfg.Server.ReadTimeout != Duration(5*time.Second) {
+		t.Errorf("ReadTimeout = %v, want 5s", time.Duration(cfg.Server.ReadTimeout))
+	}
+	if cfg.Server.IdleTimeout != Duration(120*time.Second) {
+		t.Errorf("IdleTimeout = %v, want the 120s default", time.Duration(cfg.Server.IdleTimeout))
+	}
+	if cfg.Server.ShutdownGrace != Duration(30*time.Second) {
+		t.Errorf("ShutdownGrace = %v, want 30s", time.Duration(cfg.Server.ShutdownGrace))
+	}
+	if len(cfg.Server.TrustedProxies) != 2 || cfg.Server.TrustedProxies[1] != "192.168.1.1" {
+		t.Errorf("TrustedProxies = %v, want two entries", cfg.Server.TrustedProxies)
+	}
+	if cfg.Server.ClientIPHeader != "X-Real-IP" {
+		t.Errorf("ClientIPHeader = %q, want X-Real-IP", cfg.Server.ClientIPHeader)
+	}
+}
+
+func TestServer_Validate(t *testing.T) {
+	server := Server{
+		ReadTimeout:    Duration(-time.Second),
+		MaxHeaderBytes: 0,
+		ShutdownGrace:  Duration(time.Second),
+		TLSKeyFile:     "tls.key",
+		TrustedProxies: []string{"10.0.0.0/8", "proxy.internal"},
+		ClientIPHeader: "X-Client-IP",
+	}
+
+	var errs ValidationError
+	server.validate(&errs)
+
+	fields := map[string]bool{}
+	for _, fe := range errs {
+		fields[fe.Field] = true
+	}
+	for _, field := range []string{
+		"server.read_timeout",
+		"server.max_header_bytes",
+		"server.tls_cert_file",
+		"server.trusted_proxies[1]",
+		"server.client_ip_header",
+	} {
+		if !fields[field] {
+			t.Errorf("expected an error for %s, got %v", field, errs)
+		}
+	}
+	if len(errs) != 5 {
+		t.Errorf("got %d errors, want 5: %v", len(errs), errs)
+	}
+}
+
+func TestLists_Validate(t *testing.T) {
+	lists := Lists{
+		RefreshInterval: Duration(-time.Minute),
+		Files: []ListFile{
+			{Path: "/etc/contact-api/blocked.txt", List: ListBlock},
+			{List: ListAllow},
+			{Path: "/etc/contact-api/trusted.txt", List: "deny"},
+		},
+	}
+
+	var errs ValidationError
+	lists.validate(&errs, "lists")
+
+	fields := map[string]bool{}
+	for _, fe := range errs {
+		fields[fe.Field] = true
+	}
+	for _, field := range []string{
+		"lists.refresh_interval",
+		"lists.files[1].path",
+		"lists.files[2].list",
+	} {
+		if !fields[field] {
+			t.Errorf("expected an error for %s, got %v", field, errs)
+		}
+	}
+	if len(errs) != 3 {
+		t.Errorf("got %d errors, want 3: %v", len(errs), errs)
+	}
+}
+
+func TestDelivery_Validate(t *testing.T) {
+	delivery := Delivery{
+		FailureThreshold: -1,
+		SMTPPool:         SMTPPool{Size: -1, IdleTimeout: Duration(-time.Second)},
+		Transports: []Transport{
+			{Name: "primary", Type: TransportSMTP, Host: "smtp.example.com", Port: "587"},
+			{Name: "primary", Type: TransportStore},
+			{Name: "Backup", Type: TransportSMTP, Port: "smtp"},
+			{Name: "hook", Type: TransportWebhook, URL: "ftp://example.com/hook"},
+			{Name: "queue", Type: "sqs"},
+			{Name: "sendgrid", Type: TransportSendGrid, URL: "api.sendgrid.com"},
+			{Name: "mailgun", Type: TransportMailgun, APIKey: "key"},
+			{Name: "ses", Type: TransportSES, Username: "AKIDEXAMPLE"},
+			{Name: "postmark", Type: TransportPostmark, APIKey: "token", URL: "https://api.postmarkapp.com"},
+			{Name: "sendmail", Type: TransportSendmail, Path: "sendmail"},
+			{Name: "outbox", Type: TransportFile},
+			{Name: "maildir", Type: TransportMaildir, Path: "/var/mail/contact"},
+		},
+	}
+
+	var errs ValidationError
+	delivery.validate(&errs)
+
+	fields := map[string]bool{}
+	for _, fe := range errs {
+		fields[fe.Field] = true
+	}
+	for _, field := range []string{
+		"delivery.failure_threshold",
+		"delivery.smtp_pool.size",
+		"delivery.smtp_pool.idle_timeout",
+		"delivery.transports[1].name",
+		"delivery.transports[2].name",
+		"delivery.transports[2].port",
+		"delivery.transports[3].url",
+		"delivery.transports[4].type",
+		"delivery.transports[5].api_key",
+		"delivery.transports[5].url",
+		"delivery.transports[6].domain",
+		"delivery.transports[7].region",
+		"delivery.transports[7].username",
+		"delivery.transports[9].path",
+		"delivery.transports[10].path",
+	} {
+		if !fields[field] {
+			t.Errorf("expected an error for %s, got %v", field, errs)
+		}
+	}
+	if len(errs) != 15 {
+		t.Errorf("got %d errors, want 15: %v", len(errs), errs)
+	}
+}
+
+func TestConfig_ValidateDevMail(t *testing.T) {
+	tests := []struct {
+		name           string
+		devMail        DevMail
+		expectedFields []string
+	}{
+		{name: "disabled", devMail: DevMail{}, expectedFields: []string{"delivery.transports[1].type"}},
+		{name: "enabled", devMail: DevMail{Enabled: true}},
+		{name: "negative capacity", devMail: DevMail{Enabled: true, Capacity: -1}, expectedFields: []string{"dev_mail.capacity"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{DevMail: tt.devMail, Delivery: Delivery{Transports: []Transport{
+				{Name: "primary", Type: TransportSMTP},
+				{Name: "dev", Type: TransportDev},
+			}}}
+			var errs ValidationError
+			cfg.validate(&errs)
+
+			fields := map[string]bool{}
+			for _, fe := range errs {
+				if strings.HasPrefix(fe.Field, "dev_mail.") || strings.HasPrefix(fe.Field, "delivery.") {
+					fields[fe.Field] = true
+				}
+			}
+			for _, field := range tt.expectedFields {
+				if !fields[field] {
+					t.Errorf("expected an error for %s, got %v", field, errs)
+				}
+			}
+			if len(fields) != len(tt.expectedFields) {
+				t.Errorf("got errors for %v, want %v", fields, tt.expectedFields)
+			}
 		})
 	}
 }
+
+// pemKey encodes a private key as PEM
+func pemKey(t *testing.T, blockType string, der []byte, err error) string {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}))
+}
+
+func TestDKIM_Validate(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	rsaPEM := pemKey(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey), nil)
+	edDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	edPEM := pemKey(t, "PRIVATE KEY", edDER, err)
+	ecDER, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	ecPEM := pemKey(t, "PRIVATE KEY", ecDER, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(edKey.Public())
+	publicPEM := pemKey(t, "PUBLIC KEY", publicDER, err)
+
+	dkim := DKIM{Keys: []DKIMKey{
+		{Domain: "example.com", Selector: "rsa2026", PrivateKey: rsaPEM},
+		{Domain: "mail.example.com", Selector: "ed._domainkey", PrivateKey: edPEM, Headers: []string{"from", "To"}, Canonicalization: "simple/relaxed"},
+		{Domain: "example", Selector: "bad selector", PrivateKey: "not a key"},
+		{Domain: "example.com", Selector: "ec", PrivateKey: ecPEM, Headers: []string{"To", "Sub ject"}, Canonicalization: "relaxed"},
+		{Domain: "example.com", Selector: "public", PrivateKey: publicPEM},
+	}}
+
+	var errs ValidationError
+	dkim.validate(&errs)
+
+	fields := map[string]bool{}
+	for _, fe := range errs {
+		fields[fe.Field] = true
+	}
+	for _, field := range []string{
+		"dkim.keys[2].domain",
+		"dkim.keys[2].selector",
+		"dkim.keys[2].private_key",
+		"dkim.keys[3].private_key",
+		"dkim.keys[3].headers[1]",
+		"dkim.keys[3].headers",
+		"dkim.keys[3].canonicalization",
+		"dkim.keys[4].private_key",
+	} {
+		if !fields[field] {
+			t.Errorf("expected an error for %s, got %v", field, errs)
+		}
+	}
+	if len(errs) != 8 {
+		t.Errorf("got %d errors, want 8: %v", len(errs), errs)
+	}
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("HSTS_MAX_AGE", "24h")
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"server": {"security_headers": {
+		"headers": {"Referrer-Policy": "same-origin", "X-Frame-Options": ""},
+		"routes": {"/docs/": {"Content-Security-Policy": "default-src 'self'"}}
+	}}}`), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	os.Setenv("CONFIG_FILE", path)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+
+	h := cfg.Server.SecurityHeaders
+	if h.HSTSMaxAge != Duration(24*time.Hour) {
+		t.Errorf("HSTSMaxAge = %v, want 24h", time.Duration(h.HSTSMaxAge))
+	}
+	if h.Headers["Referrer-Policy"] != "same-origin" {
+		t.Errorf("Referrer-Policy = %q, want same-origin", h.Headers["Referrer-Policy"])
+	}
+	if h.Headers["X-Content-Type-Options"] != "nosniff" {
+		t.Errorf("X-Content-Type-Options = %q, want the nosniff default kept", h.Headers["X-Content-Type-Options"])
+	}
+	if value, ok := h.Headers["X-Frame-Options"]; !ok || value != "" {
+		t.Errorf("X-Frame-Options = %q, want an empty value removing it", value)
+	}
+	if _, ok := h.Routes["/swagger/"]; !ok {
+		t.Error("Expected the default /swagger/ route to be kept")
+	}
+	if _, ok := h.Routes["/docs/"]; !ok {
+		t.Error("Expected the /docs/ route to be added")
+	}
+
+	invalid := SecurityHeaders{
+		HSTSMaxAge: Duration(-time.Second),
+		Headers:    map[string]string{"Bad Header": "x", "X-Test": "a\r\nSet-Cookie: b"},
+		Routes:     map[string]map[string]string{"swagger": {}},
+	}
+	var errs ValidationError
+	invalid.validate(&errs)
+	if len(errs) != 4 {
+		t.Errorf("got %d errors, want 4: %v", len(errs), errs)
+	}
+}
+
+func TestConfig_ValidateWebsites(t *testing.T) {
+	cfg := Config{
+		SMTPHost:      "mail-server",
+		SMTPPort:      "25",
+		DefaultFrom:   "noreply@example.com",
+		DefaultTo:     "contact@example.com",
+		Port:          "3002",
+		MaxBodySize:   1024,
+		HealthTimeout: Duration(time.Second),
+		Websites: map[string]Website{
+			"main": {
+				Recipients:      []string{"sales@example.com", "nope"},
+				SubjectTemplate: "{{.Subject",
+				Webhooks:        []string{"ftp://example.com"},
+				DefaultLocale:   "Spanish",
+				Locales: map[string]Templates{
+					"pt-BR":   {BodyTemplate: "{{.Message"},
+					"english": {},
+				},
+				AutoReply: AutoReply{Enabled: true, SubjectTemplate: "{{"},
+				Routing: Routing{
+					Rules: []RoutingRule{
+						{Match: []Condition{{Field: "phone", Equals: "1"}, {Field: "fields.team", Regex: "("}}},
+						{Route: Route{Priority: "urgent", Channels: []string{"sms"}}},
+						{Match: []Condition{{Field: "subject", Equals: "a", Contains: "b"}}},
+					},
+					Default: Route{Recipients: []string{"bad"}, SubjectTag: "a\nb"},
+				},
+
+				AntiSpam: AntiSpam{ProofOfWork: ProofOfWork{Difficulty: MaxDifficulty + 1}, Scoring: SpamScoring{
+					Weights:           map[string]float64{"links": -1, "karma": 2},
+					Thresholds:        SpamThresholds{Tag: 8, Quarantine: 6, Reject: 7},
+					Patterns:          []string{"(", "ok"},
+					Scripts:           []string{"Latin", "Klingon"},
+					DisposableDomains: []string{"user@example.com"},
+					Model:             "local",
+				}},
+				Form: Form{Fields: []FormField{
+					{Name: "Company"},
+					{Name: "team", Type: "radio"},
+					{Name: "team", Type: FieldSelect},
+					{Name: "phone", Options: []string{"a"}, Labels: map[string]string{"Portuguese": "Telefone"}},
+				}},
+				Transports: []string{"smtp", "archive"},
+			},
+			"Bad Name": {},
+		},
+	}
+
+	var validationErr ValidationError
+	if !errors.As(cfg.Validate(), &validationErr) {
+		t.Fatal("Validate() should fail")
+	}
+
+	fields := map[string]bool{}
+	for _, fe := range validationErr {
+		fields[fe.Field] = true
+	}
+	for _, field := range []string{
+		"websites.main.recipients[1]",
+		"websites.main.subject_template",
+		"websites.main.webhooks[0]",
+		"websites.main.default_locale",
+		"websites.main.locales.pt-BR.body_template",
+		"websites.main.locales.english",
+		"websites.main.auto_reply.subject_template",
+		"websites.main.anti_spam.proof_of_work.difficulty",
+		"websites.main.form.fields[0].name",
+		"websites.main.form.fields[1].type",
+		"websites.main.form.fields[2].name",
+		"websites.main.transports[1]",
+		"websites.main.form.fields[2].options",
+		"websites.main.form.fields[3].options",
+		"websites.main.form.fields[3].labels.Portuguese",
+		"websites.main.routing.rules[0].match[0].field",
+		"websites.main.routing.rules[0].match[1].regex",
+		"websites.main.routing.rules[1].match",
+		"websites.main.routing.rules[1].priority",
+		"websites.main.routing.rules[1].channels[0]",
+		"websites.main.routing.rules[2].match[0]",
+		"websites.main.routing.default.recipients[0]",
+		"websites.main.routing.default.subject_tag",
+		"websites.main.anti_spam.scoring.weights.links",
+		"websites.main.anti_spam.scoring.weights.karma",
+		"websites.main.anti_spam.scoring.thresholds.quarantine",
+		"websites.main.anti_spam.scoring.thresholds.reject",
+		"websites.main.anti_spam.scoring.patterns[0]",
+		"websites.main.anti_spam.scoring.scripts[1]",
+		"websites.main.anti_spam.scoring.disposable_domains[0]",
+		"websites.main.anti_spam.scoring.model",
+		"websites.Bad Name",
+	} {
+		if !fields[field] {
+			t.Errorf("expected an error for %s, got %v", field, validationErr)
+		}
+	}
+	if fields["websites.main.recipients[0]"] {
+		t.Error("valid recipient reported as invalid")
+	}
+	if fields["websites.main.anti_spam.scoring.patterns[1]"] || fields["websites.main.anti_spam.scoring.scripts[0]"] {
+		t.Error("valid spam settings reported as invalid")
+	}
+	if fields["websites.main.routing.rules[0].match[1].field"] {
+		t.Error("custom field reported as invalid")
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := Redacted(Config{
+		AdminToken:   "secret",
+		SMTPPassword: "smtp-secret",
+		ChallengeKey: "pow-secret",
+		Websites:     map[string]Website{"main": {WebhookSecret: "hook-secret"}},
+		Delivery: Delivery{Transports: []Transport{
+			{Name: "backup", Type: TransportSMTP, Password: "backup-secret"},
+			{Name: "sendgrid", Type: TransportSendGrid, APIKey: "sg-secret"},
+		}},
+		DKIM: DKIM{Keys: []DKIMKey{{Domain: "example.com", Selector: "mail", PrivateKey: "dkim-secret"}}},
+	})
+	if cfg.AdminToken == "secret" {
+		t.Error("Redacted() must hide the admin token")
+	}
+	if cfg.SMTPPassword == "smtp-secret" {
+		t.Error("Redacted() must hide the SMTP password")
+	}
+	if cfg.ChallengeKey == "pow-secret" {
+		t.Error("Redacted() must hide the challenge key")
+	}
+	if !IsRedacted(cfg.Websites["main"].WebhookSecret) {
+		t.Errorf("Redacted() must hide webhook secrets, got %q", cfg.Websites["main"].WebhookSecret)
+	}
+	if !IsRedacted(cfg.Delivery.Transports[0].Password) {
+		t.Errorf("Redacted() must hide transport passwords, got %q", cfg.Delivery.Transports[0].Password)
+	}
+	if !IsRedacted(cfg.Delivery.Transports[1].APIKey) {
+		t.Errorf("Redacted() must hide transport API keys, got %q", cfg.Delivery.Transports[1].APIKey)
+	}
+	if !IsRedacted(cfg.DKIM.Keys[0].PrivateKey) {
+		t.Errorf("Redacted() must hide DKIM private keys, got %q", cfg.DKIM.Keys[0].PrivateKey)
+	}
+	if Redacted(Config{}).AdminToken != "" {
+		t.Error("Redacted() should leave unset secrets empty")
+	}
+}
+
+func TestDuration_JSON(t *testing.T) {
+	var cfg Config
+	if err := json.Unmarshal([]byte(`{"health_cache_ttl": "30s", "health_timeout": 2000000000}`), &cfg); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+	if cfg.HealthCacheTTL != Duration(30*time.Second) {
+		t.Errorf("HealthCacheTTL = %v, want 30s", time.Duration(cfg.HealthCacheTTL))
+	}
+	if cfg.HealthTimeout != Duration(2*time.Second) {
+		t.Errorf("HealthTimeout = %v, want 2s", time.Duration(cfg.HealthTimeout))
+	}
+
+	out, _ := json.Marshal(cfg.HealthCacheTTL)
+	if string(out) != `"30s"` {
+		t.Errorf("Marshal = %s, want \"30s\"", out)
+	}
+
+	if err := json.Unmarshal([]byte(`{"health_timeout": "soon"}`), &cfg); err == nil {
+		t.Error("Unmarshal should reject an invalid duration")
+	}
+}
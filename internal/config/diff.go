@@ -0,0 +1,48 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// FieldChange describes a single changed field between two website versions
+type FieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+// DiffWebsites lists the fields that differ between two website definitions,
+// using their JSON field names. Changed secrets are listed with their values redacted.
+func DiffWebsites(from, to Website) []FieldChange {
+	before := websiteFields(from)
+	after := websiteFields(to)
+
+	changes := []FieldChange{}
+	for field, value := range after {
+		if reflect.DeepEqual(before[field], value) {
+			continue
+		}
+		change := FieldChange{Field: field, From: before[field], To: value}
+		if field == "webhook_secret" {
+			change.From = RedactWebsite(from).WebhookSecret
+			change.To = RedactWebsite(to).WebhookSecret
+		}
+		changes = append(changes, change)
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+	return changes
+}
+
+// websiteFields flattens a website into its JSON fields
+func websiteFields(site Website) map[string]any {
+	raw, _ := json.Marshal(site)
+
+	fields := map[string]any{}
+	_ = json.Unmarshal(raw, &fields)
+	return fields
+}
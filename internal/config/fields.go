@@ -0,0 +1,124 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// unmarshalerType is implemented by types that decode themselves
+var unmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+
+// unknownFields records every key of decoded JSON that no field of t decodes,
+// so that a misspelled key is reported rather than silently ignored. Keys
+// match field names regardless of case, as they do when decoding.
+func unknownFields(errs *ValidationError, value any, t reflect.Type, path string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(unmarshalerType) {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return
+		}
+		fields := jsonFields(t)
+		for _, key := range sortedKeys(object) {
+			field, ok := fields[key]
+			if !ok {
+				field, ok = foldField(fields, key)
+			}
+			if !ok {
+				errs.add(joinPath(path, key), "unknown field")
+				continue
+			}
+			unknownFields(errs, object[key], field.Type, joinPath(path, key))
+		}
+	case reflect.Map:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return
+		}
+		for _, key := range sortedKeys(object) {
+			unknownFields(errs, object[key], t.Elem(), joinPath(path, key))
+		}
+	case reflect.Slice, reflect.Array:
+		list, ok := value.([]any)
+		if !ok {
+			return
+		}
+		for i, item := range list {
+			unknownFields(errs, item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))
+		}
+	}
+}
+
+// jsonFields maps the JSON names of a struct's fields, including those of
+// embedded structs, to the fields
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || len(field.Index) > 1 && !embeddedPath(t, field.Index) {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+// embeddedPath reports whether a promoted field is reached only through
+// untagged embedded structs, as encoding/json promotes them
+func embeddedPath(t reflect.Type, index []int) bool {
+	for _, i := range index[:len(index)-1] {
+		field := t.Field(i)
+		if !field.Anonymous || field.Tag.Get("json") != "" {
+			return false
+		}
+		t = field.Type
+	}
+	return true
+}
+
+// foldField finds a field whose JSON name matches key regardless of case
+func foldField(fields map[string]reflect.StructField, key string) (reflect.StructField, bool) {
+	for name, field := range fields {
+		if strings.EqualFold(name, key) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// sortedKeys returns the keys of an object in order, for stable reports
+func sortedKeys(object map[string]any) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// joinPath appends a key to a field path
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// SecretSource resolves named secrets referenced as ${NAME} in config files
+type SecretSource interface {
+	Lookup(name string) (value string, ok bool, err error)
+}
+
+// FileSecretSource is a SecretSource backed by files, whose paths are watched
+// so that rotated secrets trigger a reload
+type FileSecretSource interface {
+	SecretSource
+	File(name string) string
+}
+
+// EnvSource resolves secrets from environment variables
+type EnvSource struct{}
+
+// Lookup returns the environment variable with the given name
+func (EnvSource) Lookup(name string) (string, bool, error) {
+	value, ok := os.LookupEnv(name)
+	return value, ok, nil
+}
+
+// DirSource resolves secrets from a directory holding one file per secret,
+// such as Docker secrets in /run/secrets or a mounted Kubernetes Secret
+type DirSource struct {
+	Dir string
+}
+
+// Lookup reads the file named after the secret
+func (s DirSource) Lookup(name string) (string, bool, error) {
+	if name != filepath.Base(name) || name == ".." {
+		return "", false, nil
+	}
+
+	data, err := os.ReadFile(s.File(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read secret %s: %w", name, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+// File returns the path of the file holding a secret
+func (s DirSource) File(name string) string {
+	return filepath.Join(s.Dir, name)
+}
+
+// DefaultSecretSources resolves secrets from the environment and then from
+// SECRETS_DIR when it is set
+func DefaultSecretSources() []SecretSource {
+	sources := []SecretSource{EnvSource{}}
+	if dir := os.Getenv("SECRETS_DIR"); dir != "" {
+		sources = append(sources, DirSource{Dir: dir})
+	}
+	return sources
+}
+
+// secretFields are the JSON names of secret values. In a config file each one
+// can instead name a file holding the secret with a _file suffix, such as
+// "api_key_file", like the *_FILE environment variables.
+var secretFields = []string{
+	"admin_token", "smtp_username", "smtp_password", "challenge_key", "webhook_secret",
+	"username", "password", "secret", "api_key", "private_key",
+}
+
+// referencePattern matches ${NAME} references in config file values
+var referencePattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_.-]*)\}`)
+
+// resolver expands references through a chain of secret sources and records
+// the files it read
+type resolver struct {
+	sources []SecretSource
+	files   []string
+}
+
+// expand replaces every ${NAME} reference in a string
+func (r *resolver) expand(value string) (string, error) {
+	var firstErr error
+	expanded := referencePattern.ReplaceAllStringFunc(value, func(ref string) string {
+		name := referencePattern.FindStringSubmatch(ref)[1]
+		resolved, err := r.lookup(name)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		return resolved
+	})
+	return expanded, firstErr
+}
+
+// lookup resolves a name through the first source that knows it
+func (r *resolver) lookup(name string) (string, error) {
+	for _, source := range r.sources {
+		value, ok, err := source.Lookup(name)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			if fs, isFile := source.(FileSecretSource); isFile {
+				r.files = append(r.files, fs.File(name))
+			}
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("undefined reference ${%s}", name)
+}
+
+// readFile reads a secret from a file and records the file so it is watched
+// for rotation
+func (r *resolver) readFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	r.files = append(r.files, path)
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// expandAll walks decoded JSON, expands references in every string and reads
+// secret fields given as files
+func (r *resolver) expandAll(value any) (any, error) {
+	switch v := value.(type) {
+	case string:
+		return r.expand(v)
+	case []any:
+		for i := range v {
+			expanded, err := r.expandAll(v[i])
+			if err != nil {
+				return nil, err
+			}
+			v[i] = expanded
+		}
+	case map[string]any:
+		for key := range v {
+			expanded, err := r.expandAll(v[key])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			v[key] = expanded
+		}
+		if err := r.readFields(v); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+// readFields replaces the _file variants of secret fields in an object with
+// the content of the files they name. Contents are not expanded.
+func (r *resolver) readFields(object map[string]any) error {
+	for _, field := range secretFields {
+		path, ok := object[field+"_file"].(string)
+		if !ok {
+			continue
+		}
+		delete(object, field+"_file")
+		if path == "" {
+			continue
+		}
+		if value, _ := object[field].(string); value != "" {
+			return fmt.Errorf("only one of %s and %s_file may be set", field, field)
+		}
+
+		value, err := r.readFile(path)
+		if err != nil {
+			return fmt.Errorf("%s_file: %w", field, err)
+		}
+		object[field] = value
+	}
+	return nil
+}
+
+// secretEnv sets target from KEY, or from the file named by KEY_FILE, and
+// records the file so it is watched for rotation
+func (r *resolver) secretEnv(errs *ValidationError, target *string, field, key string) {
+	value, hasValue := os.LookupEnv(key)
+	path, hasFile := os.LookupEnv(key + "_FILE")
+
+	switch {
+	case hasValue && hasFile && value != "" && path != "":
+		errs.add(field, "only one of %s and %s_FILE may be set", key, key)
+	case hasFile && path != "":
+		secret, err := r.readFile(path)
+		if err != nil {
+			errs.add(field, "%s_FILE: %v", key, err)
+			return
+		}
+		*target = secret
+	case value != "":
+		*target = value
+	}
+}
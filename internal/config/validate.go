@@ -0,0 +1,585 @@
+package config
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"maps"
+	"net"
+	"net/mail"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/nahuelsantos/contact-api/internal/clientip"
+	"github.com/nahuelsantos/contact-api/internal/i18n"
+	"github.com/nahuelsantos/contact-api/internal/webhook"
+)
+
+// WebsiteIDPattern restricts website identifiers to URL-safe slugs
+var WebsiteIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// FieldError is a validation problem with a configuration field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError collects every problem found in a configuration
+type ValidationError []FieldError
+
+// Error lists every problem on its own line
+func (v ValidationError) Error() string {
+	lines := make([]string, 0, len(v))
+	for _, fe := range v {
+		lines = append(lines, fe.Field+": "+fe.Message)
+	}
+	return "invalid configuration:\n  " + strings.Join(lines, "\n  ")
+}
+
+// add records a problem with a field
+func (v *ValidationError) add(field, format string, args ...any) {
+	*v = append(*v, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Validate checks the configuration and returns a ValidationError listing
+// every problem, or nil when it is valid
+func (c Config) Validate() error {
+	var errs ValidationError
+	c.validate(&errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validate appends every problem with the configuration to errs
+func (c Config) validate(errs *ValidationError) {
+	validateAddress(errs, "default_from", c.DefaultFrom)
+	validateAddress(errs, "default_to", c.DefaultTo)
+	validatePort(errs, "smtp_port", c.SMTPPort)
+	validatePort(errs, "port", c.Port)
+
+	if c.SMTPHost == "" {
+		errs.add("smtp_host", "must not be empty")
+	}
+	if c.MaxBodySize <= 0 {
+		errs.add("max_body_size", "must be positive")
+	}
+	if c.HealthCacheTTL < 0 {
+		errs.add("health_cache_ttl", "must not be negative")
+	}
+	if c.HealthTimeout <= 0 {
+		errs.add("health_timeout", "must be positive")
+	}
+	if c.WatchInterval < 0 {
+		errs.add("watch_interval", "must not be negative")
+	}
+	if c.MaxSubmissions < 0 {
+		errs.add("max_submissions", "must not be negative")
+	}
+	if c.Retention < 0 {
+		errs.add("submission_retention", "must not be negative")
+	}
+	if c.OutboxWarn < 0 {
+		errs.add("outbox_warn", "must not be negative")
+	}
+	if c.OutboxMax < 0 {
+		errs.add("outbox_max", "must not be negative")
+	}
+	if c.OutboxWarn > 0 && c.OutboxMax > 0 && c.OutboxWarn > c.OutboxMax {
+		errs.add("outbox_warn", "must not be greater than outbox_max")
+	}
+	c.Lists.validate(errs, "lists")
+	if c.DNS.Resolver != "" {
+		if _, port, err := net.SplitHostPort(c.DNS.Resolver); err != nil {
+			errs.add("dns.resolver", "must be host:port, got %q", c.DNS.Resolver)
+		} else {
+			validatePort(errs, "dns.resolver", port)
+		}
+	}
+	if c.DNS.Timeout <= 0 {
+		errs.add("dns.timeout", "must be positive")
+	}
+
+	c.Server.validate(errs)
+	c.Delivery.validate(errs)
+	c.DKIM.validate(errs)
+	if c.DevMail.Capacity < 0 {
+		errs.add("dev_mail.capacity", "must not be negative")
+	}
+	for i, t := range c.Delivery.Transports {
+		if t.Type == TransportDev && !c.DevMail.Enabled {
+			errs.add("delivery.transports["+strconv.Itoa(i)+"].type", "dev requires dev_mail.enabled")
+		}
+	}
+
+	transports := c.Delivery.TransportNames()
+	for name, site := range c.Websites {
+		site.validate(errs, "websites."+name, name)
+		for i, transport := range site.Transports {
+			if !slices.Contains(transports, transport) {
+				errs.add(fmt.Sprintf("websites.%s.transports[%d]", name, i), "must be one of %s, got %q", strings.Join(transports, ", "), transport)
+			}
+		}
+	}
+}
+
+// validate checks the HTTP server settings. Zero timeouts disable them.
+func (s Server) validate(errs *ValidationError) {
+	timeouts := []struct {
+		field string
+		value Duration
+	}{
+		{"server.read_timeout", s.ReadTimeout},
+		{"server.read_header_timeout", s.ReadHeaderTimeout},
+		{"server.write_timeout", s.WriteTimeout},
+		{"server.idle_timeout", s.IdleTimeout},
+	}
+	for _, t := range timeouts {
+		if t.value < 0 {
+			errs.add(t.field, "must not be negative")
+		}
+	}
+	if s.MaxHeaderBytes <= 0 {
+		errs.add("server.max_header_bytes", "must be positive")
+	}
+	if s.ShutdownGrace <= 0 {
+		errs.add("server.shutdown_grace", "must be positive")
+	}
+
+	if s.TLSCertFile == "" && s.TLSKeyFile != "" {
+		errs.add("server.tls_cert_file", "must be set together with tls_key_file")
+	}
+	if s.TLSKeyFile == "" && s.TLSCertFile != "" {
+		errs.add("server.tls_key_file", "must be set together with tls_cert_file")
+	}
+
+	for i, proxy := range s.TrustedProxies {
+		if _, err := clientip.ParsePrefixes([]string{proxy}); err != nil {
+			errs.add(fmt.Sprintf("server.trusted_proxies[%d]", i), "must be an IP address or CIDR range, got %q", proxy)
+		}
+	}
+	if s.ClientIPHeader != "" && !clientip.ValidHeader(s.ClientIPHeader) {
+		errs.add("server.client_ip_header", "must be one of %s, got %q",
+			strings.Join(clientip.Headers, ", "), s.ClientIPHeader)
+	}
+
+	s.SecurityHeaders.validate(errs)
+}
+
+// validate appends every problem with the delivery transports to errs
+func (d Delivery) validate(errs *ValidationError) {
+	if d.FailureThreshold < 0 {
+		errs.add("delivery.failure_threshold", "must not be negative")
+	}
+	if d.Cooldown < 0 {
+		errs.add("delivery.cooldown", "must not be negative")
+	}
+	if d.SMTPPool.Size < 0 {
+		errs.add("delivery.smtp_pool.size", "must not be negative")
+	}
+	if d.SMTPPool.MaxMessages < 0 {
+		errs.add("delivery.smtp_pool.max_messages", "must not be negative")
+	}
+	if d.SMTPPool.IdleTimeout < 0 {
+		errs.add("delivery.smtp_pool.idle_timeout", "must not be negative")
+	}
+
+	seen := map[string]bool{}
+	for i, t := range d.Transports {
+		path := "delivery.transports[" + strconv.Itoa(i) + "]"
+		if !WebsiteIDPattern.MatchString(t.Name) {
+			errs.add(path+".name", "must be a lowercase slug, got %q", t.Name)
+		} else if seen[t.Name] {
+			errs.add(path+".name", "duplicates transport %q", t.Name)
+		}
+		seen[t.Name] = true
+
+		switch t.Type {
+		case TransportSMTP:
+			if t.Port != "" {
+				validatePort(errs, path+".port", t.Port)
+			}
+		case TransportWebhook:
+			if err := webhook.ValidateURL(t.URL); err != nil {
+				errs.add(path+".url", "%v", err)
+			}
+		case TransportStore:
+		case TransportSendGrid, TransportPostmark, TransportResend:
+			validateProvider(errs, path, t)
+		case TransportMailgun:
+			validateProvider(errs, path, t)
+			if t.Domain == "" {
+				errs.add(path+".domain", "must not be empty")
+			}
+		case TransportSES:
+			if t.URL != "" {
+				validateBaseURL(errs, path+".url", t.URL)
+			}
+			if t.Region == "" {
+				errs.add(path+".region", "must not be empty")
+			}
+			if t.Username == "" || t.Password == "" {
+				errs.add(path+".username", "must be set with password to an access key")
+			}
+		case TransportSendmail:
+			if t.Path != "" && !filepath.IsAbs(t.Path) {
+				errs.add(path+".path", "must be an absolute path, got %q", t.Path)
+			}
+		case TransportFile, TransportMaildir:
+			if t.Path == "" {
+				errs.add(path+".path", "must not be empty")
+			}
+		case TransportDev:
+		default:
+			errs.add(path+".type", "must be one of %s, got %q", strings.Join(TransportTypes, ", "), t.Type)
+		}
+	}
+}
+
+// Domain names and DKIM selectors, as dot-separated labels
+var (
+	domainPattern   = regexp.MustCompile(`^(?i)([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z][a-z0-9-]{0,61}[a-z0-9]$`)
+	selectorPattern = regexp.MustCompile(`^(?i)[a-z0-9_]([a-z0-9_-]{0,62})(\.[a-z0-9_][a-z0-9_-]{0,62})*$`)
+)
+
+// validate appends every problem with the DKIM keys to errs
+func (d DKIM) validate(errs *ValidationError) {
+	for i, k := range d.Keys {
+		path := "dkim.keys[" + strconv.Itoa(i) + "]"
+		if !domainPattern.MatchString(k.Domain) {
+			errs.add(path+".domain", "must be a domain name, got %q", k.Domain)
+		}
+		if !selectorPattern.MatchString(k.Selector) {
+			errs.add(path+".selector", "must be a DKIM selector, got %q", k.Selector)
+		}
+		if _, err := k.Signer(); err != nil {
+			errs.add(path+".private_key", "%v", err)
+		}
+		for j, name := range k.Headers {
+			if !headerNamePattern.MatchString(name) {
+				errs.add(fmt.Sprintf("%s.headers[%d]", path, j), "must be a header name, got %q", name)
+			}
+		}
+		if len(k.Headers) > 0 && !slices.ContainsFunc(k.Headers, func(name string) bool { return strings.EqualFold(name, "From") }) {
+			errs.add(path+".headers", "must include From")
+		}
+		if k.Canonicalization != "" && !slices.Contains(DKIMCanonicalizations, k.Canonicalization) {
+			errs.add(path+".canonicalization", "must be one of %s, got %q", strings.Join(DKIMCanonicalizations, ", "), k.Canonicalization)
+		}
+	}
+}
+
+// routeFields lists the submission fields routing conditions can match
+var routeFields = []string{"name", "email", "subject", "message", "locale"}
+
+// validate appends every problem with a routing rule to errs
+func (r RoutingRule) validate(errs *ValidationError, path string) {
+	if len(r.Match) == 0 {
+		errs.add(path+".match", "must have at least one condition")
+	}
+	for i, cond := range r.Match {
+		cond.validate(errs, fmt.Sprintf("%s.match[%d]", path, i))
+	}
+	r.Route.validate(errs, path)
+}
+
+// validate appends every problem with a routing condition to errs
+func (c Condition) validate(errs *ValidationError, path string) {
+	custom, isCustom := strings.CutPrefix(c.Field, "fields.")
+	if !slices.Contains(routeFields, c.Field) && (!isCustom || custom == "") {
+		errs.add(path+".field", "must be one of %s or fields.<name>, got %q", strings.Join(routeFields, ", "), c.Field)
+	}
+
+	operators := 0
+	for _, set := range []bool{c.Equals != "", c.Contains != "", c.Regex != "", len(c.OneOf) > 0} {
+		if set {
+			operators++
+		}
+	}
+	if operators != 1 {
+		errs.add(path, "must set exactly one of equals, contains, regex or one_of")
+	}
+	if c.Regex != "" {
+		if _, err := regexp.Compile(c.Regex); err != nil {
+			errs.add(path+".regex", "does not compile: %v", err)
+		}
+	}
+}
+
+// validate appends every problem with the list files to errs
+func (l Lists) validate(errs *ValidationError, path string) {
+	if l.RefreshInterval < 0 {
+		errs.add(path+".refresh_interval", "must not be negative")
+	}
+	for i, file := range l.Files {
+		field := path + ".files[" + strconv.Itoa(i) + "]"
+		if file.Path == "" {
+			errs.add(field+".path", "must not be empty")
+		}
+		if file.List != ListBlock && file.List != ListAllow {
+			errs.add(field+".list", "must be %s or %s, got %q", ListBlock, ListAllow, file.List)
+		}
+	}
+}
+
+// spamChecks lists the built-in spam checks weights can refer to
+var spamChecks = []string{"links", "keywords", "script", "caps", "phrases", "disposable", "bayes"}
+
+// validate appends every problem with the spam scoring settings to errs
+func (s SpamScoring) validate(errs *ValidationError, path string) {
+	for _, name := range slices.Sorted(maps.Keys(s.Weights)) {
+		if !slices.Contains(spamChecks, name) {
+			errs.add(path+".weights."+name, "must be one of %s", strings.Join(spamChecks, ", "))
+		}
+		if s.Weights[name] < 0 {
+			errs.add(path+".weights."+name, "must not be negative")
+		}
+	}
+
+	t := s.Thresholds
+	thresholds := []struct {
+		name  string
+		value float64
+	}{{"tag", t.Tag}, {"quarantine", t.Quarantine}, {"reject", t.Reject}}
+	for _, threshold := range thresholds {
+		if threshold.value < 0 {
+			errs.add(path+".thresholds."+threshold.name, "must not be negative")
+		}
+	}
+	if t.Tag > 0 && t.Quarantine > 0 && t.Quarantine < t.Tag {
+		errs.add(path+".thresholds.quarantine", "must not be below the tag threshold")
+	}
+	if t.Reject > 0 && max(t.Tag, t.Quarantine) > t.Reject {
+		errs.add(path+".thresholds.reject", "must not be below the tag and quarantine thresholds")
+	}
+
+	if s.Model != "" && s.Model != "website" && s.Model != "global" {
+		errs.add(path+".model", "must be website or global, got %q", s.Model)
+	}
+	for i, pattern := range s.Patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			errs.add(path+".patterns["+strconv.Itoa(i)+"]", "does not compile: %v", err)
+		}
+	}
+	for i, script := range s.Scripts {
+		if _, ok := unicode.Scripts[script]; !ok {
+			errs.add(path+".scripts["+strconv.Itoa(i)+"]", "must be a Unicode script such as Latin or Cyrillic, got %q", script)
+		}
+	}
+	for i, domain := range s.DisposableDomains {
+		if domain == "" || strings.ContainsAny(domain, "@ ") {
+			errs.add(path+".disposable_domains["+strconv.Itoa(i)+"]", "must be a domain name, got %q", domain)
+		}
+	}
+}
+
+// validate appends every problem with a route to errs
+func (r Route) validate(errs *ValidationError, path string) {
+	for i, recipient := range r.Recipients {
+		validateAddress(errs, path+".recipients["+strconv.Itoa(i)+"]", recipient)
+	}
+	if strings.ContainsAny(r.SubjectTag, "\r\n") {
+		errs.add(path+".subject_tag", "must not contain line breaks")
+	}
+	if r.Priority != "" && !slices.Contains([]string{"high", "normal", "low"}, r.Priority) {
+		errs.add(path+".priority", "must be high, normal or low, got %q", r.Priority)
+	}
+	for i, channel := range r.Channels {
+		if channel != ChannelEmail && channel != ChannelWebhook {
+			errs.add(path+".channels["+strconv.Itoa(i)+"]", "must be %s or %s, got %q", ChannelEmail, ChannelWebhook, channel)
+		}
+	}
+	for i, url := range r.Webhooks {
+		if err := webhook.ValidateURL(url); err != nil {
+			errs.add(path+".webhooks["+strconv.Itoa(i)+"]", "%v", err)
+		}
+	}
+}
+
+// validateTemplates checks that a subject and body template parse
+func validateTemplates(errs *ValidationError, path string, t Templates) {
+	if t.SubjectTemplate != "" {
+		if _, err := template.New("subject").Parse(t.SubjectTemplate); err != nil {
+			errs.add(path+".subject_template", "does not parse: %v", err)
+		}
+	}
+	if t.BodyTemplate != "" {
+		if _, err := htmltemplate.New("body").Parse(t.BodyTemplate); err != nil {
+			errs.add(path+".body_template", "does not parse: %v", err)
+		}
+	}
+}
+
+// validateLocales checks the language tags and templates of localized variants
+func validateLocales(errs *ValidationError, path string, locales map[string]Templates) {
+	for _, tag := range slices.Sorted(maps.Keys(locales)) {
+		field := path + "." + tag
+		if !i18n.ValidTag(tag) {
+			errs.add(field, "must be a language tag such as en or pt-BR")
+		}
+		validateTemplates(errs, field, locales[tag])
+	}
+}
+
+// headerNamePattern matches HTTP header field names
+var headerNamePattern = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+.^_`|~-]+$")
+
+// validate appends every problem with the security headers to errs
+func (h SecurityHeaders) validate(errs *ValidationError) {
+	if h.HSTSMaxAge < 0 {
+		errs.add("server.security_headers.hsts_max_age", "must not be negative")
+	}
+	validateHeaders(errs, "server.security_headers.headers", h.Headers)
+	for _, prefix := range slices.Sorted(maps.Keys(h.Routes)) {
+		headers := h.Routes[prefix]
+		field := fmt.Sprintf("server.security_headers.routes[%q]", prefix)
+		if !strings.HasPrefix(prefix, "/") {
+			errs.add(field, "must be a path starting with /")
+		}
+		validateHeaders(errs, field, headers)
+	}
+}
+
+// validateHeaders checks header names and values
+func validateHeaders(errs *ValidationError, field string, headers map[string]string) {
+	for _, name := range slices.Sorted(maps.Keys(headers)) {
+		value := headers[name]
+		if !headerNamePattern.MatchString(name) {
+			errs.add(field, "invalid header name %q", name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			errs.add(field, "header %s must not contain line breaks", name)
+		}
+	}
+}
+
+// validate appends every problem with a website definition to errs
+func (w Website) validate(errs *ValidationError, path, name string) {
+	if !WebsiteIDPattern.MatchString(name) {
+		errs.add(path, "identifier must be a lowercase slug")
+	}
+
+	for i, recipient := range w.Recipients {
+		validateAddress(errs, path+".recipients["+strconv.Itoa(i)+"]", recipient)
+	}
+	if w.From != "" {
+		validateAddress(errs, path+".from", w.From)
+	}
+
+	validateTemplates(errs, path, Templates{SubjectTemplate: w.SubjectTemplate, BodyTemplate: w.BodyTemplate})
+	if w.DefaultLocale != "" && !i18n.ValidTag(w.DefaultLocale) {
+		errs.add(path+".default_locale", "must be a language tag such as en or pt-BR, got %q", w.DefaultLocale)
+	}
+	validateLocales(errs, path+".locales", w.Locales)
+
+	validateTemplates(errs, path+".auto_reply", Templates{SubjectTemplate: w.AutoReply.SubjectTemplate, BodyTemplate: w.AutoReply.BodyTemplate})
+	validateLocales(errs, path+".auto_reply.locales", w.AutoReply.Locales)
+
+	for i, url := range w.Webhooks {
+		if err := webhook.ValidateURL(url); err != nil {
+			errs.add(path+".webhooks["+strconv.Itoa(i)+"]", "%v", err)
+		}
+	}
+
+	if w.AntiSpam.MaxLinks < 0 {
+		errs.add(path+".anti_spam.max_links", "must not be negative")
+	}
+	if pow := w.AntiSpam.ProofOfWork; pow.Difficulty < 0 || pow.Difficulty > MaxDifficulty {
+		errs.add(path+".anti_spam.proof_of_work.difficulty", "must be between 0 and %d, got %d", MaxDifficulty, pow.Difficulty)
+	}
+	if w.AntiSpam.ProofOfWork.TTL < 0 {
+		errs.add(path+".anti_spam.proof_of_work.ttl", "must not be negative")
+	}
+	w.AntiSpam.Scoring.validate(errs, path+".anti_spam.scoring")
+
+	for i, rule := range w.Routing.Rules {
+		rule.validate(errs, fmt.Sprintf("%s.routing.rules[%d]", path, i))
+	}
+	w.Routing.Default.validate(errs, path+".routing.default")
+
+	w.Form.validate(errs, path+".form")
+}
+
+// FormFieldPattern restricts custom form field names to identifiers
+var FormFieldPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// validate appends every problem with a form definition to errs
+func (f Form) validate(errs *ValidationError, path string) {
+	if len(f.Fields) > MaxFormFields {
+		errs.add(path+".fields", "must have at most %d fields, got %d", MaxFormFields, len(f.Fields))
+	}
+
+	seen := map[string]bool{}
+	for i, field := range f.Fields {
+		fieldPath := path + ".fields[" + strconv.Itoa(i) + "]"
+		if !FormFieldPattern.MatchString(field.Name) {
+			errs.add(fieldPath+".name", "must be a lowercase identifier such as company, got %q", field.Name)
+		} else if seen[field.Name] {
+			errs.add(fieldPath+".name", "duplicates field %q", field.Name)
+		}
+		seen[field.Name] = true
+
+		if field.Type != "" && !slices.Contains(FormFieldTypes, field.Type) {
+			errs.add(fieldPath+".type", "must be one of %s, got %q", strings.Join(FormFieldTypes, ", "), field.Type)
+		}
+		if field.Type == FieldSelect && len(field.Options) == 0 {
+			errs.add(fieldPath+".options", "must not be empty for select fields")
+		}
+		if field.Type != FieldSelect && len(field.Options) > 0 {
+			errs.add(fieldPath+".options", "only apply to select fields")
+		}
+		for _, tag := range slices.Sorted(maps.Keys(field.Labels)) {
+			if !i18n.ValidTag(tag) {
+				errs.add(fieldPath+".labels."+tag, "must be keyed by a language tag such as en or pt-BR")
+			}
+		}
+	}
+}
+
+// validateAddress checks that a field holds a single valid email address
+func validateAddress(errs *ValidationError, field, value string) {
+	if value == "" {
+		errs.add(field, "must not be empty")
+		return
+	}
+	if _, err := mail.ParseAddress(value); err != nil {
+		errs.add(field, "invalid email address %q", value)
+	}
+}
+
+// validateProvider checks the API key and base URL of an email provider
+// transport
+func validateProvider(errs *ValidationError, path string, t Transport) {
+	if t.APIKey == "" {
+		errs.add(path+".api_key", "must not be empty")
+	}
+	if t.URL != "" {
+		validateBaseURL(errs, path+".url", t.URL)
+	}
+}
+
+// validateBaseURL checks that a field holds an absolute http or https URL
+func validateBaseURL(errs *ValidationError, field, value string) {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs.add(field, "must be an absolute http or https URL, got %q", value)
+	}
+}
+
+// validatePort checks that a field holds a TCP port number
+func validatePort(errs *ValidationError, field, value string) {
+	port, err := strconv.Atoi(value)
+	if err != nil {
+		errs.add(field, "must be a number, got %q", value)
+		return
+	}
+	if port < 1 || port > 65535 {
+		errs.add(field, "must be between 1 and 65535, got %d", port)
+	}
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"os"
+	"time"
+)
+
+// Watch polls the files returned by paths every interval and calls onChange
+// when their contents change. Paths are re-read on every poll so that files
+// added by a reload, such as new secret files, are watched too. It returns
+// when ctx is cancelled.
+func Watch(ctx context.Context, interval time.Duration, paths func() []string, onChange func()) {
+	if interval <= 0 {
+		return
+	}
+
+	last := filesDigest(paths())
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			current := filesDigest(paths())
+			if current == nil || bytes.Equal(current, last) {
+				continue
+			}
+			last = current
+			onChange()
+		}
+	}
+}
+
+// filesDigest hashes the paths and contents of files, returning nil if any
+// cannot be read so that a file being replaced is not mistaken for a change
+func filesDigest(paths []string) []byte {
+	h := sha256.New()
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		h.Write([]byte(path))
+		h.Write(data)
+	}
+	return h.Sum(nil)
+}
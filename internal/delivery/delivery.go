@@ -0,0 +1,322 @@
+// Package delivery sends notifications through an ordered chain of
+// transports, falling back to the next transport when one fails and skipping
+// transports whose circuit breaker is open.
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/devmail"
+	"github.com/nahuelsantos/contact-api/internal/email"
+	"github.com/nahuelsantos/contact-api/internal/observability"
+	"github.com/nahuelsantos/contact-api/internal/webhook"
+)
+
+// Defaults for breakers the configuration leaves unset
+const (
+	DefaultFailureThreshold = 5
+	DefaultCooldown         = 30 * time.Second
+)
+
+// ErrOpen is reported for transports skipped while their breaker is open
+var ErrOpen = errors.New("circuit breaker is open")
+
+// Transport delivers a notification
+type Transport interface {
+	Send(ctx context.Context, req email.Request) error
+}
+
+// Result tells which transport delivered a message
+type Result struct {
+	Transport string
+	Type      string
+}
+
+// Status describes a transport and the state of its breaker
+type Status struct {
+	Name  string `json:"name" example:"smtp"`
+	Type  string `json:"type" example:"smtp"`
+	State string `json:"state" example:"closed"`
+}
+
+// Dispatcher delivers messages through the configured transports
+type Dispatcher struct {
+	webhooks  *webhook.Client
+	providers *http.Client
+	devMail   *devmail.Catcher
+
+	mu         sync.RWMutex
+	transports map[string]*entry
+	order      []string
+}
+
+// entry is a configured transport with its breaker
+type entry struct {
+	def       config.Transport
+	transport Transport
+	breaker   *Breaker
+}
+
+// New returns a dispatcher for the transports of cfg. Webhook transports
+// post with webhooks.
+func New(cfg config.Config, webhooks *webhook.Client) *Dispatcher {
+	d := &Dispatcher{
+		webhooks:   webhooks,
+		providers:  &http.Client{Timeout: email.DefaultProviderTimeout},
+		devMail:    devmail.New(cfg.DevMail.Capacity),
+		transports: map[string]*entry{},
+	}
+	d.Configure(cfg)
+	return d
+}
+
+// Configure replaces the transports with those of cfg. Transports whose
+// definition is unchanged keep the state of their breaker. With the dev mail
+// catcher enabled, the default transport captures messages instead of
+// sending them over SMTP.
+func (d *Dispatcher) Configure(cfg config.Config) {
+	threshold, cooldown := cfg.Delivery.FailureThreshold, time.Duration(cfg.Delivery.Cooldown)
+	if threshold == 0 {
+		threshold = DefaultFailureThreshold
+	}
+	if cooldown == 0 {
+		cooldown = DefaultCooldown
+	}
+
+	defs := cfg.Delivery.Transports
+	if len(defs) == 0 {
+		defs = []config.Transport{{Name: config.DefaultTransport, Type: config.TransportSMTP}}
+		if cfg.DevMail.Enabled {
+			defs[0].Type = config.TransportDev
+		}
+	}
+	if cfg.DevMail.Enabled {
+		d.devMail.Resize(cfg.DevMail.Capacity)
+	} else {
+		d.devMail.Clear()
+	}
+
+	d.mu.Lock()
+	transports := make(map[string]*entry, len(defs))
+	order := make([]string, 0, len(defs))
+	for _, def := range defs {
+		old := d.transports[def.Name]
+		e := &entry{def: def, transport: d.build(def, cfg, old)}
+		if old != nil && old.def == def {
+			e.breaker = old.breaker
+			e.breaker.Configure(threshold, cooldown)
+		} else {
+			e.breaker = NewBreaker(threshold, cooldown)
+		}
+		transports[def.Name] = e
+		order = append(order, def.Name)
+		observeBreaker(def.Name, e.breaker)
+	}
+	var unused []*email.Pool
+	for name, old := range d.transports {
+		if _, ok := transports[name]; !ok {
+			observability.BreakerState.DeleteLabelValues(name)
+		}
+		if pool := poolOf(old); pool != nil && pool != poolOf(transports[name]) {
+			unused = append(unused, pool)
+		}
+	}
+	d.transports, d.order = transports, order
+	d.mu.Unlock()
+
+	for _, pool := range unused {
+		pool.Close()
+	}
+}
+
+// DevMail returns the development mail catcher of dev transports
+func (d *Dispatcher) DevMail() *devmail.Catcher {
+	return d.devMail
+}
+
+// Close closes the connection pools of the transports
+func (d *Dispatcher) Close() {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	for _, e := range d.transports {
+		if pool := poolOf(e); pool != nil {
+			pool.Close()
+		}
+	}
+}
+
+// build creates the transport for a definition. SMTP transports keep the
+// pool of the transport they replace when they connect to the same server
+// with the same pool settings.
+func (d *Dispatcher) build(def config.Transport, cfg config.Config, old *entry) Transport {
+	switch def.Type {
+	case config.TransportWebhook:
+		return webhookTransport{client: d.webhooks, url: def.URL, secret: def.Secret}
+	case config.TransportStore:
+		return storeTransport{}
+	case config.TransportSendGrid, config.TransportMailgun, config.TransportPostmark, config.TransportSES, config.TransportResend:
+		return serviceTransport{service: email.NewProvider(def, d.providers)}
+	case config.TransportSendmail:
+		return serviceTransport{service: email.NewSendmailService(def.Path)}
+	case config.TransportFile:
+		return serviceTransport{service: email.NewFileService(def.Path)}
+	case config.TransportMaildir:
+		return serviceTransport{service: email.NewMaildirService(def.Path)}
+	case config.TransportDev:
+		return serviceTransport{service: d.devMail}
+	}
+
+	t := newSMTPTransport(def, cfg)
+	if cfg.Delivery.SMTPPool.Size <= 0 {
+		return t
+	}
+	if prev, ok := transportOf(old).(smtpTransport); ok && prev.pool != nil && sameServer(prev, t) &&
+		prev.cfg.Delivery.SMTPPool == cfg.Delivery.SMTPPool {
+		t.pool = prev.pool
+		return t
+	}
+	t.pool = email.NewPool(def.Name, t.cfg, cfg.Delivery.SMTPPool)
+	return t
+}
+
+// transportOf returns the transport of an entry, nil without one
+func transportOf(e *entry) Transport {
+	if e == nil {
+		return nil
+	}
+	return e.transport
+}
+
+// poolOf returns the connection pool of an entry, nil without one
+func poolOf(e *entry) *email.Pool {
+	if t, ok := transportOf(e).(smtpTransport); ok {
+		return t.pool
+	}
+	return nil
+}
+
+// Deliver sends a message through the named transports in order, or through
+// every transport when names is empty, and returns the one that delivered
+// it. Transports whose breaker is open are skipped until their cooldown lets
+// a probe through, so a chain whose breakers are all open fails fast with
+// ErrOpen. A transport that rejects the message permanently ends delivery
+// with its error; otherwise the error lists the failure of every transport.
+func (d *Dispatcher) Deliver(ctx context.Context, website string, names []string, req email.Request) (Result, error) {
+	chain := d.chain(names)
+
+	var errs []error
+	for _, e := range chain {
+		if !e.breaker.Allow() {
+			observability.DeliveryAttempts.WithLabelValues(e.def.Name, "skipped").Inc()
+			errs = append(errs, fmt.Errorf("%s: %w", e.def.Name, ErrOpen))
+			continue
+		}
+		if err := d.attempt(ctx, e, website, req); err != nil {
+			// A rejected message would be rejected by the next transport too
+			if email.Permanent(err) {
+				observability.Deliveries.WithLabelValues(website, "none").Inc()
+				return Result{}, err
+			}
+			errs = append(errs, err)
+			continue
+		}
+		return d.delivered(website, e), nil
+	}
+
+	observability.Deliveries.WithLabelValues(website, "none").Inc()
+	if len(errs) == 0 {
+		return Result{}, errors.New("no transport to deliver through")
+	}
+	return Result{}, errors.Join(errs...)
+}
+
+// Ping checks that the SMTP servers of the named transports, or of every
+// transport when names is empty, accept connections. Other transports are not
+// probed, so a chain without SMTP transports always passes.
+func (d *Dispatcher) Ping(names []string) error {
+	var errs []error
+	for _, e := range d.chain(names) {
+		t, ok := e.transport.(smtpTransport)
+		if !ok {
+			continue
+		}
+		if err := email.Ping(t.cfg); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", e.def.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Statuses lists the named transports in order, or every transport when
+// names is empty, with the state of their breakers
+func (d *Dispatcher) Statuses(names []string) []Status {
+	chain := d.chain(names)
+	statuses := make([]Status, 0, len(chain))
+	for _, e := range chain {
+		statuses = append(statuses, Status{Name: e.def.Name, Type: e.def.Type, State: e.breaker.State()})
+	}
+	return statuses
+}
+
+// chain resolves transport names, skipping unknown ones
+func (d *Dispatcher) chain(names []string) []*entry {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if len(names) == 0 {
+		names = d.order
+	}
+	chain := make([]*entry, 0, len(names))
+	for _, name := range names {
+		if e, ok := d.transports[name]; ok {
+			chain = append(chain, e)
+		}
+	}
+	return chain
+}
+
+// attempt sends a message through one transport and records the outcome.
+// Only retryable failures count against the breaker: a transport that
+// rejects a message has answered, so it is working.
+func (d *Dispatcher) attempt(ctx context.Context, e *entry, website string, req email.Request) error {
+	err := e.transport.Send(ctx, req)
+	if err != nil {
+		permanent := email.Permanent(err)
+		if permanent {
+			e.breaker.Success()
+		} else {
+			e.breaker.Failure()
+		}
+		observability.DeliveryAttempts.WithLabelValues(e.def.Name, "failed").Inc()
+		slog.WarnContext(ctx, "Delivery through transport failed",
+			"transport", e.def.Name, "website", website, "state", e.breaker.State(),
+			"permanent", permanent, "error", err)
+		err = fmt.Errorf("%s: %w", e.def.Name, err)
+	} else {
+		e.breaker.Success()
+		observability.DeliveryAttempts.WithLabelValues(e.def.Name, "sent").Inc()
+	}
+	observeBreaker(e.def.Name, e.breaker)
+	return err
+}
+
+// delivered records the transport that delivered a message
+func (d *Dispatcher) delivered(website string, e *entry) Result {
+	observability.Deliveries.WithLabelValues(website, e.def.Name).Inc()
+	return Result{Transport: e.def.Name, Type: e.def.Type}
+}
+
+// breakerStates maps breaker states to the values of the state metric
+var breakerStates = map[string]float64{StateClosed: 0, StateHalfOpen: 1, StateOpen: 2}
+
+// observeBreaker reports the state of a transport's breaker
+func observeBreaker(name string, b *Breaker) {
+	observability.BreakerState.WithLabelValues(name).Set(breakerStates[b.State()])
+}
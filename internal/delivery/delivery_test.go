@@ -0,0 +1,497 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/email"
+	"github.com/nahuelsantos/contact-api/internal/webhook"
+)
+
+// clock is a fake time source for breakers
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func TestBreaker(t *testing.T) {
+	c := &clock{t: time.Now()}
+	b := NewBreaker(2, time.Minute)
+	b.now = c.now
+
+	steps := []struct {
+		name          string
+		action        func()
+		expectedAllow bool
+		expectedState string
+	}{
+		{name: "closed", action: func() {}, expectedAllow: true, expectedState: StateClosed},
+		{name: "one failure", action: b.Failure, expectedAllow: true, expectedState: StateClosed},
+		{name: "success resets", action: b.Success, expectedAllow: true, expectedState: StateClosed},
+		{name: "below threshold", action: b.Failure, expectedAllow: true, expectedState: StateClosed},
+		{name: "threshold trips", action: b.Failure, expectedAllow: false, expectedState: StateOpen},
+		{name: "cooling down", action: func() { c.t = c.t.Add(59 * time.Second) }, expectedAllow: false, expectedState: StateOpen},
+		{name: "probe after cooldown", action: func() { c.t = c.t.Add(time.Second) }, expectedAllow: true, expectedState: StateHalfOpen},
+		{name: "one probe at a time", action: func() {}, expectedAllow: false, expectedState: StateHalfOpen},
+		{name: "failed probe reopens", action: b.Failure, expectedAllow: false, expectedState: StateOpen},
+		{name: "second probe", action: func() { c.t = c.t.Add(time.Minute) }, expectedAllow: true, expectedState: StateHalfOpen},
+		{name: "successful probe closes", action: b.Success, expectedAllow: true, expectedState: StateClosed},
+	}
+
+	for _, step := range steps {
+		step.action()
+		if allowed := b.Allow(); allowed != step.expectedAllow {
+			t.Errorf("%s: Allow() = %v, want %v", step.name, allowed, step.expectedAllow)
+		}
+		if state := b.State(); state != step.expectedState {
+			t.Errorf("%s: State() = %s, want %s", step.name, state, step.expectedState)
+		}
+	}
+}
+
+func TestDispatcher_Deliver(t *testing.T) {
+	var mu sync.Mutex
+	dials := map[string]int{}
+	down := map[string]bool{"primary:25": true}
+	originalDialer := email.DefaultSMTPDialer
+	email.DefaultSMTPDialer = func(addr string) (email.SMTPClient, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		dials[addr]++
+		if down[addr] {
+			return nil, errors.New("connection refused")
+		}
+		return &email.MockSMTPClient{}, nil
+	}
+	defer func() { email.DefaultSMTPDialer = originalDialer }()
+
+	var posted email.Request
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(webhook.SignatureHeader) == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&posted)
+	}))
+	defer hook.Close()
+
+	cfg := config.Config{
+		SMTPHost: "global",
+		SMTPPort: "25",
+		Delivery: config.Delivery{
+			FailureThreshold: 2,
+			Cooldown:         config.Duration(time.Minute),
+			Transports: []config.Transport{
+				{Name: "primary", Type: config.TransportSMTP, Host: "primary"},
+				{Name: "secondary", Type: config.TransportSMTP},
+				{Name: "hook", Type: config.TransportWebhook, URL: hook.URL, Secret: "s3cret"},
+				{Name: "archive", Type: config.TransportStore},
+			},
+		},
+	}
+	d := New(cfg, webhook.NewClient(time.Second))
+	c := &clock{t: time.Now()}
+	d.transports["primary"].breaker.now = c.now
+
+	req := email.Request{From: "john@example.com", To: "contact@example.com", Subject: "Hello", Body: "Hi"}
+	deliver := func(names ...string) (Result, error) {
+		return d.Deliver(context.Background(), "main", names, req)
+	}
+
+	// The primary fails and the secondary delivers until the breaker trips
+	for i := 0; i < 3; i++ {
+		result, err := deliver()
+		if err != nil || result.Transport != "secondary" {
+			t.Fatalf("Deliver() = %+v, %v; want the secondary transport", result, err)
+		}
+	}
+	if dials["primary:25"] != 2 {
+		t.Errorf("Expected the open breaker to skip the primary, got %d dials", dials["primary:25"])
+	}
+	if state := d.Statuses([]string{"primary"})[0].State; state != StateOpen {
+		t.Errorf("Expected the primary breaker to be open, got %s", state)
+	}
+
+	// A website chain of its own
+	if result, err := deliver("hook", "archive"); err != nil || result.Transport != "hook" {
+		t.Errorf("Deliver() = %+v, %v; want the webhook transport", result, err)
+	}
+	if posted.Subject != "Hello" || posted.To != "contact@example.com" {
+		t.Errorf("Unexpected webhook payload: %+v", posted)
+	}
+
+	// Every transport of a chain failing
+	down["global:25"] = true
+	_, err := deliver("primary", "secondary")
+	if err == nil || !strings.Contains(err.Error(), "primary: "+ErrOpen.Error()) || !strings.Contains(err.Error(), "secondary: SMTP connection error") {
+		t.Errorf("Expected the failure of every transport, got %v", err)
+	}
+	if result, err := deliver("primary", "secondary", "archive"); err != nil || result.Type != config.TransportStore {
+		t.Errorf("Deliver() = %+v, %v; want the store transport", result, err)
+	}
+
+	// A chain whose breakers are all open fails without an attempt
+	deliver("secondary")
+	if state := d.Statuses([]string{"secondary"})[0].State; state != StateOpen {
+		t.Fatalf("Expected the secondary breaker to be open, got %s", state)
+	}
+	down["global:25"] = false
+	before := dials["global:25"]
+	if _, err := deliver("primary", "secondary"); !errors.Is(err, ErrOpen) {
+		t.Errorf("Expected %v, got %v", ErrOpen, err)
+	}
+	if dials["global:25"] != before {
+		t.Errorf("Expected no attempt through open breakers, got %d dials", dials["global:25"]-before)
+	}
+	if state := d.Statuses([]string{"secondary"})[0].State; state != StateOpen {
+		t.Errorf("Expected the secondary breaker to stay open, got %s", state)
+	}
+
+	// The primary is probed once its cooldown has passed
+	down["primary:25"] = false
+	c.t = c.t.Add(time.Minute)
+	if result, err := deliver(); err != nil || result.Transport != "primary" {
+		t.Errorf("Deliver() = %+v, %v; want the recovered primary", result, err)
+	}
+	if state := d.Statuses([]string{"primary"})[0].State; state != StateClosed {
+		t.Errorf("Expected the primary breaker to close, got %s", state)
+	}
+}
+
+func TestDispatcher_PermanentFailure(t *testing.T) {
+	dials := map[string]int{}
+	originalDialer := email.DefaultSMTPDialer
+	email.DefaultSMTPDialer = func(addr string) (email.SMTPClient, error) {
+		dials[addr]++
+		return &email.MockSMTPClient{
+			RcptFunc: func(to string) error {
+				if addr == "primary:25" {
+					return &textproto.Error{Code: 550, Msg: "mailbox unavailable"}
+				}
+				return nil
+			},
+		}, nil
+	}
+	defer func() { email.DefaultSMTPDialer = originalDialer }()
+
+	cfg := config.Config{
+		SMTPPort: "25",
+		Delivery: config.Delivery{
+			FailureThreshold: 2,
+			Transports: []config.Transport{
+				{Name: "primary", Type: config.TransportSMTP, Host: "primary"},
+				{Name: "secondary", Type: config.TransportSMTP, Host: "secondary"},
+			},
+		},
+	}
+	d := New(cfg, webhook.NewClient(time.Second))
+
+	req := email.Request{From: "john@example.com", To: "contact@example.com", Subject: "Hello", Body: "Hi"}
+	for i := 0; i < 3; i++ {
+		_, err := d.Deliver(context.Background(), "main", nil, req)
+		if !email.Permanent(err) {
+			t.Fatalf("Expected a permanent error, got %v", err)
+		}
+	}
+
+	if dials["secondary:25"] != 0 {
+		t.Errorf("Expected a rejected message not to fall through the chain, got %d dials", dials["secondary:25"])
+	}
+	if dials["primary:25"] != 3 {
+		t.Errorf("Expected every message to be tried through the primary, got %d dials", dials["primary:25"])
+	}
+	if state := d.Statuses([]string{"primary"})[0].State; state != StateClosed {
+		t.Errorf("Expected rejections not to open the breaker, got %s", state)
+	}
+}
+
+func TestDispatcher_AuthFailure(t *testing.T) {
+	dials := map[string]int{}
+	originalDialer := email.DefaultSMTPDialer
+	email.DefaultSMTPDialer = func(addr string) (email.SMTPClient, error) {
+		dials[addr]++
+		return &email.MockSMTPClient{
+			MailFunc: func(from string) error {
+				if addr == "primary:25" {
+					return &textproto.Error{Code: 530, Msg: "authentication required"}
+				}
+				return nil
+			},
+		}, nil
+	}
+	defer func() { email.DefaultSMTPDialer = originalDialer }()
+
+	cfg := config.Config{
+		SMTPPort: "25",
+		Delivery: config.Delivery{
+			FailureThreshold: 2,
+			Transports: []config.Transport{
+				{Name: "primary", Type: config.TransportSMTP, Host: "primary"},
+				{Name: "secondary", Type: config.TransportSMTP, Host: "secondary"},
+			},
+		},
+	}
+	d := New(cfg, webhook.NewClient(time.Second))
+
+	// A transport refusing its credentials is skipped like one that is down
+	req := email.Request{From: "john@example.com", To: "contact@example.com", Subject: "Hello", Body: "Hi"}
+	for i := 0; i < 3; i++ {
+		if result, err := d.Deliver(context.Background(), "main", nil, req); err != nil || result.Transport != "secondary" {
+			t.Fatalf("Deliver() = %+v, %v; want the secondary", result, err)
+		}
+	}
+
+	if dials["primary:25"] != 2 {
+		t.Errorf("Expected the primary to be tried until its breaker opens, got %d dials", dials["primary:25"])
+	}
+	if state := d.Statuses([]string{"primary"})[0].State; state != StateOpen {
+		t.Errorf("Expected authentication failures to open the breaker, got %s", state)
+	}
+}
+
+func TestDispatcher_Ping(t *testing.T) {
+	var dialed []string
+	originalDialer := email.DefaultSMTPDialer
+	email.DefaultSMTPDialer = func(addr string) (email.SMTPClient, error) {
+		dialed = append(dialed, addr)
+		if addr == "primary:25" {
+			return nil, errors.New("connection refused")
+		}
+		return &email.MockSMTPClient{}, nil
+	}
+	defer func() { email.DefaultSMTPDialer = originalDialer }()
+
+	cfg := config.Config{
+		SMTPHost: "global",
+		SMTPPort: "25",
+		Delivery: config.Delivery{Transports: []config.Transport{
+			{Name: "primary", Type: config.TransportSMTP, Host: "primary"},
+			{Name: "secondary", Type: config.TransportSMTP, Host: "secondary"},
+			{Name: "archive", Type: config.TransportStore},
+		}},
+	}
+	d := New(cfg, webhook.NewClient(time.Second))
+
+	tests := []struct {
+		name           string
+		names          []string
+		expectedDialed []string
+		expectedErr    string
+	}{
+		{name: "every transport", expectedDialed: []string{"primary:25", "secondary:25"}, expectedErr: "primary: "},
+		{name: "reachable transport", names: []string{"secondary"}, expectedDialed: []string{"secondary:25"}},
+		{name: "no smtp transport", names: []string{"archive"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialed = nil
+			err := d.Ping(tt.names)
+			if tt.expectedErr == "" && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if tt.expectedErr != "" && (err == nil || !strings.Contains(err.Error(), tt.expectedErr)) {
+				t.Errorf("Expected an error containing %q, got %v", tt.expectedErr, err)
+			}
+			if strings.Join(dialed, ",") != strings.Join(tt.expectedDialed, ",") {
+				t.Errorf("Expected to dial %v, got %v", tt.expectedDialed, dialed)
+			}
+		})
+	}
+}
+
+func TestDispatcher_Configure(t *testing.T) {
+	cfg := config.Config{SMTPHost: "localhost", SMTPPort: "25"}
+	d := New(cfg, webhook.NewClient(time.Second))
+
+	statuses := d.Statuses(nil)
+	if len(statuses) != 1 || statuses[0].Name != config.DefaultTransport || statuses[0].Type != config.TransportSMTP {
+		t.Fatalf("Expected the default SMTP transport, got %+v", statuses)
+	}
+
+	cfg.Delivery.Transports = []config.Transport{
+		{Name: "archive", Type: config.TransportStore},
+		{Name: "backup", Type: config.TransportSMTP, Host: "backup"},
+	}
+	d.Configure(cfg)
+	d.transports["archive"].breaker.Failure()
+	for i := 0; i < DefaultFailureThreshold; i++ {
+		d.transports["backup"].breaker.Failure()
+	}
+
+	// Unchanged transports keep their breaker, changed ones start closed
+	cfg.Delivery.Transports[1].Host = "backup-2"
+	d.Configure(cfg)
+	if b := d.transports["archive"].breaker; b.failures != 1 {
+		t.Errorf("Expected the unchanged transport to keep its breaker, got %d failures", b.failures)
+	}
+	if state := d.Statuses([]string{"backup"})[0].State; state != StateClosed {
+		t.Errorf("Expected the changed transport to start closed, got %s", state)
+	}
+	if statuses := d.Statuses([]string{"backup", "missing", "archive"}); len(statuses) != 2 || statuses[0].Name != "backup" {
+		t.Errorf("Expected the named transports in order, got %+v", statuses)
+	}
+}
+
+func TestDispatcher_SMTPPool(t *testing.T) {
+	var mu sync.Mutex
+	dials := map[string]int{}
+	originalDialer := email.DefaultSMTPDialer
+	email.DefaultSMTPDialer = func(addr string) (email.SMTPClient, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		dials[addr]++
+		return &email.MockSMTPClient{}, nil
+	}
+	defer func() { email.DefaultSMTPDialer = originalDialer }()
+
+	cfg := config.Config{SMTPHost: "localhost", SMTPPort: "25"}
+	cfg.Delivery.SMTPPool.Size = 2
+	d := New(cfg, webhook.NewClient(time.Second))
+	defer d.Close()
+
+	req := email.Request{From: "john@example.com", To: "contact@example.com", Subject: "Hello", Body: "Hi"}
+	send := func() {
+		t.Helper()
+		if _, err := d.Deliver(context.Background(), "main", nil, req); err != nil {
+			t.Fatalf("Deliver() returned error: %v", err)
+		}
+	}
+
+	send()
+	send()
+	if dials["localhost:25"] != 1 {
+		t.Errorf("Expected the pooled connection to be reused, got %d dials", dials["localhost:25"])
+	}
+
+	// Reloading an unchanged server keeps its connections
+	pool := poolOf(d.transports[config.DefaultTransport])
+	d.Configure(cfg)
+	if poolOf(d.transports[config.DefaultTransport]) != pool {
+		t.Error("Expected the pool to be kept across reloads")
+	}
+
+	// A new server gets a new pool and the old one is closed
+	cfg.SMTPHost = "mail.example.com"
+	d.Configure(cfg)
+	send()
+	if dials["mail.example.com:25"] != 1 {
+		t.Errorf("Expected a connection to the new server, got %d dials", dials["mail.example.com:25"])
+	}
+	if err := pool.Send(context.Background(), req); !errors.Is(err, email.ErrPoolClosed) {
+		t.Errorf("Expected the replaced pool to be closed, got %v", err)
+	}
+
+	// New DKIM keys change the messages a pool composes, so it is replaced
+	pool = poolOf(d.transports[config.DefaultTransport])
+	cfg.DKIM.Keys = []config.DKIMKey{{Domain: "example.org", Selector: "mail"}}
+	d.Configure(cfg)
+	if poolOf(d.transports[config.DefaultTransport]) == pool {
+		t.Error("Expected a new pool for new DKIM keys")
+	}
+
+	// Without pooling every message dials
+	cfg.Delivery.SMTPPool.Size = 0
+	d.Configure(cfg)
+	send()
+	send()
+	if dials["mail.example.com:25"] != 3 {
+		t.Errorf("Expected a connection per message, got %d dials", dials["mail.example.com:25"])
+	}
+}
+
+func TestDispatcher_Providers(t *testing.T) {
+	var hits []string
+	provider := func(status int) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits = append(hits, r.URL.Path)
+			w.WriteHeader(status)
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+	sendgrid, postmark := provider(http.StatusServiceUnavailable), provider(http.StatusOK)
+
+	cfg := config.Config{Delivery: config.Delivery{Transports: []config.Transport{
+		{Name: "sendgrid", Type: config.TransportSendGrid, APIKey: "key", URL: sendgrid.URL},
+		{Name: "postmark", Type: config.TransportPostmark, APIKey: "token", URL: postmark.URL},
+	}}}
+	d := New(cfg, webhook.NewClient(time.Second))
+
+	req := email.Request{From: "forms@example.com", To: "contact@example.com", Subject: "Hello", Body: "Hi"}
+	result, err := d.Deliver(context.Background(), "main", nil, req)
+	if err != nil || result.Transport != "postmark" {
+		t.Fatalf("Deliver() = %+v, %v; want the postmark transport", result, err)
+	}
+	if len(hits) != 2 || hits[0] != "/v3/mail/send" || hits[1] != "/email" {
+		t.Errorf("Expected SendGrid then Postmark, got %v", hits)
+	}
+
+	// A provider outage is worth retrying later
+	_, err = d.Deliver(context.Background(), "main", []string{"sendgrid"}, req)
+	if err == nil || email.Permanent(err) {
+		t.Errorf("Expected a retryable failure, got %v", err)
+	}
+}
+
+func TestDispatcher_LocalTransports(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.Config{Delivery: config.Delivery{Transports: []config.Transport{
+		{Name: "sendmail", Type: config.TransportSendmail, Path: filepath.Join(dir, "missing-sendmail")},
+		{Name: "maildir", Type: config.TransportMaildir, Path: filepath.Join(dir, "Maildir")},
+		{Name: "outbox", Type: config.TransportFile, Path: filepath.Join(dir, "outbox")},
+	}}}
+	d := New(cfg, webhook.NewClient(time.Second))
+
+	req := email.Request{From: "forms@example.com", To: "contact@example.com", Subject: "Hello", Body: "Hi"}
+	result, err := d.Deliver(context.Background(), "main", nil, req)
+	if err != nil || result.Transport != "maildir" {
+		t.Fatalf("Deliver() = %+v, %v; want the maildir transport", result, err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(dir, "Maildir", "new")); len(entries) != 1 {
+		t.Errorf("Expected 1 message in the Maildir, got %d", len(entries))
+	}
+
+	if result, err := d.Deliver(context.Background(), "main", []string{"outbox"}, req); err != nil || result.Transport != "outbox" {
+		t.Fatalf("Deliver() = %+v, %v; want the file transport", result, err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "outbox", "*.eml")); len(files) != 1 {
+		t.Errorf("Expected 1 .eml file, got %v", files)
+	}
+}
+
+func TestDispatcher_DevMail(t *testing.T) {
+	cfg := config.Config{DevMail: config.DevMail{Enabled: true, Capacity: 2}}
+	d := New(cfg, webhook.NewClient(time.Second))
+
+	// The default transport captures messages instead of sending them
+	req := email.Request{From: "forms@example.com", To: "contact@example.com", Subject: "Hello", Body: "Hi"}
+	for i := 0; i < 3; i++ {
+		result, err := d.Deliver(context.Background(), "main", nil, req)
+		if err != nil || result.Transport != config.DefaultTransport || result.Type != config.TransportDev {
+			t.Fatalf("Deliver() = %+v, %v; want the dev transport", result, err)
+		}
+	}
+	if messages := d.DevMail().List(); len(messages) != 2 || messages[0].Subject != "Hello" {
+		t.Errorf("Expected the 2 newest messages, got %d", len(messages))
+	}
+
+	// Disabling the catcher drops its messages
+	d.Configure(config.Config{})
+	if messages := d.DevMail().List(); len(messages) != 0 {
+		t.Errorf("Expected no messages once disabled, got %d", len(messages))
+	}
+	if statuses := d.Statuses(nil); statuses[0].Type != config.TransportSMTP {
+		t.Errorf("Expected the default transport to use SMTP again, got %s", statuses[0].Type)
+	}
+}
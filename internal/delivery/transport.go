@@ -0,0 +1,83 @@
+package delivery
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/email"
+	"github.com/nahuelsantos/contact-api/internal/webhook"
+)
+
+// smtpTransport sends messages to an SMTP server, over pooled connections
+// when pooling is enabled
+type smtpTransport struct {
+	cfg  config.Config
+	pool *email.Pool
+}
+
+// newSMTPTransport returns a transport for an SMTP server. Without a host it
+// uses the global SMTP server and credentials; credentials are never sent to
+// another host.
+func newSMTPTransport(def config.Transport, cfg config.Config) smtpTransport {
+	if def.Host != "" {
+		cfg.SMTPHost = def.Host
+		cfg.SMTPUsername, cfg.SMTPPassword = def.Username, def.Password
+	}
+	if def.Port != "" {
+		cfg.SMTPPort = def.Port
+	}
+	return smtpTransport{cfg: cfg}
+}
+
+// Send delivers a message over SMTP
+func (t smtpTransport) Send(ctx context.Context, req email.Request) error {
+	if t.pool != nil {
+		return t.pool.Send(ctx, req)
+	}
+	return email.Send(req, t.cfg)
+}
+
+// sameServer reports whether two SMTP transports connect to the same server
+// with the same credentials and compose the same messages, so a pool can be
+// kept across reloads
+func sameServer(a, b smtpTransport) bool {
+	return a.cfg.SMTPHost == b.cfg.SMTPHost && a.cfg.SMTPPort == b.cfg.SMTPPort &&
+		a.cfg.SMTPUsername == b.cfg.SMTPUsername && a.cfg.SMTPPassword == b.cfg.SMTPPassword &&
+		a.cfg.DefaultFrom == b.cfg.DefaultFrom && reflect.DeepEqual(a.cfg.DKIM, b.cfg.DKIM)
+}
+
+// webhookTransport posts messages as JSON to a URL
+type webhookTransport struct {
+	client *webhook.Client
+	url    string
+	secret string
+}
+
+// Send posts a message, signed when the transport has a secret
+func (t webhookTransport) Send(ctx context.Context, req email.Request) error {
+	return t.client.Post(ctx, t.url, t.secret, req)
+}
+
+// serviceTransport sends messages through an email service that takes a
+// context, such as the HTTP API of an email provider or a local sendmail
+type serviceTransport struct {
+	service interface {
+		SendContext(ctx context.Context, req email.Request) error
+	}
+}
+
+// Send delivers a message through the service
+func (t serviceTransport) Send(ctx context.Context, req email.Request) error {
+	return t.service.SendContext(ctx, req)
+}
+
+// storeTransport keeps messages in storage only. The submission record is
+// the stored copy, so sending always succeeds, and the outbox forwards it
+// once an earlier transport works again.
+type storeTransport struct{}
+
+// Send accepts a message
+func (storeTransport) Send(context.Context, email.Request) error {
+	return nil
+}
@@ -0,0 +1,257 @@
+// Package devmail holds the development mail catcher: a transport that keeps
+// the last messages in memory instead of sending them, and the pages that
+// show them. It replaces a separate SMTP sink such as MailHog in local
+// setups and must never be enabled in production.
+package devmail
+
+import (
+	"context"
+	_ "embed"
+	"html"
+	"html/template"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/email"
+)
+
+// DefaultCapacity is the number of messages kept when the configuration sets
+// none
+const DefaultCapacity = 100
+
+// Message is a captured message
+type Message struct {
+	ID         string    `json:"id" example:"1"`
+	ReceivedAt time.Time `json:"received_at"`
+	email.Request
+	Size int `json:"size" example:"512"`
+
+	// Raw is the message as it would have been sent over SMTP
+	Raw []byte `json:"-"`
+}
+
+// Text returns the body as plain text, with the tags of HTML bodies removed
+func (m Message) Text() string {
+	if !m.HTML {
+		return m.Body
+	}
+	return stripTags(m.Body)
+}
+
+// Catcher keeps the most recent messages in a ring buffer, dropping the
+// oldest one when it is full. It is safe for concurrent use.
+type Catcher struct {
+	mu   sync.Mutex
+	ring []Message
+	next int
+	size int
+	seq  uint64
+	now  func() time.Time
+}
+
+// New returns a catcher keeping up to capacity messages, or DefaultCapacity
+// when capacity is zero
+func New(capacity int) *Catcher {
+	c := &Catcher{now: time.Now}
+	c.Resize(capacity)
+	return c
+}
+
+// Resize changes the number of messages kept, dropping the oldest ones that
+// no longer fit
+func (c *Catcher) Resize(capacity int) {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if capacity == len(c.ring) {
+		return
+	}
+	kept := c.list()
+	if len(kept) > capacity {
+		kept = kept[:capacity]
+	}
+	c.ring = make([]Message, capacity)
+	c.next, c.size = 0, 0
+	for i := len(kept) - 1; i >= 0; i-- {
+		c.push(kept[i])
+	}
+}
+
+// Send implements email.Service
+func (c *Catcher) Send(req email.Request, cfg config.Config) error {
+	if req.From == "" {
+		req.From = cfg.DefaultFrom
+	}
+	return c.SendContext(context.Background(), req)
+}
+
+// SendContext captures a message. It never fails.
+func (c *Catcher) SendContext(_ context.Context, req email.Request) error {
+	c.Capture(req)
+	return nil
+}
+
+// Capture keeps a message and returns it with its ID
+func (c *Catcher) Capture(req email.Request) Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.seq++
+	now := c.now()
+	raw := email.Message(req, now)
+	msg := Message{ID: strconv.FormatUint(c.seq, 10), ReceivedAt: now, Request: req, Size: len(raw), Raw: raw}
+	c.push(msg)
+
+	log.Printf("Email for %s captured as dev mail message %s", req.To, msg.ID)
+	return msg
+}
+
+// List returns the kept messages, newest first
+func (c *Catcher) List() []Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.list()
+}
+
+// Get returns the kept message with an ID
+func (c *Catcher) Get(id string) (Message, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, msg := range c.list() {
+		if msg.ID == id {
+			return msg, true
+		}
+	}
+	return Message{}, false
+}
+
+// Clear drops every kept message
+func (c *Catcher) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.ring)
+	c.next, c.size = 0, 0
+}
+
+// push adds a message over the oldest one when the ring is full
+func (c *Catcher) push(msg Message) {
+	c.ring[c.next] = msg
+	c.next = (c.next + 1) % len(c.ring)
+	if c.size < len(c.ring) {
+		c.size++
+	}
+}
+
+// list returns the messages of the ring, newest first
+func (c *Catcher) list() []Message {
+	messages := make([]Message, 0, c.size)
+	for i := 1; i <= c.size; i++ {
+		messages = append(messages, c.ring[(c.next-i+len(c.ring))%len(c.ring)])
+	}
+	return messages
+}
+
+// stripTags removes the tags of an HTML document, along with its head,
+// scripts and styles, and unescapes entities
+func stripTags(s string) string {
+	var b strings.Builder
+	skip := ""
+	for len(s) > 0 {
+		start := strings.IndexByte(s, '<')
+		if start < 0 {
+			if skip == "" {
+				b.WriteString(s)
+			}
+			break
+		}
+		if skip == "" {
+			b.WriteString(s[:start])
+		}
+		end := strings.IndexByte(s[start:], '>')
+		if end < 0 {
+			break
+		}
+		inner := s[start+1 : start+end]
+		closing := strings.HasPrefix(inner, "/")
+		tag := ""
+		if fields := strings.Fields(strings.ToLower(strings.Trim(inner, "/"))); len(fields) > 0 {
+			tag = fields[0]
+		}
+		switch {
+		case skip == "" && !closing && (tag == "head" || tag == "script" || tag == "style"):
+			skip = tag
+		case skip != "" && closing && tag == skip:
+			skip = ""
+		case skip == "" && (tag == "br" || tag == "p" || tag == "div" || tag == "tr" || tag == "li"):
+			b.WriteString("\n")
+		}
+		s = s[start+end+1:]
+	}
+
+	var lines []string
+	for _, line := range strings.Split(html.UnescapeString(b.String()), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" && (len(lines) == 0 || lines[len(lines)-1] == "") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+//go:embed list.html
+var listHTML string
+
+//go:embed message.html
+var messageHTML string
+
+var (
+	listTemplate    = template.Must(template.New("list").Parse(listHTML))
+	messageTemplate = template.Must(template.New("message").Parse(messageHTML))
+)
+
+// Views of a message page
+const (
+	ViewHTML = "html"
+	ViewText = "text"
+	ViewRaw  = "raw"
+)
+
+// ListPage is the page listing captured messages
+type ListPage struct {
+	Messages []Message
+}
+
+// Render writes the page
+func (p ListPage) Render(w io.Writer) error {
+	return listTemplate.Execute(w, p)
+}
+
+// MessagePage is the page showing a captured message in one of its views
+type MessagePage struct {
+	Message Message
+	View    string
+}
+
+// Render writes the page. The HTML view frames the body of the message,
+// served separately in a sandbox.
+func (p MessagePage) Render(w io.Writer) error {
+	if p.View != ViewHTML && p.View != ViewText && p.View != ViewRaw {
+		p.View = ViewText
+		if p.Message.HTML {
+			p.View = ViewHTML
+		}
+	}
+	if p.View == ViewHTML && !p.Message.HTML {
+		p.View = ViewText
+	}
+	return messageTemplate.Execute(w, p)
+}
@@ -0,0 +1,145 @@
+package devmail
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/email"
+)
+
+// ids returns the IDs of messages in order
+func ids(messages []Message) string {
+	var list []string
+	for _, msg := range messages {
+		list = append(list, msg.ID)
+	}
+	return strings.Join(list, ",")
+}
+
+func TestCatcher(t *testing.T) {
+	c := New(3)
+	for i := 0; i < 5; i++ {
+		if err := c.SendContext(context.Background(), email.Request{To: "contact@example.com", Subject: "Hello"}); err != nil {
+			t.Fatalf("SendContext() returned error: %v", err)
+		}
+	}
+
+	// The ring keeps the newest messages
+	if got := ids(c.List()); got != "5,4,3" {
+		t.Errorf("Expected messages 5,4,3, got %s", got)
+	}
+	if _, ok := c.Get("2"); ok {
+		t.Error("Expected message 2 to be dropped")
+	}
+	msg, ok := c.Get("4")
+	if !ok {
+		t.Fatal("Expected message 4 to be kept")
+	}
+	if !strings.Contains(string(msg.Raw), "Subject: Hello\r\n") || msg.Size != len(msg.Raw) {
+		t.Errorf("Expected the raw message with its size, got %d bytes:\n%s", msg.Size, msg.Raw)
+	}
+
+	c.Resize(2)
+	if got := ids(c.List()); got != "5,4" {
+		t.Errorf("Expected messages 5,4 after shrinking, got %s", got)
+	}
+	c.Resize(4)
+	c.Capture(email.Request{To: "contact@example.com"})
+	if got := ids(c.List()); got != "6,5,4" {
+		t.Errorf("Expected messages 6,5,4 after growing, got %s", got)
+	}
+
+	c.Clear()
+	if got := c.List(); len(got) != 0 {
+		t.Errorf("Expected no messages after clearing, got %d", len(got))
+	}
+	if msg := c.Capture(email.Request{}); msg.ID != "7" {
+		t.Errorf("Expected IDs to keep counting after clearing, got %s", msg.ID)
+	}
+}
+
+func TestCatcher_Send(t *testing.T) {
+	c := New(0)
+	if err := c.Send(email.Request{To: "visitor@example.com"}, config.Config{DefaultFrom: "noreply@example.com"}); err != nil {
+		t.Fatalf("Send() returned error: %v", err)
+	}
+	if messages := c.List(); len(messages) != 1 || messages[0].From != "noreply@example.com" {
+		t.Errorf("Expected a message from the default sender, got %+v", messages)
+	}
+}
+
+func TestMessage_Text(t *testing.T) {
+	tests := []struct {
+		name     string
+		request  email.Request
+		expected string
+	}{
+		{name: "plain text", request: email.Request{Body: "Hello <world>"}, expected: "Hello <world>"},
+		{
+			name: "html",
+			request: email.Request{HTML: true, Body: `<html><head><title>Ignored</title><style>p { color: red }</style></head>` +
+				`<body><p>Hello &amp; welcome</p><script>alert(1)</script><p>Line<br/>break</p></body></html>`},
+			expected: "Hello & welcome\n\nLine\nbreak",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (Message{Request: tt.request}).Text(); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestListPage_Render(t *testing.T) {
+	c := New(0)
+	c.now = func() time.Time { return time.Date(2026, 10, 18, 12, 30, 0, 0, time.UTC) }
+	c.Capture(email.Request{From: "forms@example.com", To: "contact@example.com", Subject: `<b>"Hi"</b>`})
+
+	var page strings.Builder
+	if err := (ListPage{Messages: c.List()}).Render(&page); err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+	for _, expected := range []string{
+		`<td class="time">2026-10-18 12:30:00</td>`,
+		`<a href="/_dev/mail/messages/1">&lt;b&gt;&#34;Hi&#34;&lt;/b&gt;</a>`,
+	} {
+		if !strings.Contains(page.String(), expected) {
+			t.Errorf("Expected the page to contain %s, got:\n%s", expected, page.String())
+		}
+	}
+}
+
+func TestMessagePage_Render(t *testing.T) {
+	c := New(0)
+	htmlMessage := c.Capture(email.Request{To: "contact@example.com", Subject: "HTML", Body: "<p>Hello</p>", HTML: true})
+	textMessage := c.Capture(email.Request{To: "contact@example.com", Subject: "Text", Body: "<script>alert(1)</script>"})
+
+	tests := []struct {
+		name     string
+		page     MessagePage
+		expected string
+	}{
+		{name: "html by default", page: MessagePage{Message: htmlMessage}, expected: `<iframe sandbox src="/_dev/mail/messages/1/html"`},
+		{name: "text of html", page: MessagePage{Message: htmlMessage, View: ViewText}, expected: "<pre>Hello</pre>"},
+		{name: "text by default", page: MessagePage{Message: textMessage}, expected: "<pre>&lt;script&gt;alert(1)&lt;/script&gt;</pre>"},
+		{name: "no html view of text", page: MessagePage{Message: textMessage, View: ViewHTML}, expected: "<pre>&lt;script&gt;"},
+		{name: "raw", page: MessagePage{Message: textMessage, View: ViewRaw}, expected: "Subject: Text\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n&lt;script&gt;"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var page strings.Builder
+			if err := tt.page.Render(&page); err != nil {
+				t.Fatalf("Render() returned error: %v", err)
+			}
+			if !strings.Contains(page.String(), tt.expected) {
+				t.Errorf("Expected the page to contain %s, got:\n%s", tt.expected, page.String())
+			}
+		})
+	}
+}
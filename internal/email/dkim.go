@@ -0,0 +1,210 @@
+package email
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+)
+
+// DefaultDKIMCanonicalization is used by keys that set no canonicalization
+const DefaultDKIMCanonicalization = "relaxed/relaxed"
+
+// DefaultDKIMHeaders are the header fields signed by keys that list none.
+// Reply-To is signed even when a message has none, so that it cannot be
+// added in transit.
+var DefaultDKIMHeaders = []string{"From", "Reply-To", "To", "Subject", "Date", "Content-Type"}
+
+// wspRun matches runs of whitespace within a line
+var wspRun = regexp.MustCompile(`[ \t]+`)
+
+// dkimSigners caches parsed private keys by their PEM encoding
+var dkimSigners sync.Map
+
+// Sign adds a DKIM-Signature header to a message for each key of its sender
+// domain, as described in RFC 6376 and RFC 8463. Messages without a key are
+// returned unchanged. Line endings are normalized to CRLF first, as the
+// message is sent.
+func Sign(message []byte, from string, keys []config.DKIMKey, now time.Time) ([]byte, error) {
+	matched := dkimKeys(from, keys)
+	if len(matched) == 0 {
+		return message, nil
+	}
+
+	message = bytes.ReplaceAll(unixLines(message), []byte("\n"), []byte("\r\n"))
+	header, body := message, []byte(nil)
+	if i := bytes.Index(message, []byte("\r\n\r\n")); i >= 0 {
+		header, body = message[:i+2], message[i+4:]
+	}
+	fields := headerFields(header)
+
+	var signatures []byte
+	for _, key := range matched {
+		signature, err := dkimSignature(key, fields, body, now)
+		if err != nil {
+			return nil, fmt.Errorf("DKIM error for %s: %w", key.Domain, err)
+		}
+		signatures = append(signatures, signature...)
+	}
+	return append(signatures, message...), nil
+}
+
+// dkimKeys returns the keys of the sender domain, or of its closest parent
+// domain with keys
+func dkimKeys(from string, keys []config.DKIMKey) []config.DKIMKey {
+	_, address := splitAddress(from)
+	at := strings.LastIndexByte(address, '@')
+	if at < 0 {
+		return nil
+	}
+	domain := strings.ToLower(address[at+1:])
+
+	var matched []config.DKIMKey
+	best := ""
+	for _, key := range keys {
+		d := strings.ToLower(key.Domain)
+		if d != domain && !strings.HasSuffix(domain, "."+d) {
+			continue
+		}
+		if len(d) > len(best) {
+			matched, best = nil, d
+		}
+		if d == best {
+			matched = append(matched, key)
+		}
+	}
+	return matched
+}
+
+// dkimSignature returns the DKIM-Signature header of a message for a key
+func dkimSignature(key config.DKIMKey, fields []string, body []byte, now time.Time) ([]byte, error) {
+	signer, err := dkimSigner(key)
+	if err != nil {
+		return nil, err
+	}
+	algorithm, opts := "rsa-sha256", crypto.Hash(crypto.SHA256)
+	if _, ok := signer.(ed25519.PrivateKey); ok {
+		// Ed25519 signs the hash itself, see RFC 8463
+		algorithm, opts = "ed25519-sha256", crypto.Hash(0)
+	}
+
+	canonicalization := key.Canonicalization
+	if canonicalization == "" {
+		canonicalization = DefaultDKIMCanonicalization
+	}
+	headerCanon, bodyCanon, _ := strings.Cut(canonicalization, "/")
+	names := key.Headers
+	if len(names) == 0 {
+		names = DefaultDKIMHeaders
+	}
+
+	bodyHash := sha256.Sum256(canonicalBody(body, bodyCanon))
+	signature := fmt.Sprintf("DKIM-Signature: v=1; a=%s; c=%s; d=%s; s=%s; t=%d;\r\n\th=%s;\r\n\tbh=%s;\r\n\tb=",
+		algorithm, canonicalization, key.Domain, key.Selector, now.Unix(),
+		strings.Join(names, ":"), base64.StdEncoding.EncodeToString(bodyHash[:]))
+
+	h := sha256.New()
+	for _, field := range signedFields(fields, names) {
+		h.Write([]byte(canonicalHeader(field, headerCanon)))
+	}
+	h.Write([]byte(strings.TrimSuffix(canonicalHeader(signature+"\r\n", headerCanon), "\r\n")))
+
+	b, err := signer.Sign(rand.Reader, h.Sum(nil), opts)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(signature + base64.StdEncoding.EncodeToString(b) + "\r\n"), nil
+}
+
+// dkimSigner returns the parsed private key of a DKIM key
+func dkimSigner(key config.DKIMKey) (crypto.Signer, error) {
+	if signer, ok := dkimSigners.Load(key.PrivateKey); ok {
+		return signer.(crypto.Signer), nil
+	}
+	signer, err := key.Signer()
+	if err != nil {
+		return nil, err
+	}
+	dkimSigners.Store(key.PrivateKey, signer)
+	return signer, nil
+}
+
+// headerFields splits a header block into fields, continuation lines
+// included and each ending with CRLF
+func headerFields(header []byte) []string {
+	var fields []string
+	for _, line := range strings.SplitAfter(string(header), "\r\n") {
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(fields) > 0 {
+			fields[len(fields)-1] += line
+			continue
+		}
+		fields = append(fields, line)
+	}
+	return fields
+}
+
+// signedFields picks the fields named by the h= tag. Repeated names take the
+// instances of a field from the bottom up, and names without an instance
+// left sign nothing.
+func signedFields(fields, names []string) []string {
+	used := make([]bool, len(fields))
+	var signed []string
+	for _, name := range names {
+		for i := len(fields) - 1; i >= 0; i-- {
+			fieldName, _, _ := strings.Cut(fields[i], ":")
+			if !used[i] && strings.EqualFold(strings.TrimSpace(fieldName), name) {
+				used[i] = true
+				signed = append(signed, fields[i])
+				break
+			}
+		}
+	}
+	return signed
+}
+
+// canonicalHeader canonicalizes a header field ending with CRLF, see
+// RFC 6376 section 3.4
+func canonicalHeader(field, canon string) string {
+	if canon == "simple" {
+		return field
+	}
+	name, value, _ := strings.Cut(field, ":")
+	value = strings.ReplaceAll(value, "\r\n", "")
+	return strings.ToLower(strings.TrimSpace(name)) + ":" + strings.Join(strings.FieldsFunc(value, isWSP), " ") + "\r\n"
+}
+
+// canonicalBody canonicalizes a message body, see RFC 6376 section 3.4
+func canonicalBody(body []byte, canon string) []byte {
+	if canon == "relaxed" {
+		lines := bytes.Split(body, []byte("\r\n"))
+		for i, line := range lines {
+			lines[i] = wspRun.ReplaceAll(bytes.TrimRightFunc(line, isWSP), []byte(" "))
+		}
+		body = bytes.Join(lines, []byte("\r\n"))
+	}
+
+	for bytes.HasSuffix(body, []byte("\r\n")) {
+		body = body[:len(body)-2]
+	}
+	if len(body) == 0 && canon == "relaxed" {
+		return nil
+	}
+	return append(body[:len(body):len(body)], '\r', '\n')
+}
+
+// isWSP reports whether r is a space or horizontal tab
+func isWSP(r rune) bool {
+	return r == ' ' || r == '\t'
+}
@@ -0,0 +1,251 @@
+package email
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+)
+
+// RFC 8463 appendix A: the Ed25519 key and message of the example
+const (
+	rfc8463Seed   = "nWGxne/9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A="
+	rfc8463Header = "From: Joe SixPack <joe@football.example.com>\r\n" +
+		"To: Suzie Q <suzie@shopping.example.net>\r\n" +
+		"Subject: Is dinner ready?\r\n" +
+		"Date: Fri, 11 Jul 2003 21:00:37 -0700 (PDT)\r\n" +
+		"Message-ID: <20030712040037.46341.5F8J@football.example.com>\r\n"
+	rfc8463Body = "Hi.\r\n\r\nWe lost the game.  Are you hungry yet?\r\n\r\nJoe.\r\n"
+)
+
+func TestDKIM_RFC8463(t *testing.T) {
+	bodyHash := sha256.Sum256(canonicalBody([]byte(rfc8463Body), "relaxed"))
+	if got := base64.StdEncoding.EncodeToString(bodyHash[:]); got != "2jUSOH9NhtVGCQWNr9BrIAPreKQjO6Sn7XIkfJVOzv8=" {
+		t.Errorf("Expected the body hash of the example, got %s", got)
+	}
+
+	// Ed25519 signatures are deterministic, so the example signature is
+	// reproduced exactly
+	signature := "DKIM-Signature: v=1; a=ed25519-sha256; c=relaxed/relaxed;\r\n" +
+		" d=football.example.com; i=@football.example.com;\r\n" +
+		" q=dns/txt; s=brisbane; t=1528637909; h=from : to :\r\n" +
+		" subject : date : message-id : from : subject : date;\r\n" +
+		" bh=2jUSOH9NhtVGCQWNr9BrIAPreKQjO6Sn7XIkfJVOzv8=;\r\n" +
+		" b=\r\n"
+	names := strings.Split("from:to:subject:date:message-id:from:subject:date", ":")
+	h := sha256.New()
+	for _, field := range signedFields(headerFields([]byte(rfc8463Header)), names) {
+		h.Write([]byte(canonicalHeader(field, "relaxed")))
+	}
+	h.Write([]byte(strings.TrimSuffix(canonicalHeader(signature, "relaxed"), "\r\n")))
+
+	seed, _ := base64.StdEncoding.DecodeString(rfc8463Seed)
+	b := ed25519.Sign(ed25519.NewKeyFromSeed(seed), h.Sum(nil))
+	if got := base64.StdEncoding.EncodeToString(b); got != "/gCrinpcQOoIfuHNQIbq4pgh9kyIK3AQUdt9OdqQehSwhEIug4D11BusFa3bT3FY5OsU7ZbnKELq+eXdp1Q1Dw==" {
+		t.Errorf("Expected the signature of the example, got %s", got)
+	}
+}
+
+func TestDKIM_Canonicalization(t *testing.T) {
+	// RFC 6376 section 3.4.6
+	header, body := "A: X\r\nB : Y\t\r\n\tZ  \r\n", " C \r\nD \t E\r\n\r\n\r\n"
+
+	var relaxed strings.Builder
+	for _, field := range headerFields([]byte(header)) {
+		relaxed.WriteString(canonicalHeader(field, "relaxed"))
+	}
+	if relaxed.String() != "a:X\r\nb:Y Z\r\n" {
+		t.Errorf("Expected relaxed headers %q, got %q", "a:X\r\nb:Y Z\r\n", relaxed.String())
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		canon    string
+		expected string
+	}{
+		{name: "relaxed", body: body, canon: "relaxed", expected: " C\r\nD E\r\n"},
+		{name: "simple", body: body, canon: "simple", expected: " C \r\nD \t E\r\n"},
+		{name: "relaxed empty", body: "\r\n\r\n", canon: "relaxed", expected: ""},
+		{name: "simple empty", body: "", canon: "simple", expected: "\r\n"},
+		{name: "missing final line break", body: "Hi", canon: "simple", expected: "Hi\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(canonicalBody([]byte(tt.body), tt.canon)); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+// dkimKey returns a DKIM key with a PEM encoded private key
+func dkimKey(t *testing.T, domain, selector string, key crypto.Signer) config.DKIMKey {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+	return config.DKIMKey{Domain: domain, Selector: selector, PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))}
+}
+
+// verifyDKIM checks the DKIM-Signature of a signed message made by selector
+// and returns its tags
+func verifyDKIM(t *testing.T, signed []byte, selector string, public crypto.PublicKey) map[string]string {
+	t.Helper()
+
+	header, body, _ := strings.Cut(string(signed), "\r\n\r\n")
+	fields := headerFields([]byte(header + "\r\n"))
+	for _, field := range fields {
+		name, value, _ := strings.Cut(field, ":")
+		if name != "DKIM-Signature" {
+			continue
+		}
+		tags := map[string]string{}
+		for _, tag := range strings.Split(strings.Join(strings.Fields(value), ""), ";") {
+			k, v, _ := strings.Cut(tag, "=")
+			tags[k] = v
+		}
+		if tags["s"] != selector {
+			continue
+		}
+
+		headerCanon, bodyCanon, _ := strings.Cut(tags["c"], "/")
+		bodyHash := sha256.Sum256(canonicalBody([]byte(body), bodyCanon))
+		if tags["bh"] != base64.StdEncoding.EncodeToString(bodyHash[:]) {
+			t.Errorf("Expected the body hash to match, got %s", tags["bh"])
+		}
+
+		h := sha256.New()
+		for _, f := range signedFields(fields, strings.Split(tags["h"], ":")) {
+			h.Write([]byte(canonicalHeader(f, headerCanon)))
+		}
+		unsigned := field[:strings.LastIndex(field, "b=")+2]
+		h.Write([]byte(strings.TrimSuffix(canonicalHeader(unsigned+"\r\n", headerCanon), "\r\n")))
+		digest := h.Sum(nil)
+		b, _ := base64.StdEncoding.DecodeString(tags["b"])
+
+		switch public := public.(type) {
+		case *rsa.PublicKey:
+			if err := rsa.VerifyPKCS1v15(public, crypto.SHA256, digest, b); err != nil {
+				t.Errorf("Expected a valid RSA signature, got %v", err)
+			}
+		case ed25519.PublicKey:
+			if !ed25519.Verify(public, digest, b) {
+				t.Error("Expected a valid Ed25519 signature")
+			}
+		}
+		return tags
+	}
+	t.Fatalf("Expected a signature by selector %s, got:\n%s", selector, signed)
+	return nil
+}
+
+func TestSign(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	edPublic, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
+
+	relaxedSimple := dkimKey(t, "example.com", "rsa", rsaKey)
+	relaxedSimple.Canonicalization = "relaxed/simple"
+	simple := dkimKey(t, "example.com", "ed", edKey)
+	simple.Canonicalization = "simple/simple"
+	simple.Headers = []string{"from", "subject", "subject"}
+	keys := []config.DKIMKey{relaxedSimple, simple, dkimKey(t, "other.example", "other", edKey)}
+
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	req := Request{From: "Forms <forms@example.com>", To: "contact@example.com", Subject: "Hello  there", Body: "Line one\n\nLine  two\n"}
+	signed, err := Sign(Message(req, now), req.From, keys, now)
+	if err != nil {
+		t.Fatalf("Sign() returned error: %v", err)
+	}
+
+	if strings.Count(string(signed), "DKIM-Signature:") != 2 {
+		t.Fatalf("Expected a signature for each key of the domain, got:\n%s", signed)
+	}
+	if !strings.Contains(string(signed), "\r\n\r\nLine one\r\n\r\nLine  two\r\n") {
+		t.Errorf("Expected line endings normalized to CRLF, got %q", signed)
+	}
+	tags := verifyDKIM(t, signed, "rsa", &rsaKey.PublicKey)
+	if tags["a"] != "rsa-sha256" || tags["d"] != "example.com" || tags["t"] != "1792324800" || tags["h"] != strings.Join(DefaultDKIMHeaders, ":") {
+		t.Errorf("Unexpected RSA signature tags: %v", tags)
+	}
+	if tags = verifyDKIM(t, signed, "ed", edPublic); tags["a"] != "ed25519-sha256" || tags["c"] != "simple/simple" || tags["h"] != "from:subject:subject" {
+		t.Errorf("Unexpected Ed25519 signature tags: %v", tags)
+	}
+}
+
+func TestSign_Domains(t *testing.T) {
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	keys := []config.DKIMKey{
+		dkimKey(t, "example.com", "parent", edKey),
+		dkimKey(t, "Mail.Example.com", "child", edKey),
+	}
+
+	tests := []struct {
+		from     string
+		selector string
+	}{
+		{from: "forms@example.com", selector: "parent"},
+		{from: "forms@news.example.com", selector: "parent"},
+		{from: "Forms <forms@mail.example.COM>", selector: "child"},
+		{from: "forms@notexample.com"},
+		{from: "forms@example.org"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from, func(t *testing.T) {
+			message := Message(Request{From: tt.from, To: "contact@example.com"}, time.Now())
+			signed, err := Sign(message, tt.from, keys, time.Now())
+			if err != nil {
+				t.Fatalf("Sign() returned error: %v", err)
+			}
+			if tt.selector == "" {
+				if string(signed) != string(message) {
+					t.Errorf("Expected the message unchanged, got:\n%s", signed)
+				}
+				return
+			}
+			if !strings.Contains(string(signed), "s="+tt.selector+";") || strings.Count(string(signed), "DKIM-Signature:") != 1 {
+				t.Errorf("Expected one signature by %s, got:\n%s", tt.selector, signed)
+			}
+		})
+	}
+}
+
+func TestService_SendDKIM(t *testing.T) {
+	edPublic, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	writer := &MockWriteCloser{}
+	service := NewService(func(addr string) (SMTPClient, error) {
+		return &MockSMTPClient{DataFunc: func() (io.WriteCloser, error) { return writer, nil }}, nil
+	})
+
+	cfg := config.Config{SMTPHost: "mail-server", SMTPPort: "25", DefaultFrom: "noreply@example.com",
+		DKIM: config.DKIM{Keys: []config.DKIMKey{dkimKey(t, "example.com", "mail", edKey)}}}
+	if err := service.Send(Request{To: "contact@example.com", Subject: "Hello", Body: "Hi"}, cfg); err != nil {
+		t.Fatalf("Send() returned error: %v", err)
+	}
+	if !strings.HasPrefix(string(writer.Data), "DKIM-Signature: ") {
+		t.Fatalf("Expected a signed message, got:\n%s", writer.Data)
+	}
+	verifyDKIM(t, writer.Data, "mail", edPublic)
+
+	cfg.DKIM.Keys[0].PrivateKey = "not a key"
+	if err := service.Send(Request{To: "contact@example.com", Subject: "Hello", Body: "Hi"}, cfg); err == nil || !strings.Contains(err.Error(), "DKIM") {
+		t.Errorf("Expected a DKIM error, got %v", err)
+	}
+}
@@ -1,10 +1,18 @@
 package email
 
 import (
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"log"
+	"maps"
+	"mime"
+	"net/mail"
 	"net/smtp"
+	"slices"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/nahuelsantos/contact-api/internal/config"
 )
@@ -12,10 +20,14 @@ import (
 // Request represents an incoming request to send an email
 type Request struct {
 	From    string `json:"from"`
+	ReplyTo string `json:"reply_to,omitempty"`
 	To      string `json:"to"`
 	Subject string `json:"subject"`
 	Body    string `json:"body"`
 	HTML    bool   `json:"html"`
+
+	// Headers are extra message headers, written in name order
+	Headers map[string]string `json:"headers,omitempty"`
 }
 
 // Service defines the operations for sending emails
@@ -28,9 +40,13 @@ type SMTPDialer func(addr string) (SMTPClient, error)
 
 // defaultSMTPDialFn is the standard implementation of SMTPDialer
 func defaultSMTPDialFn(addr string) (SMTPClient, error) {
-	return smtp.Dial(addr)
+	return dialSMTP(addr)
 }
 
+// ErrSMTPUTF8 is returned when an address is not ASCII and the SMTP server
+// does not support the SMTPUTF8 extension
+var ErrSMTPUTF8 = errors.New("SMTP server does not support internationalized addresses")
+
 // DefaultSMTPDialer is the default dialer that can be replaced for testing
 var DefaultSMTPDialer SMTPDialer = defaultSMTPDialFn
 
@@ -53,49 +69,73 @@ func (s *ServiceImpl) Send(req Request, cfg config.Config) error {
 	if req.From == "" {
 		req.From = cfg.DefaultFrom
 	}
+	message, err := compose(req, cfg)
+	if err != nil {
+		log.Printf("Email signing error: %v", err)
+		return err
+	}
 
-	// Set headers
-	headers := make(map[string]string)
-	headers["From"] = req.From
-	headers["To"] = req.To
-	headers["Subject"] = req.Subject
-	headers["Date"] = time.Now().Format(time.RFC1123Z)
+	client, err := s.connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
 
-	var contentType string
-	if req.HTML {
-		contentType = "text/html; charset=UTF-8"
-	} else {
-		contentType = "text/plain; charset=UTF-8"
+	if err = transmit(client, req, message); err != nil {
+		return err
 	}
-	headers["Content-Type"] = contentType
 
-	// Compose the message
-	message := ""
-	for key, value := range headers {
-		message += fmt.Sprintf("%s: %s\r\n", key, value)
+	// Send the QUIT command and close the connection
+	err = client.Quit()
+	if err != nil {
+		log.Printf("SMTP quit error: %v", err)
+		return fmt.Errorf("SMTP quit error: %w", err)
 	}
-	message += "\r\n" + req.Body
 
-	// Connect to the SMTP server
+	log.Printf("Email sent successfully to %s", req.To)
+	return nil
+}
+
+// connect dials the SMTP server of cfg and logs in when credentials are
+// configured
+func (s *ServiceImpl) connect(cfg config.Config) (SMTPClient, error) {
 	addr := fmt.Sprintf("%s:%s", cfg.SMTPHost, cfg.SMTPPort)
 	log.Printf("Attempting to send email via SMTP server: %s", addr)
 
-	// Create client with TLS disabled
 	client, err := s.smtpDialer(addr)
 	if err != nil {
 		log.Printf("SMTP connection error: %v", err)
-		return fmt.Errorf("SMTP connection error: %w", err)
+		return nil, fmt.Errorf("SMTP connection error: %w", err)
+	}
+	setDeadline(client, time.Now().Add(smtpTimeout))
+
+	// Authenticate when credentials are configured
+	if err = s.authenticate(client, cfg); err != nil {
+		log.Printf("SMTP auth error: %v", err)
+		client.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// transmit sends one message over an open connection
+func transmit(client SMTPClient, req Request, message []byte) error {
+	// Addresses that are not ASCII need the SMTPUTF8 extension
+	if ok, _ := client.Extension("SMTPUTF8"); !ok && !isASCII(req.From+req.To) {
+		log.Printf("SMTP server does not support SMTPUTF8 for %s", req.To)
+		return ErrSMTPUTF8
 	}
-	defer client.Close()
 
 	// Set the sender and recipient
-	if err = client.Mail(req.From); err != nil {
+	if err := client.Mail(req.From); err != nil {
 		log.Printf("SMTP FROM error: %v", err)
 		return fmt.Errorf("SMTP FROM error: %w", err)
 	}
-	if err = client.Rcpt(req.To); err != nil {
-		log.Printf("SMTP RCPT error: %v", err)
-		return fmt.Errorf("SMTP RCPT error: %w", err)
+	for _, rcpt := range recipients(req.To) {
+		if err := client.Rcpt(rcpt); err != nil {
+			log.Printf("SMTP RCPT error: %v", err)
+			return fmt.Errorf("SMTP RCPT error: %w", err)
+		}
 	}
 
 	// Send the email body
@@ -104,7 +144,7 @@ func (s *ServiceImpl) Send(req Request, cfg config.Config) error {
 		log.Printf("SMTP DATA error: %v", err)
 		return fmt.Errorf("SMTP DATA error: %w", err)
 	}
-	_, err = w.Write([]byte(message))
+	_, err = w.Write(message)
 	if err != nil {
 		log.Printf("SMTP write error: %v", err)
 		return fmt.Errorf("SMTP write error: %w", err)
@@ -114,18 +154,165 @@ func (s *ServiceImpl) Send(req Request, cfg config.Config) error {
 		log.Printf("SMTP close error: %v", err)
 		return fmt.Errorf("SMTP close error: %w", err)
 	}
+	return nil
+}
 
-	// Send the QUIT command and close the connection
-	err = client.Quit()
+// compose builds the message of a request as sent over SMTP, DKIM-signed
+// when cfg has a key for its sender
+func compose(req Request, cfg config.Config) ([]byte, error) {
+	now := time.Now()
+	return Sign(Message(req, now), req.From, cfg.DKIM.Keys, now)
+}
+
+// Message composes the headers and body of the email for a request. Line
+// breaks in header values are replaced by spaces, so a value cannot add
+// headers of its own. Header values with non-ASCII text, such as localized
+// subjects or display names, are written as RFC 2047 encoded words.
+func Message(req Request, date time.Time) []byte {
+	contentType := "text/plain; charset=UTF-8"
+	if req.HTML {
+		contentType = "text/html; charset=UTF-8"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "From: %s\r\n", addressHeader(req.From))
+	if req.ReplyTo != "" {
+		fmt.Fprintf(&b, "Reply-To: %s\r\n", addressHeader(req.ReplyTo))
+	}
+	fmt.Fprintf(&b, "To: %s\r\n", addressHeader(req.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", textHeader(req.Subject))
+	for _, name := range slices.Sorted(maps.Keys(req.Headers)) {
+		fmt.Fprintf(&b, "%s: %s\r\n", name, textHeader(req.Headers[name]))
+	}
+	fmt.Fprintf(&b, "Content-Type: %s\r\n", contentType)
+	b.WriteString("\r\n")
+	b.WriteString(req.Body)
+	return []byte(b.String())
+}
+
+// headerLineBreaks replaces the line breaks of a header value
+var headerLineBreaks = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
+
+// textHeader encodes an unstructured header value holding non-ASCII text
+func textHeader(v string) string {
+	v = headerLineBreaks.Replace(v)
+	if isASCII(v) {
+		return v
+	}
+	return mime.QEncoding.Encode("utf-8", v)
+}
+
+// addressHeader encodes the non-ASCII display names of an address list.
+// Addresses themselves are kept, as internationalized addresses are sent
+// with SMTPUTF8 rather than encoded.
+func addressHeader(v string) string {
+	v = headerLineBreaks.Replace(v)
+	if isASCII(v) {
+		return v
+	}
+	list, err := mail.ParseAddressList(v)
 	if err != nil {
-		log.Printf("SMTP quit error: %v", err)
+		return v
+	}
+	addrs := make([]string, len(list))
+	for i, addr := range list {
+		if addr.Name == "" {
+			addrs[i] = addr.Address
+			continue
+		}
+		addrs[i] = addr.String()
+	}
+	return strings.Join(addrs, ", ")
+}
+
+// PriorityHeaders returns the headers mail clients use to flag a message as
+// high, normal or low priority, or nil for any other value
+func PriorityHeaders(priority string) map[string]string {
+	switch priority {
+	case "high":
+		return map[string]string{"X-Priority": "1 (Highest)", "Importance": "high"}
+	case "normal":
+		return map[string]string{"X-Priority": "3 (Normal)", "Importance": "normal"}
+	case "low":
+		return map[string]string{"X-Priority": "5 (Lowest)", "Importance": "low"}
+	default:
+		return nil
+	}
+}
+
+// authenticate upgrades the connection with STARTTLS when the server offers it
+// and logs in with the configured credentials. It does nothing without credentials.
+func (s *ServiceImpl) authenticate(client SMTPClient, cfg config.Config) error {
+	if cfg.SMTPUsername == "" {
+		return nil
+	}
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: cfg.SMTPHost, MinVersion: tls.VersionTLS12}); err != nil {
+			return fmt.Errorf("SMTP STARTTLS error: %w", err)
+		}
+	}
+
+	auth := smtp.PlainAuth("", cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPHost)
+	if err := client.Auth(auth); err != nil {
+		return fmt.Errorf("SMTP AUTH error: %w", err)
+	}
+	return nil
+}
+
+// isASCII reports whether a string holds only ASCII characters
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// recipients splits a comma-separated To header into individual addresses
+func recipients(to string) []string {
+	var rcpts []string
+	for _, addr := range strings.Split(to, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			rcpts = append(rcpts, addr)
+		}
+	}
+	return rcpts
+}
+
+// Ping verifies that the configured SMTP server is reachable by connecting
+// and issuing EHLO and NOOP without sending a message
+func (s *ServiceImpl) Ping(cfg config.Config) error {
+	addr := fmt.Sprintf("%s:%s", cfg.SMTPHost, cfg.SMTPPort)
+
+	client, err := s.smtpDialer(addr)
+	if err != nil {
+		return fmt.Errorf("SMTP connection error: %w", err)
+	}
+	defer client.Close()
+
+	if err = client.Hello("localhost"); err != nil {
+		return fmt.Errorf("SMTP EHLO error: %w", err)
+	}
+	if err = client.Noop(); err != nil {
+		return fmt.Errorf("SMTP NOOP error: %w", err)
+	}
+	if err = client.Quit(); err != nil {
 		return fmt.Errorf("SMTP quit error: %w", err)
 	}
 
-	log.Printf("Email sent successfully to %s", req.To)
 	return nil
 }
 
+// Ping is a package-level function that checks SMTP reachability using the
+// default email service
+func Ping(cfg config.Config) error {
+	service := NewService(DefaultSMTPDialer)
+	return service.Ping(cfg)
+}
+
 // Send is a package-level function that uses the default email service
 // This is for backward compatibility
 func Send(req Request, cfg config.Config) error {
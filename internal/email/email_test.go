@@ -1,9 +1,13 @@
 package email
 
 import (
+	"crypto/tls"
 	"errors"
 	"io"
+	"net/smtp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/nahuelsantos/contact-api/internal/config"
 )
@@ -272,3 +276,240 @@ func TestSend(t *testing.T) {
 		t.Error("DefaultSMTPDialer was not called")
 	}
 }
+
+func TestService_Ping(t *testing.T) {
+	cfg := config.Config{SMTPHost: "mail-server", SMTPPort: "25"}
+
+	tests := []struct {
+		name          string
+		mockDialer    func(addr string) (SMTPClient, error)
+		expectedError bool
+	}{
+		{
+			name: "Success",
+			mockDialer: func(addr string) (SMTPClient, error) {
+				return &MockSMTPClient{}, nil
+			},
+			expectedError: false,
+		},
+		{
+			name: "Connection Error",
+			mockDialer: func(addr string) (SMTPClient, error) {
+				return nil, errors.New("connection refused")
+			},
+			expectedError: true,
+		},
+		{
+			name: "EHLO Error",
+			mockDialer: func(addr string) (SMTPClient, error) {
+				return &MockSMTPClient{
+					HelloFunc: func(localName string) error { return errors.New("ehlo error") },
+				}, nil
+			},
+			expectedError: true,
+		},
+		{
+			name: "NOOP Error",
+			mockDialer: func(addr string) (SMTPClient, error) {
+				return &MockSMTPClient{
+					NoopFunc: func() error { return errors.New("noop error") },
+				}, nil
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := NewService(tc.mockDialer).Ping(cfg)
+			if tc.expectedError && err == nil {
+				t.Errorf("expected error but got nil")
+			}
+			if !tc.expectedError && err != nil {
+				t.Errorf("expected no error but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestService_SendMultipleRecipients(t *testing.T) {
+	var rcpts []string
+	service := NewService(func(addr string) (SMTPClient, error) {
+		return &MockSMTPClient{
+			RcptFunc: func(to string) error {
+				rcpts = append(rcpts, to)
+				return nil
+			},
+		}, nil
+	})
+
+	err := service.Send(Request{
+		From:    "sender@example.com",
+		To:      "sales@example.com, support@example.com",
+		Subject: "Test",
+		Body:    "Test",
+	}, config.Config{SMTPHost: "mail-server", SMTPPort: "25"})
+	if err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+
+	if len(rcpts) != 2 || rcpts[0] != "sales@example.com" || rcpts[1] != "support@example.com" {
+		t.Errorf("unexpected recipients: %v", rcpts)
+	}
+}
+
+func TestService_SendSMTPUTF8(t *testing.T) {
+	tests := []struct {
+		name     string
+		smtputf8 bool
+		wantErr  error
+	}{
+		{name: "supported", smtputf8: true},
+		{name: "unsupported", wantErr: ErrSMTPUTF8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewService(func(addr string) (SMTPClient, error) {
+				return &MockSMTPClient{
+					ExtensionFunc: func(ext string) (bool, string) {
+						return ext == "SMTPUTF8" && tt.smtputf8, ""
+					},
+				}, nil
+			})
+
+			err := service.Send(Request{
+				From:    "sender@example.com",
+				To:      "josé@example.com",
+				Subject: "Test",
+				Body:    "Test",
+			}, config.Config{SMTPHost: "mail-server", SMTPPort: "25"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestService_SendWithAuth(t *testing.T) {
+	tests := []struct {
+		name             string
+		username         string
+		startTLS         bool
+		authErr          error
+		expectedStartTLS bool
+		expectedAuth     bool
+		expectedError    bool
+	}{
+		{name: "No credentials", username: "", expectedAuth: false},
+		{name: "Credentials without STARTTLS", username: "user", expectedAuth: true},
+		{name: "Credentials with STARTTLS", username: "user", startTLS: true, expectedStartTLS: true, expectedAuth: true},
+		{name: "Auth Error", username: "user", authErr: errors.New("535 bad credentials"), expectedAuth: true, expectedError: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var startedTLS, authed bool
+			service := NewService(func(addr string) (SMTPClient, error) {
+				return &MockSMTPClient{
+					ExtensionFunc: func(ext string) (bool, string) { return ext == "STARTTLS" && tc.startTLS, "" },
+					StartTLSFunc: func(config *tls.Config) error {
+						startedTLS = true
+						return nil
+					},
+					AuthFunc: func(auth smtp.Auth) error {
+						authed = true
+						return tc.authErr
+					},
+				}, nil
+			})
+
+			err := service.Send(Request{To: "recipient@example.com", Subject: "Test", Body: "Test"}, config.Config{
+				SMTPHost:     "mail-server",
+				SMTPPort:     "587",
+				SMTPUsername: tc.username,
+				SMTPPassword: "secret",
+			})
+
+			if tc.expectedError != (err != nil) {
+				t.Errorf("expected error %v, got: %v", tc.expectedError, err)
+			}
+			if startedTLS != tc.expectedStartTLS {
+				t.Errorf("STARTTLS = %v, want %v", startedTLS, tc.expectedStartTLS)
+			}
+			if authed != tc.expectedAuth {
+				t.Errorf("AUTH = %v, want %v", authed, tc.expectedAuth)
+			}
+		})
+	}
+}
+
+func TestMessage(t *testing.T) {
+	date := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	got := string(Message(Request{
+		From:    "forms@example.com",
+		ReplyTo: "john@example.com",
+		To:      "sales@example.com",
+		Subject: "Hello",
+		Body:    "<p>Hi</p>",
+		HTML:    true,
+		Headers: PriorityHeaders("high"),
+	}, date))
+
+	want := "Date: Tue, 02 Jan 2024 15:04:05 +0000\r\n" +
+		"From: forms@example.com\r\n" +
+		"Reply-To: john@example.com\r\n" +
+		"To: sales@example.com\r\n" +
+		"Subject: Hello\r\n" +
+		"Importance: high\r\n" +
+		"X-Priority: 1 (Highest)\r\n" +
+		"Content-Type: text/html; charset=UTF-8\r\n" +
+		"\r\n" +
+		"<p>Hi</p>"
+	if got != want {
+		t.Errorf("Message() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestMessage_EncodedHeaders(t *testing.T) {
+	got := string(Message(Request{
+		From:    "Formulário <forms@example.com>",
+		ReplyTo: "José Núñez <jose@example.com>",
+		To:      "ventas@example.com, josé@example.com",
+		Subject: "Hemos recibido tu mensaje, José",
+		Headers: map[string]string{"X-Form": "Contacto ñ"},
+	}, time.Now()))
+
+	for _, want := range []string{
+		"From: =?utf-8?q?Formul=C3=A1rio?= <forms@example.com>\r\n",
+		"Reply-To: =?utf-8?q?Jos=C3=A9_N=C3=BA=C3=B1ez?= <jose@example.com>\r\n",
+		"To: ventas@example.com, josé@example.com\r\n",
+		"Subject: =?utf-8?q?Hemos_recibido_tu_mensaje,_Jos=C3=A9?=\r\n",
+		"X-Form: =?utf-8?q?Contacto_=C3=B1?=\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected header %q in\n%s", want, got)
+		}
+	}
+}
+
+func TestMessage_HeaderInjection(t *testing.T) {
+	got := string(Message(Request{
+		From:    "forms@example.com",
+		ReplyTo: "john@example.com\r\nBcc: victim@example.com",
+		To:      "sales@example.com\nBcc: victim@example.com",
+		Subject: "Hello\r\nBcc: victim@example.com",
+		Headers: map[string]string{"X-Form": "main\rBcc: victim@example.com"},
+		Body:    "Hi",
+	}, time.Now()))
+
+	headers, _, _ := strings.Cut(got, "\r\n\r\n")
+	for _, line := range strings.Split(headers, "\r\n") {
+		if strings.HasPrefix(line, "Bcc:") || strings.ContainsAny(line, "\r\n") {
+			t.Errorf("Expected no injected header, got %q", line)
+		}
+	}
+	if !strings.Contains(headers, "Subject: Hello Bcc: victim@example.com\r\n") {
+		t.Errorf("Expected the line break in the subject to become a space, got\n%s", headers)
+	}
+}
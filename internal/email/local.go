@@ -0,0 +1,191 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+)
+
+// DefaultSendmailPath is the sendmail binary used when a transport sets none
+const DefaultSendmailPath = "/usr/sbin/sendmail"
+
+// SendmailService delivers messages by piping them to a local sendmail
+// binary, with the recipients given as arguments rather than read from the
+// headers
+type SendmailService struct {
+	path string
+	now  func() time.Time
+}
+
+// NewSendmailService returns a service running the sendmail binary at path,
+// or at DefaultSendmailPath when it is empty
+func NewSendmailService(path string) *SendmailService {
+	if path == "" {
+		path = DefaultSendmailPath
+	}
+	return &SendmailService{path: path, now: time.Now}
+}
+
+// Send implements Service
+func (s *SendmailService) Send(req Request, cfg config.Config) error {
+	if req.From == "" {
+		req.From = cfg.DefaultFrom
+	}
+	return s.SendContext(context.Background(), req)
+}
+
+// SendContext runs sendmail -i with the message on its standard input. The
+// recipients follow --, so none of them can be taken for an option.
+func (s *SendmailService) SendContext(ctx context.Context, req Request) error {
+	args := []string{"-i"}
+	if _, sender := splitAddress(req.From); sender != "" {
+		args = append(args, "-f", sender)
+	}
+	args = append(args, "--")
+	for _, rcpt := range recipients(req.To) {
+		_, addr := splitAddress(rcpt)
+		args = append(args, addr)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, s.path, args...)
+	cmd.Stdin = bytes.NewReader(unixLines(Message(req, s.now())))
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, truncate(msg, 200))
+		}
+		log.Printf("sendmail error: %v", err)
+		return fmt.Errorf("sendmail error: %w", err)
+	}
+
+	log.Printf("Email sent successfully to %s through sendmail", req.To)
+	return nil
+}
+
+// FileService writes each message to a .eml file in a directory, byte for
+// byte as it would be sent over SMTP without a DKIM signature
+type FileService struct {
+	dir string
+	now func() time.Time
+}
+
+// NewFileService returns a service writing messages to dir
+func NewFileService(dir string) *FileService {
+	return &FileService{dir: dir, now: time.Now}
+}
+
+// Send implements Service
+func (s *FileService) Send(req Request, cfg config.Config) error {
+	if req.From == "" {
+		req.From = cfg.DefaultFrom
+	}
+	return s.SendContext(context.Background(), req)
+}
+
+// SendContext writes a message to a new file named after the time it was
+// written. Files appear complete, never partially written.
+func (s *FileService) SendContext(_ context.Context, req Request) error {
+	now := s.now()
+	name := now.UTC().Format("20060102-150405.000000000") + "-" + randomHex(4) + ".eml"
+	if err := writeFile(s.dir, filepath.Join(s.dir, name), Message(req, now)); err != nil {
+		log.Printf("eml file error: %v", err)
+		return fmt.Errorf("eml file error: %w", err)
+	}
+
+	log.Printf("Email for %s written to %s", req.To, name)
+	return nil
+}
+
+// MaildirService delivers messages to the new directory of a Maildir, where
+// mail clients and IMAP servers pick them up
+type MaildirService struct {
+	dir string
+	now func() time.Time
+}
+
+// maildirSeq makes Maildir file names unique within the process
+var maildirSeq atomic.Uint64
+
+// NewMaildirService returns a service delivering to the Maildir at dir,
+// created on first delivery
+func NewMaildirService(dir string) *MaildirService {
+	return &MaildirService{dir: dir, now: time.Now}
+}
+
+// Send implements Service
+func (s *MaildirService) Send(req Request, cfg config.Config) error {
+	if req.From == "" {
+		req.From = cfg.DefaultFrom
+	}
+	return s.SendContext(context.Background(), req)
+}
+
+// SendContext writes a message to tmp and moves it to new, as the Maildir
+// format requires
+func (s *MaildirService) SendContext(_ context.Context, req Request) error {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(s.dir, sub), 0o700); err != nil {
+			return fmt.Errorf("maildir error: %w", err)
+		}
+	}
+
+	now := s.now()
+	host, _ := os.Hostname()
+	host = strings.NewReplacer("/", `\057`, ":", `\072`).Replace(host)
+	name := fmt.Sprintf("%d.M%dP%dQ%d.%s", now.Unix(), now.Nanosecond()/1000, os.Getpid(), maildirSeq.Add(1), host)
+
+	if err := writeFile(filepath.Join(s.dir, "tmp"), filepath.Join(s.dir, "new", name), unixLines(Message(req, now))); err != nil {
+		log.Printf("maildir error: %v", err)
+		return fmt.Errorf("maildir error: %w", err)
+	}
+
+	log.Printf("Email for %s delivered to maildir %s", req.To, s.dir)
+	return nil
+}
+
+// writeFile writes data to a temporary file in tmpDir and renames it to
+// target, so readers never see a partial file
+func writeFile(tmpDir, target string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(tmpDir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), target)
+}
+
+// unixLines converts CRLF line endings to LF, the convention of local mail
+// tools and Maildir
+func unixLines(message []byte) []byte {
+	return bytes.ReplaceAll(message, []byte("\r\n"), []byte("\n"))
+}
+
+// randomHex returns n random bytes as hex
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,120 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+var localRequest = Request{
+	From:    "Contact Form <forms@example.com>",
+	ReplyTo: "john@example.com",
+	To:      "sales@example.com",
+	Subject: "Hello",
+	Body:    "Name: John Doe\r\nMessage: Hi there",
+	Headers: PriorityHeaders("normal"),
+}
+
+func fixedTime() time.Time {
+	return time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+}
+
+func TestFileService(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	service := NewFileService(dir)
+	service.now = fixedTime
+
+	for i := 0; i < 2; i++ {
+		if err := service.SendContext(context.Background(), localRequest); err != nil {
+			t.Fatalf("SendContext() returned error: %v", err)
+		}
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil || len(files) != 2 {
+		t.Fatalf("Expected 2 files, got %v (%v)", files, err)
+	}
+	golden, err := os.ReadFile(filepath.Join("testdata", "message.eml"))
+	if err != nil {
+		t.Fatalf("Failed to read golden file: %v", err)
+	}
+	for _, file := range files {
+		if !strings.HasPrefix(filepath.Base(file), "20240102-150405.000000000-") || filepath.Ext(file) != ".eml" {
+			t.Errorf("Unexpected file name %s", filepath.Base(file))
+		}
+		got, _ := os.ReadFile(file)
+		if !bytes.Equal(got, golden) {
+			t.Errorf("%s =\n%q\nwant\n%q", filepath.Base(file), got, golden)
+		}
+	}
+}
+
+func TestMaildirService(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "Maildir")
+	service := NewMaildirService(dir)
+	service.now = fixedTime
+
+	if err := service.SendContext(context.Background(), localRequest); err != nil {
+		t.Fatalf("SendContext() returned error: %v", err)
+	}
+
+	for _, sub := range []string{"tmp", "cur"} {
+		if entries, err := os.ReadDir(filepath.Join(dir, sub)); err != nil || len(entries) != 0 {
+			t.Errorf("Expected an empty %s directory, got %v (%v)", sub, entries, err)
+		}
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, "new"))
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Expected 1 new message, got %v (%v)", entries, err)
+	}
+	if name := entries[0].Name(); !strings.HasPrefix(name, "1704207845.M0P") || strings.Contains(name, "/") {
+		t.Errorf("Unexpected Maildir file name %s", name)
+	}
+
+	got, _ := os.ReadFile(filepath.Join(dir, "new", entries[0].Name()))
+	if bytes.Contains(got, []byte("\r")) || !bytes.HasPrefix(got, []byte("Date: Tue, 02 Jan 2024 15:04:05 +0000\nFrom: ")) {
+		t.Errorf("Expected the message with LF line endings, got %q", got)
+	}
+}
+
+func TestSendmailService(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a shell")
+	}
+
+	dir := t.TempDir()
+	script := filepath.Join(dir, "sendmail")
+	fake := "#!/bin/sh\n" +
+		"echo \"$@\" > " + filepath.Join(dir, "args") + "\n" +
+		"cat > " + filepath.Join(dir, "stdin") + "\n" +
+		"[ \"$FAIL\" = \"\" ] || { echo 'recipient rejected' >&2; exit 75; }\n"
+	if err := os.WriteFile(script, []byte(fake), 0o755); err != nil {
+		t.Fatalf("Failed to write fake sendmail: %v", err)
+	}
+
+	service := NewSendmailService(script)
+	service.now = fixedTime
+	if err := service.SendContext(context.Background(), localRequest); err != nil {
+		t.Fatalf("SendContext() returned error: %v", err)
+	}
+
+	args, _ := os.ReadFile(filepath.Join(dir, "args"))
+	if got := strings.TrimSpace(string(args)); got != "-i -f forms@example.com -- sales@example.com" {
+		t.Errorf("Unexpected sendmail arguments %q", got)
+	}
+	stdin, _ := os.ReadFile(filepath.Join(dir, "stdin"))
+	if bytes.Contains(stdin, []byte("\r")) || !bytes.Contains(stdin, []byte("\nTo: sales@example.com\n")) {
+		t.Errorf("Unexpected sendmail input %q", stdin)
+	}
+
+	t.Setenv("FAIL", "1")
+	err := service.SendContext(context.Background(), localRequest)
+	if err == nil || !strings.Contains(err.Error(), "recipient rejected") {
+		t.Errorf("Expected the sendmail error output, got %v", err)
+	}
+}
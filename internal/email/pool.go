@@ -0,0 +1,294 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/textproto"
+	"sync"
+	"time"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/observability"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Defaults for pools the configuration leaves unset
+const (
+	DefaultPoolMaxMessages = 100
+	DefaultPoolIdleTimeout = 30 * time.Second
+)
+
+// ErrPoolClosed is returned when sending through a closed pool
+var ErrPoolClosed = errors.New("SMTP pool is closed")
+
+// Pool keeps up to a fixed number of authenticated connections to one SMTP
+// server and reuses them across messages. Connections are reset with RSET
+// after each message and checked with NOOP before they are reused; sends
+// wait for a free connection when all of them are busy.
+type Pool struct {
+	name  string
+	cfg   config.Config
+	opts  config.SMTPPool
+	slots chan struct{}
+	stop  chan struct{}
+	now   func() time.Time
+
+	mu     sync.Mutex
+	idle   []*pooledConn
+	busy   int
+	closed bool
+}
+
+// pooledConn is an open connection with its usage
+type pooledConn struct {
+	client   SMTPClient
+	messages int
+	lastUsed time.Time
+}
+
+// PoolStats counts the open connections of a pool
+type PoolStats struct {
+	Idle int
+	Busy int
+}
+
+// NewPool returns a pool of connections to the SMTP server of cfg, named
+// after its transport in metrics. Idle connections are closed in the
+// background until the pool is closed.
+func NewPool(name string, cfg config.Config, opts config.SMTPPool) *Pool {
+	if opts.Size < 1 {
+		opts.Size = 1
+	}
+	if opts.MaxMessages == 0 {
+		opts.MaxMessages = DefaultPoolMaxMessages
+	}
+	if opts.IdleTimeout == 0 {
+		opts.IdleTimeout = config.Duration(DefaultPoolIdleTimeout)
+	}
+
+	p := &Pool{
+		name:  name,
+		cfg:   cfg,
+		opts:  opts,
+		slots: make(chan struct{}, opts.Size),
+		stop:  make(chan struct{}),
+		now:   time.Now,
+	}
+	go p.reap(time.Duration(opts.IdleTimeout))
+	return p
+}
+
+// Send delivers a message over a pooled connection, waiting for one to be
+// free until ctx is done. The connection is given until the deadline of ctx,
+// or DefaultSMTPTimeout when that is sooner, to check and send the message.
+func (p *Pool) Send(ctx context.Context, req Request) error {
+	if req.From == "" {
+		req.From = p.cfg.DefaultFrom
+	}
+	message, err := compose(req, p.cfg)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-p.slots }()
+	observability.SMTPPoolWait.WithLabelValues(p.name).Observe(time.Since(start).Seconds())
+
+	deadline := time.Now().Add(smtpTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn, err := p.get(deadline)
+	if err != nil {
+		return err
+	}
+	setDeadline(conn.client, deadline)
+	err = transmit(conn.client, req, message)
+	p.put(conn, err)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Email sent successfully to %s", req.To)
+	return nil
+}
+
+// Stats counts the idle and busy connections of the pool
+func (p *Pool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return PoolStats{Idle: len(p.idle), Busy: p.busy}
+}
+
+// Close quits the idle connections and stops the pool. Busy connections are
+// closed once their message is sent.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.stop)
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+
+	for _, conn := range idle {
+		p.gauge("idle").Dec()
+		p.close(conn, "shutdown", true)
+	}
+}
+
+// get takes the most recently used healthy idle connection, or dials a new
+// one when none is left. Reused connections are checked before deadline.
+func (p *Pool) get(deadline time.Time) (*pooledConn, error) {
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			return nil, ErrPoolClosed
+		}
+		p.busy++
+		p.gauge("busy").Inc()
+		n := len(p.idle)
+		if n == 0 {
+			p.mu.Unlock()
+			break
+		}
+		conn := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.gauge("idle").Dec()
+		p.mu.Unlock()
+
+		if p.now().Sub(conn.lastUsed) >= time.Duration(p.opts.IdleTimeout) {
+			p.discard(conn, "idle", true)
+			continue
+		}
+		setDeadline(conn.client, deadline)
+		if err := conn.client.Noop(); err != nil {
+			p.discard(conn, "unhealthy", false)
+			continue
+		}
+		observability.SMTPPoolCheckouts.WithLabelValues(p.name, "reused").Inc()
+		return conn, nil
+	}
+
+	client, err := NewService(DefaultSMTPDialer).connect(p.cfg)
+	if err != nil {
+		p.release()
+		observability.SMTPPoolCheckouts.WithLabelValues(p.name, "failed").Inc()
+		return nil, err
+	}
+	observability.SMTPPoolCheckouts.WithLabelValues(p.name, "dialed").Inc()
+	return &pooledConn{client: client}, nil
+}
+
+// put returns a connection after a message. Connections that failed outside
+// of an SMTP reply, or that reached the message limit, are closed instead.
+func (p *Pool) put(conn *pooledConn, sendErr error) {
+	if sendErr != nil {
+		// A rejected command leaves the session usable, a broken
+		// connection does not
+		var reply *textproto.Error
+		if !errors.As(sendErr, &reply) && !errors.Is(sendErr, ErrSMTPUTF8) {
+			p.discard(conn, "error", false)
+			return
+		}
+	} else {
+		conn.messages++
+		if conn.messages >= p.opts.MaxMessages {
+			p.discard(conn, "max_messages", true)
+			return
+		}
+	}
+
+	if err := conn.client.Reset(); err != nil {
+		p.discard(conn, "error", false)
+		return
+	}
+	conn.lastUsed = p.now()
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		p.discard(conn, "shutdown", true)
+		return
+	}
+	p.busy--
+	p.gauge("busy").Dec()
+	p.idle = append(p.idle, conn)
+	p.gauge("idle").Inc()
+	p.mu.Unlock()
+}
+
+// discard closes a busy connection
+func (p *Pool) discard(conn *pooledConn, reason string, quit bool) {
+	p.release()
+	p.close(conn, reason, quit)
+}
+
+// release frees the place of a busy connection
+func (p *Pool) release() {
+	p.mu.Lock()
+	p.busy--
+	p.gauge("busy").Dec()
+	p.mu.Unlock()
+}
+
+// close ends a connection, politely with QUIT when the session is in a
+// known state
+func (p *Pool) close(conn *pooledConn, reason string, quit bool) {
+	observability.SMTPPoolClosed.WithLabelValues(p.name, reason).Inc()
+	setDeadline(conn.client, time.Now().Add(smtpTimeout))
+	if quit && conn.client.Quit() == nil {
+		return
+	}
+	conn.client.Close()
+}
+
+// reap closes idle connections every interval until the pool is closed
+func (p *Pool) reap(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.prune()
+		}
+	}
+}
+
+// prune closes the connections idle for longer than the idle timeout. Idle
+// connections are kept in the order they were returned, oldest first.
+func (p *Pool) prune() {
+	cutoff := p.now().Add(-time.Duration(p.opts.IdleTimeout))
+
+	p.mu.Lock()
+	n := 0
+	for n < len(p.idle) && !p.idle[n].lastUsed.After(cutoff) {
+		n++
+	}
+	stale := make([]*pooledConn, n)
+	copy(stale, p.idle[:n])
+	p.idle = append(p.idle[:0], p.idle[n:]...)
+	p.gauge("idle").Sub(float64(n))
+	p.mu.Unlock()
+
+	for _, conn := range stale {
+		p.close(conn, "idle", true)
+	}
+}
+
+// gauge returns the connection gauge of the pool for a state
+func (p *Pool) gauge(state string) prometheus.Gauge {
+	return observability.SMTPPoolConnections.WithLabelValues(p.name, state)
+}
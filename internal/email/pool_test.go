@@ -0,0 +1,282 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/textproto"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+)
+
+// countingServer dials mock clients and counts the commands they receive
+type countingServer struct {
+	dials, noops, resets, quits, closes atomic.Int32
+
+	noopErr error
+	rcptErr error
+	dataErr error
+	// data is called while a message is written, to hold connections busy
+	data func()
+}
+
+func (s *countingServer) dial(addr string) (SMTPClient, error) {
+	s.dials.Add(1)
+	return &MockSMTPClient{
+		NoopFunc:  func() error { s.noops.Add(1); return s.noopErr },
+		RcptFunc:  func(to string) error { return s.rcptErr },
+		ResetFunc: func() error { s.resets.Add(1); return nil },
+		QuitFunc:  func() error { s.quits.Add(1); return nil },
+		CloseFunc: func() error { s.closes.Add(1); return nil },
+		DataFunc: func() (io.WriteCloser, error) {
+			if s.data != nil {
+				s.data()
+			}
+			if s.dataErr != nil {
+				return nil, s.dataErr
+			}
+			return &MockWriteCloser{}, nil
+		},
+	}, nil
+}
+
+func setupPool(t *testing.T, server *countingServer, opts config.SMTPPool) *Pool {
+	t.Helper()
+
+	originalDialer := DefaultSMTPDialer
+	DefaultSMTPDialer = server.dial
+	t.Cleanup(func() { DefaultSMTPDialer = originalDialer })
+
+	pool := NewPool("test", config.Config{SMTPHost: "mail-server", SMTPPort: "25", DefaultFrom: "noreply@example.com"}, opts)
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+var poolRequest = Request{To: "recipient@example.com", Subject: "Test Email", Body: "Hello"}
+
+func TestPool_Reuse(t *testing.T) {
+	server := &countingServer{}
+	pool := setupPool(t, server, config.SMTPPool{Size: 2, MaxMessages: 3})
+
+	for i := 0; i < 4; i++ {
+		if err := pool.Send(context.Background(), poolRequest); err != nil {
+			t.Fatalf("Send() returned error: %v", err)
+		}
+	}
+
+	// The connection is reused twice, then quits after its third message
+	if server.dials.Load() != 2 {
+		t.Errorf("Expected 2 dials, got %d", server.dials.Load())
+	}
+	if server.noops.Load() != 2 {
+		t.Errorf("Expected a NOOP before each reuse, got %d", server.noops.Load())
+	}
+	if server.resets.Load() != 3 {
+		t.Errorf("Expected a RSET after each kept message, got %d", server.resets.Load())
+	}
+	if server.quits.Load() != 1 {
+		t.Errorf("Expected the full connection to quit, got %d", server.quits.Load())
+	}
+	if stats := pool.Stats(); stats.Idle != 1 || stats.Busy != 0 {
+		t.Errorf("Expected 1 idle connection, got %+v", stats)
+	}
+}
+
+func TestPool_Health(t *testing.T) {
+	tests := []struct {
+		name          string
+		server        *countingServer
+		expectedError bool
+		expectedDials int32
+	}{
+		{name: "healthy", server: &countingServer{}, expectedDials: 1},
+		{name: "failed noop", server: &countingServer{noopErr: errors.New("connection reset")}, expectedDials: 2},
+		{name: "rejected recipient keeps the connection", server: &countingServer{rcptErr: &textproto.Error{Code: 550, Msg: "no such user"}}, expectedError: true, expectedDials: 1},
+		{name: "broken connection is dropped", server: &countingServer{dataErr: errors.New("broken pipe")}, expectedError: true, expectedDials: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := setupPool(t, tt.server, config.SMTPPool{Size: 1})
+
+			for i := 0; i < 2; i++ {
+				if err := pool.Send(context.Background(), poolRequest); (err != nil) != tt.expectedError {
+					t.Fatalf("Send() error = %v, expectedError %v", err, tt.expectedError)
+				}
+			}
+			if tt.server.dials.Load() != tt.expectedDials {
+				t.Errorf("Expected %d dials, got %d", tt.expectedDials, tt.server.dials.Load())
+			}
+		})
+	}
+}
+
+func TestPool_IdleTimeout(t *testing.T) {
+	server := &countingServer{}
+	pool := setupPool(t, server, config.SMTPPool{Size: 2, IdleTimeout: config.Duration(time.Hour)})
+
+	now := time.Now()
+	pool.mu.Lock()
+	pool.now = func() time.Time { return now }
+	pool.mu.Unlock()
+
+	if err := pool.Send(context.Background(), poolRequest); err != nil {
+		t.Fatalf("Send() returned error: %v", err)
+	}
+
+	// A connection idle for too long is replaced instead of reused
+	now = now.Add(time.Hour)
+	if err := pool.Send(context.Background(), poolRequest); err != nil {
+		t.Fatalf("Send() returned error: %v", err)
+	}
+	if server.dials.Load() != 2 || server.quits.Load() != 1 {
+		t.Errorf("Expected the idle connection to be replaced, got %d dials and %d quits", server.dials.Load(), server.quits.Load())
+	}
+
+	// Pruning closes idle connections without waiting for a message
+	now = now.Add(time.Hour)
+	pool.prune()
+	if stats := pool.Stats(); stats.Idle != 0 {
+		t.Errorf("Expected no idle connection after pruning, got %+v", stats)
+	}
+	if server.quits.Load() != 2 {
+		t.Errorf("Expected the pruned connection to quit, got %d quits", server.quits.Load())
+	}
+}
+
+func TestPool_Bounded(t *testing.T) {
+	release := make(chan struct{})
+	var inData atomic.Int32
+	server := &countingServer{data: func() {
+		inData.Add(1)
+		<-release
+	}}
+	pool := setupPool(t, server, config.SMTPPool{Size: 2})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := pool.Send(context.Background(), poolRequest); err != nil {
+				t.Errorf("Send() returned error: %v", err)
+			}
+		}()
+	}
+	for inData.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// A third send waits for a free connection
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := pool.Send(ctx, poolRequest); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the send to wait for a connection, got %v", err)
+	}
+	if stats := pool.Stats(); stats.Busy != 2 {
+		t.Errorf("Expected 2 busy connections, got %+v", stats)
+	}
+
+	close(release)
+	wg.Wait()
+	if err := pool.Send(context.Background(), poolRequest); err != nil {
+		t.Fatalf("Send() returned error: %v", err)
+	}
+	if server.dials.Load() != 2 {
+		t.Errorf("Expected 2 dials, got %d", server.dials.Load())
+	}
+}
+
+func TestPool_Close(t *testing.T) {
+	server := &countingServer{}
+	pool := setupPool(t, server, config.SMTPPool{Size: 1})
+
+	if err := pool.Send(context.Background(), poolRequest); err != nil {
+		t.Fatalf("Send() returned error: %v", err)
+	}
+	pool.Close()
+
+	if server.quits.Load() != 1 {
+		t.Errorf("Expected the idle connection to quit, got %d", server.quits.Load())
+	}
+	if err := pool.Send(context.Background(), poolRequest); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Expected ErrPoolClosed, got %v", err)
+	}
+}
+
+// silentServer accepts connections, optionally greets them, and then never
+// answers
+func silentServer(t *testing.T, greeting string) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	var mu sync.Mutex
+	var conns []net.Conn
+	t.Cleanup(func() {
+		ln.Close()
+		mu.Lock()
+		defer mu.Unlock()
+		for _, conn := range conns {
+			conn.Close()
+		}
+	})
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			conns = append(conns, conn)
+			mu.Unlock()
+			_, _ = io.WriteString(conn, greeting)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestPool_Unresponsive(t *testing.T) {
+	originalTimeout := smtpTimeout
+	smtpTimeout = 200 * time.Millisecond
+	defer func() { smtpTimeout = originalTimeout }()
+
+	tests := []struct {
+		name     string
+		greeting string
+	}{
+		{"No greeting", ""},
+		{"No reply to EHLO", "220 mail.example.com ESMTP\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, port, _ := net.SplitHostPort(silentServer(t, tt.greeting))
+			pool := NewPool("test", config.Config{SMTPHost: host, SMTPPort: port, DefaultFrom: "noreply@example.com"}, config.SMTPPool{Size: 1})
+			defer pool.Close()
+
+			done := make(chan error, 1)
+			go func() { done <- pool.Send(context.Background(), poolRequest) }()
+
+			select {
+			case err := <-done:
+				var netErr net.Error
+				if !errors.As(err, &netErr) || !netErr.Timeout() {
+					t.Errorf("Expected a timeout, got %v", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Expected Send to give up on a server that never responds")
+			}
+			if stats := pool.Stats(); stats.Busy != 0 || stats.Idle != 0 {
+				t.Errorf("Expected the connection to be dropped, got %+v", stats)
+			}
+		})
+	}
+}
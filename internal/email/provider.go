@@ -0,0 +1,425 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"net/http"
+	"net/mail"
+	"net/textproto"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+)
+
+// DefaultProviderTimeout bounds requests to email provider APIs
+const DefaultProviderTimeout = 10 * time.Second
+
+// ProviderError is a failure reported by the API of an email provider.
+// Validation errors of the message are permanent. Other failures, including
+// rejected credentials or a misconfigured account, are worth retrying or
+// trying through another transport.
+type ProviderError struct {
+	Provider   string
+	StatusCode int
+	Message    string
+	Retryable  bool
+}
+
+// Error describes the failure
+func (e *ProviderError) Error() string {
+	kind := "permanent"
+	if e.Retryable {
+		kind = "retryable"
+	}
+	return fmt.Sprintf("%s API error (status %d, %s): %s", e.Provider, e.StatusCode, kind, e.Message)
+}
+
+// Permanent reports whether a delivery failure would fail again when
+// retried: a provider or SMTP server rejected the message itself, such as an
+// invalid recipient, rather than being unreachable, overloaded or refusing
+// the credentials of the transport. Joined errors are permanent when all of
+// them are.
+func Permanent(err error) bool {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs := joined.Unwrap()
+		for _, err := range errs {
+			if !Permanent(err) {
+				return false
+			}
+		}
+		return len(errs) > 0
+	}
+
+	var provider *ProviderError
+	if errors.As(err, &provider) {
+		return !provider.Retryable
+	}
+	var reply *textproto.Error
+	if errors.As(err, &reply) {
+		return mailboxRejected(reply.Code)
+	}
+	return errors.Is(err, ErrSMTPUTF8)
+}
+
+// messageRejected reports whether a provider API status rejects the message
+// as invalid, as opposed to the request, the credentials or the account
+func messageRejected(status int) bool {
+	return status == http.StatusBadRequest || status == http.StatusUnprocessableEntity
+}
+
+// mailboxRejected reports whether an SMTP reply refuses a mailbox or the
+// message for it. Other permanent replies, such as 530 and 535 for missing
+// or refused authentication, concern the transport.
+func mailboxRejected(code int) bool {
+	switch code {
+	case 550, 551, 552, 553:
+		return true
+	}
+	return false
+}
+
+// Provider sends email through the HTTP API of a transactional email
+// provider, for environments where SMTP ports are blocked
+type Provider struct {
+	def    config.Transport
+	api    providerAPI
+	client *http.Client
+	now    func() time.Time
+}
+
+// providerAPI describes how to call the API of a provider
+type providerAPI struct {
+	baseURL string
+	request func(ctx context.Context, p *Provider, req Request) (*http.Request, error)
+}
+
+// providerAPIs maps transport types to the APIs they call
+var providerAPIs = map[string]providerAPI{
+	config.TransportSendGrid: {baseURL: "https://api.sendgrid.com", request: sendgridRequest},
+	config.TransportMailgun:  {baseURL: "https://api.mailgun.net", request: mailgunRequest},
+	config.TransportPostmark: {baseURL: "https://api.postmarkapp.com", request: postmarkRequest},
+	config.TransportSES:      {request: sesRequest},
+	config.TransportResend:   {baseURL: "https://api.resend.com", request: resendRequest},
+}
+
+// NewProvider returns the provider of a transport definition. Requests are
+// sent with client, or with a client timing out after DefaultProviderTimeout
+// when it is nil.
+func NewProvider(def config.Transport, client *http.Client) *Provider {
+	if client == nil {
+		client = &http.Client{Timeout: DefaultProviderTimeout}
+	}
+	return &Provider{def: def, api: providerAPIs[def.Type], client: client, now: time.Now}
+}
+
+// Send implements Service. The provider's own settings are used instead of
+// the SMTP settings of cfg.
+func (p *Provider) Send(req Request, cfg config.Config) error {
+	if req.From == "" {
+		req.From = cfg.DefaultFrom
+	}
+	return p.SendContext(context.Background(), req)
+}
+
+// SendContext delivers a message through the provider's API
+func (p *Provider) SendContext(ctx context.Context, req Request) error {
+	if p.api.request == nil {
+		return fmt.Errorf("unknown email provider %q", p.def.Type)
+	}
+
+	httpReq, err := p.api.request(ctx, p, req)
+	if err != nil {
+		return fmt.Errorf("%s request error: %w", p.def.Type, err)
+	}
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		log.Printf("%s connection error: %v", p.def.Type, err)
+		return fmt.Errorf("%s connection error: %w", p.def.Type, err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		err := &ProviderError{
+			Provider:   p.def.Type,
+			StatusCode: resp.StatusCode,
+			Message:    providerMessage(body, resp.Status),
+			Retryable:  !messageRejected(resp.StatusCode),
+		}
+		log.Printf("%v", err)
+		return err
+	}
+
+	log.Printf("Email sent successfully to %s through %s", req.To, p.def.Type)
+	return nil
+}
+
+// baseURL returns the API base URL without a trailing slash
+func (p *Provider) baseURL() string {
+	base := p.def.URL
+	if base == "" {
+		base = p.api.baseURL
+	}
+	return strings.TrimSuffix(base, "/")
+}
+
+// providerMessage extracts the error message of a provider response, which
+// providers put in message, Message or errors[].message
+func providerMessage(body []byte, status string) string {
+	var parsed struct {
+		Message string `json:"message"`
+		Errors  []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if json.Unmarshal(body, &parsed) == nil {
+		if parsed.Message != "" {
+			return parsed.Message
+		}
+		if len(parsed.Errors) > 0 && parsed.Errors[0].Message != "" {
+			return parsed.Errors[0].Message
+		}
+	}
+	if text := strings.TrimSpace(string(body)); text != "" {
+		return truncate(text, 200)
+	}
+	return status
+}
+
+// truncate returns at most n bytes of s without splitting a character
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// splitAddress returns the display name and email of an address, or the
+// address itself when it does not parse
+func splitAddress(s string) (name, email string) {
+	if addr, err := mail.ParseAddress(s); err == nil {
+		return addr.Name, addr.Address
+	}
+	return "", s
+}
+
+// sortedHeaders returns the names of the extra headers of a request in order
+func sortedHeaders(req Request) []string {
+	return slices.Sorted(maps.Keys(req.Headers))
+}
+
+// jsonRequest builds a POST request with a JSON body
+func jsonRequest(ctx context.Context, target string, payload any) (*http.Request, []byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("User-Agent", "contact-api")
+	return httpReq, body, nil
+}
+
+// sendgridRequest builds a SendGrid v3 mail send request
+func sendgridRequest(ctx context.Context, p *Provider, req Request) (*http.Request, error) {
+	type address struct {
+		Email string `json:"email"`
+		Name  string `json:"name,omitempty"`
+	}
+	type personalization struct {
+		To []address `json:"to"`
+	}
+	type content struct {
+		Type  string `json:"type"`
+		Value string `json:"value"`
+	}
+
+	var to []address
+	for _, rcpt := range recipients(req.To) {
+		name, email := splitAddress(rcpt)
+		to = append(to, address{Email: email, Name: name})
+	}
+	fromName, fromEmail := splitAddress(req.From)
+	payload := struct {
+		Personalizations []personalization `json:"personalizations"`
+		From             address           `json:"from"`
+		ReplyTo          *address          `json:"reply_to,omitempty"`
+		Subject          string            `json:"subject"`
+		Content          []content         `json:"content"`
+		Headers          map[string]string `json:"headers,omitempty"`
+	}{
+		Personalizations: []personalization{{To: to}},
+		From:             address{Email: fromEmail, Name: fromName},
+		Subject:          req.Subject,
+		Content:          []content{{Type: "text/plain", Value: req.Body}},
+		Headers:          req.Headers,
+	}
+	if req.HTML {
+		payload.Content[0].Type = "text/html"
+	}
+	if req.ReplyTo != "" {
+		name, email := splitAddress(req.ReplyTo)
+		payload.ReplyTo = &address{Email: email, Name: name}
+	}
+
+	httpReq, _, err := jsonRequest(ctx, p.baseURL()+"/v3/mail/send", payload)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+p.def.APIKey)
+	return httpReq, nil
+}
+
+// mailgunRequest builds a Mailgun messages request for the transport's domain
+func mailgunRequest(ctx context.Context, p *Provider, req Request) (*http.Request, error) {
+	form := url.Values{}
+	form.Set("from", req.From)
+	for _, rcpt := range recipients(req.To) {
+		form.Add("to", rcpt)
+	}
+	form.Set("subject", req.Subject)
+	if req.HTML {
+		form.Set("html", req.Body)
+	} else {
+		form.Set("text", req.Body)
+	}
+	if req.ReplyTo != "" {
+		form.Set("h:Reply-To", req.ReplyTo)
+	}
+	for _, name := range sortedHeaders(req) {
+		form.Set("h:"+name, req.Headers[name])
+	}
+
+	target := p.baseURL() + "/v3/" + url.PathEscape(p.def.Domain) + "/messages"
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, target, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("User-Agent", "contact-api")
+	httpReq.SetBasicAuth("api", p.def.APIKey)
+	return httpReq, nil
+}
+
+// postmarkRequest builds a Postmark single email request
+func postmarkRequest(ctx context.Context, p *Provider, req Request) (*http.Request, error) {
+	type header struct {
+		Name  string `json:"Name"`
+		Value string `json:"Value"`
+	}
+	payload := struct {
+		From     string   `json:"From"`
+		To       string   `json:"To"`
+		ReplyTo  string   `json:"ReplyTo,omitempty"`
+		Subject  string   `json:"Subject"`
+		TextBody string   `json:"TextBody,omitempty"`
+		HTMLBody string   `json:"HtmlBody,omitempty"`
+		Headers  []header `json:"Headers,omitempty"`
+	}{
+		From:    req.From,
+		To:      strings.Join(recipients(req.To), ", "),
+		ReplyTo: req.ReplyTo,
+		Subject: req.Subject,
+	}
+	if req.HTML {
+		payload.HTMLBody = req.Body
+	} else {
+		payload.TextBody = req.Body
+	}
+	for _, name := range sortedHeaders(req) {
+		payload.Headers = append(payload.Headers, header{Name: name, Value: req.Headers[name]})
+	}
+
+	httpReq, _, err := jsonRequest(ctx, p.baseURL()+"/email", payload)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("X-Postmark-Server-Token", p.def.APIKey)
+	return httpReq, nil
+}
+
+// resendRequest builds a Resend send email request
+func resendRequest(ctx context.Context, p *Provider, req Request) (*http.Request, error) {
+	payload := struct {
+		From    string            `json:"from"`
+		To      []string          `json:"to"`
+		ReplyTo []string          `json:"reply_to,omitempty"`
+		Subject string            `json:"subject"`
+		Text    string            `json:"text,omitempty"`
+		HTML    string            `json:"html,omitempty"`
+		Headers map[string]string `json:"headers,omitempty"`
+	}{
+		From:    req.From,
+		To:      recipients(req.To),
+		Subject: req.Subject,
+		Headers: req.Headers,
+	}
+	if req.HTML {
+		payload.HTML = req.Body
+	} else {
+		payload.Text = req.Body
+	}
+	if req.ReplyTo != "" {
+		payload.ReplyTo = []string{req.ReplyTo}
+	}
+
+	httpReq, _, err := jsonRequest(ctx, p.baseURL()+"/emails", payload)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+p.def.APIKey)
+	return httpReq, nil
+}
+
+// sesRequest builds a signed SES v2 SendEmail request carrying the raw
+// message, so its headers reach recipients unchanged
+func sesRequest(ctx context.Context, p *Provider, req Request) (*http.Request, error) {
+	type destination struct {
+		ToAddresses []string `json:"ToAddresses"`
+	}
+	type raw struct {
+		Data string `json:"Data"`
+	}
+	type content struct {
+		Raw raw `json:"Raw"`
+	}
+	payload := struct {
+		FromEmailAddress string      `json:"FromEmailAddress"`
+		Destination      destination `json:"Destination"`
+		Content          content     `json:"Content"`
+	}{
+		FromEmailAddress: req.From,
+		Destination:      destination{ToAddresses: recipients(req.To)},
+		Content:          content{Raw: raw{Data: base64.StdEncoding.EncodeToString(Message(req, p.now()))}},
+	}
+
+	base := p.baseURL()
+	if base == "" {
+		base = "https://email." + p.def.Region + ".amazonaws.com"
+	}
+	httpReq, body, err := jsonRequest(ctx, base+"/v2/email/outbound-emails", payload)
+	if err != nil {
+		return nil, err
+	}
+	signV4(httpReq, body, p.def.Username, p.def.Password, p.def.Region, "ses", p.now())
+	return httpReq, nil
+}
@@ -0,0 +1,296 @@
+package email
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+)
+
+// captured is a request received by a fake provider
+type captured struct {
+	method string
+	path   string
+	header http.Header
+	body   []byte
+}
+
+func fakeProvider(t *testing.T, status int, response string) (*httptest.Server, *captured) {
+	t.Helper()
+
+	got := &captured{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.method, got.path, got.header = r.Method, r.URL.Path, r.Header
+		got.body, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		fmt.Fprint(w, response)
+	}))
+	t.Cleanup(server.Close)
+	return server, got
+}
+
+var providerRequest = Request{
+	From:    "Contact Form <forms@example.com>",
+	ReplyTo: "john@example.com",
+	To:      "sales@example.com, support@example.com",
+	Subject: "Hello",
+	Body:    "<p>Hi</p>",
+	HTML:    true,
+	Headers: map[string]string{"X-Priority": "1 (Highest)"},
+}
+
+func TestProvider_Send(t *testing.T) {
+	tests := []struct {
+		name         string
+		transport    config.Transport
+		status       int
+		expectedPath string
+		check        func(t *testing.T, got *captured)
+	}{
+		{
+			name:         "sendgrid",
+			transport:    config.Transport{Type: config.TransportSendGrid, APIKey: "sg-key"},
+			status:       http.StatusAccepted,
+			expectedPath: "/v3/mail/send",
+			check: func(t *testing.T, got *captured) {
+				if got.header.Get("Authorization") != "Bearer sg-key" {
+					t.Errorf("Unexpected authorization %q", got.header.Get("Authorization"))
+				}
+				var body struct {
+					Personalizations []struct {
+						To []struct{ Email string }
+					}
+					From    struct{ Email, Name string }
+					ReplyTo struct{ Email string } `json:"reply_to"`
+					Content []struct{ Type, Value string }
+					Headers map[string]string
+				}
+				decode(t, got.body, &body)
+				if len(body.Personalizations) != 1 || len(body.Personalizations[0].To) != 2 || body.Personalizations[0].To[1].Email != "support@example.com" {
+					t.Errorf("Unexpected recipients: %+v", body.Personalizations)
+				}
+				if body.From.Email != "forms@example.com" || body.From.Name != "Contact Form" || body.ReplyTo.Email != "john@example.com" {
+					t.Errorf("Unexpected addresses: %+v %+v", body.From, body.ReplyTo)
+				}
+				if len(body.Content) != 1 || body.Content[0].Type != "text/html" || body.Headers["X-Priority"] != "1 (Highest)" {
+					t.Errorf("Unexpected content: %+v %v", body.Content, body.Headers)
+				}
+			},
+		},
+		{
+			name:         "mailgun",
+			transport:    config.Transport{Type: config.TransportMailgun, APIKey: "mg-key", Domain: "mg.example.com"},
+			status:       http.StatusOK,
+			expectedPath: "/v3/mg.example.com/messages",
+			check: func(t *testing.T, got *captured) {
+				r := &http.Request{Header: got.header}
+				if user, pass, ok := r.BasicAuth(); !ok || user != "api" || pass != "mg-key" {
+					t.Errorf("Unexpected basic auth %q:%q", user, pass)
+				}
+				form, err := url.ParseQuery(string(got.body))
+				if err != nil {
+					t.Fatalf("Invalid form body: %v", err)
+				}
+				if len(form["to"]) != 2 || form.Get("html") != "<p>Hi</p>" || form.Get("h:Reply-To") != "john@example.com" || form.Get("h:X-Priority") != "1 (Highest)" {
+					t.Errorf("Unexpected form: %v", form)
+				}
+			},
+		},
+		{
+			name:         "postmark",
+			transport:    config.Transport{Type: config.TransportPostmark, APIKey: "pm-token"},
+			status:       http.StatusOK,
+			expectedPath: "/email",
+			check: func(t *testing.T, got *captured) {
+				if got.header.Get("X-Postmark-Server-Token") != "pm-token" {
+					t.Errorf("Unexpected token %q", got.header.Get("X-Postmark-Server-Token"))
+				}
+				var body struct {
+					To, ReplyTo, HtmlBody, TextBody string
+					Headers                         []struct{ Name, Value string }
+				}
+				decode(t, got.body, &body)
+				if body.To != "sales@example.com, support@example.com" || body.ReplyTo != "john@example.com" {
+					t.Errorf("Unexpected addresses: %+v", body)
+				}
+				if body.HtmlBody != "<p>Hi</p>" || body.TextBody != "" || len(body.Headers) != 1 {
+					t.Errorf("Unexpected content: %+v", body)
+				}
+			},
+		},
+		{
+			name:         "resend",
+			transport:    config.Transport{Type: config.TransportResend, APIKey: "re-key"},
+			status:       http.StatusOK,
+			expectedPath: "/emails",
+			check: func(t *testing.T, got *captured) {
+				if got.header.Get("Authorization") != "Bearer re-key" {
+					t.Errorf("Unexpected authorization %q", got.header.Get("Authorization"))
+				}
+				var body struct {
+					To      []string
+					ReplyTo []string `json:"reply_to"`
+					HTML    string
+				}
+				decode(t, got.body, &body)
+				if len(body.To) != 2 || len(body.ReplyTo) != 1 || body.HTML != "<p>Hi</p>" {
+					t.Errorf("Unexpected body: %+v", body)
+				}
+			},
+		},
+		{
+			name:         "ses",
+			transport:    config.Transport{Type: config.TransportSES, Region: "eu-west-1", Username: "AKIDEXAMPLE", Password: "secret"},
+			status:       http.StatusOK,
+			expectedPath: "/v2/email/outbound-emails",
+			check: func(t *testing.T, got *captured) {
+				auth := got.header.Get("Authorization")
+				if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") || !strings.Contains(auth, "/eu-west-1/ses/aws4_request") {
+					t.Errorf("Unexpected authorization %q", auth)
+				}
+				var body struct {
+					FromEmailAddress string
+					Destination      struct{ ToAddresses []string }
+					Content          struct{ Raw struct{ Data string } }
+				}
+				decode(t, got.body, &body)
+				raw, err := base64.StdEncoding.DecodeString(body.Content.Raw.Data)
+				if err != nil {
+					t.Fatalf("Invalid raw message: %v", err)
+				}
+				if len(body.Destination.ToAddresses) != 2 || !strings.Contains(string(raw), "Reply-To: john@example.com\r\n") {
+					t.Errorf("Unexpected message: %+v\n%s", body.Destination, raw)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, got := fakeProvider(t, tt.status, `{}`)
+			tt.transport.URL = server.URL + "/"
+
+			provider := NewProvider(tt.transport, server.Client())
+			if err := provider.SendContext(context.Background(), providerRequest); err != nil {
+				t.Fatalf("SendContext() returned error: %v", err)
+			}
+			if got.method != http.MethodPost || got.path != tt.expectedPath {
+				t.Errorf("Expected POST %s, got %s %s", tt.expectedPath, got.method, got.path)
+			}
+			tt.check(t, got)
+		})
+	}
+}
+
+func TestProvider_Errors(t *testing.T) {
+	tests := []struct {
+		name              string
+		transport         string
+		status            int
+		response          string
+		expectedMessage   string
+		expectedPermanent bool
+	}{
+		{name: "sendgrid bad request", transport: config.TransportSendGrid, status: http.StatusBadRequest, response: `{"errors":[{"message":"The from address does not match a verified Sender Identity"}]}`, expectedMessage: "verified Sender Identity", expectedPermanent: true},
+		{name: "mailgun unauthorized", transport: config.TransportMailgun, status: http.StatusUnauthorized, response: `Forbidden`, expectedMessage: "Forbidden"},
+		{name: "sendgrid forbidden", transport: config.TransportSendGrid, status: http.StatusForbidden, response: `{"errors":[{"message":"access forbidden"}]}`, expectedMessage: "access forbidden"},
+		{name: "postmark inactive recipient", transport: config.TransportPostmark, status: http.StatusUnprocessableEntity, response: `{"ErrorCode":406,"Message":"Inactive recipient"}`, expectedMessage: "Inactive recipient", expectedPermanent: true},
+		{name: "resend rate limit", transport: config.TransportResend, status: http.StatusTooManyRequests, response: `{"statusCode":429,"message":"Too many requests","name":"rate_limit_exceeded"}`, expectedMessage: "Too many requests"},
+		{name: "ses outage", transport: config.TransportSES, status: http.StatusServiceUnavailable, response: ``, expectedMessage: "503 Service Unavailable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := fakeProvider(t, tt.status, tt.response)
+			provider := NewProvider(config.Transport{Type: tt.transport, URL: server.URL, Region: "us-east-1"}, server.Client())
+
+			err := provider.SendContext(context.Background(), providerRequest)
+			var providerErr *ProviderError
+			if !errors.As(err, &providerErr) {
+				t.Fatalf("Expected a ProviderError, got %v", err)
+			}
+			if providerErr.StatusCode != tt.status || !strings.Contains(providerErr.Message, tt.expectedMessage) {
+				t.Errorf("Unexpected error: %+v", providerErr)
+			}
+			if Permanent(err) != tt.expectedPermanent {
+				t.Errorf("Permanent() = %v, want %v", Permanent(err), tt.expectedPermanent)
+			}
+		})
+	}
+
+	// Unreachable providers are worth retrying
+	provider := NewProvider(config.Transport{Type: config.TransportResend, URL: "http://127.0.0.1:1"}, nil)
+	if err := provider.SendContext(context.Background(), providerRequest); err == nil || Permanent(err) {
+		t.Errorf("Expected a retryable connection error, got %v", err)
+	}
+}
+
+func TestPermanent(t *testing.T) {
+	permanent := &ProviderError{StatusCode: 400}
+	retryable := &ProviderError{StatusCode: 503, Retryable: true}
+
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "provider rejection", err: fmt.Errorf("backup: %w", permanent), expected: true},
+		{name: "provider outage", err: retryable},
+		{name: "SMTP rejection", err: fmt.Errorf("SMTP RCPT error: %w", &textproto.Error{Code: 550, Msg: "no such user"}), expected: true},
+		{name: "SMTP greylisting", err: &textproto.Error{Code: 451, Msg: "try again later"}},
+		{name: "SMTP authentication required", err: fmt.Errorf("SMTP FROM error: %w", &textproto.Error{Code: 530, Msg: "authentication required"})},
+		{name: "SMTP credentials refused", err: fmt.Errorf("SMTP AUTH error: %w", &textproto.Error{Code: 535, Msg: "bad credentials"})},
+		{name: "connection error", err: errors.New("connection refused")},
+		{name: "every transport rejected", err: errors.Join(permanent, permanent), expected: true},
+		{name: "one transport unreachable", err: errors.Join(permanent, errors.New("connection refused"))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Permanent(tt.err); got != tt.expected {
+				t.Errorf("Permanent() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSignV4(t *testing.T) {
+	// Example request from the AWS Signature Version 4 documentation
+	req, err := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	signV4(req, nil, "AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "us-east-1", "iam",
+		time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, " +
+		"SignedHeaders=content-type;host;x-amz-date, " +
+		"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7"
+	if got := req.Header.Get("Authorization"); got != expected {
+		t.Errorf("Authorization = %q, want %q", got, expected)
+	}
+	if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+		t.Errorf("X-Amz-Date = %q, want 20150830T123600Z", got)
+	}
+}
+
+func decode(t *testing.T, body []byte, v any) {
+	t.Helper()
+	if err := json.Unmarshal(body, v); err != nil {
+		t.Fatalf("Invalid JSON body %s: %v", body, err)
+	}
+}
@@ -1,17 +1,74 @@
 package email
 
 import (
+	"crypto/tls"
 	"io"
+	"net"
 	"net/smtp"
+	"time"
 )
 
+// DefaultSMTPTimeout bounds dialing an SMTP server and each use of a
+// connection, so a server that stops responding cannot hold a send forever
+const DefaultSMTPTimeout = 30 * time.Second
+
+// smtpTimeout is the SMTP timeout in effect, shortened by tests
+var smtpTimeout = DefaultSMTPTimeout
+
 // SMTPClient defines the interface for SMTP operations
 // This allows us to mock the SMTP client for testing
 type SMTPClient interface {
+	Hello(localName string) error
+	Noop() error
+	Extension(ext string) (bool, string)
+	StartTLS(config *tls.Config) error
 	Mail(from string) error
 	Rcpt(to string) error
 	Data() (io.WriteCloser, error)
+	Reset() error
 	Quit() error
 	Close() error
 	Auth(auth smtp.Auth) error
 }
+
+// deadliner is implemented by clients whose connection can be given a deadline
+type deadliner interface {
+	SetDeadline(t time.Time) error
+}
+
+// smtpConn is an SMTP client along with its network connection
+type smtpConn struct {
+	*smtp.Client
+	conn net.Conn
+}
+
+// SetDeadline bounds the reads and writes of the connection
+func (c *smtpConn) SetDeadline(t time.Time) error {
+	return c.conn.SetDeadline(t)
+}
+
+// dialSMTP connects to an SMTP server within smtpTimeout, including the
+// greeting it sends
+func dialSMTP(addr string) (SMTPClient, error) {
+	conn, err := net.DialTimeout("tcp", addr, smtpTimeout)
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Now().Add(smtpTimeout))
+
+	host, _, _ := net.SplitHostPort(addr)
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &smtpConn{Client: client, conn: conn}, nil
+}
+
+// setDeadline bounds the next commands on a client's connection, when it
+// has one
+func setDeadline(client SMTPClient, t time.Time) {
+	if d, ok := client.(deadliner); ok {
+		_ = d.SetDeadline(t)
+	}
+}
@@ -1,19 +1,25 @@
 package email
 
 import (
+	"crypto/tls"
 	"io"
 	"net/smtp"
 )
 
 // MockSMTPClient implements a mock SMTP client for testing
 type MockSMTPClient struct {
-	DialFunc  func(addr string) (SMTPClient, error)
-	MailFunc  func(from string) error
-	RcptFunc  func(to string) error
-	DataFunc  func() (io.WriteCloser, error)
-	QuitFunc  func() error
-	CloseFunc func() error
-	AuthFunc  func(auth smtp.Auth) error
+	DialFunc      func(addr string) (SMTPClient, error)
+	HelloFunc     func(localName string) error
+	NoopFunc      func() error
+	ExtensionFunc func(ext string) (bool, string)
+	StartTLSFunc  func(config *tls.Config) error
+	MailFunc      func(from string) error
+	RcptFunc      func(to string) error
+	DataFunc      func() (io.WriteCloser, error)
+	ResetFunc     func() error
+	QuitFunc      func() error
+	CloseFunc     func() error
+	AuthFunc      func(auth smtp.Auth) error
 }
 
 // Dial is a mock implementation of net/smtp.Dial
@@ -24,6 +30,38 @@ func (m *MockSMTPClient) Dial(addr string) (SMTPClient, error) {
 	return m, nil
 }
 
+// Hello is a mock implementation of smtp.Client.Hello
+func (m *MockSMTPClient) Hello(localName string) error {
+	if m.HelloFunc != nil {
+		return m.HelloFunc(localName)
+	}
+	return nil
+}
+
+// Noop is a mock implementation of smtp.Client.Noop
+func (m *MockSMTPClient) Noop() error {
+	if m.NoopFunc != nil {
+		return m.NoopFunc()
+	}
+	return nil
+}
+
+// Extension is a mock implementation of smtp.Client.Extension
+func (m *MockSMTPClient) Extension(ext string) (bool, string) {
+	if m.ExtensionFunc != nil {
+		return m.ExtensionFunc(ext)
+	}
+	return false, ""
+}
+
+// StartTLS is a mock implementation of smtp.Client.StartTLS
+func (m *MockSMTPClient) StartTLS(config *tls.Config) error {
+	if m.StartTLSFunc != nil {
+		return m.StartTLSFunc(config)
+	}
+	return nil
+}
+
 // Mail is a mock implementation of smtp.Client.Mail
 func (m *MockSMTPClient) Mail(from string) error {
 	if m.MailFunc != nil {
@@ -48,6 +86,14 @@ func (m *MockSMTPClient) Data() (io.WriteCloser, error) {
 	return &MockWriteCloser{}, nil
 }
 
+// Reset is a mock implementation of smtp.Client.Reset
+func (m *MockSMTPClient) Reset() error {
+	if m.ResetFunc != nil {
+		return m.ResetFunc()
+	}
+	return nil
+}
+
 // Quit is a mock implementation of smtp.Client.Quit
 func (m *MockSMTPClient) Quit() error {
 	if m.QuitFunc != nil {
@@ -0,0 +1,412 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsantos/contact-api/internal/auth"
+	"github.com/nahuelsantos/contact-api/internal/i18n"
+	"github.com/nahuelsantos/contact-api/internal/storage"
+)
+
+// principalKey is the gin context key holding the authenticated principal
+const principalKey = "principal"
+
+// CreateAPIKeyRequest describes a new admin API key
+type CreateAPIKeyRequest struct {
+	Name        string   `json:"name" binding:"required" example:"support dashboard"`
+	Websites    []string `json:"websites" binding:"required,min=1" example:"main"`
+	Permissions []string `json:"permissions" binding:"required,min=1" example:"read-submissions"`
+}
+
+// APIKeyResponse describes an API key without its hash.
+// Key is only set when the key is created or rotated.
+type APIKeyResponse struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Key         string     `json:"key,omitempty"`
+	Websites    []string   `json:"websites"`
+	Permissions []string   `json:"permissions"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RotatedAt   *time.Time `json:"rotated_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+}
+
+// newAPIKeyResponse converts a stored key for output
+func newAPIKeyResponse(key storage.APIKey, plaintext string) APIKeyResponse {
+	return APIKeyResponse{
+		ID:          key.ID,
+		Name:        key.Name,
+		Key:         plaintext,
+		Websites:    key.Websites,
+		Permissions: key.Permissions,
+		CreatedAt:   key.CreatedAt,
+		RotatedAt:   key.RotatedAt,
+		RevokedAt:   key.RevokedAt,
+		LastUsedAt:  key.LastUsedAt,
+	}
+}
+
+// RegisterAdminRoutes mounts the authenticated admin API on a router group
+func (a *API) RegisterAdminRoutes(rg *gin.RouterGroup) {
+	admin := rg.Group("/admin", a.RequireAdmin(), a.AuditAdmin())
+
+	admin.GET("/keys", a.RequirePermission(auth.PermManageKeys), a.ListAPIKeys)
+	admin.POST("/keys", a.RequirePermission(auth.PermManageKeys), a.CreateAPIKey)
+	admin.POST("/keys/:id/rotate", a.RequirePermission(auth.PermManageKeys), a.RotateAPIKey)
+	admin.DELETE("/keys/:id", a.RequirePermission(auth.PermManageKeys), a.RevokeAPIKey)
+	admin.GET("/audit", a.RequirePermission(auth.PermReadAudit), a.ListAuditLog)
+
+	admin.GET("/websites", a.RequirePermission(auth.PermManageSites), a.ListWebsites)
+	admin.GET("/websites/:website", a.RequirePermission(auth.PermManageSites), a.GetWebsite)
+	admin.PUT("/websites/:website", a.RequirePermission(auth.PermManageSites), a.PutWebsite)
+	admin.DELETE("/websites/:website", a.RequirePermission(auth.PermManageSites), a.DeleteWebsite)
+	admin.GET("/websites/:website/versions", a.RequirePermission(auth.PermManageSites), a.ListWebsiteVersions)
+	admin.GET("/websites/:website/versions/:version/diff", a.RequirePermission(auth.PermManageSites), a.DiffWebsiteVersions)
+	admin.POST("/websites/:website/versions/:version/rollback", a.RequirePermission(auth.PermManageSites), a.RollbackWebsite)
+	admin.GET("/websites/:website/diagnostics", a.RequirePermission(auth.PermManageSites), a.WebsiteDiagnostics)
+	admin.POST("/websites/:website/routing/test", a.RequirePermission(auth.PermManageSites), a.TestRouting)
+	admin.GET("/websites/:website/submissions", a.RequirePermission(auth.PermReadSubmissions), a.ListSubmissions)
+	admin.POST("/websites/:website/submissions/:id/resend", a.RequirePermission(auth.PermResend), a.ResendSubmission)
+	admin.POST("/websites/:website/submissions/:id/label", a.RequirePermission(auth.PermTrainSpam), a.LabelSubmission)
+	admin.GET("/websites/:website/spam/model", a.RequirePermission(auth.PermTrainSpam), a.ExportSpamModel)
+	admin.PUT("/websites/:website/spam/model", a.RequirePermission(auth.PermTrainSpam), a.ImportSpamModel)
+	admin.GET("/spam/model", a.RequirePermission(auth.PermTrainSpam), a.ExportGlobalSpamModel)
+	admin.PUT("/spam/model", a.RequirePermission(auth.PermTrainSpam), a.ImportGlobalSpamModel)
+
+	admin.GET("/lists", a.RequirePermission(auth.PermManageLists), a.ListListEntries)
+	admin.POST("/lists", a.RequirePermission(auth.PermManageLists), a.CreateListEntry)
+	admin.DELETE("/lists/:id", a.RequirePermission(auth.PermManageLists), a.DeleteListEntry)
+}
+
+// RequireAdmin authenticates admin requests with either the configured admin
+// token, which has every permission, or an API key
+func (a *API) RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+
+		principal, err := a.authenticate(token)
+		if err != nil {
+			slog.WarnContext(c.Request.Context(), "Rejected admin request", "path", c.Request.URL.Path, "ip", c.ClientIP())
+			abortFail(c, http.StatusUnauthorized, CodeUnauthorized, "Unauthorized")
+			return
+		}
+
+		c.Set(principalKey, principal)
+		c.Next()
+	}
+}
+
+// authenticate resolves a bearer token to a principal
+func (a *API) authenticate(token string) (auth.Principal, error) {
+	if token == "" {
+		return auth.Principal{}, auth.ErrInvalidKey
+	}
+	if a.Config().AdminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(a.Config().AdminToken)) == 1 {
+		return auth.Root(), nil
+	}
+
+	id, err := auth.ParseKeyID(token)
+	if err != nil {
+		return auth.Principal{}, err
+	}
+	key, err := a.Store.GetAPIKey(id)
+	if err != nil {
+		return auth.Principal{}, auth.ErrInvalidKey
+	}
+	if err := auth.Verify(token, key); err != nil {
+		return auth.Principal{}, err
+	}
+
+	a.Store.TouchAPIKey(key.ID, time.Now().UTC())
+	return auth.NewPrincipal(key), nil
+}
+
+// RequirePermission rejects principals without a permission, or without
+// access to the website in the route when there is one
+func (a *API) RequirePermission(perm auth.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := principalFrom(c)
+
+		website := c.Param("website")
+		if !principal.Can(perm) || (website != "" && !principal.CanAccessWebsite(website)) {
+			abortFail(c, http.StatusForbidden, CodeForbidden, "Forbidden")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// AuditAdmin records an audit entry for every authenticated admin request
+func (a *API) AuditAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		principal := principalFrom(c)
+		entry := storage.AuditEntry{
+			KeyID:   principal.KeyID,
+			KeyName: principal.Name,
+			Action:  c.Request.Method + " " + c.FullPath(),
+			Website: c.Param("website"),
+			Target:  auditTarget(c),
+			Status:  c.Writer.Status(),
+			IP:      c.ClientIP(),
+		}
+		if err := a.Store.AppendAudit(entry); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Failed to write audit entry", "error", err, "action", entry.Action)
+		}
+	}
+}
+
+// auditTarget returns the record an admin request acted on, if any
+func auditTarget(c *gin.Context) string {
+	if id := c.Param("id"); id != "" {
+		return id
+	}
+	return c.Param("version")
+}
+
+// principalFrom returns the principal set by RequireAdmin
+func principalFrom(c *gin.Context) auth.Principal {
+	if v, ok := c.Get(principalKey); ok {
+		if principal, ok := v.(auth.Principal); ok {
+			return principal
+		}
+	}
+	return auth.Principal{}
+}
+
+// ListAPIKeys lists the admin API keys within the caller's website scope
+// @Summary List API keys
+// @Description List admin API keys scoped to websites the caller can access, without their secrets
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} Response{data=[]APIKeyResponse}
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Router /admin/keys [get]
+func (a *API) ListAPIKeys(c *gin.Context) {
+	principal := principalFrom(c)
+
+	data := make([]APIKeyResponse, 0)
+	for _, key := range a.Store.ListAPIKeys() {
+		if principal.Covers(key.Websites, nil) {
+			data = append(data, newAPIKeyResponse(key, ""))
+		}
+	}
+
+	respond(c, http.StatusOK, Response{
+		Success: true,
+		Message: "API keys retrieved",
+		Data:    data,
+	})
+}
+
+// CreateAPIKey creates an admin API key scoped to websites and permissions
+// @Summary Create API key
+// @Description Create an admin API key. The key is only returned once.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param key body CreateAPIKeyRequest true "Key definition"
+// @Success 201 {object} Response{data=APIKeyResponse}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Router /admin/keys [post]
+func (a *API) CreateAPIKey(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		failBinding(c, i18n.Default, &req, err)
+		return
+	}
+
+	perms, err := auth.ParsePermissions(req.Permissions)
+	if err != nil {
+		failFields(c, i18n.Default, FieldError{Field: "permissions", Code: FieldInvalid, Message: err.Error()})
+		return
+	}
+
+	// A key can never grant more than its creator holds
+	if !principalFrom(c).Covers(req.Websites, perms) {
+		fail(c, http.StatusForbidden, CodeForbidden, "Cannot grant permissions or websites you do not have")
+		return
+	}
+
+	key := storage.APIKey{
+		ID:          storage.NewID(),
+		Name:        req.Name,
+		Websites:    req.Websites,
+		Permissions: req.Permissions,
+		CreatedAt:   time.Now().UTC(),
+	}
+	plaintext, err := auth.GenerateSecret(key.ID)
+	if err != nil {
+		a.internalError(c, "Failed to create API key", err)
+		return
+	}
+	key.Hash = auth.HashSecret(plaintext)
+
+	if err := a.Store.SaveAPIKey(key); err != nil {
+		a.internalError(c, "Failed to create API key", err)
+		return
+	}
+
+	respond(c, http.StatusCreated, Response{
+		Success: true,
+		Message: "API key created. Store it now, it will not be shown again.",
+		Data:    newAPIKeyResponse(key, plaintext),
+	})
+}
+
+// RotateAPIKey replaces the secret of an API key, invalidating the old one
+// @Summary Rotate API key
+// @Description Replace the secret of an API key. The new key is only returned once.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Key ID"
+// @Success 200 {object} Response{data=APIKeyResponse}
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Router /admin/keys/{id}/rotate [post]
+func (a *API) RotateAPIKey(c *gin.Context) {
+	key, ok := a.managedKey(c)
+	if !ok {
+		return
+	}
+
+	plaintext, err := auth.GenerateSecret(key.ID)
+	if err != nil {
+		a.internalError(c, "Failed to rotate API key", err)
+		return
+	}
+	now := time.Now().UTC()
+	key.Hash = auth.HashSecret(plaintext)
+	key.RotatedAt = &now
+
+	if err := a.Store.SaveAPIKey(key); err != nil {
+		a.internalError(c, "Failed to rotate API key", err)
+		return
+	}
+
+	respond(c, http.StatusOK, Response{
+		Success: true,
+		Message: "API key rotated. Store it now, it will not be shown again.",
+		Data:    newAPIKeyResponse(key, plaintext),
+	})
+}
+
+// RevokeAPIKey permanently disables an API key
+// @Summary Revoke API key
+// @Description Permanently disable an API key
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Key ID"
+// @Success 200 {object} Response{data=APIKeyResponse}
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Router /admin/keys/{id} [delete]
+func (a *API) RevokeAPIKey(c *gin.Context) {
+	key, ok := a.managedKey(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+	key.RevokedAt = &now
+
+	if err := a.Store.SaveAPIKey(key); err != nil {
+		a.internalError(c, "Failed to revoke API key", err)
+		return
+	}
+
+	respond(c, http.StatusOK, Response{
+		Success: true,
+		Message: "API key revoked",
+		Data:    newAPIKeyResponse(key, ""),
+	})
+}
+
+// managedKey loads the key in the route and checks that the caller covers
+// its scopes, writing the error response when it does not
+func (a *API) managedKey(c *gin.Context) (storage.APIKey, bool) {
+	key, err := a.Store.GetAPIKey(c.Param("id"))
+	if errors.Is(err, storage.ErrNotFound) || (err == nil && key.RevokedAt != nil) {
+		fail(c, http.StatusNotFound, CodeAPIKeyNotFound, "API key not found")
+		return key, false
+	}
+	if err != nil {
+		a.internalError(c, "Failed to load API key", err)
+		return key, false
+	}
+
+	perms, _ := auth.ParsePermissions(key.Permissions)
+	if !principalFrom(c).Covers(key.Websites, perms) {
+		fail(c, http.StatusForbidden, CodeForbidden, "Cannot manage a key with permissions or websites you do not have")
+		return key, false
+	}
+
+	return key, true
+}
+
+// ListAuditLog returns recent admin actions on websites the caller can access.
+// Actions on no website are only shown to keys scoped to every website.
+// @Summary List audit log
+// @Description List the most recent admin API actions on websites the caller can access, newest first
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Maximum number of entries" default(100)
+// @Success 200 {object} Response{data=[]storage.AuditEntry}
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Router /admin/audit [get]
+func (a *API) ListAuditLog(c *gin.Context) {
+	principal := principalFrom(c)
+	limit := queryLimit(c, 100)
+
+	entries := make([]storage.AuditEntry, 0)
+	for _, entry := range a.Store.ListAudit(0) {
+		if len(entries) == limit {
+			break
+		}
+		if principal.CanAccessWebsite(entry.Website) {
+			entries = append(entries, entry)
+		}
+	}
+
+	respond(c, http.StatusOK, Response{
+		Success: true,
+		Message: "Audit log retrieved",
+		Data:    entries,
+	})
+}
+
+// queryLimit reads the limit query parameter, falling back to a default
+func queryLimit(c *gin.Context, fallback int) int {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		return fallback
+	}
+	return limit
+}
+
+// internalError logs an unexpected error and writes a generic 500 response
+func (a *API) internalError(c *gin.Context, message string, err error) {
+	slog.ErrorContext(c.Request.Context(), message, "error", err, "path", c.Request.URL.Path)
+	fail(c, http.StatusInternalServerError, CodeInternal, message)
+}
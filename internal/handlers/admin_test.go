@@ -0,0 +1,357 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/email"
+	"github.com/nahuelsantos/contact-api/internal/storage"
+)
+
+func setupAdminAPI(t *testing.T) (*API, *gin.Engine) {
+	t.Helper()
+
+	cfg := config.Config{
+		SMTPHost:    "localhost",
+		SMTPPort:    "1025",
+		DefaultFrom: "test@example.com",
+		DefaultTo:   "contact@example.com",
+		AdminToken:  "root-token",
+	}
+
+	api := New(cfg, nil)
+	r := gin.New()
+	v1 := r.Group("/api/v1")
+	v1.POST("/contact/:website", api.ContactHandler)
+	api.RegisterAdminRoutes(v1)
+
+	return api, r
+}
+
+func doAdminRequest(t *testing.T, r *gin.Engine, method, path, token string, body any) (*httptest.ResponseRecorder, Response) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatalf("Failed to marshal JSON: %v", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), method, path, &buf)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var response Response
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	return w, response
+}
+
+func createKey(t *testing.T, r *gin.Engine, token string, req CreateAPIKeyRequest) APIKeyResponse {
+	t.Helper()
+
+	w, response := doAdminRequest(t, r, "POST", "/api/v1/admin/keys", token, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, w.Code, response.Message)
+	}
+
+	raw, _ := json.Marshal(response.Data)
+	var key APIKeyResponse
+	if err := json.Unmarshal(raw, &key); err != nil {
+		t.Fatalf("Error unmarshaling key: %v", err)
+	}
+	return key
+}
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	api, r := setupAdminAPI(t)
+
+	key := createKey(t, r, "root-token", CreateAPIKeyRequest{
+		Name:        "support",
+		Websites:    []string{"main"},
+		Permissions: []string{"read-submissions"},
+	})
+	if key.Key == "" {
+		t.Fatal("Expected the plaintext key to be returned on creation")
+	}
+
+	stored, err := api.Store.GetAPIKey(key.ID)
+	if err != nil {
+		t.Fatalf("Key was not stored: %v", err)
+	}
+	if stored.Hash == key.Key {
+		t.Error("Key must be hashed at rest")
+	}
+
+	// The key works within its scope only
+	if w, _ := doAdminRequest(t, r, "GET", "/api/v1/admin/websites/main/submissions", key.Key, nil); w.Code != http.StatusOK {
+		t.Errorf("Expected scoped request to succeed, got %d", w.Code)
+	}
+	if w, _ := doAdminRequest(t, r, "GET", "/api/v1/admin/websites/blog/submissions", key.Key, nil); w.Code != http.StatusForbidden {
+		t.Errorf("Expected other website to be forbidden, got %d", w.Code)
+	}
+	if w, _ := doAdminRequest(t, r, "GET", "/api/v1/admin/keys", key.Key, nil); w.Code != http.StatusForbidden {
+		t.Errorf("Expected missing permission to be forbidden, got %d", w.Code)
+	}
+
+	// Rotation invalidates the old secret
+	w, response := doAdminRequest(t, r, "POST", "/api/v1/admin/keys/"+key.ID+"/rotate", "root-token", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected rotation to succeed, got %d: %s", w.Code, response.Message)
+	}
+	raw, _ := json.Marshal(response.Data)
+	var rotated APIKeyResponse
+	_ = json.Unmarshal(raw, &rotated)
+
+	if w, _ := doAdminRequest(t, r, "GET", "/api/v1/admin/websites/main/submissions", key.Key, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected old secret to be rejected, got %d", w.Code)
+	}
+	if w, _ := doAdminRequest(t, r, "GET", "/api/v1/admin/websites/main/submissions", rotated.Key, nil); w.Code != http.StatusOK {
+		t.Errorf("Expected rotated secret to work, got %d", w.Code)
+	}
+
+	// Revocation disables the key
+	if w, _ := doAdminRequest(t, r, "DELETE", "/api/v1/admin/keys/"+key.ID, "root-token", nil); w.Code != http.StatusOK {
+		t.Fatalf("Expected revocation to succeed, got %d", w.Code)
+	}
+	if w, _ := doAdminRequest(t, r, "GET", "/api/v1/admin/websites/main/submissions", rotated.Key, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected revoked key to be rejected, got %d", w.Code)
+	}
+}
+
+func TestCreateAPIKey_CannotEscalate(t *testing.T) {
+	_, r := setupAdminAPI(t)
+
+	manager := createKey(t, r, "root-token", CreateAPIKeyRequest{
+		Name:        "site manager",
+		Websites:    []string{"main"},
+		Permissions: []string{"manage-keys", "read-submissions"},
+	})
+
+	tests := []struct {
+		name         string
+		req          CreateAPIKeyRequest
+		expectedCode int
+	}{
+		{
+			name:         "within scope",
+			req:          CreateAPIKeyRequest{Name: "a", Websites: []string{"main"}, Permissions: []string{"read-submissions"}},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "extra permission",
+			req:          CreateAPIKeyRequest{Name: "b", Websites: []string{"main"}, Permissions: []string{"resend"}},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "all websites",
+			req:          CreateAPIKeyRequest{Name: "c", Websites: []string{"*"}, Permissions: []string{"read-submissions"}},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "unknown permission",
+			req:          CreateAPIKeyRequest{Name: "d", Websites: []string{"main"}, Permissions: []string{"superuser"}},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, response := doAdminRequest(t, r, "POST", "/api/v1/admin/keys", manager.Key, tt.req)
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status code %d, got %d: %s", tt.expectedCode, w.Code, response.Message)
+			}
+		})
+	}
+}
+
+func TestAuditAdmin(t *testing.T) {
+	api, r := setupAdminAPI(t)
+
+	key := createKey(t, r, "root-token", CreateAPIKeyRequest{
+		Name:        "auditor",
+		Websites:    []string{"*"},
+		Permissions: []string{"read-audit"},
+	})
+	doAdminRequest(t, r, "GET", "/api/v1/admin/audit", key.Key, nil)
+	doAdminRequest(t, r, "GET", "/api/v1/admin/audit", "wrong-token", nil)
+
+	entries := api.Store.ListAudit(0)
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 audit entries, got %d", len(entries))
+	}
+	if entries[0].KeyID != key.ID || entries[0].Action != "GET /api/v1/admin/audit" {
+		t.Errorf("Unexpected audit entry: %+v", entries[0])
+	}
+	if entries[1].KeyID != "root" || entries[1].Status != http.StatusCreated {
+		t.Errorf("Unexpected audit entry: %+v", entries[1])
+	}
+}
+
+func TestAdminListings_Scoped(t *testing.T) {
+	_, r := setupAdminAPI(t)
+
+	mainKey := createKey(t, r, "root-token", CreateAPIKeyRequest{
+		Name:        "main manager",
+		Websites:    []string{"main"},
+		Permissions: []string{"manage-keys", "read-audit", "read-submissions"},
+	})
+	blogKey := createKey(t, r, "root-token", CreateAPIKeyRequest{
+		Name:        "blog reader",
+		Websites:    []string{"blog"},
+		Permissions: []string{"read-submissions"},
+	})
+	doAdminRequest(t, r, "GET", "/api/v1/admin/websites/main/submissions", mainKey.Key, nil)
+	doAdminRequest(t, r, "GET", "/api/v1/admin/websites/blog/submissions", blogKey.Key, nil)
+
+	tests := []struct {
+		name     string
+		path     string
+		field    string
+		expected []string
+	}{
+		{name: "keys", path: "/api/v1/admin/keys", field: "id", expected: []string{mainKey.ID}},
+		{name: "audit", path: "/api/v1/admin/audit", field: "website", expected: []string{"main"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, response := doAdminRequest(t, r, "GET", tt.path, mainKey.Key, nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, response.Message)
+			}
+
+			var got []string
+			items, _ := response.Data.([]any)
+			for _, item := range items {
+				fields, _ := item.(map[string]any)
+				value, _ := fields[tt.field].(string)
+				got = append(got, value)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestResendSubmission(t *testing.T) {
+	api, r := setupAdminAPI(t)
+
+	originalDialer := email.DefaultSMTPDialer
+	defer func() { email.DefaultSMTPDialer = originalDialer }()
+
+	// The first delivery fails and is stored as failed
+	email.DefaultSMTPDialer = func(addr string) (email.SMTPClient, error) {
+		return nil, errors.New("connection refused")
+	}
+	form := ContactFormData{Name: "John Doe", Email: "john@example.com", Subject: "Hi", Message: "Hello"}
+	if w, _ := doAdminRequest(t, r, "POST", "/api/v1/contact/main", "", form); w.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected delivery to fail, got %d", w.Code)
+	}
+
+	failed := api.Store.ListSubmissions(storage.SubmissionFilter{Website: "main", Status: storage.StatusFailed})
+	if len(failed) != 1 {
+		t.Fatalf("Expected 1 failed submission, got %d", len(failed))
+	}
+
+	// Resending after the SMTP server recovers marks it as sent
+	email.DefaultSMTPDialer = func(addr string) (email.SMTPClient, error) {
+		return &email.MockSMTPClient{}, nil
+	}
+	w, response := doAdminRequest(t, r, "POST", "/api/v1/admin/websites/main/submissions/"+failed[0].ID+"/resend", "root-token", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected resend to succeed, got %d: %s", w.Code, response.Message)
+	}
+
+	sub, err := api.Store.GetSubmission(failed[0].ID)
+	if err != nil {
+		t.Fatalf("Submission disappeared: %v", err)
+	}
+	if sub.Status != storage.StatusSent || sub.Attempts != 2 {
+		t.Errorf("Expected sent after 2 attempts, got %s after %d", sub.Status, sub.Attempts)
+	}
+
+	// Submissions cannot be resent through another website's route
+	w, _ = doAdminRequest(t, r, "POST", "/api/v1/admin/websites/blog/submissions/"+sub.ID+"/resend", "root-token", nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected cross-website resend to be not found, got %d", w.Code)
+	}
+}
+
+func TestContactHandler_TransportFallback(t *testing.T) {
+	api, r := setupAdminAPI(t)
+
+	originalDialer := email.DefaultSMTPDialer
+	email.DefaultSMTPDialer = func(addr string) (email.SMTPClient, error) {
+		return nil, errors.New("connection refused")
+	}
+	defer func() { email.DefaultSMTPDialer = originalDialer }()
+
+	cfg := api.Config()
+	cfg.Delivery.Transports = []config.Transport{
+		{Name: "primary", Type: config.TransportSMTP},
+		{Name: "archive", Type: config.TransportStore},
+	}
+	if err := api.Reload(cfg); err != nil {
+		t.Fatalf("Failed to reload: %v", err)
+	}
+
+	// The failing SMTP server falls back to storing the submission
+	form := ContactFormData{Name: "John Doe", Email: "john@example.com", Subject: "Hi", Message: "Hello"}
+	if w, response := doAdminRequest(t, r, "POST", "/api/v1/contact/main", "", form); w.Code != http.StatusOK {
+		t.Fatalf("Expected the fallback to accept the submission, got %d: %s", w.Code, response.Message)
+	}
+
+	stored := api.Store.ListSubmissions(storage.SubmissionFilter{Website: "main", Status: storage.StatusStored})
+	if len(stored) != 1 {
+		t.Fatalf("Expected 1 stored submission, got %d", len(stored))
+	}
+	if stored[0].Transport != "archive" {
+		t.Errorf("Expected the archive transport, got %q", stored[0].Transport)
+	}
+}
+
+func TestContactHandler_PermanentFailure(t *testing.T) {
+	api, r := setupAdminAPI(t)
+
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"message":"The to address is invalid"}`))
+	}))
+	defer provider.Close()
+
+	cfg := api.Config()
+	cfg.Delivery.Transports = []config.Transport{{Name: "resend", Type: config.TransportResend, APIKey: "key", URL: provider.URL}}
+	if err := api.Reload(cfg); err != nil {
+		t.Fatalf("Failed to reload: %v", err)
+	}
+
+	form := ContactFormData{Name: "John Doe", Email: "john@example.com", Subject: "Hi", Message: "Hello"}
+	if w, _ := doAdminRequest(t, r, "POST", "/api/v1/contact/main", "", form); w.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected delivery to fail, got %d", w.Code)
+	}
+
+	failed := api.Store.ListSubmissions(storage.SubmissionFilter{Website: "main", Status: storage.StatusFailed})
+	if len(failed) != 1 || !failed[0].Permanent {
+		t.Fatalf("Expected 1 permanently failed submission, got %+v", failed)
+	}
+}
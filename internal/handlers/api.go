@@ -1,41 +1,180 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	htmltemplate "html/template"
 	"log/slog"
 	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/nahuelsantos/contact-api/internal/blocklist"
 	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/delivery"
 	"github.com/nahuelsantos/contact-api/internal/email"
+	"github.com/nahuelsantos/contact-api/internal/health"
+	"github.com/nahuelsantos/contact-api/internal/i18n"
+	"github.com/nahuelsantos/contact-api/internal/mailcheck"
+	"github.com/nahuelsantos/contact-api/internal/middleware"
+	"github.com/nahuelsantos/contact-api/internal/pow"
+	"github.com/nahuelsantos/contact-api/internal/spam"
+	"github.com/nahuelsantos/contact-api/internal/storage"
+	"github.com/nahuelsantos/contact-api/internal/webhook"
 	"go.opentelemetry.io/otel"
 )
 
 // Response represents the API response
 type Response struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-	Data    any    `json:"data,omitempty"`
+	Success   bool         `json:"success"`
+	Message   string       `json:"message"`
+	Data      any          `json:"data,omitempty"`
+	Code      string       `json:"code,omitempty" example:"validation_failed"`
+	Errors    []FieldError `json:"errors,omitempty"`
+	RequestID string       `json:"request_id,omitempty" example:"4bf92f3577b34da6a3ce929d0e0e4736"`
+}
+
+// respond writes a JSON response tagged with the request ID. Failures are
+// written as problem details to clients that ask for them.
+func respond(c *gin.Context, status int, resp Response) {
+	resp.RequestID = middleware.GetRequestID(c)
+	if !resp.Success && wantsProblem(c) {
+		writeProblem(c, status, resp)
+		return
+	}
+	c.JSON(status, resp)
+}
+
+// abortFail writes an error response and stops the handler chain
+func abortFail(c *gin.Context, status int, code, message string) {
+	fail(c, status, code, message)
+	c.Abort()
 }
 
 // ContactFormData represents a contact form submission
 type ContactFormData struct {
-	Name    string `json:"name" binding:"required" example:"John Doe"`
-	Email   string `json:"email" binding:"required,email" example:"john@example.com"`
-	Subject string `json:"subject" binding:"required" example:"Inquiry about services"`
-	Message string `json:"message" binding:"required" example:"I would like to know more about your services"`
+	Name      string            `json:"name" binding:"required,max=200,excludesall=\r\n" example:"John Doe"`
+	Email     string            `json:"email" binding:"required,email,max=254" example:"john@example.com"`
+	Subject   string            `json:"subject" binding:"required,max=200,excludesall=\r\n" example:"Inquiry about services"`
+	Message   string            `json:"message" binding:"required,max=10000" example:"I would like to know more about your services"`
+	Locale    string            `json:"locale,omitempty" binding:"max=35" example:"es"`
+	Fields    map[string]string `json:"fields,omitempty" binding:"max=20,dive,max=1000"`
+	Gotcha    string            `json:"_gotcha,omitempty" swaggerignore:"true"`
+	Challenge string            `json:"challenge,omitempty" binding:"max=1024"`
+	Solution  string            `json:"solution,omitempty" binding:"max=32" example:"48213"`
+	Spam      *spam.Result      `json:"-"`
 }
 
+// ErrWebsiteNotFound is returned for websites that are unknown or disabled
+var ErrWebsiteNotFound = errors.New("website not found")
+
 // API holds handler dependencies
 type API struct {
-	Config config.Config
+	Store      *storage.Store
+	Health     *health.Monitor
+	Webhooks   *webhook.Client
+	Lists      *blocklist.Lists
+	Challenges *pow.Issuer
+	Delivery   *delivery.Dispatcher
+
+	// replies limits the auto-replies sent to each address
+	replies *replyLimiter
+
+	// cfg holds the current configuration and is swapped on reload
+	cfg atomic.Pointer[config.Config]
+
+	// websites caches the merged configured and stored website definitions
+	// along with their compiled routing patterns
+	websites  atomic.Pointer[websiteSet]
+	refreshMu sync.Mutex
+}
+
+// New creates a new API handler with dependencies.
+// If store is nil an in-memory store is used.
+func New(cfg config.Config, store *storage.Store) *API {
+	if store == nil {
+		store, _ = storage.Open("")
+	}
+
+	a := &API{
+		Store:      store,
+		Webhooks:   webhook.NewClient(10 * time.Second),
+		Lists:      blocklist.New(store),
+		Challenges: pow.NewIssuer(cfg.ChallengeKey),
+		replies:    newReplyLimiter(autoReplyLimit, autoReplyWindow),
+	}
+	a.Delivery = delivery.New(cfg, a.Webhooks)
+	a.cfg.Store(&cfg)
+	if err := store.SetRetention(cfg.MaxSubmissions, time.Duration(cfg.Retention)); err != nil {
+		slog.Error("Failed to apply submission retention", "error", err)
+	}
+	if err := a.Lists.LoadFiles(cfg.Lists.Files); err != nil {
+		slog.Error("Failed to load list files", "error", err)
+	}
+
+	a.Health = health.NewMonitor(time.Duration(cfg.HealthCacheTTL), time.Duration(cfg.HealthTimeout),
+		health.CheckFunc("smtp", func(_ context.Context) error {
+			return a.Delivery.Ping(nil)
+		}),
+		health.CheckFunc("storage", func(_ context.Context) error {
+			return store.Ping()
+		}),
+		health.CheckFunc("outbox", func(ctx context.Context) error {
+			cfg := a.Config()
+			return health.Threshold("outbox", cfg.OutboxWarn, cfg.OutboxMax, func(_ context.Context) (int, error) {
+				return store.CountSubmissions(storage.SubmissionFilter{Status: storage.StatusFailed}), nil
+			}).Check(ctx)
+		}),
+	)
+	a.refreshWebsites()
+
+	return a
 }
 
-// New creates a new API handler with dependencies
-func New(cfg config.Config) *API {
-	return &API{
-		Config: cfg,
+// Config returns the current configuration
+func (a *API) Config() config.Config {
+	return *a.cfg.Load()
+}
+
+// Reload validates a new configuration and swaps it in atomically. An invalid
+// configuration is rejected and the current one stays in place.
+func (a *API) Reload(cfg config.Config) error {
+	var errs []error
+	for name, site := range cfg.Websites {
+		recipients := site.Recipients
+		if len(recipients) == 0 {
+			recipients = []string{cfg.DefaultTo}
+		}
+		for _, check := range validateWebsite(site, name, recipients) {
+			for _, msg := range check.Errors {
+				errs = append(errs, fmt.Errorf("websites.%s.%s: %s", name, check.Name, msg))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	if cfg.ChallengeKey != a.Config().ChallengeKey {
+		a.Challenges.SetKey(cfg.ChallengeKey)
+	}
+	a.cfg.Store(&cfg)
+	a.Delivery.Configure(cfg)
+	a.Health.Configure(time.Duration(cfg.HealthCacheTTL), time.Duration(cfg.HealthTimeout))
+	a.refreshWebsites()
+	if err := a.Store.SetRetention(cfg.MaxSubmissions, time.Duration(cfg.Retention)); err != nil {
+		slog.Error("Failed to apply submission retention", "error", err)
 	}
+	if err := a.Lists.LoadFiles(cfg.Lists.Files); err != nil {
+		slog.Error("Failed to load list files", "error", err)
+	}
+	return nil
 }
 
 // ContactHandler processes contact form submissions
@@ -48,6 +187,8 @@ func New(cfg config.Config) *API {
 // @Param contact body ContactFormData true "Contact form data"
 // @Success 200 {object} Response
 // @Failure 400 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
 // @Failure 500 {object} Response
 // @Router /contact/{website} [post]
 func (a *API) ContactHandler(c *gin.Context) {
@@ -56,78 +197,176 @@ func (a *API) ContactHandler(c *gin.Context) {
 	defer span.End()
 
 	website := c.Param("website")
+	site, ok := a.lookupWebsite(website)
+	if !ok || site.Disabled {
+		fail(c, http.StatusNotFound, CodeWebsiteNotFound, i18n.T(requestLocale(c, config.Website{}, ""), "website.not_found"))
+		return
+	}
 
+	// The body is decoded even when validation fails, so its locale applies
 	var contactForm ContactFormData
-	if err := c.ShouldBindJSON(&contactForm); err != nil {
-		slog.Error("Invalid contact form data", "error", err, "website", website)
-		c.JSON(http.StatusBadRequest, Response{
-			Success: false,
-			Message: "Invalid request format: " + err.Error(),
+	err := c.ShouldBindJSON(&contactForm)
+	contactForm.Locale = requestLocale(c, site, contactForm.Locale)
+	locale := catalogLocale(site, contactForm.Locale)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Invalid contact form data", "error", err, "website", website)
+		failBinding(c, locale, &contactForm, err)
+		return
+	}
+
+	if problems := checkFormFields(site, contactForm, locale); len(problems) > 0 {
+		failFields(c, locale, problems...)
+		return
+	}
+
+	// Internationalized sender domains are stored and sent as ASCII
+	sender, err := mailcheck.Normalize(contactForm.Email)
+	if err != nil {
+		failFields(c, locale, FieldError{Field: "email", Code: FieldInvalidEmail, Message: i18n.T(locale, "field.invalid_email")})
+		return
+	}
+	contactForm.Email = sender.String()
+
+	// Blocked submissions are rejected. Only allowed IP addresses skip the spam
+	// checks, since the sender address is whatever the submitter claims.
+	listed := a.Lists.Check(website, c.ClientIP(), contactForm.Email)
+	if listed.Blocked() {
+		slog.WarnContext(c.Request.Context(), "Rejected blocked submission",
+			"website", website, "ip", c.ClientIP(), "entry", listed.Entry.Value)
+		fail(c, http.StatusForbidden, CodeBlocked, i18n.T(locale, "contact.blocked"))
+		return
+	}
+
+	// Honeypot submissions get a normal response so bots do not adapt
+	if site.AntiSpam.Honeypot && contactForm.Gotcha != "" {
+		slog.WarnContext(c.Request.Context(), "Dropped honeypot submission", "website", website, "ip", c.ClientIP())
+		respond(c, http.StatusOK, Response{
+			Success: true,
+			Message: i18n.T(locale, "contact.sent"),
 		})
 		return
 	}
 
+	if !listed.Trusted() {
+		if code, key, ok := a.checkChallenge(site, contactForm, website); !ok {
+			slog.WarnContext(c.Request.Context(), "Rejected submission without a valid proof of work",
+				"website", website, "ip", c.ClientIP(), "reason", code)
+			fail(c, http.StatusBadRequest, code, i18n.T(locale, key))
+			return
+		}
+		if problem := a.checkEmail(c.Request.Context(), site, sender, locale); problem != nil {
+			slog.WarnContext(c.Request.Context(), "Rejected sender address",
+				"website", website, "ip", c.ClientIP(), "reason", problem.Code)
+			failFields(c, locale, *problem)
+			return
+		}
+	}
+
+	if !listed.Trusted() && site.AntiSpam.MaxLinks > 0 && spam.CountLinks(contactForm.Message) > site.AntiSpam.MaxLinks {
+		slog.WarnContext(c.Request.Context(), "Rejected submission with too many links", "website", website, "ip", c.ClientIP())
+		fail(c, http.StatusBadRequest, CodeTooManyLinks, i18n.T(locale, "contact.too_many_links"))
+		return
+	}
+
+	if !listed.Trusted() {
+		contactForm.Spam = a.scoreSpam(site, contactForm, website)
+
+		// The challenge is only used up once the submission is accepted or
+		// rejected as spam, so a visitor can correct their address or message
+		// and submit again without solving a new one
+		if code, key, ok := a.useChallenge(site, contactForm, website); !ok {
+			slog.WarnContext(c.Request.Context(), "Rejected submission without a valid proof of work",
+				"website", website, "ip", c.ClientIP(), "reason", code)
+			fail(c, http.StatusBadRequest, code, i18n.T(locale, key))
+			return
+		}
+	}
+	if contactForm.Spam != nil {
+		switch contactForm.Spam.Action {
+		case spam.ActionReject:
+			slog.WarnContext(c.Request.Context(), "Rejected spam submission",
+				"website", website, "ip", c.ClientIP(), "score", contactForm.Spam.Score)
+			fail(c, http.StatusBadRequest, CodeSpamRejected, i18n.T(locale, "contact.spam"))
+			return
+		case spam.ActionQuarantine:
+			// Quarantined submissions get a normal response so bots do not adapt
+			slog.WarnContext(c.Request.Context(), "Quarantined spam submission",
+				"website", website, "ip", c.ClientIP(), "score", contactForm.Spam.Score)
+			a.quarantine(contactForm, website)
+			respond(c, http.StatusOK, Response{
+				Success: true,
+				Message: i18n.T(locale, "contact.sent"),
+			})
+			return
+		}
+	}
+
 	// Log contact form submission
-	slog.Info("Contact form submission",
+	_, rule := a.matchRoute(site, contactForm)
+	slog.InfoContext(c.Request.Context(), "Contact form submission",
 		"website", website,
 		"email", contactForm.Email,
 		"subject", contactForm.Subject,
+		"route", rule,
+		"spam_score", spamScore(contactForm.Spam),
 	)
 
-	// Construct email from contact form
-	emailReq := email.Request{
-		From:    contactForm.Email,
-		To:      a.getRecipientForWebsite(website),
-		Subject: fmt.Sprintf("[%s] Contact Form: %s", website, contactForm.Subject),
-		Body:    a.formatContactEmail(contactForm, website),
-		HTML:    true,
-	}
-
-	// Send the email
-	err := email.Send(emailReq, a.Config)
+	// Send the email and keep a record of the outcome
+	result, err := a.deliver(site, contactForm, website)
+	a.recordSubmission(storage.Submission{}, contactForm, website, result, err)
 	if err != nil {
-		slog.Error("Failed to send contact form email",
+		slog.ErrorContext(c.Request.Context(), "Failed to send contact form email",
 			"error", err,
 			"website", website,
 			"email", contactForm.Email,
 		)
-		c.JSON(http.StatusInternalServerError, Response{
-			Success: false,
-			Message: "Failed to send your message. Please try again later.",
-		})
+		fail(c, http.StatusInternalServerError, CodeDeliveryFailed, i18n.T(locale, "contact.failed"))
 		return
 	}
 
-	slog.Info("Contact form sent successfully",
+	slog.InfoContext(c.Request.Context(), "Contact form sent successfully",
 		"website", website,
 		"email", contactForm.Email,
 	)
 
-	c.JSON(http.StatusOK, Response{
+	a.notifyWebhooks(site, contactForm, website)
+	a.sendAutoReply(site, contactForm, website, !listed.Trusted() && site.AntiSpam.ProofOfWork.Enabled)
+
+	respond(c, http.StatusOK, Response{
 		Success: true,
-		Message: "Your message has been sent successfully! We will get back to you soon.",
+		Message: i18n.T(locale, "contact.sent"),
 	})
 }
 
-// WebsiteHealthCheck provides a health check for a specific website configuration
+// WebsiteHealthCheck reports whether a website exists and accepts submissions
+// without exposing any of its configuration
 // @Summary Health check for website
-// @Description Check if the contact form is properly configured for a website
+// @Description Check if the contact form exists and is enabled for a website
 // @Tags health
 // @Produce json
 // @Param website path string true "Website identifier" example:"main"
 // @Success 200 {object} Response
+// @Failure 404 {object} Response
 // @Router /contact/{website}/health [get]
 func (a *API) WebsiteHealthCheck(c *gin.Context) {
 	website := c.Param("website")
-	recipient := a.getRecipientForWebsite(website)
+	site, ok := a.lookupWebsite(website)
+	if !ok {
+		fail(c, http.StatusNotFound, CodeWebsiteNotFound, "Website not found")
+		return
+	}
 
-	c.JSON(http.StatusOK, Response{
-		Success: true,
-		Message: "Website contact form is configured",
-		Data: map[string]string{
-			"website":   website,
-			"recipient": recipient,
-			"smtp_host": a.Config.SMTPHost,
+	message := "Website contact form is enabled"
+	if site.Disabled {
+		message = "Website contact form is disabled"
+	}
+
+	respond(c, http.StatusOK, Response{
+		Success: !site.Disabled,
+		Message: message,
+		Data: map[string]any{
+			"website": website,
+			"enabled": !site.Disabled,
 		},
 	})
 }
@@ -140,28 +379,413 @@ func (a *API) WebsiteHealthCheck(c *gin.Context) {
 // @Success 200 {object} Response
 // @Router /health [get]
 func (a *API) HealthCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, Response{
+	respond(c, http.StatusOK, Response{
 		Success: true,
 		Message: "Contact API service is running",
 	})
 }
 
-// getRecipientForWebsite returns the email recipient for a specific website
-// This is where future website-specific configuration can be added
-func (a *API) getRecipientForWebsite(_ string) string {
-	// For now, use the default recipient for all websites
-	// In the future, this could look up website-specific recipients from:
-	// - Environment variables (e.g., RECIPIENT_WEBSITE_MAIN)
-	// - Configuration file
-	// - Database
-	return a.Config.DefaultTo
+// LivenessCheck reports whether the process is alive without probing dependencies
+// @Summary Liveness probe
+// @Description Check if the Contact API process is alive
+// @Tags health
+// @Produce json
+// @Success 200 {object} Response
+// @Router /livez [get]
+func (a *API) LivenessCheck(c *gin.Context) {
+	respond(c, http.StatusOK, Response{
+		Success: true,
+		Message: "Contact API service is alive",
+	})
+}
+
+// ReadinessCheck reports whether the dependencies needed to deliver messages are available
+// @Summary Readiness probe
+// @Description Check SMTP and other dependencies, returning per-dependency status and latency
+// @Tags health
+// @Produce json
+// @Success 200 {object} Response{data=health.Report}
+// @Failure 503 {object} Response{data=health.Report}
+// @Router /readyz [get]
+func (a *API) ReadinessCheck(c *gin.Context) {
+	// Detach from the request so a disconnecting client does not poison the cached report
+	report := a.Health.Check(context.WithoutCancel(c.Request.Context()))
+
+	if !report.Ready() {
+		respond(c, http.StatusServiceUnavailable, Response{
+			Success: false,
+			Message: "Contact API service is not ready",
+			Code:    CodeNotReady,
+			Data:    report,
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, Response{
+		Success: true,
+		Message: "Contact API service is ready",
+		Data:    report,
+	})
+}
+
+// deliver builds the notification email for a submission and sends it
+// through the website's transports
+func (a *API) deliver(site config.Website, form ContactFormData, website string) (delivery.Result, error) {
+	if route, _ := a.matchRoute(site, form); !route.Notifies(config.ChannelEmail) {
+		return delivery.Result{}, nil
+	}
+	return a.Delivery.Deliver(context.Background(), website, site.Transports, a.composeEmail(site, form, website))
+}
+
+// composeEmail builds the notification email for a submission, addressed and
+// tagged by the route it takes. It is always sent from a configured sender,
+// never from the visitor, who is only named in Reply-To: messages are
+// DKIM-signed for their sender domain.
+func (a *API) composeEmail(site config.Website, form ContactFormData, website string) email.Request {
+	route, _ := a.matchRoute(site, form)
+	emailReq := email.Request{
+		From:    site.From,
+		ReplyTo: form.Email,
+		To:      a.routeRecipients(site, route),
+		Subject: tagSubject(spamTag(form.Spam), tagSubject(route.SubjectTag, a.formatSubject(site, form, website))),
+		Body:    a.formatBody(site, form, website),
+		HTML:    true,
+		Headers: email.PriorityHeaders(route.Priority),
+	}
+	if emailReq.From == "" {
+		emailReq.From = a.Config().DefaultFrom
+	}
+	return emailReq
+}
+
+// composeAutoReply builds the confirmation email sent back to the visitor,
+// with replies going to the website recipients. The message and custom
+// fields are left out, so the reply cannot carry content to a third party.
+func (a *API) composeAutoReply(site config.Website, form ContactFormData, website string) email.Request {
+	form.Message, form.Fields = "", nil
+	locale := catalogLocale(site, form.Locale)
+	from := site.From
+	if from == "" {
+		from = a.Config().DefaultFrom
+	}
+
+	subject := i18n.T(locale, "reply.subject")
+	if text := localizedTemplate(site, site.AutoReply.Locales, site.AutoReply.SubjectTemplate, form.Locale, subjectTemplate); text != "" {
+		rendered, err := renderSubject(text, form, website)
+		if err == nil {
+			subject = rendered
+		} else {
+			slog.Error("Failed to render auto-reply subject template", "error", err, "website", website, "locale", form.Locale)
+		}
+	}
+
+	body := formatAutoReply(locale, form, website)
+	if text := localizedTemplate(site, site.AutoReply.Locales, site.AutoReply.BodyTemplate, form.Locale, bodyTemplate); text != "" {
+		rendered, err := renderBody(text, form, website)
+		if err == nil {
+			body = rendered
+		} else {
+			slog.Error("Failed to render auto-reply body template", "error", err, "website", website, "locale", form.Locale)
+		}
+	}
+
+	route, _ := a.matchRoute(site, form)
+	return email.Request{
+		From:    from,
+		To:      form.Email,
+		ReplyTo: a.routeRecipients(site, route),
+		Subject: subject,
+		Body:    body,
+		HTML:    true,
+	}
+}
+
+// sendAutoReply confirms a submission to the visitor in the background when
+// the website enables it, through the website's transports. Only submissions
+// that solved a challenge or scored no spam points get a reply, at most
+// autoReplyLimit per address and window. Failures are logged and never
+// affect the response.
+func (a *API) sendAutoReply(site config.Website, form ContactFormData, website string, challenged bool) {
+	// Replying to unverified submissions would send mail to forged addresses
+	clean := form.Spam != nil && form.Spam.Score == 0
+	if !site.AutoReply.Enabled || !challenged && !clean {
+		return
+	}
+	if !a.replies.Allow(form.Email) {
+		slog.Warn("Skipped auto-reply over the limit for its address", "website", website)
+		return
+	}
+
+	req := a.composeAutoReply(site, form, website)
+	go func() {
+		if _, err := a.Delivery.Deliver(context.Background(), website, site.Transports, req); err != nil {
+			slog.Error("Failed to send auto-reply", "error", err, "website", website)
+		}
+	}()
+}
+
+// Website returns the definition of an enabled website, configured or stored
+func (a *API) Website(website string) (config.Website, error) {
+	site, ok := a.lookupWebsite(website)
+	if !ok || site.Disabled {
+		return config.Website{}, ErrWebsiteNotFound
+	}
+	return site, nil
+}
+
+// Compose builds the notification email a submission to a website produces
+func (a *API) Compose(website string, form ContactFormData) (email.Request, error) {
+	site, err := a.Website(website)
+	if err != nil {
+		return email.Request{}, err
+	}
+	form.Locale = siteLocale(site, form.Locale, site.DefaultLocale)
+	return a.composeEmail(site, form, website), nil
+}
+
+// Resend delivers a stored submission again and records the attempt. The
+// updated submission is returned along with the delivery error, if any.
+func (a *API) Resend(sub storage.Submission) (storage.Submission, error) {
+	site, ok := a.lookupWebsite(sub.Website)
+	if !ok {
+		return sub, ErrWebsiteNotFound
+	}
+
+	form := ContactFormData{
+		Name:    sub.Name,
+		Email:   sub.Email,
+		Subject: sub.Subject,
+		Message: sub.Message,
+		Locale:  siteLocale(site, sub.Locale, site.DefaultLocale),
+		Fields:  sub.Fields,
+	}
+	// Submissions released from quarantine are delivered untagged
+	if sub.Spam != nil && sub.Spam.Action == spam.ActionTag {
+		form.Spam = &spam.Result{Score: sub.Spam.Score, Action: sub.Spam.Action}
+	}
+	result, sendErr := a.deliver(site, form, sub.Website)
+	return a.recordSubmission(sub, form, sub.Website, result, sendErr), sendErr
+}
+
+// recordSubmission stores the outcome of a delivery attempt and the
+// transport that delivered it
+func (a *API) recordSubmission(sub storage.Submission, form ContactFormData, website string, result delivery.Result, sendErr error) storage.Submission {
+	sub = a.fillSubmission(sub, form, website)
+	sub.Attempts++
+	sub.Status = storage.StatusSent
+	sub.Transport = result.Transport
+	sub.Error = ""
+	sub.Permanent = false
+	switch {
+	case sendErr != nil:
+		sub.Status = storage.StatusFailed
+		sub.Error = sendErr.Error()
+		sub.Permanent = email.Permanent(sendErr)
+	case result.Type == config.TransportStore:
+		sub.Status = storage.StatusStored
+	}
+	return a.saveSubmission(sub)
+}
+
+// quarantine stores a submission flagged as spam without delivering it, so
+// it can be reviewed and released with a resend
+func (a *API) quarantine(form ContactFormData, website string) storage.Submission {
+	sub := a.fillSubmission(storage.Submission{}, form, website)
+	sub.Status = storage.StatusQuarantined
+	return a.saveSubmission(sub)
+}
+
+// fillSubmission copies a submitted form into its stored record
+func (a *API) fillSubmission(sub storage.Submission, form ContactFormData, website string) storage.Submission {
+	sub.Website = website
+	sub.Name = form.Name
+	sub.Email = form.Email
+	sub.Subject = form.Subject
+	sub.Message = form.Message
+	sub.Locale = form.Locale
+	sub.Fields = form.Fields
+	if form.Spam != nil {
+		sub.Spam = spamVerdict(form.Spam)
+	}
+	if site, ok := a.lookupWebsite(website); ok {
+		_, sub.Route = a.matchRoute(site, form)
+	}
+	return sub
+}
+
+// saveSubmission stores a submission. Storage failures are logged but never
+// fail the request, since the message itself was handled.
+func (a *API) saveSubmission(sub storage.Submission) storage.Submission {
+	saved, err := a.Store.SaveSubmission(sub)
+	if err != nil {
+		slog.Error("Failed to store submission", "error", err, "website", sub.Website)
+	}
+	return saved
+}
+
+// lookupWebsite returns the configuration for a website. When no websites are
+// configured every identifier is accepted and delivered to the default recipient.
+func (a *API) lookupWebsite(website string) (config.Website, bool) {
+	websites := a.websiteMap()
+	if len(websites) == 0 {
+		return config.Website{}, true
+	}
+	site, ok := websites[website]
+	return site, ok
+}
+
+// websiteSet is a snapshot of the website definitions and the routing
+// patterns they use
+type websiteSet struct {
+	sites    map[string]config.Website
+	patterns map[string]*regexp.Regexp
+}
+
+// websiteMap returns the cached website definitions
+func (a *API) websiteMap() map[string]config.Website {
+	if websites := a.websites.Load(); websites != nil {
+		return websites.sites
+	}
+	return nil
+}
+
+// routePatterns returns the compiled routing patterns of the cached websites
+func (a *API) routePatterns() map[string]*regexp.Regexp {
+	if websites := a.websites.Load(); websites != nil {
+		return websites.patterns
+	}
+	return nil
+}
+
+// refreshWebsites rebuilds the website cache from the configuration file and
+// storage. Stored definitions override configured ones and tombstones hide them.
+// Routing patterns are compiled again, so patterns no longer used are dropped.
+func (a *API) refreshWebsites() {
+	a.refreshMu.Lock()
+	defer a.refreshMu.Unlock()
+
+	websites := make(map[string]config.Website, len(a.Config().Websites))
+	for name, site := range a.Config().Websites {
+		websites[name] = site
+	}
+
+	for name, version := range a.Store.LatestWebsiteVersions() {
+		if version.Deleted {
+			delete(websites, name)
+			continue
+		}
+		websites[name] = version.Config
+	}
+
+	a.websites.Store(&websiteSet{sites: websites, patterns: compilePatterns(websites)})
+}
+
+// getRecipientForWebsite returns the email recipients for a website as a
+// comma-separated list, falling back to the default recipient
+func (a *API) getRecipientForWebsite(site config.Website) string {
+	if len(site.Recipients) == 0 {
+		return a.Config().DefaultTo
+	}
+	return strings.Join(site.Recipients, ", ")
+}
+
+// templateData is the data available to website subject and body templates
+type templateData struct {
+	ContactFormData
+	Website string
+}
+
+// formatSubject renders the email subject using the website's template, or
+// the default format when none is configured or rendering fails
+func (a *API) formatSubject(site config.Website, form ContactFormData, website string) string {
+	if text := localizedTemplate(site, site.Locales, site.SubjectTemplate, form.Locale, subjectTemplate); text != "" {
+		subject, err := renderSubject(text, form, website)
+		if err == nil {
+			return subject
+		}
+		slog.Error("Failed to render subject template", "error", err, "website", website, "locale", form.Locale)
+	}
+	return i18n.T(catalogLocale(site, form.Locale), "email.subject", website, form.Subject)
+}
+
+// renderSubject executes a subject template against the form data
+func renderSubject(text string, form ContactFormData, website string) (string, error) {
+	tmpl, err := template.New("subject").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, templateData{ContactFormData: form, Website: website}); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// formatBody renders the email body using the website's template, or the
+// default layout when none is configured or rendering fails
+func (a *API) formatBody(site config.Website, form ContactFormData, website string) string {
+	if text := localizedTemplate(site, site.Locales, site.BodyTemplate, form.Locale, bodyTemplate); text != "" {
+		body, err := renderBody(text, form, website)
+		if err == nil {
+			return body
+		}
+		slog.Error("Failed to render body template", "error", err, "website", website, "locale", form.Locale)
+	}
+	return formatContactEmail(catalogLocale(site, form.Locale), form, website)
+}
+
+// renderBody executes an HTML body template against the form data, escaping
+// the submitted values
+func renderBody(text string, form ContactFormData, website string) (string, error) {
+	tmpl, err := htmltemplate.New("body").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, templateData{ContactFormData: form, Website: website}); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// notifyWebhooks posts the submission to the website's webhooks in the background
+func (a *API) notifyWebhooks(site config.Website, form ContactFormData, website string) {
+	route, _ := a.matchRoute(site, form)
+	urls := routeWebhooks(site, route)
+	if len(urls) == 0 || !route.Notifies(config.ChannelWebhook) {
+		return
+	}
+
+	payload := map[string]any{
+		"website":     website,
+		"name":        form.Name,
+		"email":       form.Email,
+		"subject":     form.Subject,
+		"message":     form.Message,
+		"locale":      form.Locale,
+		"fields":      form.Fields,
+		"received_at": time.Now().UTC(),
+	}
+	if form.Spam != nil {
+		payload["spam"] = form.Spam
+	}
+
+	for _, url := range urls {
+		go func(url string) {
+			if err := a.Webhooks.Post(context.Background(), url, site.WebhookSecret, payload); err != nil {
+				slog.Error("Failed to notify webhook", "error", err, "website", website)
+			}
+		}(url)
+	}
 }
 
-// formatContactEmail formats the contact form data as an HTML email
-func (a *API) formatContactEmail(form ContactFormData, website string) string {
-	return fmt.Sprintf(`
+// defaultBodyTemplate is the notification layout used when a website has no
+// body template. Labels come from the message catalog.
+var defaultBodyTemplate = htmltemplate.Must(htmltemplate.New("default-body").Parse(`
 <!DOCTYPE html>
-<html>
+<html lang="{{.Locale}}">
 <head>
     <style>
         body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
@@ -179,28 +803,89 @@ func (a *API) formatContactEmail(form ContactFormData, website string) string {
 <body>
     <div class="container">
         <div class="header">
-            <h2>New Contact Form Submission</h2>
-            <span class="website-badge">%s</span>
+            <h2>{{.Text.title}}</h2>
+            <span class="website-badge">{{.Website}}</span>
         </div>
         <div class="content">
             <div class="field">
-                <div class="label">Name:</div>
-                <div class="value">%s</div>
+                <div class="label">{{.Text.name}}:</div>
+                <div class="value">{{.Name}}</div>
+            </div>
+            <div class="field">
+                <div class="label">{{.Text.email}}:</div>
+                <div class="value">{{.Email}}</div>
             </div>
             <div class="field">
-                <div class="label">Email:</div>
-                <div class="value">%s</div>
+                <div class="label">{{.Text.subject_label}}:</div>
+                <div class="value">{{.Subject}}</div>
             </div>
+{{- range $name, $value := .Fields}}
             <div class="field">
-                <div class="label">Subject:</div>
-                <div class="value">%s</div>
+                <div class="label">{{$name}}:</div>
+                <div class="value">{{$value}}</div>
             </div>
+{{- end}}
             <div class="field">
-                <div class="label">Message:</div>
-                <div class="message">%s</div>
+                <div class="label">{{.Text.message}}:</div>
+                <div class="message">{{.Message}}</div>
             </div>
         </div>
     </div>
 </body>
-</html>`, website, form.Name, form.Email, form.Subject, form.Message)
+</html>`))
+
+// defaultAutoReplyTemplate is the auto-reply layout used when a website has no
+// auto-reply body template
+var defaultAutoReplyTemplate = htmltemplate.Must(htmltemplate.New("default-auto-reply").Parse(`
+<!DOCTYPE html>
+<html lang="{{.Locale}}">
+<head>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <p>{{.Text.greeting}}</p>
+        <p>{{.Text.body}}</p>
+    </div>
+</body>
+</html>`))
+
+// defaultTemplateData is the data of the built-in templates
+type defaultTemplateData struct {
+	templateData
+	Text map[string]string
+}
+
+// formatContactEmail formats the contact form data as an HTML email in the
+// given catalog locale, escaping the submitted values
+func formatContactEmail(locale string, form ContactFormData, website string) string {
+	return executeDefault(defaultBodyTemplate, form, website, map[string]string{
+		"title":         i18n.T(locale, "email.title"),
+		"name":          i18n.T(locale, "email.name"),
+		"email":         i18n.T(locale, "email.email"),
+		"subject_label": i18n.T(locale, "email.subject_label"),
+		"message":       i18n.T(locale, "email.message"),
+	})
+}
+
+// formatAutoReply formats the default auto-reply in the given catalog locale
+func formatAutoReply(locale string, form ContactFormData, website string) string {
+	return executeDefault(defaultAutoReplyTemplate, form, website, map[string]string{
+		"greeting": i18n.T(locale, "reply.greeting", form.Name),
+		"body":     i18n.T(locale, "reply.body"),
+	})
+}
+
+// executeDefault renders a built-in template. The templates are fixed, so a
+// failure can only come from the writer and yields an empty body.
+func executeDefault(tmpl *htmltemplate.Template, form ContactFormData, website string, text map[string]string) string {
+	var b strings.Builder
+	_ = tmpl.Execute(&b, defaultTemplateData{
+		templateData: templateData{ContactFormData: form, Website: website},
+		Text:         text,
+	})
+	return b.String()
 }
@@ -3,14 +3,25 @@ package handlers
 import (
 	"bytes"
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/email"
+	"github.com/nahuelsantos/contact-api/internal/health"
+	"github.com/nahuelsantos/contact-api/internal/middleware"
 )
 
 func TestMain(m *testing.M) {
@@ -26,7 +37,7 @@ func setupTestAPI() *gin.Engine {
 		DefaultTo:   "contact@example.com",
 	}
 
-	api := New(cfg)
+	api := New(cfg, nil)
 	r := gin.New()
 
 	// Setup routes
@@ -36,6 +47,8 @@ func setupTestAPI() *gin.Engine {
 		v1.GET("/contact/:website/health", api.WebsiteHealthCheck)
 	}
 	r.GET("/health", api.HealthCheck)
+	r.GET("/livez", api.LivenessCheck)
+	r.GET("/readyz", api.ReadinessCheck)
 
 	return r
 }
@@ -70,6 +83,28 @@ func TestHealthCheck(t *testing.T) {
 	}
 }
 
+func TestResponse_RequestID(t *testing.T) {
+	r := gin.New()
+	r.Use(middleware.RequestID())
+	r.GET("/health", New(config.Config{}, nil).HealthCheck)
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequestWithContext(context.Background(), "GET", "/health", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set(middleware.RequestIDHeader, "health-check-1")
+	r.ServeHTTP(w, req)
+
+	var response Response
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Errorf("Error unmarshaling response: %v", err)
+	}
+	if response.RequestID != "health-check-1" {
+		t.Errorf("Expected request ID 'health-check-1', got '%s'", response.RequestID)
+	}
+}
+
 func TestWebsiteHealthCheck(t *testing.T) {
 	r := setupTestAPI()
 
@@ -203,6 +238,123 @@ func TestContactHandler_MissingRequiredFields(t *testing.T) {
 	}
 }
 
+func TestContactHandler_FieldErrors(t *testing.T) {
+	r := setupTestAPI()
+
+	tests := []struct {
+		name         string
+		body         string
+		expectedCode string
+		expected     []FieldError
+	}{
+		{
+			name:         "missing and invalid fields",
+			body:         `{"email": "not-an-email", "subject": "Hi", "message": "Hello"}`,
+			expectedCode: CodeValidationFailed,
+			expected: []FieldError{
+				{Field: "name", Code: FieldRequired},
+				{Field: "email", Code: FieldInvalidEmail},
+			},
+		},
+		{
+			name:         "too long",
+			body:         `{"name": "` + strings.Repeat("a", 201) + `", "email": "john@example.com", "subject": "Hi", "message": "Hello"}`,
+			expectedCode: CodeValidationFailed,
+			expected:     []FieldError{{Field: "name", Code: FieldTooLong}},
+		},
+		{
+			name:         "line breaks in header values",
+			body:         `{"name": "John\nDoe", "email": "john@example.com", "subject": "Hi\r\nBcc: victim@example.com", "message": "Hello\nthere"}`,
+			expectedCode: CodeValidationFailed,
+			expected: []FieldError{
+				{Field: "name", Code: FieldInvalidFormat},
+				{Field: "subject", Code: FieldInvalidFormat},
+			},
+		},
+		{
+			name:         "wrong type",
+			body:         `{"name": 42, "email": "john@example.com", "subject": "Hi", "message": "Hello"}`,
+			expectedCode: CodeValidationFailed,
+			expected:     []FieldError{{Field: "name", Code: FieldInvalidType}},
+		},
+		{
+			name:         "malformed JSON",
+			body:         `{"name": `,
+			expectedCode: CodeInvalidRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, err := http.NewRequestWithContext(context.Background(), "POST", "/api/v1/contact/main", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+			}
+
+			var response Response
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Error unmarshaling response: %v", err)
+			}
+			if response.Code != tt.expectedCode {
+				t.Errorf("Expected code '%s', got '%s'", tt.expectedCode, response.Code)
+			}
+			if strings.Contains(response.Message, "ContactFormData") {
+				t.Errorf("Expected message without validator internals, got '%s'", response.Message)
+			}
+			if len(response.Errors) != len(tt.expected) {
+				t.Fatalf("Expected %d field errors, got %v", len(tt.expected), response.Errors)
+			}
+			for i, expected := range tt.expected {
+				got := response.Errors[i]
+				if got.Field != expected.Field || got.Code != expected.Code || got.Message == "" {
+					t.Errorf("Expected field error %s/%s, got %+v", expected.Field, expected.Code, got)
+				}
+			}
+		})
+	}
+}
+
+func TestContactHandler_ProblemJSON(t *testing.T) {
+	r := setupTestAPI()
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequestWithContext(context.Background(), "POST", "/api/v1/contact/main", strings.NewReader(`{"name": "John"}`))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/problem+json")
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/problem+json" {
+		t.Errorf("Expected content type application/problem+json, got '%s'", contentType)
+	}
+
+	var problem Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("Error unmarshaling problem: %v", err)
+	}
+	if problem.Status != http.StatusBadRequest || problem.Title != "Bad Request" {
+		t.Errorf("Expected status 400 Bad Request, got %d %s", problem.Status, problem.Title)
+	}
+	if problem.Code != CodeValidationFailed || len(problem.Errors) != 3 {
+		t.Errorf("Expected %s with 3 field errors, got %s %v", CodeValidationFailed, problem.Code, problem.Errors)
+	}
+	if problem.Instance != "/api/v1/contact/main" {
+		t.Errorf("Expected instance '/api/v1/contact/main', got '%s'", problem.Instance)
+	}
+}
+
 func TestContactHandler_ValidRequest(t *testing.T) {
 	r := setupTestAPI()
 
@@ -246,3 +398,143 @@ func TestContactHandler_ValidRequest(t *testing.T) {
 		t.Errorf("Expected message '%s', got '%s'", expectedMessage, response.Message)
 	}
 }
+
+func TestContactHandler_DKIMSender(t *testing.T) {
+	var data []byte
+	originalDialer := email.DefaultSMTPDialer
+	email.DefaultSMTPDialer = func(addr string) (email.SMTPClient, error) {
+		writer := &email.MockWriteCloser{}
+		return &email.MockSMTPClient{
+			DataFunc:  func() (io.WriteCloser, error) { return writer, nil },
+			CloseFunc: func() error { data = writer.Data; return nil },
+		}, nil
+	}
+	defer func() { email.DefaultSMTPDialer = originalDialer }()
+
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to encode key: %v", err)
+	}
+	cfg := config.Config{
+		SMTPHost:    "localhost",
+		SMTPPort:    "1025",
+		DefaultFrom: "noreply@example.com",
+		DefaultTo:   "contact@example.com",
+		DKIM: config.DKIM{Keys: []config.DKIMKey{{
+			Domain:     "forms.example",
+			Selector:   "mail",
+			PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		}}},
+		Websites: map[string]config.Website{
+			"main":  {From: "contact@forms.example"},
+			"plain": {},
+		},
+	}
+	api := New(cfg, nil)
+	r := gin.New()
+	r.POST("/api/v1/contact/:website", api.ContactHandler)
+
+	tests := []struct {
+		name           string
+		website        string
+		expectedFrom   string
+		expectedSigned bool
+	}{
+		{name: "website sender", website: "main", expectedFrom: "contact@forms.example", expectedSigned: true},
+		{name: "visitor on a keyed domain", website: "plain", expectedFrom: "noreply@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data = nil
+			form := ContactFormData{Name: "Jane", Email: "ceo@forms.example", Subject: "Hello", Message: "Hello"}
+			if w, response := doAdminRequest(t, r, "POST", "/api/v1/contact/"+tt.website, "", form); w.Code != http.StatusOK {
+				t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, response.Message)
+			}
+
+			message := string(data)
+			if signed := strings.HasPrefix(message, "DKIM-Signature: "); signed != tt.expectedSigned {
+				t.Errorf("Expected signed to be %v, got:\n%s", tt.expectedSigned, message)
+			}
+			if !strings.Contains(message, "\r\nFrom: "+tt.expectedFrom+"\r\n") {
+				t.Errorf("Expected the message from %s, got:\n%s", tt.expectedFrom, message)
+			}
+			if !strings.Contains(message, "\r\nReply-To: ceo@forms.example\r\n") {
+				t.Errorf("Expected replies to go to the visitor, got:\n%s", message)
+			}
+		})
+	}
+}
+
+func TestLivenessCheck(t *testing.T) {
+	r := setupTestAPI()
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequestWithContext(context.Background(), "GET", "/livez", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestReadinessCheck(t *testing.T) {
+	tests := []struct {
+		name         string
+		check        func(ctx context.Context) error
+		expectedCode int
+	}{
+		{
+			name:         "ready",
+			check:        func(_ context.Context) error { return nil },
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "degraded is still ready",
+			check:        func(_ context.Context) error { return health.ErrDegraded },
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "not ready",
+			check:        func(_ context.Context) error { return errors.New("connection refused") },
+			expectedCode: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := New(config.Config{}, nil)
+			api.Health = health.NewMonitor(time.Minute, time.Second, health.CheckFunc("smtp", tt.check))
+
+			r := gin.New()
+			r.GET("/readyz", api.ReadinessCheck)
+
+			w := httptest.NewRecorder()
+			req, err := http.NewRequestWithContext(context.Background(), "GET", "/readyz", nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status code %d, got %d", tt.expectedCode, w.Code)
+			}
+
+			var response struct {
+				Success bool          `json:"success"`
+				Data    health.Report `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Error unmarshaling response: %v", err)
+			}
+
+			if _, ok := response.Data.Checks["smtp"]; !ok {
+				t.Errorf("Expected smtp check in response data, got %+v", response.Data.Checks)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/i18n"
+	"github.com/nahuelsantos/contact-api/internal/pow"
+)
+
+// IssueChallenge issues a proof of work challenge for a website
+// @Summary Get proof of work challenge
+// @Description Issue a signed, expiring puzzle for a website that requires proof of work. Find a decimal solution whose SHA-256 hash of "challenge:solution" starts with difficulty zero bits, then send challenge and solution with the submission. Each challenge is accepted once. /api/v1/pow.js solves challenges in the browser.
+// @Tags contact
+// @Produce json
+// @Param website path string true "Website identifier" example:"main"
+// @Success 200 {object} Response{data=pow.Challenge}
+// @Failure 404 {object} Response
+// @Router /contact/{website}/challenge [get]
+func (a *API) IssueChallenge(c *gin.Context) {
+	website := c.Param("website")
+	site, ok := a.lookupWebsite(website)
+	if !ok || site.Disabled {
+		fail(c, http.StatusNotFound, CodeWebsiteNotFound, i18n.T(requestLocale(c, config.Website{}, ""), "website.not_found"))
+		return
+	}
+	if !site.AntiSpam.ProofOfWork.Enabled {
+		fail(c, http.StatusNotFound, CodeChallengeDisabled, "Website does not require proof of work")
+		return
+	}
+
+	difficulty, ttl := proofOfWork(site)
+	c.Header("Cache-Control", "no-store")
+	respond(c, http.StatusOK, Response{
+		Success: true,
+		Message: "Challenge issued",
+		Data:    a.Challenges.Issue(website, difficulty, ttl),
+	})
+}
+
+// ChallengeScript serves the JavaScript that solves proof of work challenges
+// @Summary Proof of work solver
+// @Description JavaScript defining ContactPow.solve(endpoint), which fetches and solves a challenge for a contact endpoint and resolves to the challenge and solution fields of the submission
+// @Tags contact
+// @Produce application/javascript
+// @Success 200 {string} string
+// @Router /pow.js [get]
+func (a *API) ChallengeScript(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Data(http.StatusOK, "text/javascript; charset=utf-8", pow.Solver)
+}
+
+// checkChallenge checks the proof of work of a submission to a website that
+// requires one without using up the challenge, returning the error code and
+// message key when it fails
+func (a *API) checkChallenge(site config.Website, form ContactFormData, website string) (code, key string, ok bool) {
+	if !site.AntiSpam.ProofOfWork.Enabled {
+		return "", "", true
+	}
+	difficulty, _ := proofOfWork(site)
+	return challengeError(a.Challenges.Check(website, form.Challenge, form.Solution, difficulty))
+}
+
+// useChallenge verifies the proof of work of a submission again and marks the
+// challenge as used, once the rest of the submission has been judged
+func (a *API) useChallenge(site config.Website, form ContactFormData, website string) (code, key string, ok bool) {
+	if !site.AntiSpam.ProofOfWork.Enabled {
+		return "", "", true
+	}
+	difficulty, _ := proofOfWork(site)
+	return challengeError(a.Challenges.Verify(website, form.Challenge, form.Solution, difficulty))
+}
+
+// challengeError maps a verification error to an error code and message key
+func challengeError(err error) (code, key string, ok bool) {
+	switch {
+	case err == nil:
+		return "", "", true
+	case errors.Is(err, pow.ErrMissing):
+		return CodeChallengeRequired, "challenge.required", false
+	case errors.Is(err, pow.ErrExpired):
+		return CodeChallengeExpired, "challenge.expired", false
+	default:
+		return CodeChallengeFailed, "challenge.failed", false
+	}
+}
+
+// proofOfWork returns the difficulty and lifetime of a website's challenges
+func proofOfWork(site config.Website) (int, time.Duration) {
+	difficulty, ttl := site.AntiSpam.ProofOfWork.Difficulty, time.Duration(site.AntiSpam.ProofOfWork.TTL)
+	if difficulty == 0 {
+		difficulty = pow.DefaultDifficulty
+	}
+	if ttl == 0 {
+		ttl = pow.DefaultTTL
+	}
+	return difficulty, ttl
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/email"
+	"github.com/nahuelsantos/contact-api/internal/pow"
+)
+
+func TestContactHandler_ProofOfWork(t *testing.T) {
+	originalDialer := email.DefaultSMTPDialer
+	email.DefaultSMTPDialer = func(addr string) (email.SMTPClient, error) {
+		return &email.MockSMTPClient{}, nil
+	}
+	defer func() { email.DefaultSMTPDialer = originalDialer }()
+
+	api, r := setupAdminAPI(t)
+	r.GET("/api/v1/contact/:website/challenge", api.IssueChallenge)
+
+	if w, response := doAdminRequest(t, r, "GET", "/api/v1/contact/main/challenge", "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d before enabling, got %d (%s)", http.StatusNotFound, w.Code, response.Code)
+	}
+	doAdminRequest(t, r, "PUT", "/api/v1/admin/websites/main", "root-token", config.Website{
+		AntiSpam: config.AntiSpam{ProofOfWork: config.ProofOfWork{Enabled: true, Difficulty: 8}, MaxLinks: 1},
+	})
+
+	w, response := doAdminRequest(t, r, "GET", "/api/v1/contact/main/challenge", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, response.Message)
+	}
+	var challenge pow.Challenge
+	decodeData(t, response, &challenge)
+	if challenge.Difficulty != 8 || !challenge.ExpiresAt.After(time.Now()) {
+		t.Errorf("Unexpected challenge: %+v", challenge)
+	}
+	solution := pow.Solve(challenge.Token, challenge.Difficulty)
+	wrong := "x"
+	for n := 0; pow.LeadingZeroBits(pow.Hash(challenge.Token, wrong)) >= 8; n++ {
+		wrong = "x" + strconv.Itoa(n)
+	}
+
+	expired := api.Challenges.Issue("main", 8, -time.Second)
+	easy := api.Challenges.Issue("main", 2, time.Minute)
+	other := api.Challenges.Issue("blog", 8, time.Minute)
+
+	tests := []struct {
+		name           string
+		challenge      string
+		solution       string
+		message        string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "missing", expectedStatus: http.StatusBadRequest, expectedCode: CodeChallengeRequired},
+		{name: "wrong solution", challenge: challenge.Token, solution: wrong, expectedStatus: http.StatusBadRequest, expectedCode: CodeChallengeFailed},
+		{name: "expired", challenge: expired.Token, solution: pow.Solve(expired.Token, 8), expectedStatus: http.StatusBadRequest, expectedCode: CodeChallengeExpired},
+		{name: "under difficulty", challenge: easy.Token, solution: pow.Solve(easy.Token, 2), expectedStatus: http.StatusBadRequest, expectedCode: CodeChallengeFailed},
+		{name: "other website", challenge: other.Token, solution: pow.Solve(other.Token, 8), expectedStatus: http.StatusBadRequest, expectedCode: CodeChallengeFailed},
+		{name: "rejected message", challenge: challenge.Token, solution: solution, message: "https://a.example https://b.example", expectedStatus: http.StatusBadRequest, expectedCode: CodeTooManyLinks},
+		{name: "solved after correcting", challenge: challenge.Token, solution: solution, expectedStatus: http.StatusOK},
+		{name: "reused", challenge: challenge.Token, solution: solution, expectedStatus: http.StatusBadRequest, expectedCode: CodeChallengeFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := tt.message
+			if message == "" {
+				message = "Hello"
+			}
+			form := ContactFormData{
+				Name:      "John",
+				Email:     "john@example.com",
+				Subject:   "Hello",
+				Message:   message,
+				Challenge: tt.challenge,
+				Solution:  tt.solution,
+			}
+			w, response := doAdminRequest(t, r, "POST", "/api/v1/contact/main", "", form)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status code %d, got %d: %s", tt.expectedStatus, w.Code, response.Message)
+			}
+			if response.Code != tt.expectedCode {
+				t.Errorf("Expected code '%s', got '%s'", tt.expectedCode, response.Code)
+			}
+		})
+	}
+}
+
+func TestChallengeScript(t *testing.T) {
+	api, r := setupLocaleAPI(nil)
+	r.GET("/api/v1/pow.js", api.ChallengeScript)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/pow.js", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/javascript") {
+		t.Errorf("Expected JavaScript, got '%s'", ct)
+	}
+	if !strings.Contains(w.Body.String(), "ContactPow") {
+		t.Error("Expected the solver script")
+	}
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsantos/contact-api/internal/devmail"
+)
+
+// DevMailMessage is a captured message with its source
+type DevMailMessage struct {
+	devmail.Message
+	Source string `json:"source"`
+}
+
+// RegisterDevMailRoutes registers the pages and JSON API of the development
+// mail catcher. They answer 404 unless dev_mail.enabled is set.
+func (a *API) RegisterDevMailRoutes(r gin.IRouter) {
+	dev := r.Group("/_dev/mail", a.requireDevMail)
+
+	dev.GET("", a.DevMailList)
+	dev.POST("/clear", a.DevMailClear)
+	dev.GET("/messages/:id", a.DevMailMessage)
+	dev.GET("/messages/:id/html", a.DevMailHTML)
+	dev.GET("/messages/:id/raw", a.DevMailRaw)
+
+	dev.GET("/api/messages", a.ListDevMail)
+	dev.GET("/api/messages/:id", a.GetDevMail)
+	dev.DELETE("/api/messages", a.ClearDevMail)
+}
+
+// requireDevMail hides the catcher unless it is enabled, as if its routes
+// did not exist
+func (a *API) requireDevMail(c *gin.Context) {
+	if !a.Config().DevMail.Enabled {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Next()
+}
+
+// DevMailList serves the page listing captured messages
+func (a *API) DevMailList(c *gin.Context) {
+	var page bytes.Buffer
+	if err := (devmail.ListPage{Messages: a.Delivery.DevMail().List()}).Render(&page); err != nil {
+		a.internalError(c, "Failed to render the dev mail page", err)
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+}
+
+// DevMailClear drops every captured message and goes back to the list
+func (a *API) DevMailClear(c *gin.Context) {
+	a.Delivery.DevMail().Clear()
+	c.Redirect(http.StatusSeeOther, "/_dev/mail")
+}
+
+// DevMailMessage serves the page of a captured message in the view of the
+// view parameter: html, text or raw
+func (a *API) DevMailMessage(c *gin.Context) {
+	msg, ok := a.devMailMessage(c)
+	if !ok {
+		return
+	}
+
+	var page bytes.Buffer
+	if err := (devmail.MessagePage{Message: msg, View: c.Query("view")}).Render(&page); err != nil {
+		a.internalError(c, "Failed to render the dev mail page", err)
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+}
+
+// DevMailHTML serves the HTML body of a captured message, sandboxed so its
+// scripts never run and remote content never loads
+func (a *API) DevMailHTML(c *gin.Context) {
+	msg, ok := a.devMailMessage(c)
+	if !ok {
+		return
+	}
+	if !msg.HTML {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	c.Header("Content-Security-Policy", "sandbox; default-src 'none'; style-src 'unsafe-inline'; img-src data:; frame-ancestors 'self'")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(msg.Body))
+}
+
+// DevMailRaw serves the source of a captured message as plain text
+func (a *API) DevMailRaw(c *gin.Context) {
+	msg, ok := a.devMailMessage(c)
+	if !ok {
+		return
+	}
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", msg.Raw)
+}
+
+// ListDevMail lists the captured messages, newest first
+func (a *API) ListDevMail(c *gin.Context) {
+	respond(c, http.StatusOK, Response{
+		Success: true,
+		Message: "Captured messages",
+		Data:    a.Delivery.DevMail().List(),
+	})
+}
+
+// GetDevMail returns a captured message with its source
+func (a *API) GetDevMail(c *gin.Context) {
+	msg, ok := a.Delivery.DevMail().Get(c.Param("id"))
+	if !ok {
+		fail(c, http.StatusNotFound, CodeMessageNotFound, "Message not found")
+		return
+	}
+	respond(c, http.StatusOK, Response{
+		Success: true,
+		Message: "Captured message",
+		Data:    DevMailMessage{Message: msg, Source: string(msg.Raw)},
+	})
+}
+
+// ClearDevMail drops every captured message
+func (a *API) ClearDevMail(c *gin.Context) {
+	a.Delivery.DevMail().Clear()
+	respond(c, http.StatusOK, Response{Success: true, Message: "Captured messages cleared"})
+}
+
+// devMailMessage looks up the captured message of a page, answering 404 when
+// it is unknown or was dropped from the catcher
+func (a *API) devMailMessage(c *gin.Context) (devmail.Message, bool) {
+	msg, ok := a.Delivery.DevMail().Get(c.Param("id"))
+	if !ok {
+		c.String(http.StatusNotFound, "Message not found")
+		return msg, false
+	}
+	return msg, true
+}
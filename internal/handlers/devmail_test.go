@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/email"
+)
+
+func setupDevMailAPI(devMail config.DevMail) (*API, *gin.Engine) {
+	cfg := config.Config{
+		SMTPHost:    "localhost",
+		SMTPPort:    "1025",
+		DefaultFrom: "test@example.com",
+		DefaultTo:   "contact@example.com",
+		DevMail:     devMail,
+		Websites: map[string]config.Website{
+			"main": {
+				AutoReply: config.AutoReply{Enabled: true},
+				AntiSpam:  config.AntiSpam{Scoring: config.SpamScoring{Enabled: true}},
+			},
+		},
+	}
+
+	api := New(cfg, nil)
+	r := gin.New()
+	r.POST("/api/v1/contact/:website", api.ContactHandler)
+	api.RegisterDevMailRoutes(r)
+	return api, r
+}
+
+func doDevMailRequest(t *testing.T, r *gin.Engine, method, path string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(context.Background(), method, path, nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestDevMail_Disabled(t *testing.T) {
+	_, r := setupDevMailAPI(config.DevMail{})
+
+	for _, path := range []string{"/_dev/mail", "/_dev/mail/messages/1", "/_dev/mail/api/messages"} {
+		if w := doDevMailRequest(t, r, "GET", path); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status code %d for %s, got %d", http.StatusNotFound, path, w.Code)
+		}
+	}
+}
+
+func TestDevMail_Capture(t *testing.T) {
+	originalDialer := email.DefaultSMTPDialer
+	email.DefaultSMTPDialer = func(addr string) (email.SMTPClient, error) {
+		t.Errorf("Expected no SMTP connection, got one to %s", addr)
+		return nil, errors.New("unexpected SMTP connection")
+	}
+	defer func() { email.DefaultSMTPDialer = originalDialer }()
+
+	api, r := setupDevMailAPI(config.DevMail{Enabled: true, Capacity: 10})
+
+	w, response := doAdminRequest(t, r, "POST", "/api/v1/contact/main", "", map[string]string{
+		"name": "Jane", "email": "jane@example.com", "subject": "Hello", "message": "Hi there",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, response.Message)
+	}
+
+	// The notification and the auto-reply are both captured
+	deadline := time.Now().Add(2 * time.Second)
+	for len(api.Delivery.DevMail().List()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	w, response = doAdminRequest(t, r, "GET", "/_dev/mail/api/messages", "", nil)
+	if messages, _ := response.Data.([]any); w.Code != http.StatusOK || len(messages) != 2 {
+		t.Fatalf("Expected 2 captured messages, got %d: %v", w.Code, response.Data)
+	}
+	notification := api.Delivery.DevMail().List()[1]
+	if notification.To != "contact@example.com" {
+		t.Errorf("Expected the notification first, got a message to %s", notification.To)
+	}
+
+	_, response = doAdminRequest(t, r, "GET", "/_dev/mail/api/messages/"+notification.ID, "", nil)
+	if data, _ := response.Data.(map[string]any); data["to"] != "contact@example.com" || !strings.Contains(data["source"].(string), "To: contact@example.com\r\n") {
+		t.Errorf("Expected the message with its source, got %v", response.Data)
+	}
+	if _, response = doAdminRequest(t, r, "GET", "/_dev/mail/api/messages/99", "", nil); response.Code != CodeMessageNotFound {
+		t.Errorf("Expected code '%s', got '%s'", CodeMessageNotFound, response.Code)
+	}
+
+	pages := []struct {
+		path        string
+		contentType string
+		expected    string
+	}{
+		{path: "/_dev/mail", contentType: "text/html", expected: `href="/_dev/mail/messages/` + notification.ID + `"`},
+		{path: "/_dev/mail/messages/" + notification.ID, contentType: "text/html", expected: `<iframe sandbox src="/_dev/mail/messages/` + notification.ID + `/html"`},
+		{path: "/_dev/mail/messages/" + notification.ID + "?view=text", contentType: "text/html", expected: "Hi there"},
+		{path: "/_dev/mail/messages/" + notification.ID + "/raw", contentType: "text/plain", expected: "To: contact@example.com\r\n"},
+	}
+	for _, page := range pages {
+		w := doDevMailRequest(t, r, "GET", page.path)
+		if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), page.contentType) {
+			t.Errorf("Expected %s from %s, got %d %s", page.contentType, page.path, w.Code, w.Header().Get("Content-Type"))
+		}
+		if !strings.Contains(w.Body.String(), page.expected) {
+			t.Errorf("Expected %s to contain %s, got:\n%s", page.path, page.expected, w.Body.String())
+		}
+	}
+
+	// The HTML body is served in a sandbox, and only for HTML messages
+	w = doDevMailRequest(t, r, "GET", "/_dev/mail/messages/"+notification.ID+"/html")
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Security-Policy"), "sandbox;") || !strings.Contains(w.Body.String(), "Hi there") {
+		t.Errorf("Expected the sandboxed HTML body, got %d %q: %s", w.Code, w.Header().Get("Content-Security-Policy"), w.Body.String())
+	}
+	text := api.Delivery.DevMail().Capture(email.Request{To: "contact@example.com", Body: "Hi"})
+	if w := doDevMailRequest(t, r, "GET", "/_dev/mail/messages/"+text.ID+"/html"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for the HTML of a text message, got %d", http.StatusNotFound, w.Code)
+	}
+
+	if w := doDevMailRequest(t, r, "POST", "/_dev/mail/clear"); w.Code != http.StatusSeeOther {
+		t.Errorf("Expected status code %d, got %d", http.StatusSeeOther, w.Code)
+	}
+	if messages := api.Delivery.DevMail().List(); len(messages) != 0 {
+		t.Errorf("Expected no messages after clearing, got %d", len(messages))
+	}
+	api.Delivery.DevMail().Capture(email.Request{To: "contact@example.com"})
+	if w, _ := doAdminRequest(t, r, "DELETE", "/_dev/mail/api/messages", "", nil); w.Code != http.StatusOK || len(api.Delivery.DevMail().List()) != 0 {
+		t.Errorf("Expected the API to clear the messages, got %d", w.Code)
+	}
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"net/mail"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/delivery"
+	"github.com/nahuelsantos/contact-api/internal/webhook"
+)
+
+// DiagnosticCheck holds the outcome of a single website configuration check
+type DiagnosticCheck struct {
+	Name   string   `json:"name"`
+	OK     bool     `json:"ok"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+// WebsiteDiagnostics is the detailed configuration report for a website
+type WebsiteDiagnostics struct {
+	Website    string            `json:"website"`
+	Enabled    bool              `json:"enabled"`
+	Recipients []string          `json:"recipients"`
+	SMTPHost   string            `json:"smtp_host"`
+	Transports []delivery.Status `json:"transports"`
+	Valid      bool              `json:"valid"`
+	Checks     []DiagnosticCheck `json:"checks"`
+}
+
+// WebsiteDiagnostics validates the full configuration of a website
+// @Summary Website configuration diagnostics
+// @Description Validate recipients, templates, webhooks, transports and SMTP reachability for a website, and report the circuit breaker state of its transports
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param website path string true "Website identifier" example:"main"
+// @Success 200 {object} Response{data=WebsiteDiagnostics}
+// @Failure 401 {object} Response
+// @Failure 404 {object} Response
+// @Router /admin/websites/{website}/diagnostics [get]
+func (a *API) WebsiteDiagnostics(c *gin.Context) {
+	website := c.Param("website")
+	site, ok := a.lookupWebsite(website)
+	if !ok {
+		fail(c, http.StatusNotFound, CodeWebsiteNotFound, "Website not found")
+		return
+	}
+
+	report := a.diagnoseWebsite(site, website)
+
+	message := "Website configuration is valid"
+	if !report.Valid {
+		message = "Website configuration has problems"
+	}
+
+	respond(c, http.StatusOK, Response{
+		Success: report.Valid,
+		Message: message,
+		Data:    report,
+	})
+}
+
+// diagnoseWebsite runs every configuration check for a website
+func (a *API) diagnoseWebsite(site config.Website, website string) WebsiteDiagnostics {
+	report := WebsiteDiagnostics{
+		Website:    website,
+		Enabled:    !site.Disabled,
+		Recipients: strings.Split(a.getRecipientForWebsite(site), ", "),
+		SMTPHost:   a.Config().SMTPHost,
+		Transports: a.Delivery.Statuses(site.Transports),
+		Valid:      true,
+	}
+
+	report.Checks = append(validateWebsite(site, website, report.Recipients),
+		newDiagnosticCheck("transports", checkTransports(site.Transports, a.Config())),
+		newDiagnosticCheck("smtp", checkSMTP(a.Delivery, site.Transports)),
+	)
+
+	for _, check := range report.Checks {
+		if !check.OK {
+			report.Valid = false
+		}
+	}
+
+	return report
+}
+
+// validateWebsite runs the checks that only depend on the website definition
+func validateWebsite(site config.Website, website string, recipients []string) []DiagnosticCheck {
+	return []DiagnosticCheck{
+		newDiagnosticCheck("recipients", checkRecipients(recipients)),
+		newDiagnosticCheck("from", checkFrom(site.From)),
+		newDiagnosticCheck("subject_template", checkSubjectTemplate(site, website)),
+		newDiagnosticCheck("body_template", checkBodyTemplate(site, website)),
+		newDiagnosticCheck("auto_reply", checkAutoReply(site, website)),
+		newDiagnosticCheck("webhooks", checkWebhooks(site.Webhooks)),
+	}
+}
+
+// checkTransports verifies that a website only names configured transports
+func checkTransports(names []string, cfg config.Config) []error {
+	var errs []error
+	available := cfg.Delivery.TransportNames()
+	for _, name := range names {
+		if !slices.Contains(available, name) {
+			errs = append(errs, fmt.Errorf("unknown transport %q, expected one of %s", name, strings.Join(available, ", ")))
+		}
+	}
+	return errs
+}
+
+// newDiagnosticCheck builds a check result from the errors it produced
+func newDiagnosticCheck(name string, errs []error) DiagnosticCheck {
+	check := DiagnosticCheck{Name: name, OK: len(errs) == 0}
+	for _, err := range errs {
+		check.Errors = append(check.Errors, err.Error())
+	}
+	return check
+}
+
+// checkRecipients verifies that every recipient is a valid email address
+func checkRecipients(recipients []string) []error {
+	var errs []error
+	for _, recipient := range recipients {
+		if _, err := mail.ParseAddress(recipient); err != nil {
+			errs = append(errs, fmt.Errorf("invalid recipient %q: %w", recipient, err))
+		}
+	}
+	return errs
+}
+
+// checkFrom verifies that the website sender, when set, is a valid email address
+func checkFrom(from string) []error {
+	if from == "" {
+		return nil
+	}
+	if _, err := mail.ParseAddress(from); err != nil {
+		return []error{fmt.Errorf("invalid sender %q: %w", from, err)}
+	}
+	return nil
+}
+
+// sampleForm is the submission used to check that templates render
+var sampleForm = ContactFormData{
+	Name:    "John Doe",
+	Email:   "john@example.com",
+	Subject: "Inquiry about services",
+	Message: "I would like to know more about your services",
+}
+
+// checkSubjectTemplate verifies that the subject template and its localized
+// variants render with sample data
+func checkSubjectTemplate(site config.Website, website string) []error {
+	return checkTemplates("subject template", site.SubjectTemplate, site.Locales, subjectTemplate, renderSubject, website)
+}
+
+// checkBodyTemplate verifies that the body template and its localized
+// variants render with sample data
+func checkBodyTemplate(site config.Website, website string) []error {
+	return checkTemplates("body template", site.BodyTemplate, site.Locales, bodyTemplate, renderBody, website)
+}
+
+// checkAutoReply verifies that the auto-reply templates and their localized
+// variants render with sample data
+func checkAutoReply(site config.Website, website string) []error {
+	reply := site.AutoReply
+	return append(
+		checkTemplates("auto-reply subject template", reply.SubjectTemplate, reply.Locales, subjectTemplate, renderSubject, website),
+		checkTemplates("auto-reply body template", reply.BodyTemplate, reply.Locales, bodyTemplate, renderBody, website)...,
+	)
+}
+
+// checkTemplates renders a base template and each of its localized variants
+func checkTemplates(name, base string, locales map[string]config.Templates, pick func(config.Templates) string,
+	render func(string, ContactFormData, string) (string, error), website string) []error {
+	var errs []error
+	if base != "" {
+		if _, err := render(base, sampleForm, website); err != nil {
+			errs = append(errs, fmt.Errorf("%s does not render: %w", name, err))
+		}
+	}
+	for _, locale := range slices.Sorted(maps.Keys(locales)) {
+		text := pick(locales[locale])
+		if text == "" {
+			continue
+		}
+		if _, err := render(text, sampleForm, website); err != nil {
+			errs = append(errs, fmt.Errorf("%s %s does not render: %w", locale, name, err))
+		}
+	}
+	return errs
+}
+
+// checkWebhooks verifies that every webhook URL is valid
+func checkWebhooks(urls []string) []error {
+	var errs []error
+	for _, url := range urls {
+		if err := webhook.ValidateURL(url); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// checkSMTP verifies that the SMTP servers of a website's transports are
+// reachable
+func checkSMTP(d *delivery.Dispatcher, transports []string) []error {
+	if err := d.Ping(transports); err != nil {
+		return []error{err}
+	}
+	return nil
+}
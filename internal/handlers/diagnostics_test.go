@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/email"
+)
+
+func setupDiagnosticsAPI(adminToken string) *gin.Engine {
+	cfg := config.Config{
+		SMTPHost:    "localhost",
+		SMTPPort:    "1025",
+		DefaultFrom: "test@example.com",
+		DefaultTo:   "contact@example.com",
+		AdminToken:  adminToken,
+		Websites: map[string]config.Website{
+			"main": {
+				Recipients: []string{"sales@example.com"},
+			},
+			"broken": {
+				Recipients:      []string{"not-an-address"},
+				SubjectTemplate: "{{.Missing}}",
+				Webhooks:        []string{"ftp://example.com/hook"},
+				Transports:      []string{"missing"},
+			},
+			"retired": {
+				Disabled: true,
+			},
+		},
+	}
+
+	api := New(cfg, nil)
+	r := gin.New()
+
+	v1 := r.Group("/api/v1")
+	{
+		v1.GET("/contact/:website/health", api.WebsiteHealthCheck)
+		api.RegisterAdminRoutes(v1)
+	}
+
+	return r
+}
+
+func TestWebsiteHealthCheck_ConfiguredWebsites(t *testing.T) {
+	r := setupDiagnosticsAPI("")
+
+	tests := []struct {
+		name            string
+		website         string
+		expectedCode    int
+		expectedEnabled bool
+	}{
+		{name: "enabled", website: "main", expectedCode: http.StatusOK, expectedEnabled: true},
+		{name: "disabled", website: "retired", expectedCode: http.StatusOK, expectedEnabled: false},
+		{name: "unknown", website: "unknown", expectedCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, err := http.NewRequestWithContext(context.Background(), "GET", "/api/v1/contact/"+tt.website+"/health", nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status code %d, got %d", tt.expectedCode, w.Code)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var response Response
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Error unmarshaling response: %v", err)
+			}
+
+			data, ok := response.Data.(map[string]interface{})
+			if !ok {
+				t.Fatalf("Expected data to be a map, got %T", response.Data)
+			}
+			if data["enabled"] != tt.expectedEnabled {
+				t.Errorf("Expected enabled to be %v, got %v", tt.expectedEnabled, data["enabled"])
+			}
+			if _, leaked := data["recipient"]; leaked {
+				t.Error("Public health check must not expose the recipient")
+			}
+			if _, leaked := data["smtp_host"]; leaked {
+				t.Error("Public health check must not expose the SMTP host")
+			}
+		})
+	}
+}
+
+func TestWebsiteDiagnostics_Auth(t *testing.T) {
+	tests := []struct {
+		name         string
+		adminToken   string
+		header       string
+		expectedCode int
+	}{
+		{name: "no admin token configured", adminToken: "", header: "Bearer anything", expectedCode: http.StatusUnauthorized},
+		{name: "missing token", adminToken: "secret", header: "", expectedCode: http.StatusUnauthorized},
+		{name: "wrong token", adminToken: "secret", header: "Bearer wrong", expectedCode: http.StatusUnauthorized},
+		{name: "valid token", adminToken: "secret", header: "Bearer secret", expectedCode: http.StatusOK},
+	}
+
+	originalDialer := email.DefaultSMTPDialer
+	email.DefaultSMTPDialer = func(addr string) (email.SMTPClient, error) {
+		return &email.MockSMTPClient{}, nil
+	}
+	defer func() { email.DefaultSMTPDialer = originalDialer }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := setupDiagnosticsAPI(tt.adminToken)
+
+			w := httptest.NewRecorder()
+			req, err := http.NewRequestWithContext(context.Background(), "GET", "/api/v1/admin/websites/main/diagnostics", nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status code %d, got %d", tt.expectedCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestWebsiteDiagnostics_Report(t *testing.T) {
+	originalDialer := email.DefaultSMTPDialer
+	email.DefaultSMTPDialer = func(addr string) (email.SMTPClient, error) {
+		return &email.MockSMTPClient{}, nil
+	}
+	defer func() { email.DefaultSMTPDialer = originalDialer }()
+
+	tests := []struct {
+		name           string
+		website        string
+		expectedValid  bool
+		expectedFailed []string
+	}{
+		{name: "valid website", website: "main", expectedValid: true},
+		{name: "broken website", website: "broken", expectedValid: false, expectedFailed: []string{"recipients", "subject_template", "webhooks", "transports"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := setupDiagnosticsAPI("secret")
+
+			w := httptest.NewRecorder()
+			req, err := http.NewRequestWithContext(context.Background(), "GET", "/api/v1/admin/websites/"+tt.website+"/diagnostics", nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer secret")
+			r.ServeHTTP(w, req)
+
+			var response struct {
+				Success bool               `json:"success"`
+				Data    WebsiteDiagnostics `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Error unmarshaling response: %v", err)
+			}
+
+			if response.Data.Valid != tt.expectedValid {
+				t.Errorf("Expected valid to be %v, got %v", tt.expectedValid, response.Data.Valid)
+			}
+
+			failed := map[string]bool{}
+			for _, check := range response.Data.Checks {
+				if !check.OK {
+					failed[check.Name] = true
+				}
+			}
+			for _, name := range tt.expectedFailed {
+				if !failed[name] {
+					t.Errorf("Expected check %q to fail, got %+v", name, response.Data.Checks)
+				}
+			}
+			if len(failed) != len(tt.expectedFailed) {
+				t.Errorf("Expected %d failed checks, got %d", len(tt.expectedFailed), len(failed))
+			}
+			if tt.expectedValid && (len(response.Data.Transports) != 1 || response.Data.Transports[0].State != "closed") {
+				t.Errorf("Expected the default transport to be closed, got %+v", response.Data.Transports)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/nahuelsantos/contact-api/internal/i18n"
+)
+
+// Error codes identify why a request failed. They are part of the API
+// contract and never change once released.
+const (
+	CodeInvalidRequest     = "invalid_request"
+	CodeValidationFailed   = "validation_failed"
+	CodeUnauthorized       = "unauthorized"
+	CodeForbidden          = "forbidden"
+	CodeWebsiteNotFound    = "website_not_found"
+	CodeSubmissionNotFound = "submission_not_found"
+	CodeAPIKeyNotFound     = "api_key_not_found"
+	CodeVersionNotFound    = "version_not_found"
+	CodeVersionDeleted     = "version_deleted"
+	CodeListEntryNotFound  = "list_entry_not_found"
+	CodeMessageNotFound    = "message_not_found"
+	CodeInvalidWebsite     = "invalid_website"
+	CodeTooManyLinks       = "too_many_links"
+	CodeSpamRejected       = "spam_rejected"
+	CodeBlocked            = "blocked"
+	CodeChallengeRequired  = "challenge_required"
+	CodeChallengeExpired   = "challenge_expired"
+	CodeChallengeFailed    = "challenge_failed"
+	CodeChallengeDisabled  = "challenge_disabled"
+	CodeDeliveryFailed     = "delivery_failed"
+	CodeNotReady           = "not_ready"
+	CodeInternal           = "internal_error"
+)
+
+// Field error codes identify what is wrong with a single field
+const (
+	FieldRequired      = "required"
+	FieldTooShort      = "too_short"
+	FieldTooLong       = "too_long"
+	FieldInvalidEmail  = "invalid_email"
+	FieldInvalidType   = "invalid_type"
+	FieldInvalidFormat = "invalid_format"
+	FieldInvalid       = "invalid"
+	FieldEmailTypo     = "email_typo"
+	FieldDisposable    = "disposable_email"
+	FieldUndeliverable = "undeliverable_email"
+)
+
+// FieldError describes a problem with one field of the request body
+type FieldError struct {
+	Field   string `json:"field" example:"email"`
+	Code    string `json:"code" example:"invalid_email"`
+	Message string `json:"message" example:"must be a valid email address"`
+
+	// Suggestion is a likely correction of the value, such as the intended
+	// address for a misspelled email domain
+	Suggestion string `json:"suggestion,omitempty" example:"john@gmail.com"`
+}
+
+// problemJSON is the media type of RFC 9457 problem details
+const problemJSON = "application/problem+json"
+
+// Problem is an error response in RFC 9457 problem details format, sent to
+// clients that accept application/problem+json
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+	Data      any          `json:"data,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+// wantsProblem reports whether the client prefers problem details to the
+// default JSON response
+func wantsProblem(c *gin.Context) bool {
+	return c.NegotiateFormat(binding.MIMEJSON, problemJSON) == problemJSON
+}
+
+// writeProblem writes a failed response as problem details
+func writeProblem(c *gin.Context, status int, resp Response) {
+	body, err := json.Marshal(Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    resp.Message,
+		Instance:  c.Request.URL.Path,
+		Code:      resp.Code,
+		Errors:    resp.Errors,
+		Data:      resp.Data,
+		RequestID: resp.RequestID,
+	})
+	if err != nil {
+		c.JSON(status, resp)
+		return
+	}
+	c.Data(status, problemJSON, body)
+}
+
+// fail writes an error response with a code and message
+func fail(c *gin.Context, status int, code, message string) {
+	respond(c, status, Response{
+		Success: false,
+		Message: message,
+		Code:    code,
+	})
+}
+
+// failFields writes a validation error response listing invalid fields
+func failFields(c *gin.Context, locale string, fields ...FieldError) {
+	respond(c, http.StatusBadRequest, Response{
+		Success: false,
+		Message: i18n.T(locale, "request.invalid_fields"),
+		Code:    CodeValidationFailed,
+		Errors:  fields,
+	})
+}
+
+// failBinding writes the error response for a request body that could not be
+// bound to obj, listing the offending fields without leaking decoder internals.
+// Messages are written in the given catalog locale.
+func failBinding(c *gin.Context, locale string, obj any, err error) {
+	var validationErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &validationErrs):
+		fields := make([]FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			fields = append(fields, fieldError(locale, obj, fe))
+		}
+		respond(c, http.StatusBadRequest, Response{
+			Success: false,
+			Message: i18n.T(locale, "request.invalid_fields"),
+			Code:    CodeValidationFailed,
+			Errors:  fields,
+		})
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		respond(c, http.StatusBadRequest, Response{
+			Success: false,
+			Message: i18n.T(locale, "request.invalid_fields"),
+			Code:    CodeValidationFailed,
+			Errors: []FieldError{{
+				Field:   typeErr.Field,
+				Code:    FieldInvalidType,
+				Message: i18n.T(locale, "field.type."+jsonKind(typeErr.Type)),
+			}},
+		})
+	case errors.Is(err, io.EOF):
+		fail(c, http.StatusBadRequest, CodeInvalidRequest, i18n.T(locale, "request.empty"))
+	default:
+		fail(c, http.StatusBadRequest, CodeInvalidRequest, i18n.T(locale, "request.invalid_json"))
+	}
+}
+
+// fieldError converts a validation failure into a field error named after
+// the JSON field of obj, with its message in the given catalog locale
+func fieldError(locale string, obj any, fe validator.FieldError) FieldError {
+	field := FieldError{Field: jsonPath(obj, fe.StructNamespace())}
+
+	switch fe.Tag() {
+	case "required":
+		field.Code, field.Message = FieldRequired, i18n.T(locale, "field.required")
+	case "email":
+		field.Code, field.Message = FieldInvalidEmail, i18n.T(locale, "field.invalid_email")
+	case "min":
+		field.Code, field.Message = FieldTooShort, i18n.T(locale, "field.too_short."+lengthUnit(fe.Kind()), fe.Param())
+	case "max":
+		field.Code, field.Message = FieldTooLong, i18n.T(locale, "field.too_long."+lengthUnit(fe.Kind()), fe.Param())
+	case "excludesall":
+		// Only used to keep line breaks out of values that end up in headers
+		field.Code, field.Message = FieldInvalidFormat, i18n.T(locale, "field.single_line")
+	default:
+		field.Code, field.Message = FieldInvalid, i18n.T(locale, "field.invalid")
+	}
+	return field
+}
+
+// jsonPath converts a validator namespace such as ContactFormData.Email into
+// the dotted JSON field path of obj, such as email
+func jsonPath(obj any, namespace string) string {
+	t := reflect.TypeOf(obj)
+	parts := strings.Split(namespace, ".")[1:]
+	names := make([]string, 0, len(parts))
+	for _, part := range parts {
+		for t != nil && t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		// Elements of slices and maps keep their [index] suffix
+		name, index, _ := strings.Cut(part, "[")
+		if index != "" {
+			index = "[" + index
+		}
+		if t != nil && t.Kind() == reflect.Struct {
+			if f, ok := t.FieldByName(name); ok {
+				if tag, _, _ := strings.Cut(f.Tag.Get("json"), ","); tag != "" && tag != "-" {
+					name = tag
+				}
+				t = f.Type
+				if index != "" {
+					t = t.Elem()
+				}
+			} else {
+				t = nil
+			}
+		}
+		names = append(names, name+index)
+	}
+	return strings.Join(names, ".")
+}
+
+// lengthUnit names what min and max count for a kind of field
+func lengthUnit(kind reflect.Kind) string {
+	switch kind {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return "items"
+	default:
+		return "chars"
+	}
+}
+
+// jsonKind names the JSON type expected for a Go type
+func jsonKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsantos/contact-api/internal/auth"
+	"github.com/nahuelsantos/contact-api/internal/blocklist"
+	"github.com/nahuelsantos/contact-api/internal/i18n"
+	"github.com/nahuelsantos/contact-api/internal/storage"
+)
+
+// CreateListEntryRequest describes a new block or allow list entry. Entries
+// without a website apply to every website.
+type CreateListEntryRequest struct {
+	List      string     `json:"list" binding:"required,oneof=block allow" example:"block"`
+	Value     string     `json:"value" binding:"required,max=254" example:"203.0.113.0/24"`
+	Website   string     `json:"website,omitempty" binding:"max=100" example:"main"`
+	Reason    string     `json:"reason,omitempty" binding:"max=500" example:"Repeated abuse"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// ListListEntries lists the block and allow list entries
+// @Summary List block and allow list entries
+// @Description List the entries managed through the API and imported from files that apply to the websites of the caller. Expired entries are left out.
+// @Tags lists
+// @Produce json
+// @Security BearerAuth
+// @Param list query string false "Filter by list" Enums(block, allow)
+// @Param website query string false "Only entries that apply to this website"
+// @Success 200 {object} Response{data=[]storage.ListEntry}
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Router /admin/lists [get]
+func (a *API) ListListEntries(c *gin.Context) {
+	principal := principalFrom(c)
+	list, website := c.Query("list"), c.Query("website")
+	now := time.Now()
+
+	entries := []storage.ListEntry{}
+	for _, entry := range a.Lists.Entries() {
+		switch {
+		case entry.Expired(now):
+		case list != "" && entry.List != list:
+		case website != "" && entry.Website != "" && entry.Website != website:
+		case entry.Website != "" && !principal.CanAccessWebsite(entry.Website):
+		default:
+			entries = append(entries, entry)
+		}
+	}
+
+	respond(c, http.StatusOK, Response{
+		Success: true,
+		Message: "List entries retrieved",
+		Data:    entries,
+	})
+}
+
+// CreateListEntry adds an entry to the block or allow list
+// @Summary Create list entry
+// @Description Block or allow an IP address, CIDR range, email address or domain (which includes its subdomains). Entries without a website apply to every website and require access to every website.
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param entry body CreateListEntryRequest true "List entry"
+// @Success 201 {object} Response{data=storage.ListEntry}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Router /admin/lists [post]
+func (a *API) CreateListEntry(c *gin.Context) {
+	var req CreateListEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		failBinding(c, i18n.Default, &req, err)
+		return
+	}
+
+	var fieldErrors []FieldError
+	_, value, err := blocklist.Parse(req.Value)
+	if err != nil {
+		fieldErrors = append(fieldErrors, FieldError{Field: "value", Code: FieldInvalidFormat, Message: err.Error()})
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		fieldErrors = append(fieldErrors, FieldError{Field: "expires_at", Code: FieldInvalid, Message: "must be in the future"})
+	}
+	if len(fieldErrors) > 0 {
+		failFields(c, i18n.Default, fieldErrors...)
+		return
+	}
+
+	if !canManageListEntry(c, req.Website) {
+		fail(c, http.StatusForbidden, CodeForbidden, "Forbidden")
+		return
+	}
+
+	entry := storage.ListEntry{
+		List:    req.List,
+		Value:   value,
+		Website: req.Website,
+		Reason:  req.Reason,
+		Author:  principalFrom(c).Name,
+	}
+	if req.ExpiresAt != nil {
+		expires := req.ExpiresAt.UTC()
+		entry.ExpiresAt = &expires
+	}
+
+	entry, err = a.Store.SaveListEntry(entry)
+	if err != nil {
+		a.internalError(c, "Failed to store list entry", err)
+		return
+	}
+	a.Lists.Rebuild()
+
+	respond(c, http.StatusCreated, Response{
+		Success: true,
+		Message: "List entry created",
+		Data:    entry,
+	})
+}
+
+// DeleteListEntry removes an entry from the block or allow list
+// @Summary Delete list entry
+// @Description Remove a block or allow list entry managed through the API. Entries imported from files are removed from their file.
+// @Tags lists
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "List entry ID"
+// @Success 200 {object} Response{data=storage.ListEntry}
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Router /admin/lists/{id} [delete]
+func (a *API) DeleteListEntry(c *gin.Context) {
+	entry, err := a.Store.GetListEntry(c.Param("id"))
+	if errors.Is(err, storage.ErrNotFound) || (err == nil && entry.Website != "" && !principalFrom(c).CanAccessWebsite(entry.Website)) {
+		fail(c, http.StatusNotFound, CodeListEntryNotFound, "List entry not found")
+		return
+	}
+	if !canManageListEntry(c, entry.Website) {
+		fail(c, http.StatusForbidden, CodeForbidden, "Forbidden")
+		return
+	}
+
+	if err := a.Store.DeleteListEntry(entry.ID); err != nil {
+		a.internalError(c, "Failed to delete list entry", err)
+		return
+	}
+	a.Lists.Rebuild()
+
+	respond(c, http.StatusOK, Response{
+		Success: true,
+		Message: "List entry deleted",
+		Data:    entry,
+	})
+}
+
+// canManageListEntry reports whether the caller may change entries of a
+// website, or global entries when website is empty
+func canManageListEntry(c *gin.Context, website string) bool {
+	if website == "" {
+		website = auth.AllWebsites
+	}
+	return principalFrom(c).CanAccessWebsite(website)
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/email"
+	"github.com/nahuelsantos/contact-api/internal/storage"
+)
+
+func TestContactHandler_Lists(t *testing.T) {
+	sent := 0
+	originalDialer := email.DefaultSMTPDialer
+	email.DefaultSMTPDialer = func(addr string) (email.SMTPClient, error) {
+		return &email.MockSMTPClient{
+			RcptFunc: func(to string) error {
+				sent++
+				return nil
+			},
+		}, nil
+	}
+	defer func() { email.DefaultSMTPDialer = originalDialer }()
+
+	_, r := setupAdminAPI(t)
+	doAdminRequest(t, r, "PUT", "/api/v1/admin/websites/main", "root-token", config.Website{
+		AntiSpam: config.AntiSpam{MaxLinks: 1},
+	})
+	for _, req := range []CreateListEntryRequest{
+		{List: config.ListBlock, Value: "spam.example"},
+		{List: config.ListAllow, Value: "partner@spam.example"},
+		{List: config.ListBlock, Value: "203.0.113.0/24"},
+		{List: config.ListAllow, Value: "198.51.100.7"},
+	} {
+		if w, response := doAdminRequest(t, r, "POST", "/api/v1/admin/lists", "root-token", req); w.Code != http.StatusCreated {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, w.Code, response.Message)
+		}
+	}
+
+	tests := []struct {
+		name           string
+		ip             string
+		email          string
+		expectedStatus int
+		expectedCode   string
+		expectedSent   int
+	}{
+		{
+			name:           "blocked domain",
+			email:          "bot@mail.spam.example",
+			expectedStatus: http.StatusForbidden,
+			expectedCode:   CodeBlocked,
+		},
+		{
+			name:           "unlisted sender with too many links",
+			email:          "john@example.com",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   CodeTooManyLinks,
+		},
+		{
+			name:           "allowed sender is still checked",
+			email:          "partner@spam.example",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   CodeTooManyLinks,
+		},
+		{
+			name:           "allowed sender from blocked ip",
+			ip:             "203.0.113.9",
+			email:          "partner@spam.example",
+			expectedStatus: http.StatusForbidden,
+			expectedCode:   CodeBlocked,
+		},
+		{
+			name:           "allowed ip skips spam checks",
+			ip:             "198.51.100.7",
+			email:          "bot@mail.spam.example",
+			expectedStatus: http.StatusOK,
+			expectedSent:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent = 0
+			form := ContactFormData{
+				Name:    "John",
+				Email:   tt.email,
+				Subject: "Links",
+				Message: "See https://a.example and https://b.example",
+			}
+			body, _ := json.Marshal(form)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/contact/main", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.RemoteAddr = tt.ip + ":1234"
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			var response Response
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Error unmarshaling response: %v", err)
+			}
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status code %d, got %d: %s", tt.expectedStatus, w.Code, response.Message)
+			}
+			if response.Code != tt.expectedCode {
+				t.Errorf("Expected code '%s', got '%s'", tt.expectedCode, response.Code)
+			}
+			if sent != tt.expectedSent {
+				t.Errorf("Expected %d emails, got %d", tt.expectedSent, sent)
+			}
+		})
+	}
+}
+
+func TestListEntries(t *testing.T) {
+	api, r := setupAdminAPI(t)
+
+	expires := time.Now().Add(time.Hour)
+	w, response := doAdminRequest(t, r, "POST", "/api/v1/admin/lists", "root-token", CreateListEntryRequest{
+		List:      config.ListBlock,
+		Value:     "203.0.113.7/24",
+		Reason:    "Repeated abuse",
+		ExpiresAt: &expires,
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, w.Code, response.Message)
+	}
+	var global storage.ListEntry
+	decodeData(t, response, &global)
+	if global.Value != "203.0.113.0/24" || global.Author != "admin token" || global.ExpiresAt == nil {
+		t.Errorf("Unexpected entry: %+v", global)
+	}
+	if !api.Lists.Check("main", "203.0.113.9", "").Blocked() {
+		t.Error("Expected the entry to apply immediately")
+	}
+
+	invalid := []CreateListEntryRequest{
+		{List: "deny", Value: "spam.example"},
+		{List: config.ListBlock, Value: "not a value"},
+		{List: config.ListBlock, Value: "spam.example", ExpiresAt: &time.Time{}},
+	}
+	for _, req := range invalid {
+		if w, _ := doAdminRequest(t, r, "POST", "/api/v1/admin/lists", "root-token", req); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code %d for %+v, got %d", http.StatusBadRequest, req, w.Code)
+		}
+	}
+
+	// Scoped keys manage the entries of their websites only
+	key := createKey(t, r, "root-token", CreateAPIKeyRequest{
+		Name:        "moderator",
+		Websites:    []string{"main"},
+		Permissions: []string{"manage-lists"},
+	})
+	tests := []struct {
+		name           string
+		req            CreateListEntryRequest
+		expectedStatus int
+	}{
+		{name: "own website", req: CreateListEntryRequest{List: config.ListBlock, Value: "spam.example", Website: "main"}, expectedStatus: http.StatusCreated},
+		{name: "other website", req: CreateListEntryRequest{List: config.ListBlock, Value: "spam.example", Website: "blog"}, expectedStatus: http.StatusForbidden},
+		{name: "every website", req: CreateListEntryRequest{List: config.ListBlock, Value: "spam.example"}, expectedStatus: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w, response := doAdminRequest(t, r, "POST", "/api/v1/admin/lists", key.Key, tt.req); w.Code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, got %d: %s", tt.expectedStatus, w.Code, response.Message)
+			}
+		})
+	}
+
+	w, response = doAdminRequest(t, r, "GET", "/api/v1/admin/lists?list=block&website=main", key.Key, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	var entries []storage.ListEntry
+	decodeData(t, response, &entries)
+	if len(entries) != 2 {
+		t.Errorf("Expected the global and website entries, got %+v", entries)
+	}
+
+	if w, _ := doAdminRequest(t, r, "DELETE", "/api/v1/admin/lists/"+global.ID, key.Key, nil); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d, got %d", http.StatusForbidden, w.Code)
+	}
+	if w, _ := doAdminRequest(t, r, "DELETE", "/api/v1/admin/lists/"+global.ID, "root-token", nil); w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if api.Lists.Check("main", "203.0.113.9", "").Blocked() {
+		t.Error("Expected the deleted entry to stop applying")
+	}
+	if w, response := doAdminRequest(t, r, "DELETE", "/api/v1/admin/lists/"+global.ID, "root-token", nil); response.Code != CodeListEntryNotFound {
+		t.Errorf("Expected code '%s', got %d '%s'", CodeListEntryNotFound, w.Code, response.Code)
+	}
+}
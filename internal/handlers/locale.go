@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/i18n"
+)
+
+// requestLocale picks the locale of a submission from, in order, the locale
+// form field, the Accept-Language header and the website default
+func requestLocale(c *gin.Context, site config.Website, requested string) string {
+	tags := append([]string{requested}, i18n.ParseAcceptLanguage(c.GetHeader("Accept-Language"))...)
+	return siteLocale(site, append(tags, site.DefaultLocale)...)
+}
+
+// siteLocale returns the first locale in the fallback chain of tags that the
+// website has templates for or the message catalog supports
+func siteLocale(site config.Website, tags ...string) string {
+	for _, tag := range i18n.Chain(tags...) {
+		if _, ok := localeTemplates(site.Locales, tag); ok {
+			return tag
+		}
+		if _, ok := localeTemplates(site.AutoReply.Locales, tag); ok {
+			return tag
+		}
+		if i18n.Supported(tag) {
+			return tag
+		}
+	}
+	return i18n.Default
+}
+
+// catalogLocale returns the catalog locale used for built-in messages, falling
+// back to the website default before English
+func catalogLocale(site config.Website, locale string) string {
+	return i18n.Match(locale, site.DefaultLocale)
+}
+
+// localeTemplates returns the localized templates for a normalized tag
+func localeTemplates(locales map[string]config.Templates, tag string) (config.Templates, bool) {
+	for key, templates := range locales {
+		if i18n.Normalize(key) == tag {
+			return templates, true
+		}
+	}
+	return config.Templates{}, false
+}
+
+// localizedTemplate returns the template for locale, walking its fallback
+// chain through the website default. The base template belongs to the
+// website default locale and is used when no variant matches first.
+func localizedTemplate(site config.Website, locales map[string]config.Templates, base, locale string, pick func(config.Templates) string) string {
+	defaultLocale := i18n.Normalize(site.DefaultLocale)
+	for _, tag := range i18n.Chain(locale, site.DefaultLocale) {
+		if templates, ok := localeTemplates(locales, tag); ok && pick(templates) != "" {
+			return pick(templates)
+		}
+		if base != "" && tag == defaultLocale {
+			return base
+		}
+	}
+	return base
+}
+
+// subjectTemplate and bodyTemplate select a field of localized templates
+func subjectTemplate(t config.Templates) string { return t.SubjectTemplate }
+func bodyTemplate(t config.Templates) string    { return t.BodyTemplate }
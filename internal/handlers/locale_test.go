@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/email"
+)
+
+func setupLocaleAPI(websites map[string]config.Website) (*API, *gin.Engine) {
+	cfg := config.Config{
+		SMTPHost:    "localhost",
+		SMTPPort:    "1025",
+		DefaultFrom: "test@example.com",
+		DefaultTo:   "contact@example.com",
+		Websites:    websites,
+	}
+
+	api := New(cfg, nil)
+	r := gin.New()
+	r.POST("/api/v1/contact/:website", api.ContactHandler)
+	return api, r
+}
+
+func TestContactHandler_Localized(t *testing.T) {
+	_, r := setupLocaleAPI(map[string]config.Website{
+		"main":   {},
+		"brasil": {DefaultLocale: "pt-BR"},
+	})
+
+	tests := []struct {
+		name            string
+		website         string
+		acceptLanguage  string
+		body            string
+		expectedMessage string
+		expectedField   string
+	}{
+		{
+			name:            "English by default",
+			website:         "main",
+			body:            `{"email": "john@example.com", "subject": "Hi", "message": "Hello"}`,
+			expectedMessage: "Please correct the highlighted fields.",
+			expectedField:   "is required",
+		},
+		{
+			name:            "Accept-Language",
+			website:         "main",
+			acceptLanguage:  "fr, es-MX;q=0.8",
+			body:            `{"email": "john@example.com", "subject": "Hi", "message": "Hello"}`,
+			expectedMessage: "Corrige los campos marcados.",
+			expectedField:   "es obligatorio",
+		},
+		{
+			name:            "locale field takes precedence",
+			website:         "main",
+			acceptLanguage:  "es",
+			body:            `{"email": "john@example.com", "subject": "Hi", "message": "Hello", "locale": "pt"}`,
+			expectedMessage: "Corrija os campos destacados.",
+			expectedField:   "é obrigatório",
+		},
+		{
+			name:            "website default",
+			website:         "brasil",
+			acceptLanguage:  "de",
+			body:            `{"email": "john@example.com", "subject": "Hi", "message": "Hello"}`,
+			expectedMessage: "Corrija os campos destacados.",
+			expectedField:   "é obrigatório",
+		},
+		{
+			name:            "website not found",
+			website:         "other",
+			acceptLanguage:  "es",
+			body:            `{}`,
+			expectedMessage: "Sitio web no encontrado",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, err := http.NewRequestWithContext(context.Background(), "POST", "/api/v1/contact/"+tt.website, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			r.ServeHTTP(w, req)
+
+			var response Response
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Error unmarshaling response: %v", err)
+			}
+			if response.Message != tt.expectedMessage {
+				t.Errorf("Expected message '%s', got '%s'", tt.expectedMessage, response.Message)
+			}
+			if tt.expectedField != "" && (len(response.Errors) != 1 || response.Errors[0].Message != tt.expectedField) {
+				t.Errorf("Expected field error '%s', got %v", tt.expectedField, response.Errors)
+			}
+		})
+	}
+}
+
+func TestCompose_Localized(t *testing.T) {
+	api, _ := setupLocaleAPI(map[string]config.Website{
+		"main": {
+			DefaultLocale:   "es",
+			SubjectTemplate: "Nuevo mensaje: {{.Subject}}",
+			Locales: map[string]config.Templates{
+				"pt-BR": {SubjectTemplate: "Nova mensagem: {{.Subject}}"},
+				"en":    {SubjectTemplate: "New message: {{.Subject}}"},
+			},
+		},
+		"plain": {},
+	})
+
+	tests := []struct {
+		name            string
+		website         string
+		locale          string
+		expectedSubject string
+		expectedBody    string
+	}{
+		{name: "exact variant", website: "main", locale: "pt-br", expectedSubject: "Nova mensagem: Hi"},
+		{name: "language variant", website: "main", locale: "en-GB", expectedSubject: "New message: Hi"},
+		{name: "base template for the default locale", website: "main", locale: "fr", expectedSubject: "Nuevo mensaje: Hi"},
+		{name: "website default", website: "main", expectedSubject: "Nuevo mensaje: Hi"},
+		{name: "built-in", website: "plain", locale: "pt", expectedSubject: "[plain] Formulário de contato: Hi", expectedBody: "Nova mensagem do formulário de contato"},
+		{name: "built-in fallback", website: "plain", locale: "de", expectedSubject: "[plain] Contact Form: Hi", expectedBody: "New Contact Form Submission"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := api.Compose(tt.website, ContactFormData{
+				Name:    "<b>John</b>",
+				Email:   "john@example.com",
+				Subject: "Hi",
+				Message: "Hello",
+				Locale:  tt.locale,
+			})
+			if err != nil {
+				t.Fatalf("Compose() returned error: %v", err)
+			}
+			if req.Subject != tt.expectedSubject {
+				t.Errorf("Expected subject '%s', got '%s'", tt.expectedSubject, req.Subject)
+			}
+			if tt.expectedBody != "" && !strings.Contains(req.Body, tt.expectedBody) {
+				t.Errorf("Expected body to contain '%s'", tt.expectedBody)
+			}
+			if strings.Contains(req.Body, "<b>John</b>") {
+				t.Error("Expected submitted values to be escaped")
+			}
+		})
+	}
+}
+
+func TestContactHandler_AutoReply(t *testing.T) {
+	replied := make(chan string, 1)
+	originalDialer := email.DefaultSMTPDialer
+	email.DefaultSMTPDialer = func(addr string) (email.SMTPClient, error) {
+		return &email.MockSMTPClient{
+			RcptFunc: func(to string) error {
+				if to == "juan@example.com" {
+					replied <- to
+				}
+				return nil
+			},
+		}, nil
+	}
+	defer func() { email.DefaultSMTPDialer = originalDialer }()
+
+	api, r := setupLocaleAPI(map[string]config.Website{
+		"main": {
+			From:      "forms@example.com",
+			AutoReply: config.AutoReply{Enabled: true},
+			AntiSpam:  config.AntiSpam{Scoring: config.SpamScoring{Enabled: true}},
+		},
+	})
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequestWithContext(context.Background(), "POST", "/api/v1/contact/main",
+		strings.NewReader(`{"name": "Juan", "email": "juan@example.com", "subject": "Hola", "message": "Hola"}`))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", "es")
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	select {
+	case <-replied:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected an auto-reply to the visitor")
+	}
+
+	reply := api.composeAutoReply(api.websiteMap()["main"], ContactFormData{Name: "Juan", Email: "juan@example.com", Message: "Visit http://spam.example", Locale: "es"}, "main")
+	if reply.Subject != "Hemos recibido tu mensaje" || !strings.Contains(reply.Body, "Hola, Juan:") {
+		t.Errorf("Expected a Spanish auto-reply, got '%s'", reply.Subject)
+	}
+	if strings.Contains(reply.Body, "spam.example") {
+		t.Error("Expected the auto-reply to leave out the message")
+	}
+	if reply.From != "forms@example.com" || reply.ReplyTo != "contact@example.com" {
+		t.Errorf("Expected reply from the website to its recipients, got from %s reply-to %s", reply.From, reply.ReplyTo)
+	}
+}
+
+func TestContactHandler_AutoReplyVerified(t *testing.T) {
+	tests := []struct {
+		name     string
+		antiSpam config.AntiSpam
+		message  string
+		replies  int
+	}{
+		{"No spam protection", config.AntiSpam{}, "Hola", 0},
+		{"Clean score", config.AntiSpam{Scoring: config.SpamScoring{Enabled: true}}, "Hola", autoReplyLimit},
+		{"Spam points", config.AntiSpam{Scoring: config.SpamScoring{Enabled: true}}, "Visit http://spam.example", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			replies := 0
+			originalDialer := email.DefaultSMTPDialer
+			email.DefaultSMTPDialer = func(addr string) (email.SMTPClient, error) {
+				return &email.MockSMTPClient{
+					RcptFunc: func(to string) error {
+						if strings.EqualFold(to, "juan@example.com") {
+							mu.Lock()
+							replies++
+							mu.Unlock()
+						}
+						return nil
+					},
+				}, nil
+			}
+			defer func() { email.DefaultSMTPDialer = originalDialer }()
+
+			_, r := setupLocaleAPI(map[string]config.Website{
+				"main": {AutoReply: config.AutoReply{Enabled: true}, AntiSpam: tt.antiSpam},
+			})
+
+			// Submissions beyond the limit for an address get no reply
+			for i := 0; i < autoReplyLimit+2; i++ {
+				w, response := doAdminRequest(t, r, "POST", "/api/v1/contact/main", "", map[string]string{
+					"name": "Juan", "email": "Juan@example.com", "subject": "Hola", "message": tt.message,
+				})
+				if w.Code != http.StatusOK {
+					t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, response.Message)
+				}
+			}
+
+			time.Sleep(200 * time.Millisecond)
+			mu.Lock()
+			defer mu.Unlock()
+			if replies != tt.replies {
+				t.Errorf("Expected %d auto-replies, got %d", tt.replies, replies)
+			}
+		})
+	}
+}
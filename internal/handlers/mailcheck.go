@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/i18n"
+	"github.com/nahuelsantos/contact-api/internal/mailcheck"
+)
+
+// checkEmail runs the sender address checks a website enables and returns
+// the problem found with the address, or nil. A failed DNS lookup is logged
+// and lets the address through, so an outage does not turn visitors away.
+func (a *API) checkEmail(ctx context.Context, site config.Website, sender mailcheck.Address, locale string) *FieldError {
+	checks := site.EmailChecks
+
+	if checks.SuggestTypos {
+		if domain := mailcheck.Suggest(sender.Domain); domain != "" {
+			suggestion := mailcheck.Address{Local: sender.Local, Domain: domain}.String()
+			return &FieldError{
+				Field:      "email",
+				Code:       FieldEmailTypo,
+				Message:    i18n.T(locale, "field.email_typo", suggestion),
+				Suggestion: suggestion,
+			}
+		}
+	}
+
+	if checks.RejectDisposable && mailcheck.NewDisposableList(site.AntiSpam.Scoring.DisposableDomains).Contains(sender.Domain) {
+		return &FieldError{Field: "email", Code: FieldDisposable, Message: i18n.T(locale, "field.disposable_email")}
+	}
+
+	if checks.DNS {
+		cfg := a.Config()
+		err := mailcheck.Deliverable(ctx, mailcheck.NewResolver(cfg.DNS.Resolver), sender.Domain, time.Duration(cfg.DNS.Timeout))
+		switch {
+		case errors.Is(err, mailcheck.ErrUndeliverable):
+			return &FieldError{Field: "email", Code: FieldUndeliverable, Message: i18n.T(locale, "field.undeliverable")}
+		case err != nil:
+			slog.WarnContext(ctx, "Failed to look up sender domain", "domain", sender.Domain, "error", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/email"
+	"github.com/nahuelsantos/contact-api/internal/mailcheck"
+	"github.com/nahuelsantos/contact-api/internal/storage"
+)
+
+func TestContactHandler_EmailChecks(t *testing.T) {
+	originalDialer := email.DefaultSMTPDialer
+	email.DefaultSMTPDialer = func(addr string) (email.SMTPClient, error) {
+		return &email.MockSMTPClient{}, nil
+	}
+	defer func() { email.DefaultSMTPDialer = originalDialer }()
+
+	dns, err := mailcheck.StartMockDNSServer(map[string]mailcheck.MockDomain{
+		"mail.example":          {MX: []string{"mx.mail.example"}},
+		"nomail.example":        {MX: []string{"."}},
+		"xn--bcher-kva.example": {A: []string{"192.0.2.1"}},
+	})
+	if err != nil {
+		t.Fatalf("StartMockDNSServer() returned error: %v", err)
+	}
+	defer dns.Close()
+
+	api, r := setupAdminAPI(t)
+	cfg := api.Config()
+	cfg.DNS = config.DNS{Resolver: dns.Addr()}
+	if err := api.Reload(cfg); err != nil {
+		t.Fatalf("Reload() returned error: %v", err)
+	}
+	doAdminRequest(t, r, "PUT", "/api/v1/admin/websites/main", "root-token", config.Website{
+		EmailChecks: config.EmailChecks{DNS: true, RejectDisposable: true, SuggestTypos: true},
+	})
+
+	tests := []struct {
+		name               string
+		email              string
+		expectedStatus     int
+		expectedFieldCode  string
+		expectedSuggestion string
+		expectedStored     string
+	}{
+		{
+			name:           "deliverable",
+			email:          "john@mail.example",
+			expectedStatus: http.StatusOK,
+			expectedStored: "john@mail.example",
+		},
+		{
+			name:           "internationalized domain",
+			email:          "john@Bücher.example",
+			expectedStatus: http.StatusOK,
+			expectedStored: "john@xn--bcher-kva.example",
+		},
+		{
+			name:               "typo",
+			email:              "john@gmial.com",
+			expectedStatus:     http.StatusBadRequest,
+			expectedFieldCode:  FieldEmailTypo,
+			expectedSuggestion: "john@gmail.com",
+		},
+		{
+			name:              "disposable",
+			email:             "bot@mailinator.com",
+			expectedStatus:    http.StatusBadRequest,
+			expectedFieldCode: FieldDisposable,
+		},
+		{
+			name:              "null MX",
+			email:             "john@nomail.example",
+			expectedStatus:    http.StatusBadRequest,
+			expectedFieldCode: FieldUndeliverable,
+		},
+		{
+			name:              "missing domain",
+			email:             "john@missing.example",
+			expectedStatus:    http.StatusBadRequest,
+			expectedFieldCode: FieldUndeliverable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := ContactFormData{Name: "John", Email: tt.email, Subject: "Hello", Message: "Hello"}
+			w, response := doAdminRequest(t, r, "POST", "/api/v1/contact/main", "", form)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status code %d, got %d: %+v", tt.expectedStatus, w.Code, response.Errors)
+			}
+			if tt.expectedFieldCode != "" {
+				if response.Code != CodeValidationFailed || len(response.Errors) != 1 {
+					t.Fatalf("Expected one field error, got %s %+v", response.Code, response.Errors)
+				}
+				fe := response.Errors[0]
+				if fe.Field != "email" || fe.Code != tt.expectedFieldCode || fe.Suggestion != tt.expectedSuggestion {
+					t.Errorf("Unexpected field error: %+v", fe)
+				}
+				return
+			}
+
+			latest := api.Store.ListSubmissions(storage.SubmissionFilter{Limit: 1})[0]
+			if latest.Email != tt.expectedStored {
+				t.Errorf("Expected stored email '%s', got '%s'", tt.expectedStored, latest.Email)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+)
+
+func TestReload(t *testing.T) {
+	api := New(config.Config{
+		DefaultTo: "contact@example.com",
+		Websites: map[string]config.Website{
+			"main": {Recipients: []string{"sales@example.com"}},
+		},
+	}, nil)
+
+	// An invalid configuration is rejected and the previous one kept
+	err := api.Reload(config.Config{
+		DefaultTo: "contact@example.com",
+		Websites: map[string]config.Website{
+			"main": {Recipients: []string{"not-an-address"}},
+		},
+	})
+	if err == nil {
+		t.Fatal("Expected invalid configuration to be rejected")
+	}
+	if site, _ := api.lookupWebsite("main"); site.Recipients[0] != "sales@example.com" {
+		t.Errorf("Expected previous configuration to be kept, got %+v", site)
+	}
+
+	// A valid configuration replaces the website registry
+	err = api.Reload(config.Config{
+		DefaultTo: "contact@example.com",
+		Websites: map[string]config.Website{
+			"main": {Recipients: []string{"support@example.com"}},
+			"blog": {},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected valid configuration to be applied, got: %v", err)
+	}
+	if site, _ := api.lookupWebsite("main"); site.Recipients[0] != "support@example.com" {
+		t.Errorf("Expected reloaded recipient, got %+v", site)
+	}
+	if _, ok := api.lookupWebsite("blog"); !ok {
+		t.Error("Expected new website to be available after reload")
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Auto-replies sent to one address within a window are capped, so the form
+// cannot be used to flood a mailbox with signed mail
+const (
+	autoReplyLimit  = 3
+	autoReplyWindow = 24 * time.Hour
+
+	// maxReplyAddresses is the number of tracked addresses from which
+	// expired windows are dropped, instead of by a background task
+	maxReplyAddresses = 1000
+)
+
+// replyLimiter counts the auto-replies sent to each address in fixed windows
+type replyLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	now     func() time.Time
+	windows map[string]replyWindow
+}
+
+// replyWindow holds the auto-replies sent to an address since start
+type replyWindow struct {
+	start time.Time
+	count int
+}
+
+// newReplyLimiter creates a limiter allowing limit replies per address and window
+func newReplyLimiter(limit int, window time.Duration) *replyLimiter {
+	return &replyLimiter{limit: limit, window: window, now: time.Now, windows: map[string]replyWindow{}}
+}
+
+// Allow reports whether another auto-reply may go to address and counts it
+func (l *replyLimiter) Allow(address string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	key := strings.ToLower(strings.TrimSpace(address))
+	w, ok := l.windows[key]
+	if !ok && len(l.windows) >= maxReplyAddresses {
+		l.prune(now)
+	}
+	if !ok || now.Sub(w.start) >= l.window {
+		w = replyWindow{start: now}
+	}
+	if w.count >= l.limit {
+		return false
+	}
+	w.count++
+	l.windows[key] = w
+	return true
+}
+
+// prune removes the windows that have expired
+func (l *replyLimiter) prune(now time.Time) {
+	for key, w := range l.windows {
+		if now.Sub(w.start) >= l.window {
+			delete(l.windows, key)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/i18n"
+)
+
+// RoutingResult describes how a sample submission would be delivered
+type RoutingResult struct {
+	Rule     string            `json:"rule,omitempty" example:"sales"`
+	Default  bool              `json:"default"`
+	To       string            `json:"to" example:"sales@example.com"`
+	Subject  string            `json:"subject" example:"[quote] [main] Contact Form: Quote request"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Channels []string          `json:"channels" example:"email,webhook"`
+	Webhooks []string          `json:"webhooks,omitempty"`
+}
+
+// TestRouting shows which routing rule a sample submission hits without
+// delivering it
+// @Summary Test website routing
+// @Description Evaluate the routing rules of a website against a sample submission without sending anything
+// @Tags websites
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param website path string true "Website identifier" example:"main"
+// @Param submission body ContactFormData true "Sample submission"
+// @Success 200 {object} Response{data=RoutingResult}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Router /admin/websites/{website}/routing/test [post]
+func (a *API) TestRouting(c *gin.Context) {
+	website := c.Param("website")
+	site, ok := a.lookupWebsite(website)
+	if !ok {
+		fail(c, http.StatusNotFound, CodeWebsiteNotFound, "Website not found")
+		return
+	}
+
+	// Samples are not validated, so partial submissions can be tried
+	var form ContactFormData
+	if err := json.NewDecoder(c.Request.Body).Decode(&form); err != nil {
+		failBinding(c, i18n.Default, &form, err)
+		return
+	}
+	form.Locale = siteLocale(site, form.Locale, site.DefaultLocale)
+
+	route, rule := a.matchRoute(site, form)
+	req := a.composeEmail(site, form, website)
+	result := RoutingResult{
+		Rule:    rule,
+		Default: rule == "",
+		To:      req.To,
+		Subject: req.Subject,
+		Headers: req.Headers,
+	}
+	for _, channel := range []string{config.ChannelEmail, config.ChannelWebhook} {
+		if route.Notifies(channel) {
+			result.Channels = append(result.Channels, channel)
+		}
+	}
+	if route.Notifies(config.ChannelWebhook) {
+		result.Webhooks = routeWebhooks(site, route)
+	}
+
+	respond(c, http.StatusOK, Response{
+		Success: true,
+		Message: "Routing evaluated",
+		Data:    result,
+	})
+}
+
+// matchRoute returns the route a submission takes and the name of the rule
+// that matched, or "" when the default route applies
+func (a *API) matchRoute(site config.Website, form ContactFormData) (config.Route, string) {
+	patterns := a.routePatterns()
+	for i, rule := range site.Routing.Rules {
+		if !matchesAll(rule.Match, form, patterns) {
+			continue
+		}
+		if rule.Name == "" {
+			return rule.Route, fmt.Sprintf("rule %d", i+1)
+		}
+		return rule.Route, rule.Name
+	}
+	return site.Routing.Default, ""
+}
+
+// matchesAll reports whether a submission meets every condition. A rule
+// without conditions never matches.
+func matchesAll(conds []config.Condition, form ContactFormData, patterns map[string]*regexp.Regexp) bool {
+	if len(conds) == 0 {
+		return false
+	}
+	for _, cond := range conds {
+		if !matches(cond, formField(form, cond.Field), patterns) {
+			return false
+		}
+	}
+	return true
+}
+
+// matches evaluates a single condition against a field value. Patterns are
+// looked up in the compiled patterns and compiled when missing.
+func matches(cond config.Condition, value string, patterns map[string]*regexp.Regexp) bool {
+	switch {
+	case cond.Equals != "":
+		return strings.EqualFold(value, cond.Equals)
+	case cond.Contains != "":
+		return strings.Contains(strings.ToLower(value), strings.ToLower(cond.Contains))
+	case cond.Regex != "":
+		re, ok := patterns[cond.Regex]
+		if !ok {
+			var err error
+			if re, err = regexp.Compile(cond.Regex); err != nil {
+				return false
+			}
+		}
+		return re.MatchString(value)
+	case len(cond.OneOf) > 0:
+		return slices.Contains(cond.OneOf, value)
+	default:
+		return false
+	}
+}
+
+// formField returns the value of a submission field by its routing name
+func formField(form ContactFormData, field string) string {
+	switch field {
+	case "name":
+		return form.Name
+	case "email":
+		return form.Email
+	case "subject":
+		return form.Subject
+	case "message":
+		return form.Message
+	case "locale":
+		return form.Locale
+	}
+	if name, ok := strings.CutPrefix(field, "fields."); ok {
+		return form.Fields[name]
+	}
+	return ""
+}
+
+// compilePatterns compiles the routing patterns of websites. Patterns that do
+// not compile are left out and never match.
+func compilePatterns(websites map[string]config.Website) map[string]*regexp.Regexp {
+	patterns := map[string]*regexp.Regexp{}
+	for _, site := range websites {
+		for _, rule := range site.Routing.Rules {
+			for _, cond := range rule.Match {
+				if cond.Regex == "" {
+					continue
+				}
+				if _, ok := patterns[cond.Regex]; ok {
+					continue
+				}
+				if re, err := regexp.Compile(cond.Regex); err == nil {
+					patterns[cond.Regex] = re
+				}
+			}
+		}
+	}
+	return patterns
+}
+
+// routeRecipients returns the recipients of a route as a comma-separated
+// list, falling back to the website recipients
+func (a *API) routeRecipients(site config.Website, route config.Route) string {
+	if len(route.Recipients) > 0 {
+		return strings.Join(route.Recipients, ", ")
+	}
+	return a.getRecipientForWebsite(site)
+}
+
+// routeWebhooks returns the webhooks of a route, falling back to the website webhooks
+func routeWebhooks(site config.Website, route config.Route) []string {
+	if len(route.Webhooks) > 0 {
+		return route.Webhooks
+	}
+	return site.Webhooks
+}
+
+// tagSubject prefixes a subject with the route tag, if any
+func tagSubject(tag, subject string) string {
+	if tag == "" {
+		return subject
+	}
+	return "[" + tag + "] " + subject
+}
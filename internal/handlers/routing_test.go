@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/email"
+	"github.com/nahuelsantos/contact-api/internal/storage"
+)
+
+// routedSite routes quotes to sales and bugs to support
+var routedSite = config.Website{
+	Recipients: []string{"contact@example.com"},
+	Webhooks:   []string{"https://hooks.example.com/all"},
+	Routing: config.Routing{
+		Rules: []config.RoutingRule{
+			{
+				Name:  "sales",
+				Match: []config.Condition{{Field: "subject", Contains: "QUOTE"}},
+				Route: config.Route{Recipients: []string{"sales@example.com"}, SubjectTag: "quote", Priority: "high"},
+			},
+			{
+				Name: "support",
+				Match: []config.Condition{
+					{Field: "fields.department", OneOf: []string{"support", "billing"}},
+					{Field: "message", Regex: `(?i)\bbug\b`},
+				},
+				Route: config.Route{
+					Recipients: []string{"support@example.com"},
+					Webhooks:   []string{"https://hooks.example.com/support"},
+				},
+			},
+			{
+				Match: []config.Condition{{Field: "email", Equals: "Partner@Example.com"}},
+				Route: config.Route{Channels: []string{config.ChannelWebhook}},
+			},
+		},
+		Default: config.Route{SubjectTag: "general"},
+	},
+}
+
+func TestMatchRoute(t *testing.T) {
+	tests := []struct {
+		name     string
+		form     ContactFormData
+		expected string
+	}{
+		{
+			name:     "contains ignores case",
+			form:     ContactFormData{Subject: "Quote request"},
+			expected: "sales",
+		},
+		{
+			name:     "all conditions match",
+			form:     ContactFormData{Message: "Found a bug", Fields: map[string]string{"department": "billing"}},
+			expected: "support",
+		},
+		{
+			name:     "one condition fails",
+			form:     ContactFormData{Message: "Found a bug", Fields: map[string]string{"department": "sales"}},
+			expected: "",
+		},
+		{
+			name:     "unnamed rule",
+			form:     ContactFormData{Email: "partner@example.com"},
+			expected: "rule 3",
+		},
+		{
+			name:     "first match wins",
+			form:     ContactFormData{Subject: "Quote", Email: "partner@example.com"},
+			expected: "sales",
+		},
+		{
+			name:     "default route",
+			form:     ContactFormData{Subject: "Hello"},
+			expected: "",
+		},
+	}
+
+	api := New(config.Config{Websites: map[string]config.Website{"main": routedSite}}, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, rule := api.matchRoute(routedSite, tt.form); rule != tt.expected {
+				t.Errorf("Expected rule '%s', got '%s'", tt.expected, rule)
+			}
+		})
+	}
+}
+
+func TestRoutePatterns_Reload(t *testing.T) {
+	api := New(config.Config{Websites: map[string]config.Website{"main": routedSite}}, nil)
+	if _, ok := api.routePatterns()[`(?i)\bbug\b`]; !ok {
+		t.Fatal("Expected the routing pattern to be compiled on load")
+	}
+
+	site := config.Website{Recipients: []string{"contact@example.com"}, Routing: config.Routing{Rules: []config.RoutingRule{
+		{Name: "jobs", Match: []config.Condition{{Field: "subject", Regex: `^job`}}},
+	}}}
+	if err := api.Reload(config.Config{Websites: map[string]config.Website{"main": site}}); err != nil {
+		t.Fatalf("Failed to reload: %v", err)
+	}
+
+	patterns := api.routePatterns()
+	if len(patterns) != 1 {
+		t.Errorf("Expected 1 compiled pattern after reload, got %d", len(patterns))
+	}
+	if _, ok := patterns[`^job`]; !ok {
+		t.Error("Expected the new routing pattern to be compiled on reload")
+	}
+	if _, rule := api.matchRoute(site, ContactFormData{Subject: "job offer"}); rule != "jobs" {
+		t.Errorf("Expected rule 'jobs', got '%s'", rule)
+	}
+}
+
+func TestTestRouting(t *testing.T) {
+	_, r := setupAdminAPI(t)
+	doAdminRequest(t, r, "PUT", "/api/v1/admin/websites/main", "root-token", routedSite)
+
+	tests := []struct {
+		name             string
+		form             ContactFormData
+		expectedRule     string
+		expectedTo       string
+		expectedSubject  string
+		expectedPriority string
+		expectedChannels int
+		expectedWebhook  string
+	}{
+		{
+			name:             "sales",
+			form:             ContactFormData{Subject: "Quote request"},
+			expectedRule:     "sales",
+			expectedTo:       "sales@example.com",
+			expectedSubject:  "[quote] [main] Contact Form: Quote request",
+			expectedPriority: "1 (Highest)",
+			expectedChannels: 2,
+			expectedWebhook:  "https://hooks.example.com/all",
+		},
+		{
+			name:             "support",
+			form:             ContactFormData{Message: "A bug", Fields: map[string]string{"department": "support"}},
+			expectedRule:     "support",
+			expectedTo:       "support@example.com",
+			expectedSubject:  "[main] Contact Form: ",
+			expectedChannels: 2,
+			expectedWebhook:  "https://hooks.example.com/support",
+		},
+		{
+			name:             "webhook only",
+			form:             ContactFormData{Email: "partner@example.com"},
+			expectedRule:     "rule 3",
+			expectedTo:       "contact@example.com",
+			expectedSubject:  "[main] Contact Form: ",
+			expectedChannels: 1,
+			expectedWebhook:  "https://hooks.example.com/all",
+		},
+		{
+			name:             "default",
+			form:             ContactFormData{Subject: "Hi"},
+			expectedTo:       "contact@example.com",
+			expectedSubject:  "[general] [main] Contact Form: Hi",
+			expectedChannels: 2,
+			expectedWebhook:  "https://hooks.example.com/all",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, response := doAdminRequest(t, r, "POST", "/api/v1/admin/websites/main/routing/test", "root-token", tt.form)
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, response.Message)
+			}
+
+			var result RoutingResult
+			decodeData(t, response, &result)
+			if result.Rule != tt.expectedRule || result.Default != (tt.expectedRule == "") {
+				t.Errorf("Expected rule '%s', got '%s' (default %v)", tt.expectedRule, result.Rule, result.Default)
+			}
+			if result.To != tt.expectedTo {
+				t.Errorf("Expected to '%s', got '%s'", tt.expectedTo, result.To)
+			}
+			if result.Subject != tt.expectedSubject {
+				t.Errorf("Expected subject '%s', got '%s'", tt.expectedSubject, result.Subject)
+			}
+			if result.Headers["X-Priority"] != tt.expectedPriority {
+				t.Errorf("Expected priority '%s', got '%s'", tt.expectedPriority, result.Headers["X-Priority"])
+			}
+			if len(result.Channels) != tt.expectedChannels {
+				t.Errorf("Expected %d channels, got %v", tt.expectedChannels, result.Channels)
+			}
+			if len(result.Webhooks) != 1 || result.Webhooks[0] != tt.expectedWebhook {
+				t.Errorf("Expected webhook '%s', got %v", tt.expectedWebhook, result.Webhooks)
+			}
+		})
+	}
+
+	if w, _ := doAdminRequest(t, r, "POST", "/api/v1/admin/websites/other/routing/test", "root-token", ContactFormData{}); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestContactHandler_Routing(t *testing.T) {
+	var rcpts []string
+	originalDialer := email.DefaultSMTPDialer
+	email.DefaultSMTPDialer = func(addr string) (email.SMTPClient, error) {
+		return &email.MockSMTPClient{
+			RcptFunc: func(to string) error {
+				rcpts = append(rcpts, to)
+				return nil
+			},
+		}, nil
+	}
+	defer func() { email.DefaultSMTPDialer = originalDialer }()
+
+	api, r := setupAdminAPI(t)
+	site := routedSite
+	site.Webhooks = nil
+	site.Routing.Rules = site.Routing.Rules[:1:1]
+	site.Routing.Rules = append(site.Routing.Rules, config.RoutingRule{
+		Match: []config.Condition{{Field: "email", Equals: "partner@example.com"}},
+		Route: config.Route{Channels: []string{config.ChannelWebhook}},
+	})
+	doAdminRequest(t, r, "PUT", "/api/v1/admin/websites/main", "root-token", site)
+
+	form := ContactFormData{Name: "John", Email: "john@example.com", Subject: "Quote please", Message: "Hello"}
+	if w, _ := doAdminRequest(t, r, "POST", "/api/v1/contact/main", "", form); w.Code != http.StatusOK {
+		t.Fatalf("Expected submission to succeed, got %d", w.Code)
+	}
+	if len(rcpts) != 1 || rcpts[0] != "sales@example.com" {
+		t.Errorf("Expected delivery to the routed recipient, got %v", rcpts)
+	}
+
+	// A webhook-only route sends no email but still records the submission
+	rcpts = nil
+	form.Email, form.Subject = "partner@example.com", "Hello"
+	if w, _ := doAdminRequest(t, r, "POST", "/api/v1/contact/main", "", form); w.Code != http.StatusOK {
+		t.Fatalf("Expected submission to succeed, got %d", w.Code)
+	}
+	if len(rcpts) != 0 {
+		t.Errorf("Expected no email for a webhook-only route, got %v", rcpts)
+	}
+
+	routes := map[string]bool{}
+	for _, sub := range api.Store.ListSubmissions(storage.SubmissionFilter{Website: "main"}) {
+		routes[sub.Route] = true
+	}
+	if !routes["sales"] || !routes["rule 2"] {
+		t.Errorf("Expected submissions to record their route, got %v", routes)
+	}
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsantos/contact-api/internal/auth"
+	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/i18n"
+	"github.com/nahuelsantos/contact-api/internal/observability"
+	"github.com/nahuelsantos/contact-api/internal/spam"
+	"github.com/nahuelsantos/contact-api/internal/storage"
+)
+
+// scoreSpam runs the spam checks of a website against a submission, or
+// returns nil when the website does not score submissions
+func (a *API) scoreSpam(site config.Website, form ContactFormData, website string) *spam.Result {
+	if !site.AntiSpam.Scoring.Enabled {
+		return nil
+	}
+
+	msg := spamMessage(form)
+	model := a.spamModel(site, website)
+	if p, ok := model.Probability(msg); ok {
+		observability.SpamProbability.WithLabelValues(website).Observe(p)
+	}
+	result := spam.New(site.AntiSpam.Scoring, model).Score(msg)
+	return &result
+}
+
+// spamModel returns the Bayesian model that scores a website's submissions.
+// Websites use their own model once trained, unless configured to always use
+// the global one.
+func (a *API) spamModel(site config.Website, website string) *spam.Model {
+	if site.AntiSpam.Scoring.Model != "global" {
+		if model := a.Store.SpamModel(website); model.Trained() {
+			return model
+		}
+	}
+	return a.Store.SpamModel(storage.GlobalSpamModel)
+}
+
+// spamMessage returns the content of a submission the spam checks inspect
+func spamMessage(form ContactFormData) spam.Message {
+	return spam.Message{
+		Name:    form.Name,
+		Email:   form.Email,
+		Subject: form.Subject,
+		Message: form.Message,
+		Fields:  form.Fields,
+	}
+}
+
+// spamVerdict converts a spam result for storage
+func spamVerdict(result *spam.Result) *storage.SpamVerdict {
+	verdict := &storage.SpamVerdict{Score: result.Score, Action: result.Action}
+	for _, signal := range result.Signals {
+		verdict.Reasons = append(verdict.Reasons, signal.Check+": "+signal.Reason)
+	}
+	return verdict
+}
+
+// spamTag returns the subject tag for submissions tagged as spam
+func spamTag(result *spam.Result) string {
+	if result == nil || result.Action != spam.ActionTag {
+		return ""
+	}
+	return "SPAM"
+}
+
+// spamScore returns the score of a submission for logging
+func spamScore(result *spam.Result) float64 {
+	if result == nil {
+		return 0
+	}
+	return result.Score
+}
+
+// SpamModelSummary describes a Bayesian model without its token counts
+type SpamModelSummary struct {
+	Scope   string `json:"scope" example:"main"`
+	Spam    int    `json:"spam" example:"42"`
+	Ham     int    `json:"ham" example:"318"`
+	Tokens  int    `json:"tokens" example:"5120"`
+	Trained bool   `json:"trained"`
+}
+
+// newSpamModelSummary summarizes a model for output
+func newSpamModelSummary(scope string, model *spam.Model) SpamModelSummary {
+	if model == nil {
+		model = &spam.Model{}
+	}
+	tokens := len(model.SpamTokens)
+	for token := range model.HamTokens {
+		if _, ok := model.SpamTokens[token]; !ok {
+			tokens++
+		}
+	}
+	return SpamModelSummary{
+		Scope:   scope,
+		Spam:    model.Spam,
+		Ham:     model.Ham,
+		Tokens:  tokens,
+		Trained: model.Trained(),
+	}
+}
+
+// ExportSpamModel returns the Bayesian model of a website so it can be
+// backed up or moved
+// @Summary Export website spam model
+// @Description Export the Bayesian spam model trained from a website's labelled submissions
+// @Tags spam
+// @Produce json
+// @Security BearerAuth
+// @Param website path string true "Website identifier" example:"main"
+// @Success 200 {object} Response{data=spam.Model}
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Router /admin/websites/{website}/spam/model [get]
+func (a *API) ExportSpamModel(c *gin.Context) {
+	a.exportSpamModel(c, c.Param("website"))
+}
+
+// ImportSpamModel replaces the Bayesian model of a website
+// @Summary Import website spam model
+// @Description Replace the Bayesian spam model of a website with an exported model
+// @Tags spam
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param website path string true "Website identifier" example:"main"
+// @Param model body spam.Model true "Exported model"
+// @Success 200 {object} Response{data=SpamModelSummary}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Router /admin/websites/{website}/spam/model [put]
+func (a *API) ImportSpamModel(c *gin.Context) {
+	a.importSpamModel(c, c.Param("website"))
+}
+
+// ExportGlobalSpamModel returns the Bayesian model trained by every website
+// @Summary Export global spam model
+// @Description Export the Bayesian spam model trained from the labelled submissions of every website. Requires access to every website.
+// @Tags spam
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} Response{data=spam.Model}
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Router /admin/spam/model [get]
+func (a *API) ExportGlobalSpamModel(c *gin.Context) {
+	if requireAllWebsites(c) {
+		a.exportSpamModel(c, storage.GlobalSpamModel)
+	}
+}
+
+// ImportGlobalSpamModel replaces the Bayesian model shared by every website
+// @Summary Import global spam model
+// @Description Replace the global Bayesian spam model with an exported model. Requires access to every website.
+// @Tags spam
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param model body spam.Model true "Exported model"
+// @Success 200 {object} Response{data=SpamModelSummary}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Router /admin/spam/model [put]
+func (a *API) ImportGlobalSpamModel(c *gin.Context) {
+	if requireAllWebsites(c) {
+		a.importSpamModel(c, storage.GlobalSpamModel)
+	}
+}
+
+// exportSpamModel writes the model of a scope, empty when it was never trained
+func (a *API) exportSpamModel(c *gin.Context, scope string) {
+	model := a.Store.SpamModel(scope)
+	if model == nil {
+		model = &spam.Model{}
+	}
+	respond(c, http.StatusOK, Response{
+		Success: true,
+		Message: "Spam model exported",
+		Data:    model,
+	})
+}
+
+// importSpamModel validates a model from the request body and stores it for a scope
+func (a *API) importSpamModel(c *gin.Context, scope string) {
+	var imported spam.Model
+	if err := c.ShouldBindJSON(&imported); err != nil {
+		failBinding(c, i18n.Default, &imported, err)
+		return
+	}
+	if err := imported.Validate(); err != nil {
+		failFields(c, i18n.Default, FieldError{Field: "model", Code: FieldInvalid, Message: err.Error()})
+		return
+	}
+
+	model, err := a.Store.UpdateSpamModel(scope, func(m *spam.Model) { *m = imported })
+	if err != nil {
+		a.internalError(c, "Failed to store spam model", err)
+		return
+	}
+	respond(c, http.StatusOK, Response{
+		Success: true,
+		Message: "Spam model imported",
+		Data:    newSpamModelSummary(scope, model),
+	})
+}
+
+// requireAllWebsites rejects callers without access to every website
+func requireAllWebsites(c *gin.Context) bool {
+	if !principalFrom(c).CanAccessWebsite(auth.AllWebsites) {
+		fail(c, http.StatusForbidden, CodeForbidden, "Forbidden")
+		return false
+	}
+	return true
+}
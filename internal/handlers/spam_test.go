@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/email"
+	"github.com/nahuelsantos/contact-api/internal/spam"
+	"github.com/nahuelsantos/contact-api/internal/storage"
+)
+
+func TestContactHandler_Spam(t *testing.T) {
+	sent := 0
+	originalDialer := email.DefaultSMTPDialer
+	email.DefaultSMTPDialer = func(addr string) (email.SMTPClient, error) {
+		return &email.MockSMTPClient{
+			RcptFunc: func(to string) error {
+				sent++
+				return nil
+			},
+		}, nil
+	}
+	defer func() { email.DefaultSMTPDialer = originalDialer }()
+
+	api, r := setupAdminAPI(t)
+	doAdminRequest(t, r, "PUT", "/api/v1/admin/websites/main", "root-token", config.Website{
+		AntiSpam: config.AntiSpam{Scoring: config.SpamScoring{
+			Enabled:    true,
+			Keywords:   []string{"crypto"},
+			Patterns:   []string{`(?i)casino`},
+			Weights:    map[string]float64{"keywords": 6},
+			Thresholds: config.SpamThresholds{Tag: 5, Quarantine: 9, Reject: 12},
+		}},
+	})
+
+	tests := []struct {
+		name           string
+		subject        string
+		email          string
+		expectedStatus int
+		expectedCode   string
+		expectedSent   int
+		expectedStored string
+		expectedAction string
+	}{
+		{
+			name:           "accepted",
+			subject:        "Quote",
+			email:          "john@example.com",
+			expectedStatus: http.StatusOK,
+			expectedSent:   1,
+			expectedStored: storage.StatusSent,
+			expectedAction: spam.ActionAccept,
+		},
+		{
+			name:           "tagged",
+			subject:        "Crypto offer",
+			email:          "john@example.com",
+			expectedStatus: http.StatusOK,
+			expectedSent:   1,
+			expectedStored: storage.StatusSent,
+			expectedAction: spam.ActionTag,
+		},
+		{
+			name:           "quarantined",
+			subject:        "Crypto offer",
+			email:          "bot@mailinator.com",
+			expectedStatus: http.StatusOK,
+			expectedStored: storage.StatusQuarantined,
+			expectedAction: spam.ActionQuarantine,
+		},
+		{
+			name:           "rejected",
+			subject:        "CRYPTO CASINO: ONLINE CASINO WITH FREE SPINS",
+			email:          "bot@mailinator.com",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   CodeSpamRejected,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent = 0
+			before := len(api.Store.ListSubmissions(storage.SubmissionFilter{}))
+			form := ContactFormData{Name: "John", Email: tt.email, Subject: tt.subject, Message: "Hello"}
+			w, response := doAdminRequest(t, r, "POST", "/api/v1/contact/main", "", form)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status code %d, got %d: %s", tt.expectedStatus, w.Code, response.Message)
+			}
+			if response.Code != tt.expectedCode {
+				t.Errorf("Expected code '%s', got '%s'", tt.expectedCode, response.Code)
+			}
+			if sent != tt.expectedSent {
+				t.Errorf("Expected %d emails, got %d", tt.expectedSent, sent)
+			}
+
+			subs := api.Store.ListSubmissions(storage.SubmissionFilter{})
+			if tt.expectedStored == "" {
+				if len(subs) != before {
+					t.Error("Expected a rejected submission not to be stored")
+				}
+				return
+			}
+			if len(subs) != before+1 {
+				t.Fatalf("Expected the submission to be stored")
+			}
+			if subs[0].Status != tt.expectedStored {
+				t.Errorf("Expected status '%s', got '%s'", tt.expectedStored, subs[0].Status)
+			}
+			if subs[0].Spam == nil || subs[0].Spam.Action != tt.expectedAction {
+				t.Errorf("Expected spam action '%s', got %+v", tt.expectedAction, subs[0].Spam)
+			}
+		})
+	}
+
+	// Releasing a quarantined submission delivers it untagged
+	quarantined := api.Store.ListSubmissions(storage.SubmissionFilter{Status: storage.StatusQuarantined})
+	if len(quarantined) != 1 {
+		t.Fatalf("Expected one quarantined submission, got %d", len(quarantined))
+	}
+	sent = 0
+	released, err := api.Resend(quarantined[0])
+	if err != nil || released.Status != storage.StatusSent || sent != 1 {
+		t.Errorf("Expected the submission to be released, got status %s and %d emails (%v)", released.Status, sent, err)
+	}
+}
+
+func TestComposeEmail_SpamTag(t *testing.T) {
+	api, _ := setupLocaleAPI(nil)
+	form := ContactFormData{Email: "john@example.com", Subject: "Hi", Spam: &spam.Result{Action: spam.ActionTag}}
+
+	req := api.composeEmail(config.Website{}, form, "main")
+	if !strings.HasPrefix(req.Subject, "[SPAM] ") {
+		t.Errorf("Expected a tagged subject, got '%s'", req.Subject)
+	}
+
+	form.Spam.Action = spam.ActionQuarantine
+	if req := api.composeEmail(config.Website{}, form, "main"); strings.HasPrefix(req.Subject, "[SPAM]") {
+		t.Errorf("Expected only tagged submissions to be marked, got '%s'", req.Subject)
+	}
+}
+
+func TestLabelSubmission(t *testing.T) {
+	api, r := setupAdminAPI(t)
+	doAdminRequest(t, r, "PUT", "/api/v1/admin/websites/main", "root-token", config.Website{
+		AntiSpam: config.AntiSpam{Scoring: config.SpamScoring{Enabled: true}},
+	})
+
+	label := func(token, id, label string) (int, Response) {
+		w, response := doAdminRequest(t, r, "POST", "/api/v1/admin/websites/main/submissions/"+id+"/label", token, LabelRequest{Label: label})
+		return w.Code, response
+	}
+
+	var ids []string
+	for i := range spam.MinTraining {
+		for _, sub := range []storage.Submission{
+			{Website: "main", Email: "bot@spam.example", Message: fmt.Sprintf("Cheap replica watches, deal %d", i)},
+			{Website: "main", Email: "client@example.com", Message: fmt.Sprintf("Could we schedule meeting %d?", i)},
+		} {
+			saved, err := api.Store.SaveSubmission(sub)
+			if err != nil {
+				t.Fatalf("SaveSubmission() returned error: %v", err)
+			}
+			ids = append(ids, saved.ID)
+			expected := storage.LabelHam
+			if sub.Email == "bot@spam.example" {
+				expected = storage.LabelSpam
+			}
+			if code, response := label("root-token", saved.ID, expected); code != http.StatusOK {
+				t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, code, response.Message)
+			}
+		}
+	}
+
+	// Relabelling replaces what the models learned
+	label("root-token", ids[1], storage.LabelSpam)
+	label("root-token", ids[1], storage.LabelHam)
+	for _, scope := range []string{"main", storage.GlobalSpamModel} {
+		model := api.Store.SpamModel(scope)
+		if model.Spam != spam.MinTraining || model.Ham != spam.MinTraining {
+			t.Errorf("Expected %d spam and ham messages in %s, got %d and %d", spam.MinTraining, scope, model.Spam, model.Ham)
+		}
+	}
+
+	if code, _ := label("root-token", ids[0], "maybe"); code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, code)
+	}
+	if code, _ := label("root-token", "missing", storage.LabelSpam); code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, code)
+	}
+
+	// New submissions are scored by the trained website model
+	form := ContactFormData{Name: "Bot", Email: "bot@spam.example", Subject: "Deal", Message: "Cheap replica watches"}
+	doAdminRequest(t, r, "POST", "/api/v1/contact/main", "", form)
+	latest := api.Store.ListSubmissions(storage.SubmissionFilter{Limit: 1})[0]
+	if latest.Spam == nil || !slices.ContainsFunc(latest.Spam.Reasons, func(reason string) bool { return strings.HasPrefix(reason, "bayes: ") }) {
+		t.Errorf("Expected the Bayesian model to score the submission, got %+v", latest.Spam)
+	}
+}
+
+func TestLabelSubmission_WebsiteKey(t *testing.T) {
+	api, r := setupAdminAPI(t)
+	key := createKey(t, r, "root-token", CreateAPIKeyRequest{
+		Name:        "main-trainer",
+		Websites:    []string{"main"},
+		Permissions: []string{"train-spam"},
+	})
+	sub, err := api.Store.SaveSubmission(storage.Submission{Website: "main", Message: "Cheap replica watches"})
+	if err != nil {
+		t.Fatalf("SaveSubmission() returned error: %v", err)
+	}
+
+	label := func(token, label string) {
+		t.Helper()
+		w, response := doAdminRequest(t, r, "POST", "/api/v1/admin/websites/main/submissions/"+sub.ID+"/label", token, LabelRequest{Label: label})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, response.Message)
+		}
+	}
+
+	// A key for some websites only trains their models
+	label(key.Key, storage.LabelSpam)
+	if model := api.Store.SpamModel("main"); model.Spam != 1 {
+		t.Errorf("Expected the website model to learn the label, got %d spam messages", model.Spam)
+	}
+	if model := api.Store.SpamModel(storage.GlobalSpamModel); model != nil {
+		t.Errorf("Expected the global model to stay untrained, got %d spam messages", model.Spam)
+	}
+
+	// A key for every website trains the global model without relearning
+	// the website model
+	label("root-token", storage.LabelSpam)
+	label(key.Key, storage.LabelHam)
+	label("root-token", storage.LabelHam)
+	for _, scope := range []string{"main", storage.GlobalSpamModel} {
+		if model := api.Store.SpamModel(scope); model.Spam != 0 || model.Ham != 1 {
+			t.Errorf("Expected 1 ham message in %s, got %d spam and %d ham", scope, model.Spam, model.Ham)
+		}
+	}
+}
+
+func TestSpamModelExport(t *testing.T) {
+	api, r := setupAdminAPI(t)
+	if _, err := api.Store.UpdateSpamModel("main", func(m *spam.Model) { m.Learn(spam.Message{Message: "cheap watches"}, true) }); err != nil {
+		t.Fatalf("UpdateSpamModel() returned error: %v", err)
+	}
+
+	w, response := doAdminRequest(t, r, "GET", "/api/v1/admin/websites/main/spam/model", "root-token", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	var exported spam.Model
+	decodeData(t, response, &exported)
+	if exported.Spam != 1 || exported.SpamTokens["watches"] != 1 {
+		t.Errorf("Unexpected exported model: %+v", exported)
+	}
+
+	w, response = doAdminRequest(t, r, "PUT", "/api/v1/admin/spam/model", "root-token", exported)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, w.Code, response.Message)
+	}
+	var summary SpamModelSummary
+	decodeData(t, response, &summary)
+	if summary.Scope != storage.GlobalSpamModel || summary.Spam != 1 || summary.Tokens != 2 || summary.Trained {
+		t.Errorf("Unexpected model summary: %+v", summary)
+	}
+
+	exported.Spam = 0
+	if w, _ := doAdminRequest(t, r, "PUT", "/api/v1/admin/websites/main/spam/model", "root-token", exported); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	// The global model needs access to every website
+	key := createKey(t, r, "root-token", CreateAPIKeyRequest{
+		Name:        "moderator",
+		Websites:    []string{"main"},
+		Permissions: []string{"train-spam"},
+	})
+	if w, _ := doAdminRequest(t, r, "GET", "/api/v1/admin/websites/main/spam/model", key.Key, nil); w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if w, _ := doAdminRequest(t, r, "GET", "/api/v1/admin/spam/model", key.Key, nil); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsantos/contact-api/internal/auth"
+	"github.com/nahuelsantos/contact-api/internal/i18n"
+	"github.com/nahuelsantos/contact-api/internal/spam"
+	"github.com/nahuelsantos/contact-api/internal/storage"
+)
+
+// LabelRequest marks a submission as spam or ham
+type LabelRequest struct {
+	Label string `json:"label" binding:"required,oneof=spam ham" example:"spam"`
+}
+
+// ListSubmissions lists stored submissions for a website
+// @Summary List submissions
+// @Description List stored contact form submissions for a website, newest first
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param website path string true "Website identifier" example:"main"
+// @Param status query string false "Filter by delivery status" Enums(sent, stored, failed, quarantined)
+// @Param limit query int false "Maximum number of submissions" default(100)
+// @Success 200 {object} Response{data=[]storage.Submission}
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Router /admin/websites/{website}/submissions [get]
+func (a *API) ListSubmissions(c *gin.Context) {
+	subs := a.Store.ListSubmissions(storage.SubmissionFilter{
+		Website: c.Param("website"),
+		Status:  c.Query("status"),
+		Limit:   queryLimit(c, 100),
+	})
+
+	respond(c, http.StatusOK, Response{
+		Success: true,
+		Message: "Submissions retrieved",
+		Data:    subs,
+	})
+}
+
+// ResendSubmission delivers a stored submission again
+// @Summary Resend submission
+// @Description Deliver a stored submission again using the website's current configuration
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param website path string true "Website identifier" example:"main"
+// @Param id path string true "Submission ID"
+// @Success 200 {object} Response{data=storage.Submission}
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 502 {object} Response{data=storage.Submission}
+// @Router /admin/websites/{website}/submissions/{id}/resend [post]
+func (a *API) ResendSubmission(c *gin.Context) {
+	website := c.Param("website")
+
+	sub, err := a.Store.GetSubmission(c.Param("id"))
+	if errors.Is(err, storage.ErrNotFound) || (err == nil && sub.Website != website) {
+		fail(c, http.StatusNotFound, CodeSubmissionNotFound, "Submission not found")
+		return
+	}
+
+	sub, sendErr := a.Resend(sub)
+	if errors.Is(sendErr, ErrWebsiteNotFound) {
+		fail(c, http.StatusNotFound, CodeWebsiteNotFound, "Website not found")
+		return
+	}
+	if sendErr != nil {
+		slog.ErrorContext(c.Request.Context(), "Failed to resend submission", "error", sendErr, "website", website, "id", sub.ID)
+		respond(c, http.StatusBadGateway, Response{
+			Success: false,
+			Message: "Failed to resend submission",
+			Code:    CodeDeliveryFailed,
+			Data:    sub,
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, Response{
+		Success: true,
+		Message: "Submission resent",
+		Data:    sub,
+	})
+}
+
+// LabelSubmission marks a stored submission as spam or ham and trains the
+// website spam model with it, and the global model for keys covering every
+// website
+// @Summary Label submission
+// @Description Mark a stored submission as spam or ham. The website's Bayesian model learns from it, and so does the global model when the key covers every website. Relabelling a submission replaces what they learned.
+// @Tags spam
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param website path string true "Website identifier" example:"main"
+// @Param id path string true "Submission ID"
+// @Param label body LabelRequest true "Label"
+// @Success 200 {object} Response{data=storage.Submission}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Router /admin/websites/{website}/submissions/{id}/label [post]
+func (a *API) LabelSubmission(c *gin.Context) {
+	website := c.Param("website")
+
+	var req LabelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		failBinding(c, i18n.Default, &req, err)
+		return
+	}
+
+	sub, err := a.Store.GetSubmission(c.Param("id"))
+	if errors.Is(err, storage.ErrNotFound) || (err == nil && sub.Website != website) {
+		fail(c, http.StatusNotFound, CodeSubmissionNotFound, "Submission not found")
+		return
+	}
+
+	// The global model is shared by every website, so a key limited to some
+	// of them only trains the website's own model
+	scopes := []string{website}
+	if principalFrom(c).CanAccessWebsite(auth.AllWebsites) && sub.GlobalLabel != req.Label {
+		scopes = append(scopes, storage.GlobalSpamModel)
+	}
+
+	if sub.Label != req.Label || len(scopes) > 1 {
+		msg := spamMessage(ContactFormData{Name: sub.Name, Email: sub.Email, Subject: sub.Subject, Message: sub.Message, Fields: sub.Fields})
+		_, err := a.Store.UpdateSpamModels(scopes, func(scope string, m *spam.Model) {
+			learned := sub.Label
+			if scope == storage.GlobalSpamModel {
+				learned = sub.GlobalLabel
+			}
+			if learned == req.Label {
+				return
+			}
+			if learned != "" {
+				m.Forget(msg, learned == storage.LabelSpam)
+			}
+			m.Learn(msg, req.Label == storage.LabelSpam)
+		})
+		if err != nil {
+			a.internalError(c, "Failed to train spam model", err)
+			return
+		}
+
+		sub.Label = req.Label
+		if len(scopes) > 1 {
+			sub.GlobalLabel = req.Label
+		}
+		if sub, err = a.Store.SaveSubmission(sub); err != nil {
+			a.internalError(c, "Failed to store submission", err)
+			return
+		}
+	}
+
+	respond(c, http.StatusOK, Response{
+		Success: true,
+		Message: "Submission labelled",
+		Data:    sub,
+	})
+}
@@ -0,0 +1,327 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/i18n"
+	"github.com/nahuelsantos/contact-api/internal/storage"
+)
+
+// WebsiteDefinition is the current definition of a website and where it comes from
+type WebsiteDefinition struct {
+	Website   string         `json:"website"`
+	Source    string         `json:"source" enums:"config,storage"`
+	Version   int            `json:"version,omitempty"`
+	UpdatedAt *time.Time     `json:"updated_at,omitempty"`
+	Config    config.Website `json:"config"`
+}
+
+// WebsiteDiff lists the changes between two versions of a website
+type WebsiteDiff struct {
+	Website string               `json:"website"`
+	From    int                  `json:"from"`
+	To      int                  `json:"to"`
+	Changes []config.FieldChange `json:"changes"`
+}
+
+// websiteDefinition describes a website from the cache and storage
+func (a *API) websiteDefinition(website string) (WebsiteDefinition, bool) {
+	site, ok := a.websiteMap()[website]
+	if !ok {
+		return WebsiteDefinition{}, false
+	}
+
+	def := WebsiteDefinition{Website: website, Source: "config", Config: config.RedactWebsite(site)}
+	if versions := a.Store.ListWebsiteVersions(website); len(versions) > 0 {
+		def.Source = "storage"
+		def.Version = versions[0].Version
+		def.UpdatedAt = &versions[0].CreatedAt
+	}
+	return def, true
+}
+
+// ListWebsites lists the websites the caller may manage
+// @Summary List websites
+// @Description List website definitions from the config file and storage
+// @Tags websites
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} Response{data=[]WebsiteDefinition}
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Router /admin/websites [get]
+func (a *API) ListWebsites(c *gin.Context) {
+	principal := principalFrom(c)
+
+	names := make([]string, 0)
+	for name := range a.websiteMap() {
+		if principal.CanAccessWebsite(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	defs := make([]WebsiteDefinition, 0, len(names))
+	for _, name := range names {
+		if def, ok := a.websiteDefinition(name); ok {
+			defs = append(defs, def)
+		}
+	}
+
+	respond(c, http.StatusOK, Response{
+		Success: true,
+		Message: "Websites retrieved",
+		Data:    defs,
+	})
+}
+
+// GetWebsite returns the current definition of a website
+// @Summary Get website
+// @Description Get the current definition of a website
+// @Tags websites
+// @Produce json
+// @Security BearerAuth
+// @Param website path string true "Website identifier" example:"main"
+// @Success 200 {object} Response{data=WebsiteDefinition}
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Router /admin/websites/{website} [get]
+func (a *API) GetWebsite(c *gin.Context) {
+	def, ok := a.websiteDefinition(c.Param("website"))
+	if !ok {
+		fail(c, http.StatusNotFound, CodeWebsiteNotFound, "Website not found")
+		return
+	}
+
+	respond(c, http.StatusOK, Response{
+		Success: true,
+		Message: "Website retrieved",
+		Data:    def,
+	})
+}
+
+// PutWebsite creates or updates a website, storing a new version
+// @Summary Create or update website
+// @Description Validate and store a new version of a website definition. Takes effect immediately.
+// @Tags websites
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param website path string true "Website identifier" example:"main"
+// @Param definition body config.Website true "Website definition"
+// @Success 200 {object} Response{data=WebsiteDefinition}
+// @Failure 400 {object} Response{data=[]DiagnosticCheck}
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Router /admin/websites/{website} [put]
+func (a *API) PutWebsite(c *gin.Context) {
+	website := c.Param("website")
+	if !config.WebsiteIDPattern.MatchString(website) {
+		failFields(c, i18n.Default, FieldError{Field: "website", Code: FieldInvalidFormat, Message: "must be a lowercase slug"})
+		return
+	}
+
+	var site config.Website
+	if err := c.ShouldBindJSON(&site); err != nil {
+		failBinding(c, i18n.Default, &site, err)
+		return
+	}
+
+	// Secrets are returned redacted, so sending one back unchanged keeps it
+	if config.IsRedacted(site.WebhookSecret) {
+		site.WebhookSecret = a.websiteMap()[website].WebhookSecret
+	}
+
+	a.saveWebsiteVersion(c, website, site)
+}
+
+// DeleteWebsite removes a website by storing a tombstone version
+// @Summary Delete website
+// @Description Remove a website. Its history is kept and it can be restored with a rollback.
+// @Tags websites
+// @Produce json
+// @Security BearerAuth
+// @Param website path string true "Website identifier" example:"main"
+// @Success 200 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Router /admin/websites/{website} [delete]
+func (a *API) DeleteWebsite(c *gin.Context) {
+	website := c.Param("website")
+	if _, ok := a.websiteMap()[website]; !ok {
+		fail(c, http.StatusNotFound, CodeWebsiteNotFound, "Website not found")
+		return
+	}
+
+	if _, err := a.Store.AddWebsiteVersion(website, config.Website{}, true, principalFrom(c).KeyID); err != nil {
+		a.internalError(c, "Failed to delete website", err)
+		return
+	}
+	a.refreshWebsites()
+
+	respond(c, http.StatusOK, Response{
+		Success: true,
+		Message: "Website deleted",
+	})
+}
+
+// ListWebsiteVersions lists the stored versions of a website
+// @Summary List website versions
+// @Description List every stored version of a website, newest first
+// @Tags websites
+// @Produce json
+// @Security BearerAuth
+// @Param website path string true "Website identifier" example:"main"
+// @Success 200 {object} Response{data=[]storage.WebsiteVersion}
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Router /admin/websites/{website}/versions [get]
+func (a *API) ListWebsiteVersions(c *gin.Context) {
+	versions := a.Store.ListWebsiteVersions(c.Param("website"))
+	for i := range versions {
+		versions[i].Config = config.RedactWebsite(versions[i].Config)
+	}
+
+	respond(c, http.StatusOK, Response{
+		Success: true,
+		Message: "Website versions retrieved",
+		Data:    versions,
+	})
+}
+
+// DiffWebsiteVersions shows what changed in a version of a website
+// @Summary Diff website versions
+// @Description Compare a version with another one, by default the version before it
+// @Tags websites
+// @Produce json
+// @Security BearerAuth
+// @Param website path string true "Website identifier" example:"main"
+// @Param version path int true "Version"
+// @Param against query int false "Version to compare against"
+// @Success 200 {object} Response{data=WebsiteDiff}
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Router /admin/websites/{website}/versions/{version}/diff [get]
+func (a *API) DiffWebsiteVersions(c *gin.Context) {
+	website := c.Param("website")
+
+	to, ok := a.websiteVersion(c, c.Param("version"))
+	if !ok {
+		return
+	}
+
+	from := storage.WebsiteVersion{Website: website}
+	if against := c.Query("against"); against != "" {
+		if from, ok = a.websiteVersion(c, against); !ok {
+			return
+		}
+	} else if to.Version > 1 {
+		from, _ = a.Store.GetWebsiteVersion(website, to.Version-1)
+	}
+
+	respond(c, http.StatusOK, Response{
+		Success: true,
+		Message: "Website versions compared",
+		Data: WebsiteDiff{
+			Website: website,
+			From:    from.Version,
+			To:      to.Version,
+			Changes: config.DiffWebsites(from.Config, to.Config),
+		},
+	})
+}
+
+// RollbackWebsite restores an earlier version of a website as a new version
+// @Summary Roll back website
+// @Description Store a copy of an earlier version as the newest version. Takes effect immediately.
+// @Tags websites
+// @Produce json
+// @Security BearerAuth
+// @Param website path string true "Website identifier" example:"main"
+// @Param version path int true "Version to restore"
+// @Success 200 {object} Response{data=WebsiteDefinition}
+// @Failure 400 {object} Response{data=[]DiagnosticCheck}
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Router /admin/websites/{website}/versions/{version}/rollback [post]
+func (a *API) RollbackWebsite(c *gin.Context) {
+	version, ok := a.websiteVersion(c, c.Param("version"))
+	if !ok {
+		return
+	}
+	if version.Deleted {
+		fail(c, http.StatusBadRequest, CodeVersionDeleted, "Cannot roll back to a deleted version")
+		return
+	}
+
+	a.saveWebsiteVersion(c, version.Website, version.Config)
+}
+
+// websiteVersion loads a version of the website in the route, writing the
+// error response when it does not exist
+func (a *API) websiteVersion(c *gin.Context, raw string) (storage.WebsiteVersion, bool) {
+	number, err := strconv.Atoi(raw)
+	if err == nil {
+		version, err := a.Store.GetWebsiteVersion(c.Param("website"), number)
+		if err == nil {
+			return version, true
+		}
+		if !errors.Is(err, storage.ErrNotFound) {
+			a.internalError(c, "Failed to load website version", err)
+			return version, false
+		}
+	}
+
+	fail(c, http.StatusNotFound, CodeVersionNotFound, "Website version not found")
+	return storage.WebsiteVersion{}, false
+}
+
+// saveWebsiteVersion validates and stores a website definition, then refreshes
+// the cache so it applies to the next request
+func (a *API) saveWebsiteVersion(c *gin.Context, website string, site config.Website) {
+	recipients := site.Recipients
+	if len(recipients) == 0 {
+		recipients = []string{a.Config().DefaultTo}
+	}
+
+	var failed []DiagnosticCheck
+	checks := append(validateWebsite(site, website, recipients),
+		newDiagnosticCheck("transports", checkTransports(site.Transports, a.Config())))
+	for _, check := range checks {
+		if !check.OK {
+			failed = append(failed, check)
+		}
+	}
+	if len(failed) > 0 {
+		respond(c, http.StatusBadRequest, Response{
+			Success: false,
+			Message: "Website configuration is invalid",
+			Code:    CodeInvalidWebsite,
+			Data:    failed,
+		})
+		return
+	}
+
+	if _, err := a.Store.AddWebsiteVersion(website, site, false, principalFrom(c).KeyID); err != nil {
+		a.internalError(c, "Failed to save website", err)
+		return
+	}
+	a.refreshWebsites()
+
+	def, _ := a.websiteDefinition(website)
+	respond(c, http.StatusOK, Response{
+		Success: true,
+		Message: "Website saved",
+		Data:    def,
+	})
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/email"
+)
+
+func decodeData(t *testing.T, response Response, target any) {
+	t.Helper()
+
+	raw, err := json.Marshal(response.Data)
+	if err != nil {
+		t.Fatalf("Failed to marshal data: %v", err)
+	}
+	if err := json.Unmarshal(raw, target); err != nil {
+		t.Fatalf("Failed to unmarshal data: %v", err)
+	}
+}
+
+func TestWebsiteManagement(t *testing.T) {
+	api, r := setupAdminAPI(t)
+
+	var rcpts []string
+	originalDialer := email.DefaultSMTPDialer
+	email.DefaultSMTPDialer = func(addr string) (email.SMTPClient, error) {
+		return &email.MockSMTPClient{
+			RcptFunc: func(to string) error {
+				rcpts = append(rcpts, to)
+				return nil
+			},
+		}, nil
+	}
+	defer func() { email.DefaultSMTPDialer = originalDialer }()
+
+	// Create a website and check the cache picks it up without restart
+	w, response := doAdminRequest(t, r, "PUT", "/api/v1/admin/websites/main", "root-token", config.Website{
+		Recipients: []string{"sales@example.com"},
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected website to be saved, got %d: %s", w.Code, response.Message)
+	}
+
+	form := ContactFormData{Name: "John Doe", Email: "john@example.com", Subject: "Hi", Message: "Hello"}
+	if w, _ := doAdminRequest(t, r, "POST", "/api/v1/contact/main", "", form); w.Code != http.StatusOK {
+		t.Fatalf("Expected submission to succeed, got %d", w.Code)
+	}
+	if len(rcpts) != 1 || rcpts[0] != "sales@example.com" {
+		t.Errorf("Expected delivery to the stored recipient, got %v", rcpts)
+	}
+
+	// Websites that are not defined are now rejected
+	if w, _ := doAdminRequest(t, r, "POST", "/api/v1/contact/other", "", form); w.Code != http.StatusNotFound {
+		t.Errorf("Expected unknown website to be rejected, got %d", w.Code)
+	}
+
+	// Update and diff against the previous version
+	doAdminRequest(t, r, "PUT", "/api/v1/admin/websites/main", "root-token", config.Website{
+		Recipients: []string{"support@example.com"},
+	})
+	_, response = doAdminRequest(t, r, "GET", "/api/v1/admin/websites/main/versions/2/diff", "root-token", nil)
+	var diff WebsiteDiff
+	decodeData(t, response, &diff)
+	if diff.From != 1 || diff.To != 2 || len(diff.Changes) != 1 || diff.Changes[0].Field != "recipients" {
+		t.Errorf("Unexpected diff: %+v", diff)
+	}
+
+	// Roll back to version 1
+	w, _ = doAdminRequest(t, r, "POST", "/api/v1/admin/websites/main/versions/1/rollback", "root-token", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected rollback to succeed, got %d", w.Code)
+	}
+	_, response = doAdminRequest(t, r, "GET", "/api/v1/admin/websites/main", "root-token", nil)
+	var def WebsiteDefinition
+	decodeData(t, response, &def)
+	if def.Version != 3 || def.Config.Recipients[0] != "sales@example.com" {
+		t.Errorf("Unexpected definition after rollback: %+v", def)
+	}
+
+	// Delete hides the website but keeps its history
+	if w, _ := doAdminRequest(t, r, "DELETE", "/api/v1/admin/websites/main", "root-token", nil); w.Code != http.StatusOK {
+		t.Fatalf("Expected delete to succeed, got %d", w.Code)
+	}
+	// With no websites left every identifier is accepted again
+	if _, ok := api.lookupWebsite("main"); !ok {
+		t.Error("Expected legacy mode once no websites remain")
+	}
+	if got := len(api.Store.ListWebsiteVersions("main")); got != 4 {
+		t.Errorf("Expected 4 versions, got %d", got)
+	}
+}
+
+func TestPutWebsite_Invalid(t *testing.T) {
+	_, r := setupAdminAPI(t)
+
+	tests := []struct {
+		name    string
+		website string
+		site    config.Website
+	}{
+		{name: "bad recipient", website: "main", site: config.Website{Recipients: []string{"nope"}}},
+		{name: "bad sender", website: "main", site: config.Website{From: "nope"}},
+		{name: "bad template", website: "main", site: config.Website{BodyTemplate: "{{.Missing"}},
+		{name: "bad webhook", website: "main", site: config.Website{Webhooks: []string{"not a url"}}},
+		{name: "bad identifier", website: "Main Site", site: config.Website{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, _ := doAdminRequest(t, r, "PUT", "/api/v1/admin/websites/"+tt.website, "root-token", tt.site)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+			}
+		})
+	}
+}
+
+func TestWebsiteSecrets_Redacted(t *testing.T) {
+	api, r := setupAdminAPI(t)
+
+	doAdminRequest(t, r, "PUT", "/api/v1/admin/websites/main", "root-token", config.Website{
+		Webhooks:      []string{"https://hooks.example.com/contact"},
+		WebhookSecret: "hook-secret",
+	})
+
+	_, response := doAdminRequest(t, r, "GET", "/api/v1/admin/websites/main", "root-token", nil)
+	var def WebsiteDefinition
+	decodeData(t, response, &def)
+	if !config.IsRedacted(def.Config.WebhookSecret) {
+		t.Errorf("Expected webhook secret to be redacted, got %q", def.Config.WebhookSecret)
+	}
+
+	// Sending the redacted definition back keeps the stored secret
+	def.Config.Recipients = []string{"sales@example.com"}
+	if w, _ := doAdminRequest(t, r, "PUT", "/api/v1/admin/websites/main", "root-token", def.Config); w.Code != http.StatusOK {
+		t.Fatalf("Expected website to be saved, got %d", w.Code)
+	}
+	if site, _ := api.lookupWebsite("main"); site.WebhookSecret != "hook-secret" {
+		t.Errorf("Expected webhook secret to be kept, got %q", site.WebhookSecret)
+	}
+
+	_, response = doAdminRequest(t, r, "GET", "/api/v1/admin/websites/main/versions", "root-token", nil)
+	var versions []struct {
+		Config config.Website `json:"config"`
+	}
+	decodeData(t, response, &versions)
+	for _, v := range versions {
+		if !config.IsRedacted(v.Config.WebhookSecret) {
+			t.Errorf("Expected version secrets to be redacted, got %q", v.Config.WebhookSecret)
+		}
+	}
+}
+
+func TestRefreshWebsites_StoredOverridesConfig(t *testing.T) {
+	api := New(config.Config{
+		DefaultTo: "contact@example.com",
+		Websites: map[string]config.Website{
+			"main": {Recipients: []string{"file@example.com"}},
+			"blog": {Recipients: []string{"blog@example.com"}},
+		},
+	}, nil)
+
+	api.Store.AddWebsiteVersion("main", config.Website{Recipients: []string{"stored@example.com"}}, false, "root")
+	api.Store.AddWebsiteVersion("blog", config.Website{}, true, "root")
+	api.refreshWebsites()
+
+	site, ok := api.lookupWebsite("main")
+	if !ok || site.Recipients[0] != "stored@example.com" {
+		t.Errorf("Expected stored definition to override the config file, got %+v", site)
+	}
+	if _, ok := api.lookupWebsite("blog"); ok {
+		t.Error("Expected deleted website to be hidden")
+	}
+}
+
+func TestContactHandler_AntiSpam(t *testing.T) {
+	originalDialer := email.DefaultSMTPDialer
+	sent := 0
+	email.DefaultSMTPDialer = func(addr string) (email.SMTPClient, error) {
+		sent++
+		return &email.MockSMTPClient{}, nil
+	}
+	defer func() { email.DefaultSMTPDialer = originalDialer }()
+
+	_, r := setupAdminAPI(t)
+	doAdminRequest(t, r, "PUT", "/api/v1/admin/websites/main", "root-token", config.Website{
+		AntiSpam: config.AntiSpam{Honeypot: true, MaxLinks: 1},
+	})
+
+	tests := []struct {
+		name         string
+		form         ContactFormData
+		expectedCode int
+		expectedSent int
+	}{
+		{
+			name:         "honeypot filled",
+			form:         ContactFormData{Name: "Bot", Email: "bot@example.com", Subject: "Hi", Message: "Hello", Gotcha: "x"},
+			expectedCode: http.StatusOK,
+			expectedSent: 0,
+		},
+		{
+			name:         "too many links",
+			form:         ContactFormData{Name: "Bot", Email: "bot@example.com", Subject: "Hi", Message: "http://a.example www.b.example"},
+			expectedCode: http.StatusBadRequest,
+			expectedSent: 0,
+		},
+		{
+			name:         "legitimate",
+			form:         ContactFormData{Name: "John", Email: "john@example.com", Subject: "Hi", Message: "See https://example.com"},
+			expectedCode: http.StatusOK,
+			expectedSent: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent = 0
+			w, _ := doAdminRequest(t, r, "POST", "/api/v1/contact/main", "", tt.form)
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status code %d, got %d", tt.expectedCode, w.Code)
+			}
+			if sent != tt.expectedSent {
+				t.Errorf("Expected %d emails sent, got %d", tt.expectedSent, sent)
+			}
+		})
+	}
+}
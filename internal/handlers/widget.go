@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/i18n"
+	"github.com/nahuelsantos/contact-api/internal/widget"
+)
+
+// FormDefinition describes the contact form of a website for the widget
+type FormDefinition struct {
+	Website     string                `json:"website" example:"main"`
+	Locale      string                `json:"locale" example:"en"`
+	Fields      []FormFieldDefinition `json:"fields"`
+	Honeypot    bool                  `json:"honeypot"`
+	ProofOfWork bool                  `json:"proof_of_work"`
+	Messages    FormMessages          `json:"messages"`
+}
+
+// FormFieldDefinition is a form field with its label in the requested locale.
+// Custom fields are sent in fields and their errors refer to fields[name].
+type FormFieldDefinition struct {
+	Name        string   `json:"name" example:"email"`
+	Label       string   `json:"label" example:"Email"`
+	Type        string   `json:"type" example:"email"`
+	Required    bool     `json:"required"`
+	MaxLength   int      `json:"max_length,omitempty" example:"254"`
+	Options     []string `json:"options,omitempty"`
+	Placeholder string   `json:"placeholder,omitempty"`
+	Custom      bool     `json:"custom,omitempty"`
+}
+
+// FormMessages holds the texts the widget shows besides API responses
+type FormMessages struct {
+	Submit  string `json:"submit" example:"Send"`
+	Sending string `json:"sending" example:"Sending…"`
+	Error   string `json:"error" example:"Your message could not be sent. Check your connection and try again."`
+	Trap    string `json:"trap" example:"Leave this field empty"`
+}
+
+// maxCustomFieldLength matches the limit on fields of ContactFormData
+const maxCustomFieldLength = 1000
+
+// ContactFormDefinition describes the contact form of a website
+// @Summary Get contact form
+// @Description Describe the fields, labels and spam protection of a website's contact form, as rendered by the widget. Labels are localized by the locale parameter, the Accept-Language header and the website default.
+// @Tags contact
+// @Produce json
+// @Param website path string true "Website identifier" example:"main"
+// @Param locale query string false "Language of labels and messages" example:"es"
+// @Success 200 {object} Response{data=FormDefinition}
+// @Failure 404 {object} Response
+// @Router /contact/{website}/form [get]
+func (a *API) ContactFormDefinition(c *gin.Context) {
+	website := c.Param("website")
+	site, ok := a.lookupWebsite(website)
+	if !ok || site.Disabled {
+		fail(c, http.StatusNotFound, CodeWebsiteNotFound, i18n.T(requestLocale(c, config.Website{}, c.Query("locale")), "website.not_found"))
+		return
+	}
+
+	locale := requestLocale(c, site, c.Query("locale"))
+	respond(c, http.StatusOK, Response{
+		Success: true,
+		Message: "Contact form",
+		Data:    formDefinition(site, website, locale),
+	})
+}
+
+// formDefinition builds the form of a website in a locale
+func formDefinition(site config.Website, website, locale string) FormDefinition {
+	messages := catalogLocale(site, locale)
+
+	// Limits match the bindings of ContactFormData
+	fields := []FormFieldDefinition{
+		{Name: "name", Label: i18n.T(messages, "form.name"), Type: config.FieldText, Required: true, MaxLength: 200},
+		{Name: "email", Label: i18n.T(messages, "form.email"), Type: config.FieldEmail, Required: true, MaxLength: 254},
+		{Name: "subject", Label: i18n.T(messages, "form.subject"), Type: config.FieldText, Required: true, MaxLength: 200},
+		{Name: "message", Label: i18n.T(messages, "form.message"), Type: config.FieldTextarea, Required: true, MaxLength: 10000},
+	}
+	for _, field := range site.Form.Fields {
+		def := FormFieldDefinition{
+			Name:      field.Name,
+			Label:     formLabel(site, field, locale),
+			Type:      field.Type,
+			Required:  field.Required,
+			MaxLength: maxCustomFieldLength,
+			Options:   field.Options,
+			Custom:    true,
+		}
+		if def.Type == "" {
+			def.Type = config.FieldText
+		}
+		if def.Type == config.FieldSelect {
+			def.Placeholder = i18n.T(messages, "form.choose")
+		}
+		fields = append(fields, def)
+	}
+
+	return FormDefinition{
+		Website:     website,
+		Locale:      locale,
+		Fields:      fields,
+		Honeypot:    site.AntiSpam.Honeypot,
+		ProofOfWork: site.AntiSpam.ProofOfWork.Enabled,
+		Messages: FormMessages{
+			Submit:  i18n.T(messages, "form.submit"),
+			Sending: i18n.T(messages, "form.sending"),
+			Error:   i18n.T(messages, "form.error"),
+			Trap:    i18n.T(messages, "form.trap"),
+		},
+	}
+}
+
+// formLabel returns the label of a custom field in locale, walking its
+// fallback chain through the website default like localizedTemplate
+func formLabel(site config.Website, field config.FormField, locale string) string {
+	defaultLocale := i18n.Normalize(site.DefaultLocale)
+	for _, tag := range i18n.Chain(locale, site.DefaultLocale) {
+		for key, label := range field.Labels {
+			if i18n.Normalize(key) == tag && label != "" {
+				return label
+			}
+		}
+		if field.Label != "" && tag == defaultLocale {
+			return field.Label
+		}
+	}
+	if field.Label != "" {
+		return field.Label
+	}
+	return field.Name
+}
+
+// checkFormFields checks the custom fields of a submission against the form
+// of its website
+func checkFormFields(site config.Website, form ContactFormData, locale string) []FieldError {
+	var problems []FieldError
+	for _, field := range site.Form.Fields {
+		name := "fields[" + field.Name + "]"
+		value := strings.TrimSpace(form.Fields[field.Name])
+		if value == "" || (field.Type == config.FieldCheckbox && value == "false") {
+			if field.Required {
+				problems = append(problems, FieldError{Field: name, Code: FieldRequired, Message: i18n.T(locale, "field.required")})
+			}
+			continue
+		}
+
+		ok := true
+		switch field.Type {
+		case config.FieldEmail:
+			if _, err := mail.ParseAddress(value); err != nil {
+				problems = append(problems, FieldError{Field: name, Code: FieldInvalidEmail, Message: i18n.T(locale, "field.invalid_email")})
+			}
+			continue
+		case config.FieldSelect:
+			ok = slices.Contains(field.Options, value)
+		case config.FieldCheckbox:
+			ok = value == "true"
+		case config.FieldNumber:
+			_, err := strconv.ParseFloat(value, 64)
+			ok = err == nil
+		case config.FieldURL:
+			u, err := url.Parse(value)
+			ok = err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+		}
+		if !ok {
+			problems = append(problems, FieldError{Field: name, Code: FieldInvalid, Message: i18n.T(locale, "field.invalid")})
+		}
+	}
+	return problems
+}
+
+// WidgetScript serves the contact form widget
+// @Summary Contact form widget
+// @Description JavaScript rendering the contact form of a website into elements marked data-contact-form, configured with data-website, data-locale, data-mode="iframe", data-styles, data-redirect and data-api attributes. It shows validation errors next to their fields and handles the honeypot and proof of work.
+// @Tags widget
+// @Produce application/javascript
+// @Success 200 {string} string
+// @Router /widget/v1/widget.js [get]
+func (a *API) WidgetScript(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Data(http.StatusOK, "text/javascript; charset=utf-8", widget.Script)
+}
+
+// WidgetStyles serves the default theme of the widget
+// @Summary Contact form widget styles
+// @Description Default stylesheet of the widget, customized through CSS variables such as --contact-accent on .contact-form
+// @Tags widget
+// @Produce text/css
+// @Success 200 {string} string
+// @Router /widget/v1/widget.css [get]
+func (a *API) WidgetStyles(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Data(http.StatusOK, "text/css; charset=utf-8", widget.Styles)
+}
+
+// WidgetFrame serves a page showing the contact form of a website, for
+// embedding in an iframe
+// @Summary Contact form page
+// @Description HTML page showing the contact form of a website, for embedding in an iframe. The page reports its height and submission events to the embedding page with postMessage.
+// @Tags widget
+// @Produce html
+// @Param website path string true "Website identifier" example:"main"
+// @Param locale query string false "Language of labels and messages" example:"es"
+// @Success 200 {string} string
+// @Failure 404 {object} Response
+// @Router /widget/v1/frame/{website} [get]
+func (a *API) WidgetFrame(c *gin.Context) {
+	website := c.Param("website")
+	site, ok := a.lookupWebsite(website)
+	if !ok || site.Disabled {
+		fail(c, http.StatusNotFound, CodeWebsiteNotFound, i18n.T(requestLocale(c, config.Website{}, c.Query("locale")), "website.not_found"))
+		return
+	}
+
+	locale := requestLocale(c, site, c.Query("locale"))
+	var page bytes.Buffer
+	frame := widget.Frame{Website: website, Locale: locale, Title: i18n.T(catalogLocale(site, locale), "form.title")}
+	if err := frame.Render(&page); err != nil {
+		a.internalError(c, "Failed to render the form page", err)
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/email"
+)
+
+func formWebsites() map[string]config.Website {
+	return map[string]config.Website{
+		"main": {
+			DefaultLocale: "es",
+			AntiSpam:      config.AntiSpam{Honeypot: true, ProofOfWork: config.ProofOfWork{Enabled: true}},
+			Form: config.Form{Fields: []config.FormField{
+				{Name: "company", Label: "Empresa", Labels: map[string]string{"en": "Company"}},
+				{Name: "team", Label: "Team", Type: config.FieldSelect, Required: true, Options: []string{"sales", "support"}},
+				{Name: "terms", Type: config.FieldCheckbox, Required: true},
+				{Name: "budget", Type: config.FieldNumber},
+			}},
+		},
+		"plain":  {},
+		"closed": {Disabled: true},
+	}
+}
+
+func TestContactFormDefinition(t *testing.T) {
+	api, r := setupLocaleAPI(formWebsites())
+	r.GET("/api/v1/contact/:website/form", api.ContactFormDefinition)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedLocale string
+		expectedLabels []string
+	}{
+		{
+			name:           "website default locale",
+			path:           "/api/v1/contact/main/form",
+			expectedStatus: http.StatusOK,
+			expectedLocale: "es",
+			expectedLabels: []string{"Nombre", "Correo electrónico", "Asunto", "Mensaje", "Empresa", "Team", "terms", "budget"},
+		},
+		{
+			name:           "requested locale",
+			path:           "/api/v1/contact/main/form?locale=en",
+			expectedStatus: http.StatusOK,
+			expectedLocale: "en",
+			expectedLabels: []string{"Name", "Email", "Subject", "Message", "Company", "Team", "terms", "budget"},
+		},
+		{
+			name:           "built-in fields only",
+			path:           "/api/v1/contact/plain/form",
+			expectedStatus: http.StatusOK,
+			expectedLocale: "en",
+			expectedLabels: []string{"Name", "Email", "Subject", "Message"},
+		},
+		{name: "disabled website", path: "/api/v1/contact/closed/form", expectedStatus: http.StatusNotFound},
+		{name: "unknown website", path: "/api/v1/contact/nope/form", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, response := doAdminRequest(t, r, "GET", tt.path, "", nil)
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status code %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var def FormDefinition
+			decodeData(t, response, &def)
+			if def.Locale != tt.expectedLocale {
+				t.Errorf("Expected locale '%s', got '%s'", tt.expectedLocale, def.Locale)
+			}
+			labels := make([]string, 0, len(def.Fields))
+			for _, field := range def.Fields {
+				labels = append(labels, field.Label)
+			}
+			if strings.Join(labels, ",") != strings.Join(tt.expectedLabels, ",") {
+				t.Errorf("Expected labels %v, got %v", tt.expectedLabels, labels)
+			}
+		})
+	}
+
+	_, response := doAdminRequest(t, r, "GET", "/api/v1/contact/main/form?locale=en", "", nil)
+	var def FormDefinition
+	decodeData(t, response, &def)
+	if !def.Honeypot || !def.ProofOfWork {
+		t.Errorf("Expected the honeypot and proof of work to be announced, got %+v", def)
+	}
+	team := def.Fields[5]
+	if !team.Custom || !team.Required || team.Type != config.FieldSelect || len(team.Options) != 2 || team.Placeholder == "" {
+		t.Errorf("Unexpected select field: %+v", team)
+	}
+	if def.Fields[4].Type != config.FieldText {
+		t.Errorf("Expected custom fields to default to text, got '%s'", def.Fields[4].Type)
+	}
+}
+
+func TestContactHandler_FormFields(t *testing.T) {
+	originalDialer := email.DefaultSMTPDialer
+	email.DefaultSMTPDialer = func(addr string) (email.SMTPClient, error) {
+		return &email.MockSMTPClient{}, nil
+	}
+	defer func() { email.DefaultSMTPDialer = originalDialer }()
+
+	websites := formWebsites()
+	main := websites["main"]
+	main.AntiSpam = config.AntiSpam{}
+	websites["main"] = main
+	_, r := setupLocaleAPI(websites)
+
+	tests := []struct {
+		name           string
+		fields         map[string]string
+		expectedStatus int
+		expectedErrors map[string]string
+	}{
+		{
+			name:           "missing required fields",
+			fields:         map[string]string{"company": "Acme", "terms": "false"},
+			expectedStatus: http.StatusBadRequest,
+			expectedErrors: map[string]string{"fields[team]": FieldRequired, "fields[terms]": FieldRequired},
+		},
+		{
+			name:           "invalid values",
+			fields:         map[string]string{"team": "marketing", "terms": "yes", "budget": "lots"},
+			expectedStatus: http.StatusBadRequest,
+			expectedErrors: map[string]string{"fields[team]": FieldInvalid, "fields[terms]": FieldInvalid, "fields[budget]": FieldInvalid},
+		},
+		{
+			name:           "valid",
+			fields:         map[string]string{"team": "sales", "terms": "true", "budget": "1500.50", "extra": "kept"},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := ContactFormData{
+				Name:    "John",
+				Email:   "john@example.com",
+				Subject: "Hello",
+				Message: "Hello",
+				Fields:  tt.fields,
+			}
+			w, response := doAdminRequest(t, r, "POST", "/api/v1/contact/main", "", form)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status code %d, got %d: %s", tt.expectedStatus, w.Code, response.Message)
+			}
+			errs := map[string]string{}
+			for _, fe := range response.Errors {
+				errs[fe.Field] = fe.Code
+			}
+			if len(errs) != len(tt.expectedErrors) {
+				t.Errorf("Expected errors %v, got %v", tt.expectedErrors, errs)
+			}
+			for field, code := range tt.expectedErrors {
+				if errs[field] != code {
+					t.Errorf("Expected %s error for '%s', got '%s'", code, field, errs[field])
+				}
+			}
+		})
+	}
+}
+
+func TestWidgetAssets(t *testing.T) {
+	api, r := setupLocaleAPI(formWebsites())
+	r.GET("/api/v1/widget/v1/widget.js", api.WidgetScript)
+	r.GET("/api/v1/widget/v1/widget.css", api.WidgetStyles)
+	r.GET("/api/v1/widget/v1/frame/:website", api.WidgetFrame)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		contentType    string
+		contains       string
+	}{
+		{name: "script", path: "/api/v1/widget/v1/widget.js", expectedStatus: http.StatusOK, contentType: "text/javascript", contains: "ContactForm"},
+		{name: "styles", path: "/api/v1/widget/v1/widget.css", expectedStatus: http.StatusOK, contentType: "text/css", contains: "--contact-accent"},
+		{name: "frame", path: "/api/v1/widget/v1/frame/main?locale=pt", expectedStatus: http.StatusOK, contentType: "text/html", contains: `data-website="main" data-locale="pt"`},
+		{name: "frame of disabled website", path: "/api/v1/widget/v1/frame/closed", expectedStatus: http.StatusNotFound, contentType: "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, tt.contentType) {
+				t.Errorf("Expected content type '%s', got '%s'", tt.contentType, ct)
+			}
+			if !strings.Contains(w.Body.String(), tt.contains) {
+				t.Errorf("Expected the body to contain '%s'", tt.contains)
+			}
+		})
+	}
+}
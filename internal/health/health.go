@@ -0,0 +1,195 @@
+// Package health provides cached dependency checks for readiness probes
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Status values reported for dependencies and for the overall report
+const (
+	StatusUp       = "up"
+	StatusDegraded = "degraded"
+	StatusDown     = "down"
+)
+
+// ErrDegraded marks a check failure that should not take the service out of rotation
+var ErrDegraded = errors.New("degraded")
+
+// Checker probes a single dependency
+type Checker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+// checkFunc adapts a plain function to the Checker interface
+type checkFunc struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+func (c checkFunc) Name() string                    { return c.name }
+func (c checkFunc) Check(ctx context.Context) error { return c.fn(ctx) }
+
+// CheckFunc creates a named Checker from a function
+func CheckFunc(name string, fn func(ctx context.Context) error) Checker {
+	return checkFunc{name: name, fn: fn}
+}
+
+// Result holds the outcome of a single dependency check
+type Result struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Report holds the aggregated outcome of all dependency checks
+type Report struct {
+	Status    string            `json:"status"`
+	CheckedAt time.Time         `json:"checked_at"`
+	Checks    map[string]Result `json:"checks"`
+}
+
+// Ready reports whether the service should receive traffic
+func (r Report) Ready() bool {
+	return r.Status != StatusDown
+}
+
+// Monitor runs dependency checks and caches the report so that frequent
+// probes do not hammer the dependencies
+type Monitor struct {
+	checks  []Checker
+	ttl     time.Duration
+	timeout time.Duration
+
+	mu      sync.Mutex
+	report  Report
+	expires time.Time
+}
+
+// NewMonitor creates a monitor that caches reports for ttl and bounds each
+// check by timeout
+func NewMonitor(ttl, timeout time.Duration, checks ...Checker) *Monitor {
+	return &Monitor{
+		checks:  checks,
+		ttl:     ttl,
+		timeout: timeout,
+	}
+}
+
+// Configure changes the cache TTL and check timeout, expiring the cached report
+func (m *Monitor) Configure(ttl, timeout time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.ttl = ttl
+	m.timeout = timeout
+	m.expires = time.Time{}
+}
+
+// Check returns the cached report or runs all checks if it has expired.
+// Concurrent callers wait for a single in-flight run instead of starting their own.
+func (m *Monitor) Check(ctx context.Context) Report {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(m.expires) {
+		return m.report
+	}
+
+	m.report = m.run(ctx)
+	m.expires = time.Now().Add(m.ttl)
+	return m.report
+}
+
+// run executes all checks in parallel and aggregates their results
+func (m *Monitor) run(ctx context.Context) Report {
+	report := Report{
+		Status:    StatusUp,
+		CheckedAt: time.Now().UTC(),
+		Checks:    make(map[string]Result, len(m.checks)),
+	}
+
+	results := make([]Result, len(m.checks))
+	var wg sync.WaitGroup
+	for i, checker := range m.checks {
+		wg.Add(1)
+		go func(i int, checker Checker) {
+			defer wg.Done()
+			results[i] = m.runOne(ctx, checker)
+		}(i, checker)
+	}
+	wg.Wait()
+
+	for i, checker := range m.checks {
+		result := results[i]
+		report.Checks[checker.Name()] = result
+
+		switch {
+		case result.Status == StatusDown:
+			report.Status = StatusDown
+		case result.Status == StatusDegraded && report.Status == StatusUp:
+			report.Status = StatusDegraded
+		}
+	}
+
+	return report
+}
+
+// runOne executes a single check, abandoning it if it exceeds the timeout
+func (m *Monitor) runOne(ctx context.Context, checker Checker) Result {
+	if m.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- checker.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := Result{
+		Status:    StatusUp,
+		LatencyMS: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = StatusDown
+		if errors.Is(err, ErrDegraded) {
+			result.Status = StatusDegraded
+		}
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// Threshold creates a check that measures a depth, such as a queue length, and
+// reports degraded above warn and down above max. A zero threshold is disabled.
+func Threshold(name string, warn, max int, measure func(ctx context.Context) (int, error)) Checker {
+	return CheckFunc(name, func(ctx context.Context) error {
+		depth, err := measure(ctx)
+		if err != nil {
+			return err
+		}
+		if max > 0 && depth > max {
+			return fmt.Errorf("depth %d above maximum %d", depth, max)
+		}
+		if warn > 0 && depth > warn {
+			return fmt.Errorf("depth %d above warning threshold %d: %w", depth, warn, ErrDegraded)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,132 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMonitor_Check(t *testing.T) {
+	tests := []struct {
+		name           string
+		checks         []Checker
+		expectedStatus string
+		expectedReady  bool
+	}{
+		{
+			name:           "No checks",
+			checks:         nil,
+			expectedStatus: StatusUp,
+			expectedReady:  true,
+		},
+		{
+			name: "All up",
+			checks: []Checker{
+				CheckFunc("smtp", func(_ context.Context) error { return nil }),
+				CheckFunc("storage", func(_ context.Context) error { return nil }),
+			},
+			expectedStatus: StatusUp,
+			expectedReady:  true,
+		},
+		{
+			name: "Degraded dependency",
+			checks: []Checker{
+				CheckFunc("smtp", func(_ context.Context) error { return nil }),
+				CheckFunc("outbox", func(_ context.Context) error {
+					return fmt.Errorf("depth 120 above warning threshold: %w", ErrDegraded)
+				}),
+			},
+			expectedStatus: StatusDegraded,
+			expectedReady:  true,
+		},
+		{
+			name: "Down dependency",
+			checks: []Checker{
+				CheckFunc("smtp", func(_ context.Context) error { return errors.New("connection refused") }),
+				CheckFunc("outbox", func(_ context.Context) error { return ErrDegraded }),
+			},
+			expectedStatus: StatusDown,
+			expectedReady:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMonitor(time.Minute, time.Second, tt.checks...)
+			report := m.Check(context.Background())
+
+			if report.Status != tt.expectedStatus {
+				t.Errorf("Status = %q, want %q", report.Status, tt.expectedStatus)
+			}
+			if report.Ready() != tt.expectedReady {
+				t.Errorf("Ready() = %v, want %v", report.Ready(), tt.expectedReady)
+			}
+			if len(report.Checks) != len(tt.checks) {
+				t.Errorf("got %d check results, want %d", len(report.Checks), len(tt.checks))
+			}
+		})
+	}
+}
+
+func TestMonitor_CachesReport(t *testing.T) {
+	var calls atomic.Int32
+	m := NewMonitor(time.Minute, time.Second, CheckFunc("smtp", func(_ context.Context) error {
+		calls.Add(1)
+		return nil
+	}))
+
+	for i := 0; i < 5; i++ {
+		m.Check(context.Background())
+	}
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("check ran %d times, want 1", got)
+	}
+}
+
+func TestMonitor_Timeout(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	m := NewMonitor(0, 10*time.Millisecond, CheckFunc("smtp", func(_ context.Context) error {
+		<-block
+		return nil
+	}))
+
+	report := m.Check(context.Background())
+	result := report.Checks["smtp"]
+	if result.Status != StatusDown {
+		t.Errorf("Status = %q, want %q", result.Status, StatusDown)
+	}
+	if result.Error == "" {
+		t.Error("expected timeout error to be reported")
+	}
+}
+
+func TestThreshold(t *testing.T) {
+	tests := []struct {
+		name           string
+		depth          int
+		expectedStatus string
+	}{
+		{name: "below warning", depth: 5, expectedStatus: StatusUp},
+		{name: "above warning", depth: 15, expectedStatus: StatusDegraded},
+		{name: "above maximum", depth: 150, expectedStatus: StatusDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := Threshold("outbox", 10, 100, func(_ context.Context) (int, error) {
+				return tt.depth, nil
+			})
+			report := NewMonitor(0, time.Second, check).Check(context.Background())
+
+			if got := report.Checks["outbox"].Status; got != tt.expectedStatus {
+				t.Errorf("Status = %q, want %q", got, tt.expectedStatus)
+			}
+		})
+	}
+}
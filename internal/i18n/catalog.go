@@ -0,0 +1,153 @@
+package i18n
+
+// catalog maps a locale to its messages by key. Every key must exist in the
+// Default locale, which the others fall back to.
+var catalog = map[string]map[string]string{
+	"en": {
+		"contact.sent":           "Your message has been sent successfully! We will get back to you soon.",
+		"contact.failed":         "Failed to send your message. Please try again later.",
+		"contact.too_many_links": "Your message contains too many links.",
+		"contact.spam":           "Your message looks like spam and was not sent.",
+		"contact.blocked":        "Your message could not be accepted.",
+		"challenge.required":     "Please wait for the spam check to finish before sending.",
+		"challenge.expired":      "The spam check expired. Please try again.",
+		"challenge.failed":       "The spam check failed. Please reload the page and try again.",
+		"website.not_found":      "Website not found",
+		"request.invalid_fields": "Please correct the highlighted fields.",
+		"request.empty":          "Request body is empty",
+		"request.invalid_json":   "Request body must be valid JSON",
+		"field.required":         "is required",
+		"field.invalid_email":    "must be a valid email address",
+		"field.too_short.chars":  "must be at least %s characters",
+		"field.too_short.items":  "must have at least %s items",
+		"field.too_long.chars":   "must be at most %s characters",
+		"field.too_long.items":   "must have at most %s items",
+		"field.invalid":          "is invalid",
+		"field.single_line":      "must be a single line",
+		"field.email_typo":       "looks misspelled, did you mean %s?",
+		"field.disposable_email": "must not be a disposable email address",
+		"field.undeliverable":    "does not accept email",
+		"field.type.string":      "must be a string",
+		"field.type.number":      "must be a number",
+		"field.type.boolean":     "must be true or false",
+		"field.type.array":       "must be a list",
+		"field.type.object":      "must be an object",
+		"form.title":             "Contact form",
+		"form.name":              "Name",
+		"form.email":             "Email",
+		"form.subject":           "Subject",
+		"form.message":           "Message",
+		"form.submit":            "Send",
+		"form.sending":           "Sending…",
+		"form.error":             "Your message could not be sent. Check your connection and try again.",
+		"form.trap":              "Leave this field empty",
+		"form.choose":            "Choose…",
+		"email.subject":          "[%s] Contact Form: %s",
+		"email.title":            "New Contact Form Submission",
+		"email.name":             "Name",
+		"email.email":            "Email",
+		"email.subject_label":    "Subject",
+		"email.message":          "Message",
+		"reply.subject":          "We received your message",
+		"reply.greeting":         "Hi %s,",
+		"reply.body":             "Thanks for getting in touch. We received your message and will get back to you soon.",
+	},
+	"es": {
+		"contact.sent":           "¡Tu mensaje se ha enviado correctamente! Te responderemos pronto.",
+		"contact.failed":         "No se pudo enviar tu mensaje. Inténtalo de nuevo más tarde.",
+		"contact.too_many_links": "Tu mensaje contiene demasiados enlaces.",
+		"contact.spam":           "Tu mensaje parece spam y no se ha enviado.",
+		"contact.blocked":        "No se pudo aceptar tu mensaje.",
+		"challenge.required":     "Espera a que termine la verificación antispam antes de enviar.",
+		"challenge.expired":      "La verificación antispam caducó. Inténtalo de nuevo.",
+		"challenge.failed":       "La verificación antispam falló. Recarga la página e inténtalo de nuevo.",
+		"website.not_found":      "Sitio web no encontrado",
+		"request.invalid_fields": "Corrige los campos marcados.",
+		"request.empty":          "El cuerpo de la solicitud está vacío",
+		"request.invalid_json":   "El cuerpo de la solicitud debe ser JSON válido",
+		"field.required":         "es obligatorio",
+		"field.invalid_email":    "debe ser una dirección de correo válida",
+		"field.too_short.chars":  "debe tener al menos %s caracteres",
+		"field.too_short.items":  "debe tener al menos %s elementos",
+		"field.too_long.chars":   "debe tener como máximo %s caracteres",
+		"field.too_long.items":   "debe tener como máximo %s elementos",
+		"field.invalid":          "no es válido",
+		"field.single_line":      "debe ocupar una sola línea",
+		"field.email_typo":       "parece mal escrito, ¿quisiste decir %s?",
+		"field.disposable_email": "no debe ser una dirección de correo desechable",
+		"field.undeliverable":    "no acepta correo",
+		"field.type.string":      "debe ser un texto",
+		"field.type.number":      "debe ser un número",
+		"field.type.boolean":     "debe ser verdadero o falso",
+		"field.type.array":       "debe ser una lista",
+		"field.type.object":      "debe ser un objeto",
+		"form.title":             "Formulario de contacto",
+		"form.name":              "Nombre",
+		"form.email":             "Correo electrónico",
+		"form.subject":           "Asunto",
+		"form.message":           "Mensaje",
+		"form.submit":            "Enviar",
+		"form.sending":           "Enviando…",
+		"form.error":             "No se pudo enviar tu mensaje. Comprueba tu conexión e inténtalo de nuevo.",
+		"form.trap":              "Deja este campo vacío",
+		"form.choose":            "Elige…",
+		"email.subject":          "[%s] Formulario de contacto: %s",
+		"email.title":            "Nuevo mensaje del formulario de contacto",
+		"email.name":             "Nombre",
+		"email.email":            "Correo electrónico",
+		"email.subject_label":    "Asunto",
+		"email.message":          "Mensaje",
+		"reply.subject":          "Hemos recibido tu mensaje",
+		"reply.greeting":         "Hola, %s:",
+		"reply.body":             "Gracias por escribirnos. Hemos recibido tu mensaje y te responderemos pronto.",
+	},
+	"pt": {
+		"contact.sent":           "Sua mensagem foi enviada com sucesso! Responderemos em breve.",
+		"contact.failed":         "Não foi possível enviar sua mensagem. Tente novamente mais tarde.",
+		"contact.too_many_links": "Sua mensagem contém links demais.",
+		"contact.spam":           "Sua mensagem parece spam e não foi enviada.",
+		"contact.blocked":        "Não foi possível aceitar sua mensagem.",
+		"challenge.required":     "Aguarde a verificação antispam terminar antes de enviar.",
+		"challenge.expired":      "A verificação antispam expirou. Tente novamente.",
+		"challenge.failed":       "A verificação antispam falhou. Recarregue a página e tente novamente.",
+		"website.not_found":      "Site não encontrado",
+		"request.invalid_fields": "Corrija os campos destacados.",
+		"request.empty":          "O corpo da requisição está vazio",
+		"request.invalid_json":   "O corpo da requisição deve ser um JSON válido",
+		"field.required":         "é obrigatório",
+		"field.invalid_email":    "deve ser um endereço de e-mail válido",
+		"field.too_short.chars":  "deve ter pelo menos %s caracteres",
+		"field.too_short.items":  "deve ter pelo menos %s itens",
+		"field.too_long.chars":   "deve ter no máximo %s caracteres",
+		"field.too_long.items":   "deve ter no máximo %s itens",
+		"field.invalid":          "é inválido",
+		"field.single_line":      "deve ocupar uma única linha",
+		"field.email_typo":       "parece digitado errado, você quis dizer %s?",
+		"field.disposable_email": "não deve ser um endereço de e-mail descartável",
+		"field.undeliverable":    "não aceita e-mails",
+		"field.type.string":      "deve ser um texto",
+		"field.type.number":      "deve ser um número",
+		"field.type.boolean":     "deve ser verdadeiro ou falso",
+		"field.type.array":       "deve ser uma lista",
+		"field.type.object":      "deve ser um objeto",
+		"form.title":             "Formulário de contato",
+		"form.name":              "Nome",
+		"form.email":             "E-mail",
+		"form.subject":           "Assunto",
+		"form.message":           "Mensagem",
+		"form.submit":            "Enviar",
+		"form.sending":           "Enviando…",
+		"form.error":             "Não foi possível enviar sua mensagem. Verifique sua conexão e tente novamente.",
+		"form.trap":              "Deixe este campo vazio",
+		"form.choose":            "Escolha…",
+		"email.subject":          "[%s] Formulário de contato: %s",
+		"email.title":            "Nova mensagem do formulário de contato",
+		"email.name":             "Nome",
+		"email.email":            "E-mail",
+		"email.subject_label":    "Assunto",
+		"email.message":          "Mensagem",
+		"reply.subject":          "Recebemos sua mensagem",
+		"reply.greeting":         "Olá, %s,",
+		"reply.body":             "Obrigado pelo contato. Recebemos sua mensagem e responderemos em breve.",
+	},
+}
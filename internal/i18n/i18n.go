@@ -0,0 +1,130 @@
+// Package i18n selects locales and translates the messages shown to visitors
+package i18n
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Default is the locale used when no preferred locale is supported
+const Default = "en"
+
+// tagPattern matches normalized BCP 47 language tags such as en or pt-br
+var tagPattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+// Normalize lowercases a language tag and uses hyphens as separators
+func Normalize(tag string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+}
+
+// ValidTag reports whether tag is a well-formed language tag
+func ValidTag(tag string) bool {
+	return tagPattern.MatchString(Normalize(tag))
+}
+
+// Locales returns the locales the catalog has messages for
+func Locales() []string {
+	locales := make([]string, 0, len(catalog))
+	for locale := range catalog {
+		locales = append(locales, locale)
+	}
+	slices.Sort(locales)
+	return locales
+}
+
+// Supported reports whether the catalog has messages for a locale
+func Supported(tag string) bool {
+	_, ok := catalog[Normalize(tag)]
+	return ok
+}
+
+// Chain expands preferred tags into a fallback chain: each tag is followed by
+// its base language, and Default comes last. Empty and malformed tags are
+// skipped and duplicates removed.
+func Chain(tags ...string) []string {
+	var chain []string
+	add := func(tag string) {
+		if !slices.Contains(chain, tag) {
+			chain = append(chain, tag)
+		}
+	}
+	for _, tag := range tags {
+		tag = Normalize(tag)
+		if !tagPattern.MatchString(tag) {
+			continue
+		}
+		add(tag)
+		if base, _, found := strings.Cut(tag, "-"); found {
+			add(base)
+		}
+	}
+	add(Default)
+	return chain
+}
+
+// Match returns the first locale of the fallback chain of tags that the
+// catalog supports
+func Match(tags ...string) string {
+	for _, tag := range Chain(tags...) {
+		if Supported(tag) {
+			return tag
+		}
+	}
+	return Default
+}
+
+// ParseAcceptLanguage returns the tags of an Accept-Language header ordered
+// by preference. Wildcards and tags with q=0 are dropped.
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	var prefs []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = Normalize(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+		prefs = append(prefs, weighted{tag: tag, q: q})
+	}
+
+	sort.SliceStable(prefs, func(i, j int) bool { return prefs[i].q > prefs[j].q })
+	tags := make([]string, 0, len(prefs))
+	for _, p := range prefs {
+		tags = append(tags, p.tag)
+	}
+	return tags
+}
+
+// T returns the message for key in the first locale of the fallback chain of
+// locale that defines it, formatted with args. Unknown keys are returned as is.
+func T(locale, key string, args ...any) string {
+	for _, tag := range Chain(locale) {
+		if format, ok := catalog[tag][key]; ok {
+			if len(args) == 0 {
+				return format
+			}
+			return fmt.Sprintf(format, args...)
+		}
+	}
+	return key
+}
@@ -0,0 +1,80 @@
+package i18n
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCatalog_Complete(t *testing.T) {
+	for _, locale := range Locales() {
+		for key := range catalog[Default] {
+			if _, ok := catalog[locale][key]; !ok {
+				t.Errorf("locale %s is missing %s", locale, key)
+			}
+		}
+		for key := range catalog[locale] {
+			if _, ok := catalog[Default][key]; !ok {
+				t.Errorf("locale %s has %s, which %s does not define", locale, key, Default)
+			}
+		}
+	}
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected []string
+	}{
+		{header: "", expected: []string{}},
+		{header: "es", expected: []string{"es"}},
+		{header: "en-US,en;q=0.9,pt-BR;q=0.95", expected: []string{"en-us", "pt-br", "en"}},
+		{header: "fr;q=0, *;q=0.5, de;q=0.1", expected: []string{"de"}},
+		{header: "pt_BR;q=bad, es", expected: []string{"es"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := ParseAcceptLanguage(tt.header); !slices.Equal(got, tt.expected) {
+				t.Errorf("ParseAcceptLanguage(%q) = %v, want %v", tt.header, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+	got := Chain("pt-BR", "", "not a tag", "es", "pt")
+	expected := []string{"pt-br", "pt", "es", "en"}
+	if !slices.Equal(got, expected) {
+		t.Errorf("Chain() = %v, want %v", got, expected)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		tags     []string
+		expected string
+	}{
+		{tags: []string{"pt-BR"}, expected: "pt"},
+		{tags: []string{"fr", "es"}, expected: "es"},
+		{tags: []string{"de"}, expected: "en"},
+		{tags: nil, expected: "en"},
+	}
+
+	for _, tt := range tests {
+		if got := Match(tt.tags...); got != tt.expected {
+			t.Errorf("Match(%v) = %q, want %q", tt.tags, got, tt.expected)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	if got := T("es-MX", "field.required"); got != "es obligatorio" {
+		t.Errorf("T(es-MX) = %q, want the es message", got)
+	}
+	if got := T("fr", "field.too_long.chars", "200"); got != "must be at most 200 characters" {
+		t.Errorf("T(fr) = %q, want the en fallback", got)
+	}
+	if got := T("pt", "missing.key"); got != "missing.key" {
+		t.Errorf("T() = %q, want the key for unknown messages", got)
+	}
+}
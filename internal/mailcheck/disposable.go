@@ -0,0 +1,48 @@
+package mailcheck
+
+import "strings"
+
+// DisposableDomains are throwaway email services
+var DisposableDomains = []string{
+	"10minutemail.com",
+	"dispostable.com",
+	"fakeinbox.com",
+	"getnada.com",
+	"guerrillamail.com",
+	"mailinator.com",
+	"maildrop.cc",
+	"mintemail.com",
+	"sharklasers.com",
+	"temp-mail.org",
+	"tempmail.com",
+	"throwawaymail.com",
+	"trashmail.com",
+	"yopmail.com",
+}
+
+// DisposableList is a set of disposable email domains
+type DisposableList map[string]bool
+
+// NewDisposableList returns the built-in disposable domains plus extra ones
+func NewDisposableList(extra []string) DisposableList {
+	list := make(DisposableList, len(DisposableDomains)+len(extra))
+	for _, domain := range DisposableDomains {
+		list[domain] = true
+	}
+	for _, domain := range extra {
+		list[strings.ToLower(domain)] = true
+	}
+	return list
+}
+
+// Contains reports whether a domain belongs to a disposable email service.
+// Subdomains of a disposable service count as well.
+func (l DisposableList) Contains(domain string) bool {
+	for name := strings.ToLower(domain); name != ""; {
+		if l[name] {
+			return true
+		}
+		_, name, _ = strings.Cut(name, ".")
+	}
+	return false
+}
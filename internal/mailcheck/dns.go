@@ -0,0 +1,75 @@
+package mailcheck
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"time"
+)
+
+// ErrUndeliverable is returned for domains that do not accept email
+var ErrUndeliverable = errors.New("domain does not accept email")
+
+// Resolver looks up the DNS records that decide whether a domain accepts
+// email. *net.Resolver implements it.
+type Resolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// NewResolver returns a resolver querying the DNS server at addr (host:port),
+// or the system resolver when addr is empty
+func NewResolver(addr string) *net.Resolver {
+	if addr == "" {
+		return net.DefaultResolver
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}
+}
+
+// Deliverable checks that a domain accepts email. A domain needs MX records,
+// or an address record when it has none, and must not publish a null MX
+// record (RFC 7505). It returns ErrUndeliverable when the domain does not
+// accept email; any other error means the lookup itself failed.
+func Deliverable(ctx context.Context, r Resolver, domain string, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	records, err := r.LookupMX(ctx, domain)
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+	for _, mx := range records {
+		if host := strings.TrimSuffix(mx.Host, "."); host != "" {
+			return nil
+		}
+	}
+	if len(records) > 0 {
+		return ErrUndeliverable
+	}
+
+	// Without MX records, mail goes to the address of the domain itself
+	addrs, err := r.LookupHost(ctx, domain)
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+	if len(addrs) == 0 {
+		return ErrUndeliverable
+	}
+	return nil
+}
+
+// isNotFound reports whether a lookup failed because the records do not exist
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
@@ -0,0 +1,125 @@
+package mailcheck
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// MockDomain holds the records a MockDNSServer answers for a domain. An MX
+// host of "." publishes a null MX record.
+type MockDomain struct {
+	MX []string
+	A  []string
+}
+
+// MockDNSServer is a local DNS server answering MX and A queries for a fixed
+// set of domains, for testing. Other domains do not exist.
+type MockDNSServer struct {
+	conn    net.PacketConn
+	domains map[string]MockDomain
+}
+
+// StartMockDNSServer starts a DNS server on a random local UDP port
+func StartMockDNSServer(domains map[string]MockDomain) (*MockDNSServer, error) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &MockDNSServer{conn: conn, domains: make(map[string]MockDomain, len(domains))}
+	for name, domain := range domains {
+		s.domains[strings.ToLower(strings.TrimSuffix(name, "."))] = domain
+	}
+	go s.serve()
+	return s, nil
+}
+
+// Addr returns the host:port the server listens on
+func (s *MockDNSServer) Addr() string {
+	return s.conn.LocalAddr().String()
+}
+
+// Close stops the server
+func (s *MockDNSServer) Close() error {
+	return s.conn.Close()
+}
+
+// serve answers queries until the server is closed
+func (s *MockDNSServer) serve() {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if resp, err := s.answer(buf[:n]); err == nil {
+			s.conn.WriteTo(resp, addr)
+		}
+	}
+}
+
+// answer builds the response to a query
+func (s *MockDNSServer) answer(query []byte) ([]byte, error) {
+	var p dnsmessage.Parser
+	header, err := p.Start(query)
+	if err != nil {
+		return nil, err
+	}
+	q, err := p.Question()
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.ToLower(strings.TrimSuffix(q.Name.String(), "."))
+	domain, ok := s.domains[name]
+	rcode := dnsmessage.RCodeSuccess
+	if !ok {
+		rcode = dnsmessage.RCodeNameError
+	}
+
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{
+		ID:                 header.ID,
+		Response:           true,
+		Authoritative:      true,
+		RecursionDesired:   header.RecursionDesired,
+		RecursionAvailable: true,
+		RCode:              rcode,
+	})
+	b.EnableCompression()
+	if err := b.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := b.Question(q); err != nil {
+		return nil, err
+	}
+	if err := b.StartAnswers(); err != nil {
+		return nil, err
+	}
+
+	rh := dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: 60}
+	switch q.Type {
+	case dnsmessage.TypeMX:
+		for i, host := range domain.MX {
+			mx, err := dnsmessage.NewName(strings.TrimSuffix(host, ".") + ".")
+			if err != nil {
+				return nil, err
+			}
+			if err := b.MXResource(rh, dnsmessage.MXResource{Pref: uint16(10 * (i + 1)), MX: mx}); err != nil {
+				return nil, err
+			}
+		}
+	case dnsmessage.TypeA:
+		for _, a := range domain.A {
+			addr, err := netip.ParseAddr(a)
+			if err != nil {
+				return nil, err
+			}
+			if err := b.AResource(rh, dnsmessage.AResource{A: addr.As4()}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return b.Finish()
+}
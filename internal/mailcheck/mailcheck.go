@@ -0,0 +1,55 @@
+// Package mailcheck checks that a sender email address can receive replies.
+// It normalizes internationalized addresses, looks up the mail servers of
+// their domain, and spots disposable domains and common typos.
+package mailcheck
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Address is an email address split into its local part and domain
+type Address struct {
+	Local  string
+	Domain string
+}
+
+// String returns the address as local@domain
+func (a Address) String() string {
+	return a.Local + "@" + a.Domain
+}
+
+// SMTPUTF8 reports whether delivering to the address needs a server that
+// supports the SMTPUTF8 extension, because its local part is not ASCII
+func (a Address) SMTPUTF8() bool {
+	for i := 0; i < len(a.Local); i++ {
+		if a.Local[i] >= utf8.RuneSelf {
+			return true
+		}
+	}
+	return false
+}
+
+// Normalize parses an email address and returns it with its domain as
+// lowercase ASCII, converting internationalized domains to punycode, and its
+// local part in Unicode normalization form C. The case of the local part is
+// kept, since only the receiving server may interpret it.
+func Normalize(address string) (Address, error) {
+	address = strings.TrimSpace(address)
+	at := strings.LastIndexByte(address, '@')
+	if at <= 0 || at == len(address)-1 {
+		return Address{}, errors.New("missing local part or domain")
+	}
+
+	local := norm.NFC.String(address[:at])
+	domain, err := idna.Lookup.ToASCII(strings.TrimSuffix(address[at+1:], "."))
+	if err != nil {
+		return Address{}, fmt.Errorf("invalid domain %q: %w", address[at+1:], err)
+	}
+	return Address{Local: local, Domain: domain}, nil
+}
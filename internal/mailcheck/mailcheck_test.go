@@ -0,0 +1,136 @@
+package mailcheck
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		address  string
+		expected string
+		smtputf8 bool
+		wantErr  bool
+	}{
+		{address: "John@Example.COM", expected: "John@example.com"},
+		{address: " john@example.com. ", expected: "john@example.com"},
+		{address: "john@bücher.example", expected: "john@xn--bcher-kva.example"},
+		{address: "josé@example.com", expected: "josé@example.com", smtputf8: true},
+		{address: "jose\u0301@example.com", expected: "jos\u00e9@example.com", smtputf8: true},
+		{address: "\"a@b\"@example.com", expected: "\"a@b\"@example.com"},
+		{address: "john@", wantErr: true},
+		{address: "@example.com", wantErr: true},
+		{address: "john@exa mple.com", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			addr, err := Normalize(tt.address)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Normalize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if addr.String() != tt.expected {
+				t.Errorf("Normalize() = %q, want %q", addr, tt.expected)
+			}
+			if addr.SMTPUTF8() != tt.smtputf8 {
+				t.Errorf("SMTPUTF8() = %v, want %v", addr.SMTPUTF8(), tt.smtputf8)
+			}
+		})
+	}
+}
+
+func TestDeliverable(t *testing.T) {
+	server, err := StartMockDNSServer(map[string]MockDomain{
+		"mail.example":          {MX: []string{"mx1.mail.example", "mx2.mail.example"}},
+		"host.example":          {A: []string{"192.0.2.1"}},
+		"nomail.example":        {MX: []string{"."}, A: []string{"192.0.2.2"}},
+		"parked.example":        {},
+		"xn--bcher-kva.example": {MX: []string{"mx.example"}},
+	})
+	if err != nil {
+		t.Fatalf("StartMockDNSServer() returned error: %v", err)
+	}
+	defer server.Close()
+	resolver := NewResolver(server.Addr())
+
+	tests := []struct {
+		domain  string
+		wantErr error
+	}{
+		{domain: "mail.example"},
+		{domain: "host.example"},
+		{domain: "xn--bcher-kva.example"},
+		{domain: "nomail.example", wantErr: ErrUndeliverable},
+		{domain: "parked.example", wantErr: ErrUndeliverable},
+		{domain: "missing.example", wantErr: ErrUndeliverable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.domain, func(t *testing.T) {
+			if err := Deliverable(context.Background(), resolver, tt.domain, time.Second); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Deliverable() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDeliverable_LookupFailure(t *testing.T) {
+	server, err := StartMockDNSServer(nil)
+	if err != nil {
+		t.Fatalf("StartMockDNSServer() returned error: %v", err)
+	}
+	addr := server.Addr()
+	server.Close()
+
+	// A resolver that cannot be reached is no evidence against the domain
+	err = Deliverable(context.Background(), NewResolver(addr), "mail.example", 200*time.Millisecond)
+	if err == nil || errors.Is(err, ErrUndeliverable) {
+		t.Errorf("Expected a lookup error, got %v", err)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	tests := []struct {
+		domain   string
+		expected string
+	}{
+		{domain: "gmial.com", expected: "gmail.com"},
+		{domain: "gmail.con", expected: "gmail.com"},
+		{domain: "hotmal.com", expected: "hotmail.com"},
+		{domain: "yahooo.com", expected: "yahoo.com"},
+		{domain: "outlok.com", expected: "outlook.com"},
+		{domain: "gmail.com"},
+		{domain: "mail.com"},
+		{domain: "example.com"},
+		{domain: "gmx.net"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.domain, func(t *testing.T) {
+			if got := Suggest(tt.domain); got != tt.expected {
+				t.Errorf("Suggest() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDisposableList(t *testing.T) {
+	list := NewDisposableList([]string{"Burner.example"})
+
+	for domain, expected := range map[string]bool{
+		"mailinator.com":    true,
+		"eu.mailinator.com": true,
+		"burner.example":    true,
+		"notmailinator.com": false,
+		"example.com":       false,
+	} {
+		if got := list.Contains(domain); got != expected {
+			t.Errorf("Contains(%q) = %v, want %v", domain, got, expected)
+		}
+	}
+}
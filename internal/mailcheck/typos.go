@@ -0,0 +1,82 @@
+package mailcheck
+
+import "slices"
+
+// PopularDomains are email providers whose misspellings get a suggestion
+var PopularDomains = []string{
+	"aol.com",
+	"comcast.net",
+	"gmail.com",
+	"gmx.com",
+	"gmx.de",
+	"googlemail.com",
+	"hotmail.co.uk",
+	"hotmail.com",
+	"hotmail.es",
+	"hotmail.fr",
+	"icloud.com",
+	"live.com",
+	"mail.com",
+	"mail.ru",
+	"me.com",
+	"msn.com",
+	"outlook.com",
+	"outlook.es",
+	"proton.me",
+	"protonmail.com",
+	"qq.com",
+	"web.de",
+	"yahoo.co.uk",
+	"yahoo.com",
+	"yahoo.com.br",
+	"yahoo.es",
+	"yandex.ru",
+}
+
+// minTypoLength is the shortest domain that gets suggestions, since short
+// domains are one edit away from too many real ones
+const minTypoLength = 8
+
+// Suggest returns the popular domain a domain is likely a misspelling of, or
+// an empty string. A misspelling is one inserted, deleted, replaced or
+// swapped character away from a popular domain.
+func Suggest(domain string) string {
+	if len(domain) < minTypoLength || slices.Contains(PopularDomains, domain) {
+		return ""
+	}
+	for _, popular := range PopularDomains {
+		if distance(domain, popular) == 1 {
+			return popular
+		}
+	}
+	return ""
+}
+
+// distance returns the optimal string alignment distance between two
+// strings: the edits needed when an edit inserts, deletes or replaces a
+// character or swaps two adjacent ones
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	rows := make([][]int, len(ra)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(rb)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(ra)][len(rb)]
+}
@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsantos/contact-api/internal/config"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+func TestRequestID(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(LogHandler{Handler: slog.NewJSONHandler(&logs, nil)})
+
+	r := gin.New()
+	r.Use(RequestID())
+	r.GET("/", func(c *gin.Context) {
+		logger.InfoContext(c.Request.Context(), "handled")
+		c.String(http.StatusOK, GetRequestID(c))
+	})
+
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{name: "accepts a valid ID", incoming: "req-123.abc:1", keep: true},
+		{name: "generates a missing ID"},
+		{name: "replaces an invalid ID", incoming: "bad idé"},
+		{name: "replaces an overlong ID", incoming: strings.Repeat("a", 129)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			r.ServeHTTP(w, req)
+
+			id := w.Header().Get(RequestIDHeader)
+			if tt.keep && id != tt.incoming {
+				t.Errorf("Expected request ID %q, got %q", tt.incoming, id)
+			}
+			if !tt.keep && (len(id) != 32 || id == tt.incoming) {
+				t.Errorf("Expected a generated request ID, got %q", id)
+			}
+			if w.Body.String() != id {
+				t.Errorf("Expected handler to see request ID %q, got %q", id, w.Body.String())
+			}
+
+			var record map[string]any
+			if err := json.Unmarshal(logs.Bytes(), &record); err != nil {
+				t.Fatalf("Error unmarshaling log record: %v", err)
+			}
+			if record["request_id"] != id {
+				t.Errorf("Expected log request_id %q, got %v", id, record["request_id"])
+			}
+		})
+	}
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	settings := config.DefaultSecurityHeaders()
+	settings.HSTSIncludeSubdomains = true
+	settings.Headers["Referrer-Policy"] = "same-origin"
+	settings.Routes["/swagger/index.html"] = map[string]string{"x-frame-options": ""}
+
+	r := gin.New()
+	r.Use(SecurityHeaders(func() config.SecurityHeaders { return settings }))
+	r.GET("/*path", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name     string
+		path     string
+		tls      bool
+		expected map[string]string
+	}{
+		{
+			name: "defaults",
+			path: "/api/v1/contact/main",
+			expected: map[string]string{
+				"Content-Security-Policy":   "default-src 'none'; frame-ancestors 'none'",
+				"Referrer-Policy":           "same-origin",
+				"X-Content-Type-Options":    "nosniff",
+				"X-Frame-Options":           "DENY",
+				"Strict-Transport-Security": "",
+			},
+		},
+		{
+			name: "HSTS over TLS",
+			path: "/health",
+			tls:  true,
+			expected: map[string]string{
+				"Strict-Transport-Security": "max-age=31536000; includeSubDomains",
+			},
+		},
+		{
+			name: "route override",
+			path: "/swagger/doc.json",
+			expected: map[string]string{
+				"Content-Security-Policy": settings.Routes["/swagger/"]["Content-Security-Policy"],
+				"X-Frame-Options":         "DENY",
+			},
+		},
+		{
+			name: "framed widget",
+			path: "/api/v1/widget/v1/frame/main",
+			expected: map[string]string{
+				"Content-Security-Policy": "default-src 'none'; script-src 'self'; style-src 'self'; connect-src 'self'; frame-ancestors *",
+				"X-Frame-Options":         "",
+			},
+		},
+		{
+			name: "longest prefix removes a header",
+			path: "/swagger/index.html",
+			expected: map[string]string{
+				"Content-Security-Policy": "default-src 'none'; frame-ancestors 'none'",
+				"X-Frame-Options":         "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+			r.ServeHTTP(w, req)
+
+			for name, value := range tt.expected {
+				if got := w.Header().Get(name); got != value {
+					t.Errorf("Expected %s %q, got %q", name, value, got)
+				}
+			}
+		})
+	}
+}
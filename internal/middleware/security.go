@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nahuelsantos/contact-api/internal/config"
+)
+
+// SecurityHeaders adds the configured security headers to every response.
+// The settings are read on each request so configuration reloads apply
+// immediately. Strict-Transport-Security is only sent over TLS.
+func SecurityHeaders(settings func() config.SecurityHeaders) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		h := settings()
+		header := c.Writer.Header()
+
+		for name, value := range RouteHeaders(h, c.Request.URL.Path) {
+			if value != "" {
+				header.Set(name, value)
+			}
+		}
+
+		if c.Request.TLS != nil && h.HSTSMaxAge > 0 {
+			header.Set("Strict-Transport-Security", hsts(h))
+		}
+
+		c.Next()
+	}
+}
+
+// RouteHeaders returns the headers for a path: the defaults overridden by the
+// route with the longest matching prefix. Names are canonicalized and an
+// empty value marks a removed header.
+func RouteHeaders(h config.SecurityHeaders, path string) map[string]string {
+	headers := make(map[string]string, len(h.Headers))
+	for name, value := range h.Headers {
+		headers[http.CanonicalHeaderKey(name)] = value
+	}
+
+	var match string
+	for prefix := range h.Routes {
+		if strings.HasPrefix(path, prefix) && len(prefix) > len(match) {
+			match = prefix
+		}
+	}
+	if match != "" {
+		for name, value := range h.Routes[match] {
+			headers[http.CanonicalHeaderKey(name)] = value
+		}
+	}
+	return headers
+}
+
+// hsts formats the Strict-Transport-Security header value
+func hsts(h config.SecurityHeaders) string {
+	value := fmt.Sprintf("max-age=%d", int64(time.Duration(h.HSTSMaxAge)/time.Second))
+	if h.HSTSIncludeSubdomains {
+		value += "; includeSubDomains"
+	}
+	return value
+}
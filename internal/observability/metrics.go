@@ -0,0 +1,76 @@
+package observability
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// ConfigReloads counts configuration reload attempts by result
+	ConfigReloads = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "contact_api_config_reloads_total",
+		Help: "Configuration reload attempts by result.",
+	}, []string{"result"})
+
+	// ConfigInfo reports the hash of the live configuration as a label
+	ConfigInfo = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "contact_api_config_info",
+		Help: "Hash of the configuration currently in use. The value is always 1.",
+	}, []string{"hash"})
+
+	// SpamProbability records how the Bayesian classifier rates submissions
+	SpamProbability = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "contact_api_spam_probability",
+		Help:    "Spam probability the Bayesian classifier gives submissions, by website.",
+		Buckets: prometheus.LinearBuckets(0.1, 0.1, 10),
+	}, []string{"website"})
+
+	// DeliveryAttempts counts attempts to deliver through each transport
+	DeliveryAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "contact_api_delivery_attempts_total",
+		Help: "Delivery attempts by transport and result: sent, failed, or skipped while its circuit breaker is open.",
+	}, []string{"transport", "result"})
+
+	// Deliveries counts messages by the transport that delivered them
+	Deliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "contact_api_deliveries_total",
+		Help: "Messages by website and the transport that delivered them, none when every transport failed.",
+	}, []string{"website", "transport"})
+
+	// BreakerState reports the circuit breaker state of each transport
+	BreakerState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "contact_api_transport_breaker_state",
+		Help: "Circuit breaker state by transport: 0 closed, 1 half-open, 2 open.",
+	}, []string{"transport"})
+
+	// SMTPPoolConnections reports the pooled connections of SMTP transports
+	SMTPPoolConnections = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "contact_api_smtp_pool_connections",
+		Help: "Open pooled SMTP connections by transport and state: idle or busy.",
+	}, []string{"transport", "state"})
+
+	// SMTPPoolCheckouts counts how pooled connections were obtained
+	SMTPPoolCheckouts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "contact_api_smtp_pool_checkouts_total",
+		Help: "Connections taken from SMTP pools by transport and result: reused, dialed, or failed to dial.",
+	}, []string{"transport", "result"})
+
+	// SMTPPoolClosed counts pooled connections closed and why
+	SMTPPoolClosed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "contact_api_smtp_pool_closed_total",
+		Help: "Pooled SMTP connections closed by transport and reason: idle, max_messages, unhealthy, error or shutdown.",
+	}, []string{"transport", "reason"})
+
+	// SMTPPoolWait records how long sends wait for a pooled connection
+	SMTPPoolWait = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "contact_api_smtp_pool_wait_seconds",
+		Help:    "Time spent waiting for a free connection of an SMTP pool, by transport.",
+		Buckets: prometheus.ExponentialBuckets(0.001, 4, 8),
+	}, []string{"transport"})
+)
+
+// SetConfigHash records the hash of the live configuration
+func SetConfigHash(hash string) {
+	ConfigInfo.Reset()
+	ConfigInfo.WithLabelValues(hash).Set(1)
+}
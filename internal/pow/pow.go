@@ -0,0 +1,222 @@
+// Package pow issues and verifies hashcash-style proof of work puzzles, a
+// captcha alternative that involves no third party. A client searches for a
+// solution whose SHA-256 hash together with a signed challenge starts with a
+// number of zero bits, which takes many hashes to find and one to verify.
+package pow
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"math/bits"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults for websites that set no difficulty or TTL
+const (
+	DefaultDifficulty = 16
+	DefaultTTL        = 10 * time.Minute
+)
+
+// Solver is the JavaScript that solves challenges in the browser
+//
+//go:embed solver.js
+var Solver []byte
+
+// maxSolutionLength bounds the solutions worth hashing
+const maxSolutionLength = 32
+
+// Verification failures
+var (
+	ErrMissing = errors.New("challenge and solution are required")
+	ErrInvalid = errors.New("challenge is invalid")
+	ErrExpired = errors.New("challenge has expired")
+	ErrReused  = errors.New("challenge was already used")
+	ErrTooEasy = errors.New("solution does not meet the difficulty")
+)
+
+// Challenge is a puzzle issued to a client. Token is signed, so the website,
+// difficulty and expiry it carries cannot be changed.
+type Challenge struct {
+	Token      string    `json:"challenge" example:"MTZ8MTcyOTI4ODAwMHw..."`
+	Difficulty int       `json:"difficulty" example:"16"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// Issuer signs challenges and remembers the ones already used until they
+// expire, so each challenge is accepted once
+type Issuer struct {
+	mu     sync.Mutex
+	key    []byte
+	used   map[string]time.Time
+	purged time.Time
+}
+
+// NewIssuer returns an issuer signing challenges with key, or with a random
+// key when key is empty
+func NewIssuer(key string) *Issuer {
+	i := &Issuer{used: map[string]time.Time{}}
+	i.SetKey(key)
+	return i
+}
+
+// SetKey replaces the signing key, invalidating outstanding challenges. An
+// empty key is replaced by a random one.
+func (i *Issuer) SetKey(key string) {
+	k := []byte(key)
+	if key == "" {
+		k = make([]byte, 32)
+		rand.Read(k)
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.key = k
+}
+
+// Issue returns a new challenge for a website
+func (i *Issuer) Issue(website string, difficulty int, ttl time.Duration) Challenge {
+	nonce := make([]byte, 12)
+	rand.Read(nonce)
+	expires := time.Now().Add(ttl).Truncate(time.Second)
+
+	payload := strings.Join([]string{
+		strconv.Itoa(difficulty),
+		strconv.FormatInt(expires.Unix(), 10),
+		hex.EncodeToString(nonce),
+		website,
+	}, "|")
+	token := base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + i.sign(payload)
+
+	return Challenge{Token: token, Difficulty: difficulty, ExpiresAt: expires.UTC()}
+}
+
+// Verify checks the solution of a challenge issued for a website with at
+// least the given difficulty, and marks the challenge as used
+func (i *Issuer) Verify(website, token, solution string, difficulty int) error {
+	expires, err := i.check(website, token, solution, difficulty)
+	if err != nil {
+		return err
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if _, ok := i.used[token]; ok {
+		return ErrReused
+	}
+	i.used[token] = expires
+	return nil
+}
+
+// Check checks the solution of a challenge like Verify without marking the
+// challenge as used, so it can be verified again once the rest of the
+// submission is accepted
+func (i *Issuer) Check(website, token, solution string, difficulty int) error {
+	_, err := i.check(website, token, solution, difficulty)
+	return err
+}
+
+// check verifies a challenge and its solution and returns when the challenge
+// expires
+func (i *Issuer) check(website, token, solution string, difficulty int) (time.Time, error) {
+	if token == "" || solution == "" {
+		return time.Time{}, ErrMissing
+	}
+	if len(solution) > maxSolutionLength {
+		return time.Time{}, ErrInvalid
+	}
+
+	encoded, signature, ok := strings.Cut(token, ".")
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if !ok || err != nil || !hmac.Equal([]byte(signature), []byte(i.sign(string(raw)))) {
+		return time.Time{}, ErrInvalid
+	}
+	fields := strings.SplitN(string(raw), "|", 4)
+	if len(fields) != 4 || fields[3] != website {
+		return time.Time{}, ErrInvalid
+	}
+	issued, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return time.Time{}, ErrInvalid
+	}
+	unix, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return time.Time{}, ErrInvalid
+	}
+
+	now := time.Now()
+	expires := time.Unix(unix, 0)
+	if !now.Before(expires) {
+		return time.Time{}, ErrExpired
+	}
+	if issued < difficulty || LeadingZeroBits(Hash(token, solution)) < issued {
+		return time.Time{}, ErrTooEasy
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.purge(now)
+	if _, ok := i.used[token]; ok {
+		return time.Time{}, ErrReused
+	}
+	return expires, nil
+}
+
+// sign returns the signature of a challenge payload
+func (i *Issuer) sign(payload string) string {
+	i.mu.Lock()
+	mac := hmac.New(sha256.New, i.key)
+	i.mu.Unlock()
+
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// purge forgets used challenges that expired, at most once a minute
+func (i *Issuer) purge(now time.Time) {
+	if now.Sub(i.purged) < time.Minute {
+		return
+	}
+	i.purged = now
+	for token, expires := range i.used {
+		if !now.Before(expires) {
+			delete(i.used, token)
+		}
+	}
+}
+
+// Hash returns the hash a solution is judged by
+func Hash(token, solution string) []byte {
+	sum := sha256.Sum256([]byte(token + ":" + solution))
+	return sum[:]
+}
+
+// LeadingZeroBits counts the zero bits a hash starts with
+func LeadingZeroBits(hash []byte) int {
+	n := 0
+	for _, b := range hash {
+		if b != 0 {
+			return n + bits.LeadingZeros8(b)
+		}
+		n += 8
+	}
+	return n
+}
+
+// Solve finds a solution to a challenge the way the JavaScript solver does:
+// the first decimal counter whose hash has enough leading zero bits
+func Solve(token string, difficulty int) string {
+	for n := 0; ; n++ {
+		solution := strconv.Itoa(n)
+		if LeadingZeroBits(Hash(token, solution)) >= difficulty {
+			return solution
+		}
+	}
+}
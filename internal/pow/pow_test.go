@@ -0,0 +1,98 @@
+package pow
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIssuer_Verify(t *testing.T) {
+	issuer := NewIssuer("secret")
+	challenge := issuer.Issue("main", 8, time.Minute)
+	solution := Solve(challenge.Token, 8)
+
+	// A solution that falls short of the difficulty
+	wrong := "x"
+	for n := 0; LeadingZeroBits(Hash(challenge.Token, wrong)) >= 8; n++ {
+		wrong = "x" + strconv.Itoa(n)
+	}
+
+	// The same challenge made easier, keeping the original signature
+	encoded, signature, _ := strings.Cut(challenge.Token, ".")
+	payload, _ := base64.RawURLEncoding.DecodeString(encoded)
+	tampered := base64.RawURLEncoding.EncodeToString([]byte("1"+strings.TrimPrefix(string(payload), "8"))) + "." + signature
+	expired := issuer.Issue("main", 8, -time.Second)
+
+	tests := []struct {
+		name       string
+		website    string
+		token      string
+		solution   string
+		difficulty int
+		check      bool
+		wantErr    error
+	}{
+		{name: "missing solution", website: "main", token: challenge.Token, difficulty: 8, wantErr: ErrMissing},
+		{name: "missing challenge", website: "main", solution: solution, difficulty: 8, wantErr: ErrMissing},
+		{name: "other website", website: "blog", token: challenge.Token, solution: solution, difficulty: 8, wantErr: ErrInvalid},
+		{name: "tampered", website: "main", token: tampered, solution: solution, difficulty: 8, wantErr: ErrInvalid},
+		{name: "unsigned", website: "main", token: encoded, solution: solution, difficulty: 8, wantErr: ErrInvalid},
+		{name: "expired", website: "main", token: expired.Token, solution: Solve(expired.Token, 8), difficulty: 8, wantErr: ErrExpired},
+		{name: "wrong solution", website: "main", token: challenge.Token, solution: wrong, difficulty: 8, wantErr: ErrTooEasy},
+		{name: "difficulty raised", website: "main", token: challenge.Token, solution: solution, difficulty: 12, wantErr: ErrTooEasy},
+		{name: "checked", website: "main", token: challenge.Token, solution: solution, difficulty: 8, check: true},
+		{name: "checked again", website: "main", token: challenge.Token, solution: solution, difficulty: 8, check: true},
+		{name: "solved", website: "main", token: challenge.Token, solution: solution, difficulty: 8},
+		{name: "checked after use", website: "main", token: challenge.Token, solution: solution, difficulty: 8, check: true, wantErr: ErrReused},
+		{name: "reused", website: "main", token: challenge.Token, solution: solution, difficulty: 8, wantErr: ErrReused},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verify, name := issuer.Verify, "Verify"
+			if tt.check {
+				verify, name = issuer.Check, "Check"
+			}
+			if err := verify(tt.website, tt.token, tt.solution, tt.difficulty); !errors.Is(err, tt.wantErr) {
+				t.Errorf("%s() error = %v, want %v", name, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIssuer_SetKey(t *testing.T) {
+	issuer := NewIssuer("")
+	challenge := issuer.Issue("main", 4, time.Minute)
+
+	issuer.SetKey("rotated")
+	if err := issuer.Verify("main", challenge.Token, Solve(challenge.Token, 4), 4); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Expected challenges signed with the old key to be invalid, got %v", err)
+	}
+
+	// Issuers sharing a key accept each other's challenges
+	challenge = issuer.Issue("main", 4, time.Minute)
+	if err := NewIssuer("rotated").Verify("main", challenge.Token, Solve(challenge.Token, 4), 4); err != nil {
+		t.Errorf("Verify() returned error: %v", err)
+	}
+}
+
+func TestLeadingZeroBits(t *testing.T) {
+	tests := []struct {
+		hash     []byte
+		expected int
+	}{
+		{hash: []byte{0x80}, expected: 0},
+		{hash: []byte{0x01}, expected: 7},
+		{hash: []byte{0x00, 0x20}, expected: 10},
+		{hash: []byte{0x00, 0x00}, expected: 16},
+	}
+
+	for _, tt := range tests {
+		if got := LeadingZeroBits(tt.hash); got != tt.expected {
+			t.Errorf("LeadingZeroBits(%x) = %d, want %d", tt.hash, got, tt.expected)
+		}
+	}
+}
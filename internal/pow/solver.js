@@ -0,0 +1,59 @@
+/*
+ * Proof of work solver for the contact API.
+ *
+ *   <script src="https://api.example.com/api/v1/pow.js"></script>
+ *   const proof = await ContactPow.solve("https://api.example.com/api/v1/contact/main");
+ *   fetch("https://api.example.com/api/v1/contact/main", {
+ *     method: "POST",
+ *     headers: { "Content-Type": "application/json" },
+ *     body: JSON.stringify({ ...form, ...proof }),
+ *   });
+ */
+(function (global) {
+  "use strict";
+
+  var encoder = new TextEncoder();
+  var batch = 256;
+
+  function leadingZeroBits(hash) {
+    var n = 0;
+    for (var i = 0; i < hash.length; i++) {
+      if (hash[i] !== 0) {
+        return n + Math.clz32(hash[i]) - 24;
+      }
+      n += 8;
+    }
+    return n;
+  }
+
+  // solveChallenge finds the first decimal counter whose SHA-256 hash with
+  // the challenge starts with difficulty zero bits
+  async function solveChallenge(challenge, difficulty) {
+    for (var start = 0; ; start += batch) {
+      var hashes = [];
+      for (var n = start; n < start + batch; n++) {
+        hashes.push(crypto.subtle.digest("SHA-256", encoder.encode(challenge + ":" + n)));
+      }
+      var results = await Promise.all(hashes);
+      for (var i = 0; i < results.length; i++) {
+        if (leadingZeroBits(new Uint8Array(results[i])) >= difficulty) {
+          return String(start + i);
+        }
+      }
+    }
+  }
+
+  // solve fetches a challenge for a contact endpoint and returns the
+  // challenge and solution fields to send with the submission
+  async function solve(endpoint) {
+    var response = await fetch(endpoint.replace(/\/+$/, "") + "/challenge");
+    var body = await response.json();
+    if (!response.ok) {
+      throw new Error(body.message);
+    }
+    var solution = await solveChallenge(body.data.challenge, body.data.difficulty);
+    return { challenge: body.data.challenge, solution: solution };
+  }
+
+  global.ContactPow = { solve: solve, solveChallenge: solveChallenge };
+})(typeof window !== "undefined" ? window : globalThis);
@@ -0,0 +1,115 @@
+// Package server builds the HTTP server and its listener from configuration
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+)
+
+// New creates an HTTP server with the configured timeouts and header limit.
+// With TLS configured the certificate is served from certs.
+func New(cfg config.Server, handler http.Handler, certs *CertReloader) *http.Server {
+	srv := &http.Server{
+		Handler:           handler,
+		ReadTimeout:       time.Duration(cfg.ReadTimeout),
+		ReadHeaderTimeout: time.Duration(cfg.ReadHeaderTimeout),
+		WriteTimeout:      time.Duration(cfg.WriteTimeout),
+		IdleTimeout:       time.Duration(cfg.IdleTimeout),
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+	if certs != nil {
+		srv.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: certs.GetCertificate,
+		}
+	}
+	return srv
+}
+
+// Listen opens the Unix socket when one is configured, or TCP on port
+// otherwise. A stale socket left by a previous run is removed.
+func Listen(cfg config.Config) (net.Listener, error) {
+	path := cfg.Server.UnixSocket
+	if path == "" {
+		return net.Listen("tcp", ":"+cfg.Port)
+	}
+
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&fs.ModeSocket == 0 {
+			return nil, fmt.Errorf("unix socket %s: file exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("unix socket %s: %w", path, err)
+		}
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	// Let a reverse proxy in the same group connect
+	if err := os.Chmod(path, 0o660); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("unix socket %s: %w", path, err)
+	}
+	return ln, nil
+}
+
+// Serve serves HTTPS when srv has a TLS configuration and HTTP otherwise
+func Serve(srv *http.Server, ln net.Listener) error {
+	if srv.TLSConfig != nil {
+		return srv.ServeTLS(ln, "", "")
+	}
+	return srv.Serve(ln)
+}
+
+// CertReloader serves a certificate and key pair that can be reloaded from
+// disk without restarting the server
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	cert     atomic.Pointer[tls.Certificate]
+}
+
+// NewCertReloader loads a certificate and key pair
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload reads the certificate and key again. On error the previous pair
+// stays in use, so a half-written rotation does not break the server.
+func (r *CertReloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	r.cert.Store(&cert)
+	return nil
+}
+
+// Files returns the certificate and key paths
+func (r *CertReloader) Files() []string {
+	return []string{r.certFile, r.keyFile}
+}
+
+// GetCertificate returns the current certificate, for tls.Config
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cert := r.cert.Load()
+	if cert == nil {
+		return nil, errors.New("no TLS certificate loaded")
+	}
+	return cert, nil
+}
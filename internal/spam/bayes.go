@@ -0,0 +1,156 @@
+package spam
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// MinTraining is the number of spam and of ham messages a model needs before
+// it classifies anything
+const MinTraining = 5
+
+// Model is a naive Bayes classifier trained from labelled messages. It
+// counts how many spam and ham messages contain each token.
+type Model struct {
+	Spam       int            `json:"spam"`
+	Ham        int            `json:"ham"`
+	SpamTokens map[string]int `json:"spam_tokens"`
+	HamTokens  map[string]int `json:"ham_tokens"`
+}
+
+// Learn adds a labelled message to the model
+func (m *Model) Learn(msg Message, isSpam bool) {
+	m.update(msg, isSpam, 1)
+}
+
+// Forget removes a message learned with the given label, so it can be
+// relabelled
+func (m *Model) Forget(msg Message, isSpam bool) {
+	m.update(msg, isSpam, -1)
+}
+
+// update adds delta to the counts of a label, never going below zero
+func (m *Model) update(msg Message, isSpam bool, delta int) {
+	docs, tokens := &m.Ham, &m.HamTokens
+	if isSpam {
+		docs, tokens = &m.Spam, &m.SpamTokens
+	}
+	if *tokens == nil {
+		*tokens = make(map[string]int)
+	}
+
+	*docs = max(*docs+delta, 0)
+	for _, token := range Tokenize(msg) {
+		if count := (*tokens)[token] + delta; count > 0 {
+			(*tokens)[token] = count
+		} else {
+			delete(*tokens, token)
+		}
+	}
+}
+
+// Trained reports whether the model has seen enough of both labels
+func (m *Model) Trained() bool {
+	return m != nil && m.Spam >= MinTraining && m.Ham >= MinTraining
+}
+
+// Probability returns how likely a message is spam, or false when the model
+// is not trained yet. Tokens the model has never seen are ignored.
+func (m *Model) Probability(msg Message) (float64, bool) {
+	if !m.Trained() {
+		return 0, false
+	}
+
+	spam := math.Log(float64(m.Spam) / float64(m.Spam+m.Ham))
+	ham := math.Log(float64(m.Ham) / float64(m.Spam+m.Ham))
+	for _, token := range Tokenize(msg) {
+		s, h := m.SpamTokens[token], m.HamTokens[token]
+		if s+h == 0 {
+			continue
+		}
+		spam += math.Log(float64(s+1) / float64(m.Spam+2))
+		ham += math.Log(float64(h+1) / float64(m.Ham+2))
+	}
+	return 1 / (1 + math.Exp(ham-spam)), true
+}
+
+// Clone returns a deep copy of the model
+func (m *Model) Clone() *Model {
+	if m == nil {
+		return &Model{}
+	}
+	return &Model{
+		Spam:       m.Spam,
+		Ham:        m.Ham,
+		SpamTokens: maps.Clone(m.SpamTokens),
+		HamTokens:  maps.Clone(m.HamTokens),
+	}
+}
+
+// Validate checks an imported model for impossible counts
+func (m *Model) Validate() error {
+	if m.Spam < 0 || m.Ham < 0 {
+		return errors.New("message counts must not be negative")
+	}
+	if err := validateTokens("spam", m.SpamTokens, m.Spam); err != nil {
+		return err
+	}
+	return validateTokens("ham", m.HamTokens, m.Ham)
+}
+
+// validateTokens checks that no token appears in more messages than a label has
+func validateTokens(label string, tokens map[string]int, docs int) error {
+	for _, token := range slices.Sorted(maps.Keys(tokens)) {
+		if count := tokens[token]; count < 0 || count > docs {
+			return fmt.Errorf("%s count of token %q must be between 0 and %d", label, token, docs)
+		}
+	}
+	return nil
+}
+
+// Tokenize returns the distinct lowercase words of a message and its sender
+// domain
+func Tokenize(msg Message) []string {
+	seen := map[string]bool{}
+	var tokens []string
+	add := func(token string) {
+		if !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(msg.text()), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		if n := len([]rune(word)); n >= 2 && n <= 24 {
+			add(word)
+		}
+	}
+	if _, domain, ok := strings.Cut(strings.ToLower(msg.Email), "@"); ok && domain != "" {
+		add("domain:" + domain)
+	}
+	return tokens
+}
+
+// bayesCheck scores messages a trained model considers more likely spam than
+// ham, reaching full strength at certainty
+type bayesCheck struct {
+	model *Model
+}
+
+func (bayesCheck) Name() string { return "bayes" }
+
+func (b bayesCheck) Score(msg Message) (float64, string) {
+	p, ok := b.model.Probability(msg)
+	if !ok || p <= 0.5 {
+		return 0, ""
+	}
+	return (p - 0.5) * 2, fmt.Sprintf("%.0f%% spam probability", p*100)
+}
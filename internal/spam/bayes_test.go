@@ -0,0 +1,115 @@
+package spam
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+)
+
+// trainedModel learns a few spam and ham messages
+func trainedModel() *Model {
+	m := &Model{}
+	for i := range MinTraining {
+		m.Learn(Message{Email: "bot@spam.example", Message: fmt.Sprintf("Cheap replica watches, order %d now", i)}, true)
+		m.Learn(Message{Email: "client@example.com", Message: fmt.Sprintf("Could we schedule meeting %d about the project?", i)}, false)
+	}
+	return m
+}
+
+func TestModel_Probability(t *testing.T) {
+	if _, ok := (&Model{}).Probability(Message{Message: "replica watches"}); ok {
+		t.Error("Expected an untrained model not to classify")
+	}
+	var nilModel *Model
+	if _, ok := nilModel.Probability(Message{}); ok {
+		t.Error("Expected a nil model not to classify")
+	}
+
+	m := trainedModel()
+	tests := []struct {
+		name string
+		msg  Message
+		spam bool
+	}{
+		{name: "spam", msg: Message{Message: "Replica watches for cheap"}, spam: true},
+		{name: "spam domain", msg: Message{Email: "x@spam.example", Message: "hello"}, spam: true},
+		{name: "ham", msg: Message{Message: "Can we schedule a meeting about the project?"}, spam: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, ok := m.Probability(tt.msg)
+			if !ok {
+				t.Fatal("Expected a trained model to classify")
+			}
+			if (p > 0.5) != tt.spam {
+				t.Errorf("Expected spam %v, got probability %.2f", tt.spam, p)
+			}
+		})
+	}
+}
+
+func TestModel_Forget(t *testing.T) {
+	m := trainedModel()
+	msg := Message{Message: "Unique words here"}
+	m.Learn(msg, true)
+	m.Forget(msg, true)
+
+	if m.Spam != MinTraining {
+		t.Errorf("Expected %d spam messages, got %d", MinTraining, m.Spam)
+	}
+	if _, ok := m.SpamTokens["unique"]; ok {
+		t.Error("Expected forgotten tokens to be removed")
+	}
+	if err := m.Validate(); err != nil {
+		t.Errorf("Validate() returned error: %v", err)
+	}
+}
+
+func TestModel_Clone(t *testing.T) {
+	m := trainedModel()
+	clone := m.Clone()
+	clone.Learn(Message{Message: "brand new"}, true)
+
+	if m.Spam == clone.Spam || m.SpamTokens["brand"] != 0 {
+		t.Error("Expected the clone not to share counts with the original")
+	}
+}
+
+func TestModel_Validate(t *testing.T) {
+	tests := []struct {
+		name  string
+		model Model
+		valid bool
+	}{
+		{name: "empty", model: Model{}, valid: true},
+		{name: "negative", model: Model{Spam: -1}},
+		{name: "token above messages", model: Model{Ham: 1, HamTokens: map[string]int{"hi": 2}}},
+		{name: "negative token", model: Model{Spam: 1, SpamTokens: map[string]int{"hi": -1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.model.Validate(); (err == nil) != tt.valid {
+				t.Errorf("Expected valid %v, got %v", tt.valid, err)
+			}
+		})
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tokens := Tokenize(Message{Email: "John@Example.com", Subject: "Hello hello", Message: "A b2b offer, ¡OFERTA!"})
+	expected := []string{"hello", "b2b", "offer", "oferta", "domain:example.com"}
+	if !slices.Equal(tokens, expected) {
+		t.Errorf("Expected tokens %v, got %v", expected, tokens)
+	}
+}
+
+func TestScorer_Bayes(t *testing.T) {
+	result := New(config.SpamScoring{}, trainedModel()).Score(Message{Message: "Cheap replica watches"})
+	if !slices.ContainsFunc(result.Signals, func(s Signal) bool { return s.Check == "bayes" }) {
+		t.Errorf("Expected a bayes signal, got %v", result.Signals)
+	}
+}
@@ -0,0 +1,194 @@
+package spam
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/nahuelsantos/contact-api/internal/mailcheck"
+)
+
+// linkPattern matches URLs and bare www links in submitted text
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+`)
+
+// CountLinks returns the number of links in a text
+func CountLinks(text string) int {
+	return len(linkPattern.FindAllStringIndex(text, -1))
+}
+
+// linkCheck scores link farms, reaching full strength at five links
+type linkCheck struct{}
+
+func (linkCheck) Name() string { return "links" }
+
+func (linkCheck) Score(msg Message) (float64, string) {
+	links := CountLinks(msg.text())
+	if links == 0 {
+		return 0, ""
+	}
+	return float64(min(links, 5)) / 5, fmt.Sprintf("%d links", links)
+}
+
+// keywordCheck scores any blocked keyword or pattern at full strength
+type keywordCheck struct {
+	keywords []string
+	patterns []*regexp.Regexp
+}
+
+// newKeywordCheck lowercases keywords and compiles patterns. Patterns that do
+// not compile are skipped, since configuration validation reports them.
+func newKeywordCheck(keywords, patterns []string) keywordCheck {
+	var check keywordCheck
+	for _, keyword := range keywords {
+		if keyword != "" {
+			check.keywords = append(check.keywords, strings.ToLower(keyword))
+		}
+	}
+	for _, pattern := range patterns {
+		if re, err := regexp.Compile(pattern); err == nil {
+			check.patterns = append(check.patterns, re)
+		}
+	}
+	return check
+}
+
+func (keywordCheck) Name() string { return "keywords" }
+
+func (k keywordCheck) Score(msg Message) (float64, string) {
+	text := msg.text()
+	lower := strings.ToLower(text)
+	for _, keyword := range k.keywords {
+		if strings.Contains(lower, keyword) {
+			return 1, fmt.Sprintf("blocked keyword %q", keyword)
+		}
+	}
+	for _, re := range k.patterns {
+		if re.MatchString(text) {
+			return 1, fmt.Sprintf("blocked pattern %q", re.String())
+		}
+	}
+	return 0, ""
+}
+
+// scriptCheck scores the share of letters written in scripts the website
+// does not expect, such as Cyrillic on an English site
+type scriptCheck struct {
+	names  []string
+	tables []*unicode.RangeTable
+}
+
+// newScriptCheck resolves Unicode script names, defaulting to Latin
+func newScriptCheck(scripts []string) scriptCheck {
+	if len(scripts) == 0 {
+		scripts = []string{"Latin"}
+	}
+	var check scriptCheck
+	for _, name := range scripts {
+		if table, ok := unicode.Scripts[name]; ok {
+			check.names = append(check.names, name)
+			check.tables = append(check.tables, table)
+		}
+	}
+	return check
+}
+
+func (scriptCheck) Name() string { return "script" }
+
+func (s scriptCheck) Score(msg Message) (float64, string) {
+	letters, foreign := 0, 0
+	for _, r := range msg.Subject + " " + msg.Message {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if !unicode.IsOneOf(s.tables, r) {
+			foreign++
+		}
+	}
+	// Short texts say too little about their language
+	if letters < 10 || foreign == 0 {
+		return 0, ""
+	}
+	share := float64(foreign) / float64(letters)
+	return share, fmt.Sprintf("%.0f%% of letters outside %s", share*100, strings.Join(s.names, ", "))
+}
+
+// capsCheck scores shouting, growing from half of the letters in capitals to
+// full strength when all of them are
+type capsCheck struct{}
+
+func (capsCheck) Name() string { return "caps" }
+
+func (capsCheck) Score(msg Message) (float64, string) {
+	upper, lower := 0, 0
+	for _, r := range msg.Subject + " " + msg.Message {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		}
+	}
+	if upper+lower < 20 {
+		return 0, ""
+	}
+	ratio := float64(upper) / float64(upper+lower)
+	if ratio <= 0.5 {
+		return 0, ""
+	}
+	return (ratio - 0.5) * 2, fmt.Sprintf("%.0f%% capital letters", ratio*100)
+}
+
+// phraseCheck scores known spam phrases, reaching full strength at three
+type phraseCheck struct {
+	phrases []string
+}
+
+// newPhraseCheck adds the configured phrases to the built-in ones
+func newPhraseCheck(extra []string) phraseCheck {
+	phrases := slices.Clone(KnownPhrases)
+	for _, phrase := range extra {
+		if phrase != "" {
+			phrases = append(phrases, strings.ToLower(phrase))
+		}
+	}
+	return phraseCheck{phrases: phrases}
+}
+
+func (phraseCheck) Name() string { return "phrases" }
+
+func (p phraseCheck) Score(msg Message) (float64, string) {
+	text := strings.ToLower(msg.text())
+	var found []string
+	for _, phrase := range p.phrases {
+		if strings.Contains(text, phrase) {
+			found = append(found, phrase)
+		}
+	}
+	if len(found) == 0 {
+		return 0, ""
+	}
+	return float64(min(len(found), 3)) / 3, "spam phrases: " + strings.Join(found, ", ")
+}
+
+// disposableCheck scores senders using throwaway email services
+type disposableCheck struct {
+	domains mailcheck.DisposableList
+}
+
+// newDisposableCheck adds the configured domains to the built-in ones
+func newDisposableCheck(extra []string) disposableCheck {
+	return disposableCheck{domains: mailcheck.NewDisposableList(extra)}
+}
+
+func (disposableCheck) Name() string { return "disposable" }
+
+func (d disposableCheck) Score(msg Message) (float64, string) {
+	_, domain, ok := strings.Cut(strings.ToLower(msg.Email), "@")
+	if !ok || !d.domains.Contains(domain) {
+		return 0, ""
+	}
+	return 1, "disposable email domain " + domain
+}
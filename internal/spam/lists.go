@@ -0,0 +1,26 @@
+package spam
+
+// KnownPhrases are lowercase phrases common in contact form spam
+var KnownPhrases = []string{
+	"bitcoin investment",
+	"crypto trading",
+	"double your money",
+	"guaranteed returns",
+	"passive income",
+	"make money fast",
+	"work from home opportunity",
+	"seo services",
+	"first page of google",
+	"guaranteed ranking",
+	"buy backlinks",
+	"increase your traffic",
+	"web design services",
+	"online casino",
+	"free spins",
+	"viagra",
+	"cheap pills",
+	"limited time offer",
+	"100% free",
+	"click here",
+	"dear sir/madam",
+}
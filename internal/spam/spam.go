@@ -0,0 +1,157 @@
+// Package spam scores submissions by their content so likely spam can be
+// tagged, quarantined or rejected before it is delivered
+package spam
+
+import (
+	"math"
+	"strings"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+)
+
+// Actions taken on a scored submission, from least to most severe
+const (
+	ActionAccept     = "accept"
+	ActionTag        = "tag"
+	ActionQuarantine = "quarantine"
+	ActionReject     = "reject"
+)
+
+// DefaultWeights holds the weight of each built-in check
+var DefaultWeights = map[string]float64{
+	"links":      3,
+	"keywords":   5,
+	"script":     4,
+	"caps":       2,
+	"phrases":    4,
+	"disposable": 3,
+	"bayes":      5,
+}
+
+// DefaultThresholds apply when a website sets none
+var DefaultThresholds = config.SpamThresholds{Tag: 5, Quarantine: 10}
+
+// Message is the submitted content the checks inspect
+type Message struct {
+	Name    string
+	Email   string
+	Subject string
+	Message string
+	Fields  map[string]string
+}
+
+// text joins every free-text part of a message
+func (m Message) text() string {
+	parts := []string{m.Name, m.Subject, m.Message}
+	for _, value := range m.Fields {
+		parts = append(parts, value)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// Check scores one aspect of a message. Score returns a strength between 0
+// and 1, which the scorer multiplies by the check weight, and the reason for
+// any positive strength.
+type Check interface {
+	Name() string
+	Score(msg Message) (float64, string)
+}
+
+// Signal is the contribution of one check to a result
+type Signal struct {
+	Check  string  `json:"check" example:"links"`
+	Score  float64 `json:"score" example:"1.8"`
+	Reason string  `json:"reason" example:"3 links"`
+}
+
+// Result is the score of a message and the action it calls for
+type Result struct {
+	Score   float64  `json:"score" example:"6.8"`
+	Action  string   `json:"action" example:"tag"`
+	Signals []Signal `json:"signals,omitempty"`
+}
+
+// Scorer runs weighted checks against messages
+type Scorer struct {
+	checks     []Check
+	weights    map[string]float64
+	thresholds config.SpamThresholds
+}
+
+// New returns a scorer with the built-in checks configured for a website.
+// A trained model adds the Bayesian check.
+func New(cfg config.SpamScoring, model *Model) *Scorer {
+	s := &Scorer{
+		weights:    make(map[string]float64, len(DefaultWeights)),
+		thresholds: cfg.Thresholds,
+	}
+	if s.thresholds == (config.SpamThresholds{}) {
+		s.thresholds = DefaultThresholds
+	}
+	for name, weight := range DefaultWeights {
+		s.weights[name] = weight
+	}
+	for name, weight := range cfg.Weights {
+		s.weights[name] = weight
+	}
+
+	s.checks = []Check{
+		linkCheck{},
+		newKeywordCheck(cfg.Keywords, cfg.Patterns),
+		newScriptCheck(cfg.Scripts),
+		capsCheck{},
+		newPhraseCheck(cfg.Phrases),
+		newDisposableCheck(cfg.DisposableDomains),
+	}
+	if model.Trained() {
+		s.checks = append(s.checks, bayesCheck{model: model})
+	}
+	return s
+}
+
+// Add registers another check with its weight
+func (s *Scorer) Add(check Check, weight float64) {
+	s.checks = append(s.checks, check)
+	s.weights[check.Name()] = weight
+}
+
+// Score runs every weighted check against a message and decides its action
+func (s *Scorer) Score(msg Message) Result {
+	result := Result{Action: ActionAccept}
+	for _, check := range s.checks {
+		weight := s.weights[check.Name()]
+		if weight <= 0 {
+			continue
+		}
+		strength, reason := check.Score(msg)
+		if strength <= 0 {
+			continue
+		}
+		score := round(weight * min(strength, 1))
+		result.Score += score
+		result.Signals = append(result.Signals, Signal{Check: check.Name(), Score: score, Reason: reason})
+	}
+	result.Score = round(result.Score)
+	result.Action = s.action(result.Score)
+	return result
+}
+
+// action returns the most severe action whose threshold a score reaches
+func (s *Scorer) action(score float64) string {
+	t := s.thresholds
+	switch {
+	case t.Reject > 0 && score >= t.Reject:
+		return ActionReject
+	case t.Quarantine > 0 && score >= t.Quarantine:
+		return ActionQuarantine
+	case t.Tag > 0 && score >= t.Tag:
+		return ActionTag
+	default:
+		return ActionAccept
+	}
+}
+
+// round keeps two decimals so scores read well in logs and responses
+func round(score float64) float64 {
+	return math.Round(score*100) / 100
+}
@@ -0,0 +1,224 @@
+package spam
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+)
+
+func TestChecks(t *testing.T) {
+	tests := []struct {
+		name     string
+		check    Check
+		msg      Message
+		expected float64
+	}{
+		{
+			name:     "no links",
+			check:    linkCheck{},
+			msg:      Message{Message: "Hello there"},
+			expected: 0,
+		},
+		{
+			name:     "some links",
+			check:    linkCheck{},
+			msg:      Message{Message: "See https://a.example and www.b.example"},
+			expected: 0.4,
+		},
+		{
+			name:     "link farm",
+			check:    linkCheck{},
+			msg:      Message{Message: strings.Repeat("http://spam.example ", 8)},
+			expected: 1,
+		},
+		{
+			name:     "blocked keyword",
+			check:    newKeywordCheck([]string{"Crypto"}, nil),
+			msg:      Message{Subject: "CRYPTO deal"},
+			expected: 1,
+		},
+		{
+			name:     "blocked pattern",
+			check:    newKeywordCheck(nil, []string{`\d{4}-\d{4}`}),
+			msg:      Message{Fields: map[string]string{"phone": "call 5555-1234"}},
+			expected: 1,
+		},
+		{
+			name:     "invalid pattern is skipped",
+			check:    newKeywordCheck(nil, []string{"("}),
+			msg:      Message{Message: "("},
+			expected: 0,
+		},
+		{
+			name:     "expected script",
+			check:    newScriptCheck(nil),
+			msg:      Message{Message: "Olá, gostaria de um orçamento"},
+			expected: 0,
+		},
+		{
+			name:     "foreign script",
+			check:    newScriptCheck(nil),
+			msg:      Message{Message: "Привет, купите наши услуги"},
+			expected: 1,
+		},
+		{
+			name:     "configured scripts",
+			check:    newScriptCheck([]string{"Latin", "Cyrillic"}),
+			msg:      Message{Message: "Привет, купите наши услуги"},
+			expected: 0,
+		},
+		{
+			name:     "short text",
+			check:    newScriptCheck(nil),
+			msg:      Message{Message: "Привет"},
+			expected: 0,
+		},
+		{
+			name:     "shouting",
+			check:    capsCheck{},
+			msg:      Message{Message: "BUY NOW THE BEST OFFER EVER"},
+			expected: 1,
+		},
+		{
+			name:     "normal case",
+			check:    capsCheck{},
+			msg:      Message{Message: "I would like a quote for NASA"},
+			expected: 0,
+		},
+		{
+			name:     "known phrase",
+			check:    newPhraseCheck(nil),
+			msg:      Message{Message: "We offer SEO services"},
+			expected: 1.0 / 3,
+		},
+		{
+			name:     "configured phrase",
+			check:    newPhraseCheck([]string{"Cheap Watches", "replica"}),
+			msg:      Message{Message: "Cheap watches! Replica bags! Online casino!"},
+			expected: 1,
+		},
+		{
+			name:     "disposable domain",
+			check:    newDisposableCheck(nil),
+			msg:      Message{Email: "bot@Mailinator.com"},
+			expected: 1,
+		},
+		{
+			name:     "disposable subdomain",
+			check:    newDisposableCheck([]string{"spam.example"}),
+			msg:      Message{Email: "bot@mx.spam.example"},
+			expected: 1,
+		},
+		{
+			name:     "regular domain",
+			check:    newDisposableCheck(nil),
+			msg:      Message{Email: "john@example.com"},
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, reason := tt.check.Score(tt.msg)
+			if score != tt.expected {
+				t.Errorf("Expected score %.2f, got %.2f (%s)", tt.expected, score, reason)
+			}
+			if score > 0 && reason == "" {
+				t.Error("Expected a reason for a positive score")
+			}
+		})
+	}
+}
+
+// fixedCheck scores every message the same
+type fixedCheck float64
+
+func (fixedCheck) Name() string { return "fixed" }
+
+func (f fixedCheck) Score(Message) (float64, string) { return float64(f), "fixed" }
+
+func TestScorer(t *testing.T) {
+	ham := Message{Name: "John", Email: "john@example.com", Subject: "Quote", Message: "Could you send me a quote?"}
+
+	tests := []struct {
+		name           string
+		cfg            config.SpamScoring
+		extra          float64
+		expectedScore  float64
+		expectedAction string
+	}{
+		{
+			name:           "clean message",
+			expectedAction: ActionAccept,
+		},
+		{
+			name:           "tag by default",
+			extra:          6,
+			expectedScore:  6,
+			expectedAction: ActionTag,
+		},
+		{
+			name:           "quarantine by default",
+			extra:          10,
+			expectedScore:  10,
+			expectedAction: ActionQuarantine,
+		},
+		{
+			name:           "reject",
+			cfg:            config.SpamScoring{Thresholds: config.SpamThresholds{Reject: 4}},
+			extra:          4,
+			expectedScore:  4,
+			expectedAction: ActionReject,
+		},
+		{
+			name:           "disabled actions",
+			cfg:            config.SpamScoring{Thresholds: config.SpamThresholds{Reject: 50}},
+			extra:          20,
+			expectedScore:  20,
+			expectedAction: ActionAccept,
+		},
+		{
+			name:           "configured weight",
+			cfg:            config.SpamScoring{Keywords: []string{"quote"}, Weights: map[string]float64{"keywords": 2.5}},
+			expectedScore:  2.5,
+			expectedAction: ActionAccept,
+		},
+		{
+			name:           "disabled check",
+			cfg:            config.SpamScoring{Keywords: []string{"quote"}, Weights: map[string]float64{"keywords": 0}},
+			expectedAction: ActionAccept,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scorer := New(tt.cfg, nil)
+			if tt.extra > 0 {
+				scorer.Add(fixedCheck(1), tt.extra)
+			}
+
+			result := scorer.Score(ham)
+			if result.Score != tt.expectedScore {
+				t.Errorf("Expected score %.2f, got %.2f (%v)", tt.expectedScore, result.Score, result.Signals)
+			}
+			if result.Action != tt.expectedAction {
+				t.Errorf("Expected action '%s', got '%s'", tt.expectedAction, result.Action)
+			}
+			if result.Score > 0 && len(result.Signals) == 0 {
+				t.Error("Expected signals explaining the score")
+			}
+		})
+	}
+}
+
+func TestScorer_Spam(t *testing.T) {
+	result := New(config.SpamScoring{}, nil).Score(Message{
+		Email:   "bot@yopmail.com",
+		Subject: "BITCOIN INVESTMENT",
+		Message: "GUARANTEED RETURNS!!! CLICK HERE https://a.example https://b.example https://c.example www.d.example www.e.example",
+	})
+	if result.Action != ActionQuarantine {
+		t.Errorf("Expected obvious spam to be quarantined, got %s with %.2f (%v)", result.Action, result.Score, result.Signals)
+	}
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"slices"
+	"time"
+)
+
+// MaxAuditEntries is the number of audit entries kept, the oldest being
+// dropped first
+const MaxAuditEntries = 10000
+
+// AuditEntry records a single admin API action
+type AuditEntry struct {
+	ID      string    `json:"id"`
+	Time    time.Time `json:"time"`
+	KeyID   string    `json:"key_id"`
+	KeyName string    `json:"key_name"`
+	Action  string    `json:"action"`
+	Website string    `json:"website,omitempty"`
+	Target  string    `json:"target,omitempty"`
+	Status  int       `json:"status"`
+	IP      string    `json:"ip"`
+}
+
+// AppendAudit stores an audit entry. Only the latest MaxAuditEntries are
+// kept, and the entry is appended to the journal rather than rewriting the
+// storage file.
+func (s *Store) AppendAudit(entry AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry.ID == "" {
+		entry.ID = NewID()
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+
+	s.putAudit(entry)
+	return s.journal(journalRecord{Audit: &entry})
+}
+
+// putAudit appends an audit entry, dropping the oldest ones beyond
+// MaxAuditEntries
+func (s *Store) putAudit(entry AuditEntry) {
+	s.data.Audit = append(s.data.Audit, entry)
+	if excess := len(s.data.Audit) - MaxAuditEntries; excess > 0 {
+		s.data.Audit = slices.Delete(s.data.Audit, 0, excess)
+	}
+}
+
+// ListAudit returns the most recent audit entries, newest first
+func (s *Store) ListAudit(limit int) []AuditEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := make([]AuditEntry, 0, len(s.data.Audit))
+	for i := len(s.data.Audit) - 1; i >= 0; i-- {
+		entries = append(entries, s.data.Audit[i])
+		if limit > 0 && len(entries) == limit {
+			break
+		}
+	}
+	return entries
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// journalMinEntries is the number of journaled records below which the
+// storage file is never rewritten to fold them in
+const journalMinEntries = 1000
+
+// journalRecord is a line of the journal: a saved submission or an audit entry
+type journalRecord struct {
+	Submission *Submission `json:"submission,omitempty"`
+	Audit      *AuditEntry `json:"audit,omitempty"`
+}
+
+// journalPath returns the file saved submissions and audit entries are
+// appended to between writes of the storage file
+func (s *Store) journalPath() string {
+	return s.path + ".journal"
+}
+
+// journal appends a record to the journal, so that saving a submission or
+// auditing a request does not rewrite the whole storage file. Once the
+// journal holds half as many records as the file, the storage file is written
+// instead, which keeps the cost of a save constant on average. Callers must
+// hold the write lock.
+func (s *Store) journal(record journalRecord) error {
+	if s.path == "" || s.readOnly {
+		return nil
+	}
+	if s.journaled >= max((len(s.data.Submissions)+len(s.data.Audit))/2, journalMinEntries) {
+		return s.persist()
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode journal record: %w", err)
+	}
+	f, err := os.OpenFile(s.journalPath(), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err == nil {
+		_, err = f.Write(append(line, '\n'))
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		s.lastErr = fmt.Errorf("failed to write storage journal: %w", err)
+		return s.lastErr
+	}
+
+	s.journaled++
+	s.lastErr = nil
+	return nil
+}
+
+// replayJournal applies the records journaled since the storage file was last
+// written. A last line without a newline was cut short while being written
+// and is ignored.
+func (s *Store) replayJournal() error {
+	raw, err := os.ReadFile(s.journalPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read storage journal: %w", err)
+	}
+
+	for {
+		line, rest, ok := bytes.Cut(raw, []byte("\n"))
+		if !ok {
+			return nil
+		}
+		raw = rest
+
+		var record journalRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return fmt.Errorf("failed to parse storage journal %s: %w", s.journalPath(), err)
+		}
+		if record.Submission != nil {
+			s.putSubmission(*record.Submission)
+		}
+		if record.Audit != nil {
+			s.putAudit(*record.Audit)
+		}
+		s.journaled++
+	}
+}
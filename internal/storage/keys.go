@@ -0,0 +1,69 @@
+package storage
+
+import "time"
+
+// APIKey is an admin API key. Only the hash of the secret is stored.
+type APIKey struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Hash        string     `json:"hash"`
+	Websites    []string   `json:"websites"`
+	Permissions []string   `json:"permissions"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RotatedAt   *time.Time `json:"rotated_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+}
+
+// SaveAPIKey inserts a key or replaces the one with the same ID
+func (s *Store) SaveAPIKey(key APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.data.APIKeys {
+		if s.data.APIKeys[i].ID == key.ID {
+			s.data.APIKeys[i] = key
+			return s.persist()
+		}
+	}
+
+	s.data.APIKeys = append(s.data.APIKeys, key)
+	return s.persist()
+}
+
+// GetAPIKey returns the key with the given ID
+func (s *Store) GetAPIKey(id string) (APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, key := range s.data.APIKeys {
+		if key.ID == id {
+			return key, nil
+		}
+	}
+	return APIKey{}, ErrNotFound
+}
+
+// ListAPIKeys returns all keys, including revoked ones
+func (s *Store) ListAPIKeys() []APIKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]APIKey, len(s.data.APIKeys))
+	copy(keys, s.data.APIKeys)
+	return keys
+}
+
+// TouchAPIKey records that a key was used without persisting to disk,
+// so authentication does not cost a write on every request
+func (s *Store) TouchAPIKey(id string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.data.APIKeys {
+		if s.data.APIKeys[i].ID == id {
+			s.data.APIKeys[i].LastUsedAt = &at
+			return
+		}
+	}
+}
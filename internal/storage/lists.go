@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"slices"
+	"time"
+)
+
+// ListEntry is a block or allow list entry. Entries imported from files carry
+// their Source and are never stored.
+type ListEntry struct {
+	ID        string     `json:"id,omitempty"`
+	List      string     `json:"list" example:"block"`
+	Value     string     `json:"value" example:"203.0.113.0/24"`
+	Website   string     `json:"website,omitempty"`
+	Reason    string     `json:"reason,omitempty" example:"Repeated abuse"`
+	Source    string     `json:"source,omitempty"`
+	Author    string     `json:"author,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Expired reports whether an entry no longer applies at a given time
+func (e ListEntry) Expired(at time.Time) bool {
+	return e.ExpiresAt != nil && !at.Before(*e.ExpiresAt)
+}
+
+// SaveListEntry stores a new list entry
+func (s *Store) SaveListEntry(entry ListEntry) (ListEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry.ID = NewID()
+	entry.CreatedAt = time.Now().UTC()
+	s.data.Lists = append(s.data.Lists, entry)
+	return entry, s.persist()
+}
+
+// GetListEntry returns the list entry with the given ID
+func (s *Store) GetListEntry(id string) (ListEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, entry := range s.data.Lists {
+		if entry.ID == id {
+			return entry, nil
+		}
+	}
+	return ListEntry{}, ErrNotFound
+}
+
+// ListEntries returns every stored list entry, including expired ones
+func (s *Store) ListEntries() []ListEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.data.Lists)
+}
+
+// DeleteListEntry removes a list entry
+func (s *Store) DeleteListEntry(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := len(s.data.Lists)
+	s.data.Lists = slices.DeleteFunc(s.data.Lists, func(entry ListEntry) bool { return entry.ID == id })
+	if len(s.data.Lists) == n {
+		return ErrNotFound
+	}
+	return s.persist()
+}
+
+// PurgeExpiredListEntries deletes entries that expired by a given time and
+// returns how many were deleted
+func (s *Store) PurgeExpiredListEntries(at time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := len(s.data.Lists)
+	s.data.Lists = slices.DeleteFunc(s.data.Lists, func(entry ListEntry) bool { return entry.Expired(at) })
+	if len(s.data.Lists) == n {
+		return 0, nil
+	}
+	return n - len(s.data.Lists), s.persist()
+}
@@ -0,0 +1,56 @@
+package storage
+
+import "github.com/nahuelsantos/contact-api/internal/spam"
+
+// GlobalSpamModel is the scope of the spam model trained by every website
+const GlobalSpamModel = "*"
+
+// SpamModel returns the spam model of a website or GlobalSpamModel, or nil
+// when it was never trained. Models are replaced rather than modified, so
+// the result can be read without holding the lock.
+func (s *Store) SpamModel(scope string) *spam.Model {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.data.SpamModels[scope]
+}
+
+// UpdateSpamModel applies fn to a copy of a spam model and stores the result
+func (s *Store) UpdateSpamModel(scope string, fn func(*spam.Model)) (*spam.Model, error) {
+	models, err := s.UpdateSpamModels([]string{scope}, func(_ string, m *spam.Model) { fn(m) })
+	return models[0], err
+}
+
+// UpdateSpamModels applies fn to a copy of each spam model, along with its
+// scope, and stores the results in a single write. When the write fails no
+// model changes.
+func (s *Store) UpdateSpamModels(scopes []string, fn func(string, *spam.Model)) ([]*spam.Model, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.data.SpamModels == nil {
+		s.data.SpamModels = make(map[string]*spam.Model)
+	}
+	previous := make(map[string]*spam.Model, len(scopes))
+	models := make([]*spam.Model, len(scopes))
+	for i, scope := range scopes {
+		if _, seen := previous[scope]; !seen {
+			previous[scope] = s.data.SpamModels[scope]
+		}
+		models[i] = s.data.SpamModels[scope].Clone()
+		fn(scope, models[i])
+		s.data.SpamModels[scope] = models[i]
+	}
+
+	if err := s.persist(); err != nil {
+		for scope, model := range previous {
+			if model == nil {
+				delete(s.data.SpamModels, scope)
+			} else {
+				s.data.SpamModels[scope] = model
+			}
+		}
+		return models, err
+	}
+	return models, nil
+}
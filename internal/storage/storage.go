@@ -0,0 +1,159 @@
+// Package storage persists submissions, API keys, audit entries, website
+// definitions, spam models and list entries in a JSON file, or in memory when
+// no file is configured
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/nahuelsantos/contact-api/internal/spam"
+)
+
+// ErrNotFound is returned when a record does not exist
+var ErrNotFound = errors.New("not found")
+
+// data is the on-disk representation of the store
+type data struct {
+	Submissions []Submission           `json:"submissions"`
+	APIKeys     []APIKey               `json:"api_keys"`
+	Audit       []AuditEntry           `json:"audit"`
+	Websites    []WebsiteVersion       `json:"websites"`
+	SpamModels  map[string]*spam.Model `json:"spam_models,omitempty"`
+	Lists       []ListEntry            `json:"lists,omitempty"`
+}
+
+// Store holds all persisted records
+type Store struct {
+	path string
+
+	mu      sync.RWMutex
+	data    data
+	lastErr error
+
+	// maxSubmissions and retention bound the stored submissions, see SetRetention
+	maxSubmissions int
+	retention      time.Duration
+
+	// journaled counts the records appended to the journal since the file
+	// was last written
+	journaled int
+
+	// readOnly keeps changes in memory, see OpenReadOnly
+	readOnly bool
+}
+
+// Open loads the store from path, creating it if needed.
+// An empty path gives a store that only lives in memory.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path}
+	if path == "" {
+		return s, nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create storage directory: %w", err)
+		}
+		return s, s.persist()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read storage file: %w", err)
+	}
+
+	return s, s.load(raw)
+}
+
+// OpenReadOnly loads the store from path without ever writing to it, for
+// commands that read the storage of a running server. Changes, such as
+// submissions pruned by SetRetention, only live in memory. A missing file
+// gives an empty store.
+func OpenReadOnly(path string) (*Store, error) {
+	s := &Store{path: path, readOnly: true}
+	if path == "" {
+		return s, nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read storage file: %w", err)
+	}
+	return s, s.load(raw)
+}
+
+// load decodes the storage file and applies the journal
+func (s *Store) load(raw []byte) error {
+	if err := json.Unmarshal(raw, &s.data); err != nil {
+		return fmt.Errorf("failed to parse storage file %s: %w", s.path, err)
+	}
+	return s.replayJournal()
+}
+
+// Ping reports whether the store can currently persist changes
+func (s *Store) Ping() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.lastErr != nil {
+		return s.lastErr
+	}
+	if s.path == "" {
+		return nil
+	}
+	if _, err := os.Stat(s.path); err != nil {
+		return fmt.Errorf("storage file unavailable: %w", err)
+	}
+	return nil
+}
+
+// persist atomically writes the store to disk. Callers must hold the write lock.
+func (s *Store) persist() error {
+	if s.path == "" || s.readOnly {
+		return nil
+	}
+
+	raw, err := json.Marshal(s.data)
+	if err != nil {
+		return fmt.Errorf("failed to encode storage: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		s.lastErr = fmt.Errorf("failed to write storage file: %w", err)
+		return s.lastErr
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		s.lastErr = fmt.Errorf("failed to replace storage file: %w", err)
+		return s.lastErr
+	}
+
+	// The file now holds every journaled record
+	if err := os.Remove(s.journalPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		s.lastErr = fmt.Errorf("failed to remove storage journal: %w", err)
+		return s.lastErr
+	}
+	s.journaled = 0
+
+	s.lastErr = nil
+	return nil
+}
+
+// NewID returns a random identifier for a record
+func NewID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("failed to read random bytes: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,499 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+	"github.com/nahuelsantos/contact-api/internal/spam"
+)
+
+func TestOpen_PersistsAcrossReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "contact-api.json")
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() returned error: %v", err)
+	}
+	if err := s.Ping(); err != nil {
+		t.Errorf("Ping() returned error: %v", err)
+	}
+
+	sub, err := s.SaveSubmission(Submission{Website: "main", Email: "john@example.com", Status: StatusFailed})
+	if err != nil {
+		t.Fatalf("SaveSubmission() returned error: %v", err)
+	}
+	if err := s.SaveAPIKey(APIKey{ID: "k1", Name: "support", Hash: "abc"}); err != nil {
+		t.Fatalf("SaveAPIKey() returned error: %v", err)
+	}
+	if err := s.AppendAudit(AuditEntry{KeyID: "k1", Action: "GET /admin/keys"}); err != nil {
+		t.Fatalf("AppendAudit() returned error: %v", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() returned error on reopen: %v", err)
+	}
+	if _, err := reopened.GetSubmission(sub.ID); err != nil {
+		t.Errorf("submission not persisted: %v", err)
+	}
+	if _, err := reopened.GetAPIKey("k1"); err != nil {
+		t.Errorf("API key not persisted: %v", err)
+	}
+	if got := len(reopened.ListAudit(0)); got != 1 {
+		t.Errorf("got %d audit entries, want 1", got)
+	}
+}
+
+func TestOpenReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "contact-api.json")
+	s, _ := Open(path)
+	for i := 0; i < 3; i++ {
+		if _, err := s.SaveSubmission(Submission{Website: "main"}); err != nil {
+			t.Fatalf("SaveSubmission() returned error: %v", err)
+		}
+	}
+	file, _ := os.ReadFile(path)
+	journal, _ := os.ReadFile(path + ".journal")
+
+	readOnly, err := OpenReadOnly(path)
+	if err != nil {
+		t.Fatalf("OpenReadOnly() returned error: %v", err)
+	}
+	if got := readOnly.CountSubmissions(SubmissionFilter{}); got != 3 {
+		t.Errorf("Expected the journaled submissions, got %d", got)
+	}
+	if err := readOnly.SetRetention(1, 0); err != nil {
+		t.Fatalf("SetRetention() returned error: %v", err)
+	}
+	if err := readOnly.AppendAudit(AuditEntry{Action: "GET /admin/keys"}); err != nil {
+		t.Fatalf("AppendAudit() returned error: %v", err)
+	}
+	if got := readOnly.CountSubmissions(SubmissionFilter{}); got != 1 {
+		t.Errorf("Expected retention to apply in memory, got %d submissions", got)
+	}
+	if after, _ := os.ReadFile(path); string(after) != string(file) {
+		t.Error("Expected the storage file to be left alone")
+	}
+	if after, _ := os.ReadFile(path + ".journal"); string(after) != string(journal) {
+		t.Error("Expected the journal to be left alone")
+	}
+
+	missing := filepath.Join(t.TempDir(), "missing", "contact-api.json")
+	if _, err := OpenReadOnly(missing); err != nil {
+		t.Fatalf("OpenReadOnly() returned error for a missing file: %v", err)
+	}
+	if _, err := os.Stat(filepath.Dir(missing)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected nothing to be created, got %v", err)
+	}
+}
+
+func TestOpen_InvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "contact-api.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	if _, err := Open(path); err == nil {
+		t.Error("Open() should fail on an invalid file")
+	}
+}
+
+func TestListSubmissions(t *testing.T) {
+	s, _ := Open("")
+
+	base := time.Now()
+	for i, sub := range []Submission{
+		{ID: "1", Website: "main", Status: StatusSent},
+		{ID: "2", Website: "main", Status: StatusFailed},
+		{ID: "3", Website: "blog", Status: StatusFailed},
+		{ID: "4", Website: "main", Status: StatusSent},
+	} {
+		sub.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		s.data.Submissions = append(s.data.Submissions, sub)
+	}
+
+	tests := []struct {
+		name        string
+		filter      SubmissionFilter
+		expectedIDs []string
+	}{
+		{name: "all", filter: SubmissionFilter{}, expectedIDs: []string{"4", "3", "2", "1"}},
+		{name: "by website", filter: SubmissionFilter{Website: "main"}, expectedIDs: []string{"4", "2", "1"}},
+		{name: "by status", filter: SubmissionFilter{Status: StatusFailed}, expectedIDs: []string{"3", "2"}},
+		{name: "with limit", filter: SubmissionFilter{Website: "main", Limit: 1}, expectedIDs: []string{"4"}},
+		{name: "before", filter: SubmissionFilter{Before: base.Add(90 * time.Second)}, expectedIDs: []string{"2", "1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subs := s.ListSubmissions(tt.filter)
+			if len(subs) != len(tt.expectedIDs) {
+				t.Fatalf("got %d submissions, want %d", len(subs), len(tt.expectedIDs))
+			}
+			for i, id := range tt.expectedIDs {
+				if subs[i].ID != id {
+					t.Errorf("submission %d has ID %q, want %q", i, subs[i].ID, id)
+				}
+			}
+		})
+	}
+
+	if got := s.CountSubmissions(SubmissionFilter{Status: StatusFailed}); got != 2 {
+		t.Errorf("CountSubmissions() = %d, want 2", got)
+	}
+}
+
+func TestPurgeSubmissions(t *testing.T) {
+	s, _ := Open("")
+
+	base := time.Now()
+	for i, sub := range []Submission{
+		{ID: "1", Website: "main", Status: StatusFailed},
+		{ID: "2", Website: "main", Status: StatusSent},
+		{ID: "3", Website: "blog", Status: StatusFailed},
+		{ID: "4", Website: "main", Status: StatusFailed},
+	} {
+		sub.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		s.data.Submissions = append(s.data.Submissions, sub)
+	}
+
+	purged, err := s.PurgeSubmissions(SubmissionFilter{Website: "main", Status: StatusFailed, Before: base.Add(2 * time.Minute)})
+	if err != nil {
+		t.Fatalf("PurgeSubmissions() returned error: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("PurgeSubmissions() = %d, want 1", purged)
+	}
+	if _, err := s.GetSubmission("1"); err != ErrNotFound {
+		t.Errorf("submission 1 should be purged, got error %v", err)
+	}
+	if got := s.CountSubmissions(SubmissionFilter{}); got != 3 {
+		t.Errorf("CountSubmissions() = %d, want 3", got)
+	}
+}
+
+func TestSetRetention(t *testing.T) {
+	base := time.Now().UTC()
+
+	tests := []struct {
+		name        string
+		max         int
+		retention   time.Duration
+		expectedIDs []string
+	}{
+		{name: "unbounded", expectedIDs: []string{"4", "3", "2", "1"}},
+		{name: "max", max: 2, expectedIDs: []string{"4", "3"}},
+		{name: "retention", retention: 150 * time.Minute, expectedIDs: []string{"4", "3", "2"}},
+		{name: "both", max: 1, retention: 150 * time.Minute, expectedIDs: []string{"4"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := Open("")
+			for i, id := range []string{"1", "2", "3", "4"} {
+				s.data.Submissions = append(s.data.Submissions, Submission{ID: id, CreatedAt: base.Add(time.Duration(i-3) * time.Hour)})
+			}
+
+			if err := s.SetRetention(tt.max, tt.retention); err != nil {
+				t.Fatalf("SetRetention() returned error: %v", err)
+			}
+			var ids []string
+			for _, sub := range s.ListSubmissions(SubmissionFilter{}) {
+				ids = append(ids, sub.ID)
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.expectedIDs) {
+				t.Errorf("Expected submissions %v, got %v", tt.expectedIDs, ids)
+			}
+		})
+	}
+}
+
+func TestSaveSubmission_Retention(t *testing.T) {
+	s, _ := Open("")
+	if err := s.SetRetention(3, 0); err != nil {
+		t.Fatalf("SetRetention() returned error: %v", err)
+	}
+
+	var last Submission
+	for i := 0; i < 10; i++ {
+		last, _ = s.SaveSubmission(Submission{Website: "main", Status: StatusSent})
+	}
+	if got := s.CountSubmissions(SubmissionFilter{}); got != 3 {
+		t.Errorf("CountSubmissions() = %d, want 3", got)
+	}
+	if _, err := s.GetSubmission(last.ID); err != nil {
+		t.Errorf("Expected the newest submission to be kept, got %v", err)
+	}
+}
+
+func TestSaveSubmission_Journal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "contact-api.json")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() returned error: %v", err)
+	}
+	before, _ := os.ReadFile(path)
+
+	first, _ := s.SaveSubmission(Submission{Website: "main", Status: StatusFailed})
+	first.Status = StatusSent
+	if _, err := s.SaveSubmission(first); err != nil {
+		t.Fatalf("SaveSubmission() returned error: %v", err)
+	}
+	second, _ := s.SaveSubmission(Submission{Website: "main", Status: StatusFailed})
+
+	// Saving appends to the journal and leaves the storage file alone
+	if after, _ := os.ReadFile(path); string(after) != string(before) {
+		t.Errorf("Expected the storage file to be unchanged, got %s", after)
+	}
+
+	// A line cut short while being written is ignored
+	f, _ := os.OpenFile(path+".journal", os.O_WRONLY|os.O_APPEND, 0o600)
+	f.WriteString(`{"id":"partial","web`)
+	f.Close()
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() returned error on reopen: %v", err)
+	}
+	if sub, err := reopened.GetSubmission(first.ID); err != nil || sub.Status != StatusSent {
+		t.Errorf("Expected the updated submission from the journal, got %+v (%v)", sub, err)
+	}
+	if _, err := reopened.GetSubmission(second.ID); err != nil {
+		t.Errorf("Expected the second submission from the journal, got %v", err)
+	}
+	if got := reopened.CountSubmissions(SubmissionFilter{}); got != 2 {
+		t.Errorf("CountSubmissions() = %d, want 2", got)
+	}
+
+	// Writing the storage file folds the journal in
+	if err := reopened.SaveAPIKey(APIKey{ID: "k1"}); err != nil {
+		t.Fatalf("SaveAPIKey() returned error: %v", err)
+	}
+	if _, err := os.Stat(path + ".journal"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected the journal to be removed, got %v", err)
+	}
+	if again, _ := Open(path); again.CountSubmissions(SubmissionFilter{}) != 2 {
+		t.Error("Expected the submissions to be kept in the storage file")
+	}
+}
+
+func TestAppendAudit_Journal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "contact-api.json")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() returned error: %v", err)
+	}
+	before, _ := os.ReadFile(path)
+
+	for _, action := range []string{"GET /admin/keys", "POST /admin/keys"} {
+		if err := s.AppendAudit(AuditEntry{KeyID: "k1", Action: action}); err != nil {
+			t.Fatalf("AppendAudit() returned error: %v", err)
+		}
+	}
+	if after, _ := os.ReadFile(path); string(after) != string(before) {
+		t.Errorf("Expected the storage file to be unchanged, got %s", after)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() returned error on reopen: %v", err)
+	}
+	entries := reopened.ListAudit(0)
+	if len(entries) != 2 || entries[0].Action != "POST /admin/keys" {
+		t.Errorf("Expected the audit entries from the journal, newest first, got %+v", entries)
+	}
+}
+
+func TestAppendAudit_Cap(t *testing.T) {
+	s, _ := Open("")
+	for i := 0; i < MaxAuditEntries+5; i++ {
+		if err := s.AppendAudit(AuditEntry{Target: fmt.Sprint(i)}); err != nil {
+			t.Fatalf("AppendAudit() returned error: %v", err)
+		}
+	}
+
+	entries := s.ListAudit(0)
+	if len(entries) != MaxAuditEntries {
+		t.Fatalf("Expected %d audit entries, got %d", MaxAuditEntries, len(entries))
+	}
+	if oldest := entries[len(entries)-1].Target; oldest != "5" {
+		t.Errorf("Expected the oldest entries to be dropped, got oldest %s", oldest)
+	}
+}
+
+func TestSaveSubmission_JournalCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "contact-api.json")
+	s, _ := Open(path)
+
+	for i := 0; i <= journalMinEntries; i++ {
+		if _, err := s.SaveSubmission(Submission{Website: "main"}); err != nil {
+			t.Fatalf("SaveSubmission() returned error: %v", err)
+		}
+	}
+	if s.journaled != 0 {
+		t.Errorf("Expected the journal to be folded into the storage file, got %d entries", s.journaled)
+	}
+	reopened, _ := Open(path)
+	if got := reopened.CountSubmissions(SubmissionFilter{}); got != journalMinEntries+1 {
+		t.Errorf("CountSubmissions() = %d, want %d", got, journalMinEntries+1)
+	}
+}
+
+func TestWebsiteVersions(t *testing.T) {
+	s, _ := Open("")
+
+	v1, err := s.AddWebsiteVersion("main", config.Website{Recipients: []string{"a@example.com"}}, false, "root")
+	if err != nil {
+		t.Fatalf("AddWebsiteVersion() returned error: %v", err)
+	}
+	v2, _ := s.AddWebsiteVersion("main", config.Website{Recipients: []string{"b@example.com"}}, false, "root")
+	s.AddWebsiteVersion("blog", config.Website{}, false, "root")
+	s.AddWebsiteVersion("blog", config.Website{}, true, "root")
+
+	if v1.Version != 1 || v2.Version != 2 {
+		t.Errorf("versions = %d, %d, want 1, 2", v1.Version, v2.Version)
+	}
+
+	latest := s.LatestWebsiteVersions()
+	if latest["main"].Version != 2 || latest["main"].Config.Recipients[0] != "b@example.com" {
+		t.Errorf("unexpected latest main version: %+v", latest["main"])
+	}
+	if !latest["blog"].Deleted {
+		t.Error("latest blog version should be a tombstone")
+	}
+
+	versions := s.ListWebsiteVersions("main")
+	if len(versions) != 2 || versions[0].Version != 2 {
+		t.Errorf("unexpected version list: %+v", versions)
+	}
+
+	if _, err := s.GetWebsiteVersion("main", 1); err != nil {
+		t.Errorf("GetWebsiteVersion() returned error: %v", err)
+	}
+	if _, err := s.GetWebsiteVersion("main", 9); err != ErrNotFound {
+		t.Errorf("GetWebsiteVersion() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSpamModels(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "contact-api.json")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() returned error: %v", err)
+	}
+
+	if s.SpamModel("main") != nil {
+		t.Error("Expected no model before training")
+	}
+
+	msg := spam.Message{Message: "cheap watches"}
+	first, err := s.UpdateSpamModel("main", func(m *spam.Model) { m.Learn(msg, true) })
+	if err != nil {
+		t.Fatalf("UpdateSpamModel() returned error: %v", err)
+	}
+	if _, err := s.UpdateSpamModel("main", func(m *spam.Model) { m.Learn(msg, false) }); err != nil {
+		t.Fatalf("UpdateSpamModel() returned error: %v", err)
+	}
+
+	// Earlier models are never modified, so readers need no lock
+	if first.Ham != 0 {
+		t.Error("Expected an update to replace the model rather than modify it")
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() returned error on reopen: %v", err)
+	}
+	model := reopened.SpamModel("main")
+	if model == nil || model.Spam != 1 || model.Ham != 1 {
+		t.Errorf("Expected the model to persist, got %+v", model)
+	}
+	if reopened.SpamModel(GlobalSpamModel) != nil {
+		t.Error("Expected models to be kept per scope")
+	}
+}
+
+func TestUpdateSpamModels(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "data")
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		t.Fatalf("Failed to create data directory: %v", err)
+	}
+	s, err := Open(filepath.Join(dir, "contact-api.json"))
+	if err != nil {
+		t.Fatalf("Open() returned error: %v", err)
+	}
+
+	msg := spam.Message{Message: "cheap watches"}
+	scopes := []string{"main", GlobalSpamModel}
+	if _, err := s.UpdateSpamModels(scopes, func(_ string, m *spam.Model) { m.Learn(msg, true) }); err != nil {
+		t.Fatalf("UpdateSpamModels() returned error: %v", err)
+	}
+	for _, scope := range scopes {
+		if model := s.SpamModel(scope); model == nil || model.Spam != 1 {
+			t.Errorf("Expected model %s to be trained, got %+v", scope, model)
+		}
+	}
+
+	// A failed write leaves every model as it was
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatalf("Failed to remove data directory: %v", err)
+	}
+	if _, err := s.UpdateSpamModels([]string{"other", "main", GlobalSpamModel}, func(_ string, m *spam.Model) { m.Learn(msg, false) }); err == nil {
+		t.Fatal("Expected an error when the storage file cannot be written")
+	}
+	for _, scope := range scopes {
+		if model := s.SpamModel(scope); model == nil || model.Ham != 0 {
+			t.Errorf("Expected model %s to be unchanged, got %+v", scope, model)
+		}
+	}
+	if s.SpamModel("other") != nil {
+		t.Error("Expected no model for a scope first trained by a failed write")
+	}
+}
+
+func TestListEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "contact-api.json")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() returned error: %v", err)
+	}
+
+	now := time.Now().UTC()
+	expired := now.Add(-time.Minute)
+	kept, err := s.SaveListEntry(ListEntry{List: "block", Value: "203.0.113.0/24"})
+	if err != nil {
+		t.Fatalf("SaveListEntry() returned error: %v", err)
+	}
+	if kept.ID == "" || kept.CreatedAt.IsZero() {
+		t.Errorf("Expected an ID and creation time, got %+v", kept)
+	}
+	if _, err := s.SaveListEntry(ListEntry{List: "block", Value: "spam.example", ExpiresAt: &expired}); err != nil {
+		t.Fatalf("SaveListEntry() returned error: %v", err)
+	}
+
+	purged, err := s.PurgeExpiredListEntries(now)
+	if err != nil || purged != 1 {
+		t.Errorf("Expected one purged entry, got %d (%v)", purged, err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() returned error on reopen: %v", err)
+	}
+	entries := reopened.ListEntries()
+	if len(entries) != 1 || entries[0].ID != kept.ID {
+		t.Fatalf("Expected only the unexpired entry to persist, got %+v", entries)
+	}
+
+	if err := reopened.DeleteListEntry(kept.ID); err != nil {
+		t.Errorf("DeleteListEntry() returned error: %v", err)
+	}
+	if err := reopened.DeleteListEntry(kept.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,213 @@
+package storage
+
+import (
+	"sort"
+	"time"
+)
+
+// Labels given to submissions to train the spam classifier
+const (
+	LabelSpam = "spam"
+	LabelHam  = "ham"
+)
+
+// Submission statuses
+const (
+	StatusSent        = "sent"
+	StatusFailed      = "failed"
+	StatusQuarantined = "quarantined"
+	StatusStored      = "stored"
+)
+
+// Submission is a stored contact form submission and its delivery state
+type Submission struct {
+	ID        string            `json:"id"`
+	Website   string            `json:"website"`
+	Name      string            `json:"name"`
+	Email     string            `json:"email"`
+	Subject   string            `json:"subject"`
+	Message   string            `json:"message"`
+	Locale    string            `json:"locale,omitempty"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	Route     string            `json:"route,omitempty"`
+	Transport string            `json:"transport,omitempty"`
+	Spam      *SpamVerdict      `json:"spam,omitempty"`
+	Label     string            `json:"label,omitempty"`
+
+	// GlobalLabel is the label the global spam model learned, which only
+	// admins of every website can set
+	GlobalLabel string `json:"global_label,omitempty"`
+
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Permanent bool      `json:"permanent,omitempty"`
+	Attempts  int       `json:"attempts"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SpamVerdict records how a submission scored against the spam checks
+type SpamVerdict struct {
+	Score   float64  `json:"score"`
+	Action  string   `json:"action"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// SubmissionFilter narrows the submissions returned by ListSubmissions
+type SubmissionFilter struct {
+	Website string
+	Status  string
+	Before  time.Time
+	Limit   int
+}
+
+// matches reports whether a submission satisfies the filter
+func (f SubmissionFilter) matches(sub Submission) bool {
+	if f.Website != "" && sub.Website != f.Website {
+		return false
+	}
+	if f.Status != "" && sub.Status != f.Status {
+		return false
+	}
+	if !f.Before.IsZero() && !sub.CreatedAt.Before(f.Before) {
+		return false
+	}
+	return true
+}
+
+// SetRetention bounds the stored submissions to the most recent maxCount, and
+// to those created within retention. A zero value disables a bound.
+// Submissions beyond the bounds are deleted, oldest first, right away and
+// whenever a submission is saved.
+func (s *Store) SetRetention(maxCount int, retention time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.maxSubmissions, s.retention = maxCount, retention
+	if s.prune(time.Now().UTC()) == 0 {
+		return nil
+	}
+	return s.persist()
+}
+
+// SaveSubmission inserts a submission or replaces the one with the same ID.
+// The submission is appended to the journal rather than rewriting the
+// storage file.
+func (s *Store) SaveSubmission(sub Submission) (Submission, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	if sub.ID == "" {
+		sub.ID = NewID()
+		sub.CreatedAt = now
+	}
+	sub.UpdatedAt = now
+
+	s.putSubmission(sub)
+	s.prune(now)
+	return sub, s.journal(journalRecord{Submission: &sub})
+}
+
+// putSubmission inserts a submission or replaces the one with the same ID,
+// looking from the most recent since those are updated the most. Callers
+// must hold the write lock.
+func (s *Store) putSubmission(sub Submission) {
+	for i := len(s.data.Submissions) - 1; i >= 0; i-- {
+		if s.data.Submissions[i].ID == sub.ID {
+			s.data.Submissions[i] = sub
+			return
+		}
+	}
+	s.data.Submissions = append(s.data.Submissions, sub)
+}
+
+// prune deletes the oldest submissions beyond the retention bounds and
+// returns how many were deleted. Submissions are kept in the order they were
+// created, so only the first ones need checking. Callers must hold the write
+// lock.
+func (s *Store) prune(now time.Time) int {
+	subs := s.data.Submissions
+	n := 0
+	if s.maxSubmissions > 0 && len(subs) > s.maxSubmissions {
+		n = len(subs) - s.maxSubmissions
+	}
+	if s.retention > 0 {
+		cutoff := now.Add(-s.retention)
+		for n < len(subs) && subs[n].CreatedAt.Before(cutoff) {
+			n++
+		}
+	}
+
+	clear(subs[:n])
+	s.data.Submissions = subs[n:]
+	return n
+}
+
+// GetSubmission returns the submission with the given ID
+func (s *Store) GetSubmission(id string) (Submission, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, sub := range s.data.Submissions {
+		if sub.ID == id {
+			return sub, nil
+		}
+	}
+	return Submission{}, ErrNotFound
+}
+
+// ListSubmissions returns matching submissions, newest first
+func (s *Store) ListSubmissions(filter SubmissionFilter) []Submission {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var subs []Submission
+	for _, sub := range s.data.Submissions {
+		if filter.matches(sub) {
+			subs = append(subs, sub)
+		}
+	}
+
+	sort.SliceStable(subs, func(i, j int) bool {
+		return subs[i].CreatedAt.After(subs[j].CreatedAt)
+	})
+	if filter.Limit > 0 && len(subs) > filter.Limit {
+		subs = subs[:filter.Limit]
+	}
+	return subs
+}
+
+// CountSubmissions returns the number of matching submissions
+func (s *Store) CountSubmissions(filter SubmissionFilter) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, sub := range s.data.Submissions {
+		if filter.matches(sub) {
+			count++
+		}
+	}
+	return count
+}
+
+// PurgeSubmissions deletes matching submissions and returns how many were removed
+func (s *Store) PurgeSubmissions(filter SubmissionFilter) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.data.Submissions[:0]
+	for _, sub := range s.data.Submissions {
+		if !filter.matches(sub) {
+			kept = append(kept, sub)
+		}
+	}
+	purged := len(s.data.Submissions) - len(kept)
+	s.data.Submissions = kept
+
+	if purged == 0 {
+		return 0, nil
+	}
+	return purged, s.persist()
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"sort"
+	"time"
+
+	"github.com/nahuelsantos/contact-api/internal/config"
+)
+
+// WebsiteVersion is one immutable revision of a stored website definition.
+// Deleted versions are tombstones that hide the website.
+type WebsiteVersion struct {
+	Website   string         `json:"website"`
+	Version   int            `json:"version"`
+	Config    config.Website `json:"config"`
+	Deleted   bool           `json:"deleted"`
+	Author    string         `json:"author"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// AddWebsiteVersion appends a new version for a website and returns it
+func (s *Store) AddWebsiteVersion(website string, site config.Website, deleted bool, author string) (WebsiteVersion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	version := WebsiteVersion{
+		Website:   website,
+		Version:   1,
+		Config:    site,
+		Deleted:   deleted,
+		Author:    author,
+		CreatedAt: time.Now().UTC(),
+	}
+	for _, v := range s.data.Websites {
+		if v.Website == website && v.Version >= version.Version {
+			version.Version = v.Version + 1
+		}
+	}
+
+	s.data.Websites = append(s.data.Websites, version)
+	return version, s.persist()
+}
+
+// GetWebsiteVersion returns a specific version of a website
+func (s *Store) GetWebsiteVersion(website string, version int) (WebsiteVersion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, v := range s.data.Websites {
+		if v.Website == website && v.Version == version {
+			return v, nil
+		}
+	}
+	return WebsiteVersion{}, ErrNotFound
+}
+
+// ListWebsiteVersions returns every version of a website, newest first
+func (s *Store) ListWebsiteVersions(website string) []WebsiteVersion {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var versions []WebsiteVersion
+	for _, v := range s.data.Websites {
+		if v.Website == website {
+			versions = append(versions, v)
+		}
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Version > versions[j].Version
+	})
+	return versions
+}
+
+// LatestWebsiteVersions returns the newest version of every stored website,
+// including tombstones so callers can hide deleted websites
+func (s *Store) LatestWebsiteVersions() map[string]WebsiteVersion {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	latest := map[string]WebsiteVersion{}
+	for _, v := range s.data.Websites {
+		if current, ok := latest[v.Website]; !ok || v.Version > current.Version {
+			latest[v.Website] = v
+		}
+	}
+	return latest
+}
@@ -0,0 +1,91 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_Post(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		secret        string
+		expectedError bool
+	}{
+		{name: "Success", status: http.StatusNoContent, expectedError: false},
+		{name: "Signed", status: http.StatusOK, secret: "s3cret", expectedError: false},
+		{name: "Server Error", status: http.StatusBadGateway, expectedError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received map[string]string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+					t.Errorf("Content-Type = %q, want application/json", ct)
+				}
+				body, _ := io.ReadAll(r.Body)
+				signature := r.Header.Get(SignatureHeader)
+				if tt.secret == "" && signature != "" {
+					t.Errorf("unexpected signature %q", signature)
+				}
+				if tt.secret != "" && signature != Sign(tt.secret, body) {
+					t.Errorf("signature = %q, want %q", signature, Sign(tt.secret, body))
+				}
+				_ = json.Unmarshal(body, &received)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			err := NewClient(time.Second).Post(context.Background(), server.URL, tt.secret, map[string]string{"website": "main"})
+			if tt.expectedError && err == nil {
+				t.Errorf("expected error but got nil")
+			}
+			if !tt.expectedError && err != nil {
+				t.Errorf("expected no error but got: %v", err)
+			}
+			if received["website"] != "main" {
+				t.Errorf("unexpected payload: %v", received)
+			}
+		})
+	}
+}
+
+func TestSign(t *testing.T) {
+	// Known HMAC-SHA256 vector from RFC 4231 test case 2
+	got := Sign("Jefe", []byte("what do ya want for nothing?"))
+	want := "sha256=5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"
+	if got != want {
+		t.Errorf("Sign() = %q, want %q", got, want)
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	tests := []struct {
+		url   string
+		valid bool
+	}{
+		{url: "https://hooks.example.com/contact", valid: true},
+		{url: "http://localhost:8080/hook", valid: true},
+		{url: "ftp://example.com/hook", valid: false},
+		{url: "/relative/path", valid: false},
+		{url: "https://", valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			err := ValidateURL(tt.url)
+			if tt.valid && err != nil {
+				t.Errorf("expected %q to be valid, got: %v", tt.url, err)
+			}
+			if !tt.valid && err == nil {
+				t.Errorf("expected %q to be invalid", tt.url)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+// Package widget holds the embeddable contact form served by the API: a
+// script that renders the form of a website, its default styles and a page
+// that shows the form in an iframe. Assets are versioned by path, so pages
+// embedding them keep working when a later version changes markup or options.
+package widget
+
+import (
+	_ "embed"
+	"html/template"
+	"io"
+)
+
+// Version is the path segment of the current widget assets
+const Version = "v1"
+
+// Script renders contact forms into elements marked data-contact-form
+//
+//go:embed widget.js
+var Script []byte
+
+// Styles is the default theme, customized through CSS variables
+//
+//go:embed widget.css
+var Styles []byte
+
+//go:embed frame.html
+var frameHTML string
+
+var frameTemplate = template.Must(template.New("frame").Parse(frameHTML))
+
+// Frame is the page showing the form of a website in an iframe
+type Frame struct {
+	Website string
+	Locale  string
+	Title   string
+}
+
+// Render writes the page
+func (f Frame) Render(w io.Writer) error {
+	return frameTemplate.Execute(w, f)
+}
@@ -0,0 +1,406 @@
+/*
+ * Contact form widget for the contact API, version 1.
+ *
+ *   <div data-contact-form data-website="main"></div>
+ *   <script src="https://api.example.com/api/v1/widget/v1/widget.js" async></script>
+ *
+ * Options are data attributes of the container:
+ *
+ *   data-website   Website identifier (required)
+ *   data-locale    Language of the labels and messages (default: the browser's)
+ *   data-mode      "iframe" to show the form in a page served by the API
+ *   data-styles    "false" to leave out the default stylesheet
+ *   data-redirect  Page to open after a successful submission
+ *   data-api       API base URL (default: where this script was loaded from)
+ *
+ * The container dispatches contact:success and contact:error events with the
+ * API response as detail.
+ */
+(function (global) {
+  "use strict";
+
+  var VERSION = "v1";
+  var script = document.currentScript;
+  var scriptBase = script ? script.src.replace(/\/widget\/v1\/widget\.js(\?.*)?$/, "") : "";
+  var counter = 0;
+
+  // el creates an element. Children are appended as nodes or text, never as
+  // markup, so API responses cannot inject HTML.
+  function el(tag, attrs, children) {
+    var node = document.createElement(tag);
+    Object.keys(attrs || {}).forEach(function (name) {
+      var value = attrs[name];
+      if (value === true) {
+        node.setAttribute(name, "");
+      } else if (value !== false && value !== undefined && value !== null) {
+        node.setAttribute(name, value);
+      }
+    });
+    (children || []).forEach(function (child) {
+      node.append(child);
+    });
+    return node;
+  }
+
+  function loadStyles(api) {
+    if (document.querySelector("link[data-contact-styles]")) {
+      return;
+    }
+    document.head.append(el("link", {
+      rel: "stylesheet",
+      href: api + "/widget/" + VERSION + "/widget.css",
+      "data-contact-styles": true,
+    }));
+  }
+
+  // loadSolver loads the proof of work solver once
+  var solver;
+  function loadSolver(api) {
+    if (global.ContactPow) {
+      return Promise.resolve(global.ContactPow);
+    }
+    if (!solver) {
+      solver = new Promise(function (resolve, reject) {
+        var tag = el("script", { src: api + "/pow.js" });
+        tag.onload = function () { resolve(global.ContactPow); };
+        tag.onerror = function () {
+          solver = null;
+          reject(new Error("Failed to load the proof of work solver"));
+        };
+        document.head.append(tag);
+      });
+    }
+    return solver;
+  }
+
+  function endpoint(options) {
+    return options.api + "/contact/" + encodeURIComponent(options.website);
+  }
+
+  async function fetchForm(options) {
+    var url = endpoint(options) + "/form";
+    if (options.locale) {
+      url += "?locale=" + encodeURIComponent(options.locale);
+    }
+    var response = await fetch(url, { headers: { Accept: "application/json" } });
+    var body = await response.json();
+    if (!response.ok) {
+      throw new Error(body.message);
+    }
+    return body.data;
+  }
+
+  function control(field, id) {
+    var attrs = {
+      id: id,
+      name: field.custom ? "fields[" + field.name + "]" : field.name,
+      class: "contact-form__input",
+      required: field.required,
+      maxlength: field.max_length || null,
+    };
+    switch (field.type) {
+      case "textarea":
+        attrs.rows = 6;
+        return el("textarea", attrs);
+      case "select":
+        return el("select", attrs, [el("option", { value: "" }, [field.placeholder || ""])].concat(
+          (field.options || []).map(function (option) {
+            return el("option", { value: option }, [option]);
+          })
+        ));
+      case "checkbox":
+        attrs.type = "checkbox";
+        attrs.value = "true";
+        attrs.maxlength = null;
+        return el("input", attrs);
+      default:
+        attrs.type = field.type;
+        attrs.autocomplete = { name: "name", email: "email", tel: "tel" }[field.custom ? field.type : field.name] || null;
+        return el("input", attrs);
+    }
+  }
+
+  function render(container, options, def) {
+    var prefix = "contact-" + ++counter + "-";
+    var state = {
+      container: container,
+      options: options,
+      def: def,
+      controls: {},
+      proof: null,
+    };
+
+    state.form = el("form", { class: "contact-form", novalidate: true, lang: def.locale });
+    def.fields.forEach(function (field) {
+      var key = field.custom ? "fields[" + field.name + "]" : field.name;
+      var id = prefix + key.replace(/[^a-z0-9_]/g, "-");
+      var input = control(field, id);
+      var error = el("p", { id: id + "-error", class: "contact-form__error", hidden: true });
+      input.setAttribute("aria-describedby", error.id);
+
+      var label = el("label", { for: id, class: "contact-form__label" }, [field.label]);
+      if (field.required) {
+        label.append(" ", el("span", { class: "contact-form__required", "aria-hidden": "true" }, ["*"]));
+      }
+      var parts = field.type === "checkbox" ? [input, label, error] : [label, input, error];
+      state.form.append(el("div", { class: "contact-form__field contact-form__field--" + field.type }, parts));
+      state.controls[key] = { field: field, input: input, error: error };
+    });
+
+    if (def.honeypot) {
+      state.trap = el("input", { type: "text", name: "_gotcha", tabindex: "-1", autocomplete: "off" });
+      state.form.append(el("div", { class: "contact-form__trap", "aria-hidden": "true" }, [
+        el("label", {}, [def.messages.trap, state.trap]),
+      ]));
+    }
+
+    state.button = el("button", { type: "submit", class: "contact-form__submit" }, [def.messages.submit]);
+    state.status = el("p", { class: "contact-form__status", role: "status", "aria-live": "polite" });
+    state.form.append(state.button, state.status);
+
+    // Solving starts when the visitor starts typing, so it is usually done
+    // by the time they send
+    if (def.proof_of_work) {
+      state.form.addEventListener("focusin", function () {
+        prepare(state);
+      }, { once: true });
+    }
+    state.form.addEventListener("submit", function (event) {
+      event.preventDefault();
+      submit(state);
+    });
+
+    container.append(state.form);
+    if (options.framed) {
+      reportSize(options);
+    }
+  }
+
+  // prepare fetches and solves a proof of work challenge
+  function prepare(state) {
+    if (!state.proof) {
+      var api = state.options.api;
+      state.proof = loadSolver(api).then(function (pow) {
+        return pow.solve(endpoint(state.options));
+      });
+      state.proof.catch(function () {
+        state.proof = null;
+      });
+    }
+    return state.proof;
+  }
+
+  function payload(state) {
+    var data = { locale: state.def.locale };
+    var fields = {};
+    Object.keys(state.controls).forEach(function (key) {
+      var c = state.controls[key];
+      var value = c.field.type === "checkbox" ? (c.input.checked ? "true" : "") : c.input.value;
+      if (!c.field.custom) {
+        data[c.field.name] = value;
+      } else if (value !== "") {
+        fields[c.field.name] = value;
+      }
+    });
+    if (Object.keys(fields).length > 0) {
+      data.fields = fields;
+    }
+    if (state.trap && state.trap.value) {
+      data._gotcha = state.trap.value;
+    }
+    return data;
+  }
+
+  async function send(state, data) {
+    if (state.def.proof_of_work) {
+      var proof = await prepare(state);
+      // Each challenge is accepted once
+      state.proof = null;
+      data.challenge = proof.challenge;
+      data.solution = proof.solution;
+    }
+    var response = await fetch(endpoint(state.options), {
+      method: "POST",
+      headers: { "Content-Type": "application/json", Accept: "application/json" },
+      body: JSON.stringify(data),
+    });
+    return response.json();
+  }
+
+  async function submit(state) {
+    clearErrors(state);
+    state.button.disabled = true;
+    setStatus(state, state.def.messages.sending, "pending");
+    try {
+      var data = payload(state);
+      var body = await send(state, data);
+      // A challenge that expired while the visitor typed is solved again
+      if (!body.success && body.code === "challenge_expired") {
+        body = await send(state, data);
+      }
+      if (body.success) {
+        succeed(state, body);
+      } else {
+        showErrors(state, body);
+      }
+    } catch (err) {
+      setStatus(state, state.def.messages.error, "error");
+      emit(state, "error", { success: false, message: state.def.messages.error });
+    } finally {
+      state.button.disabled = false;
+    }
+  }
+
+  function succeed(state, body) {
+    state.form.reset();
+    setStatus(state, body.message, "success");
+    emit(state, "success", body);
+    if (state.options.redirect) {
+      global.location.assign(state.options.redirect);
+    }
+  }
+
+  function showErrors(state, body) {
+    setStatus(state, body.message, "error");
+    var first;
+    (body.errors || []).forEach(function (fe) {
+      var c = state.controls[fe.field];
+      if (!c) {
+        return;
+      }
+      c.input.setAttribute("aria-invalid", "true");
+      c.error.replaceChildren(fe.message);
+      if (fe.suggestion) {
+        var suggestion = el("button", { type: "button", class: "contact-form__suggestion" }, [fe.suggestion]);
+        suggestion.addEventListener("click", function () {
+          c.input.value = fe.suggestion;
+          c.input.removeAttribute("aria-invalid");
+          c.error.hidden = true;
+          c.input.focus();
+        });
+        c.error.append(" ", suggestion);
+      }
+      c.error.hidden = false;
+      first = first || c.input;
+    });
+    if (first) {
+      first.focus();
+    }
+    emit(state, "error", body);
+  }
+
+  function clearErrors(state) {
+    Object.keys(state.controls).forEach(function (key) {
+      var c = state.controls[key];
+      c.input.removeAttribute("aria-invalid");
+      c.error.replaceChildren();
+      c.error.hidden = true;
+    });
+  }
+
+  function setStatus(state, message, kind) {
+    state.status.className = "contact-form__status contact-form__status--" + kind;
+    state.status.replaceChildren(message || "");
+  }
+
+  // emit dispatches an event on the container and, inside the iframe page,
+  // forwards it to the embedding page
+  function emit(state, name, detail) {
+    state.container.dispatchEvent(new CustomEvent("contact:" + name, { detail: detail, bubbles: true }));
+    if (state.options.framed && global.parent !== global) {
+      global.parent.postMessage({ type: "contact-api:" + name, website: state.options.website, detail: detail }, "*");
+    }
+  }
+
+  // reportSize tells the embedding page the height of the iframe page
+  function reportSize(options) {
+    if (global.parent === global || typeof ResizeObserver === "undefined") {
+      return;
+    }
+    new ResizeObserver(function () {
+      global.parent.postMessage({
+        type: "contact-api:resize",
+        website: options.website,
+        height: document.documentElement.scrollHeight,
+      }, "*");
+    }).observe(document.body);
+  }
+
+  // frame shows the form in an iframe sized to its content
+  function frame(container, options) {
+    var url = options.api + "/widget/" + VERSION + "/frame/" + encodeURIComponent(options.website);
+    if (options.locale) {
+      url += "?locale=" + encodeURIComponent(options.locale);
+    }
+    var iframe = el("iframe", { src: url, title: container.dataset.title || "Contact form", class: "contact-form-frame" });
+    iframe.style.width = "100%";
+    iframe.style.border = "0";
+
+    global.addEventListener("message", function (event) {
+      if (event.source !== iframe.contentWindow || !event.data || typeof event.data.type !== "string") {
+        return;
+      }
+      switch (event.data.type) {
+        case "contact-api:resize":
+          iframe.style.height = event.data.height + "px";
+          break;
+        case "contact-api:success":
+        case "contact-api:error":
+          var name = event.data.type.slice("contact-api:".length);
+          container.dispatchEvent(new CustomEvent("contact:" + name, { detail: event.data.detail, bubbles: true }));
+          if (name === "success" && options.redirect) {
+            global.location.assign(options.redirect);
+          }
+          break;
+      }
+    });
+    container.append(iframe);
+  }
+
+  // init renders the form of a container once
+  function init(container) {
+    if (container.contactForm) {
+      return;
+    }
+    container.contactForm = true;
+
+    var data = container.dataset;
+    var options = {
+      website: data.website,
+      locale: data.locale || "",
+      redirect: data.redirect || "",
+      api: (data.api || scriptBase).replace(/\/+$/, ""),
+      framed: "frame" in data,
+    };
+    if (!options.website) {
+      console.error("Contact form widget: data-website is required");
+      return;
+    }
+    if (data.mode === "iframe") {
+      frame(container, options);
+      return;
+    }
+    if (data.styles !== "false") {
+      loadStyles(options.api);
+    }
+
+    fetchForm(options).then(function (def) {
+      render(container, options, def);
+    }).catch(function (err) {
+      container.append(el("p", { class: "contact-form__status contact-form__status--error", role: "alert" }, [err.message]));
+      container.dispatchEvent(new CustomEvent("contact:error", { detail: { success: false, message: err.message }, bubbles: true }));
+    });
+  }
+
+  function initAll() {
+    document.querySelectorAll("[data-contact-form]").forEach(init);
+  }
+
+  if (document.readyState === "loading") {
+    document.addEventListener("DOMContentLoaded", initAll);
+  } else {
+    initAll();
+  }
+
+  global.ContactForm = { version: VERSION, init: init };
+})(window);
@@ -0,0 +1,25 @@
+package widget
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFrame_Render(t *testing.T) {
+	var page strings.Builder
+	frame := Frame{Website: "main", Locale: "pt-BR", Title: `Contato "<fale>"`}
+	if err := frame.Render(&page); err != nil {
+		t.Fatalf("Render() returned error: %v", err)
+	}
+
+	for _, expected := range []string{
+		`<html lang="pt-BR">`,
+		`<title>Contato &#34;&lt;fale&gt;&#34;</title>`,
+		`data-website="main" data-locale="pt-BR"`,
+		`<script src="../widget.js"></script>`,
+	} {
+		if !strings.Contains(page.String(), expected) {
+			t.Errorf("Expected the page to contain %s, got:\n%s", expected, page.String())
+		}
+	}
+}
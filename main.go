@@ -1,7 +0,0 @@
-package main
-
-import contact "github.com/nahuelsantos/contact-api/cmd/contact-api"
-
-func main() {
-	contact.Run()
-}